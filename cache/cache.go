@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.New().WithField("module", "cache")
+
+// Entry is a cached response
+type Entry struct {
+	ContentType string
+	Body        []byte
+	Expires     time.Time // zero if the entry never expires
+}
+
+// Expired returns true if the entry is past its expiry time
+func (e *Entry) Expired() bool {
+	return !e.Expires.IsZero() && time.Now().After(e.Expires)
+}
+
+// Cache is a store for responses of the api
+type Cache interface {
+	Get(key string) (*Entry, bool)
+	Set(key string, entry *Entry)
+}
+
+// New creates a cache of the given type. Supported types are lru (default), db and none, size is the maximum
+// number of entries of the lru cache. For the type none a nil cache is returned.
+func New(cacheType string, size int) (Cache, error) {
+	switch cacheType {
+	case "", "lru":
+		if size <= 0 {
+			size = 10000
+		}
+		return NewLRUCache(size)
+	case "db":
+		return NewDBCache(), nil
+	case "none":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("invalid cache type %v specified. supported cache types are lru, db and none", cacheType)
+	}
+}
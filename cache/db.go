@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"time"
+)
+
+// DBCache stores the cached entries in the api_cache table of the database, this allows several frontend
+// instances to share one cache
+type DBCache struct{}
+
+// NewDBCache creates a new database backed cache and starts the removal of expired entries
+func NewDBCache() *DBCache {
+	go dbCacheCleaner()
+	return &DBCache{}
+}
+
+func dbCacheCleaner() {
+	for {
+		err := db.DeleteExpiredApiCacheEntries()
+		if err != nil {
+			logger.Errorf("error deleting expired api cache entries: %v", err)
+		}
+		time.Sleep(time.Minute * 10)
+	}
+}
+
+// dbCacheKey hashes the key as cache keys (request uris) may exceed the maximum size of an index entry
+func dbCacheKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// Get returns the entry stored for key if it is present and not yet expired
+func (c *DBCache) Get(key string) (*Entry, bool) {
+	dbEntry, err := db.GetApiCacheEntry(dbCacheKey(key))
+	if err != nil {
+		logger.Errorf("error retrieving api cache entry: %v", err)
+		return nil, false
+	}
+	if dbEntry == nil {
+		return nil, false
+	}
+
+	entry := &Entry{ContentType: dbEntry.ContentType, Body: dbEntry.Body}
+	if dbEntry.ExpiresTs != nil {
+		entry.Expires = *dbEntry.ExpiresTs
+	}
+	return entry, true
+}
+
+// Set stores the entry for key
+func (c *DBCache) Set(key string, entry *Entry) {
+	dbEntry := &types.ApiCacheEntry{Key: dbCacheKey(key), ContentType: entry.ContentType, Body: entry.Body}
+	if !entry.Expires.IsZero() {
+		dbEntry.ExpiresTs = &entry.Expires
+	}
+
+	err := db.SaveApiCacheEntry(dbEntry)
+	if err != nil {
+		logger.Errorf("error saving api cache entry: %v", err)
+	}
+}
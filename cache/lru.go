@@ -0,0 +1,38 @@
+package cache
+
+import (
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// LRUCache is an in-process cache that evicts the least recently used entries once it is full
+type LRUCache struct {
+	entries *lru.Cache
+}
+
+// NewLRUCache creates a new in-process cache holding at most size entries
+func NewLRUCache(size int) (*LRUCache, error) {
+	entries, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &LRUCache{entries: entries}, nil
+}
+
+// Get returns the entry stored for key if it is present and not yet expired
+func (c *LRUCache) Get(key string) (*Entry, bool) {
+	value, found := c.entries.Get(key)
+	if !found {
+		return nil, false
+	}
+	entry := value.(*Entry)
+	if entry.Expired() {
+		c.entries.Remove(key)
+		return nil, false
+	}
+	return entry, true
+}
+
+// Set stores the entry for key
+func (c *LRUCache) Set(key string, entry *Entry) {
+	c.entries.Add(key, entry)
+}
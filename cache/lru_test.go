@@ -0,0 +1,29 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRUCache(t *testing.T) {
+	c, err := NewLRUCache(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Set("finalized", &Entry{Body: []byte("a")})
+	c.Set("head", &Entry{Body: []byte("b"), Expires: time.Now().Add(-time.Second)})
+
+	if entry, found := c.Get("finalized"); !found || string(entry.Body) != "a" {
+		t.Errorf("expected entry without expiry to be cached")
+	}
+	if _, found := c.Get("head"); found {
+		t.Errorf("expected expired entry to be removed")
+	}
+
+	c.Set("b", &Entry{Body: []byte("b")})
+	c.Set("c", &Entry{Body: []byte("c")})
+	if _, found := c.Get("finalized"); found {
+		t.Errorf("expected least recently used entry to be evicted")
+	}
+}
@@ -0,0 +1,205 @@
+package charts
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+)
+
+// text anchors
+const (
+	anchorStart = iota
+	anchorMiddle
+	anchorEnd
+)
+
+// canvas is the drawing surface of a chart. Coordinates are pixels from the top left corner, the y coordinate of a
+// text is its top.
+type canvas interface {
+	fillRect(x0, y0, x1, y1 float64, c color.RGBA)
+	line(x0, y0, x1, y1 float64, c color.RGBA, width float64)
+	polyline(points [][2]float64, c color.RGBA, width float64)
+	text(x, y float64, s string, scale int, c color.RGBA, anchor int)
+	wedge(cx, cy, r, start, end float64, c color.RGBA)
+	encode() ([]byte, error)
+}
+
+// pngCanvas draws into an image, texts are drawn with the built-in bitmap font
+type pngCanvas struct {
+	img *image.RGBA
+}
+
+func newPNGCanvas(width, height int) *pngCanvas {
+	return &pngCanvas{img: image.NewRGBA(image.Rect(0, 0, width, height))}
+}
+
+func (p *pngCanvas) set(x, y int, c color.RGBA) {
+	if !(image.Point{x, y}.In(p.img.Rect)) {
+		return
+	}
+	if c.A == 255 {
+		p.img.SetRGBA(x, y, c)
+		return
+	}
+	// blend semi transparent colors with the background
+	bg := p.img.RGBAAt(x, y)
+	a := uint32(c.A)
+	p.img.SetRGBA(x, y, color.RGBA{
+		R: uint8((uint32(c.R)*a + uint32(bg.R)*(255-a)) / 255),
+		G: uint8((uint32(c.G)*a + uint32(bg.G)*(255-a)) / 255),
+		B: uint8((uint32(c.B)*a + uint32(bg.B)*(255-a)) / 255),
+		A: 255,
+	})
+}
+
+func (p *pngCanvas) fillRect(x0, y0, x1, y1 float64, c color.RGBA) {
+	for y := int(math.Round(y0)); y < int(math.Round(y1)); y++ {
+		for x := int(math.Round(x0)); x < int(math.Round(x1)); x++ {
+			p.set(x, y, c)
+		}
+	}
+}
+
+func (p *pngCanvas) line(x0, y0, x1, y1 float64, c color.RGBA, width float64) {
+	steps := int(math.Max(math.Abs(x1-x0), math.Abs(y1-y0)))
+	if steps == 0 {
+		steps = 1
+	}
+	w := int(math.Max(1, math.Round(width)))
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		x := int(math.Round(x0 + (x1-x0)*t))
+		y := int(math.Round(y0 + (y1-y0)*t))
+		for dy := 0; dy < w; dy++ {
+			for dx := 0; dx < w; dx++ {
+				p.set(x+dx-w/2, y+dy-w/2, c)
+			}
+		}
+	}
+}
+
+func (p *pngCanvas) polyline(points [][2]float64, c color.RGBA, width float64) {
+	for i := 1; i < len(points); i++ {
+		p.line(points[i-1][0], points[i-1][1], points[i][0], points[i][1], c, width)
+	}
+	if len(points) == 1 {
+		p.line(points[0][0], points[0][1], points[0][0], points[0][1], c, width)
+	}
+}
+
+func (p *pngCanvas) text(x, y float64, s string, scale int, c color.RGBA, anchor int) {
+	switch anchor {
+	case anchorMiddle:
+		x -= textWidth(s, scale) / 2
+	case anchorEnd:
+		x -= textWidth(s, scale)
+	}
+	x0, y0 := int(math.Round(x)), int(math.Round(y))
+	for i, r := range []rune(s) {
+		g := glyph(r)
+		for row := 0; row < glyphHeight; row++ {
+			for col := 0; col < glyphWidth; col++ {
+				if g[row][col] != '#' {
+					continue
+				}
+				for dy := 0; dy < scale; dy++ {
+					for dx := 0; dx < scale; dx++ {
+						p.set(x0+(i*glyphAdvance+col)*scale+dx, y0+row*scale+dy, c)
+					}
+				}
+			}
+		}
+	}
+}
+
+func (p *pngCanvas) wedge(cx, cy, r, start, end float64, c color.RGBA) {
+	for y := int(cy - r); y <= int(cy+r); y++ {
+		for x := int(cx - r); x <= int(cx+r); x++ {
+			dx, dy := float64(x)-cx, float64(y)-cy
+			if dx*dx+dy*dy > r*r {
+				continue
+			}
+			// angles start at 12 o'clock and run clockwise
+			a := math.Atan2(dx, -dy)
+			if a < 0 {
+				a += 2 * math.Pi
+			}
+			if a >= start && a < end {
+				p.set(x, y, c)
+			}
+		}
+	}
+}
+
+func (p *pngCanvas) encode() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := png.Encode(buf, p.img)
+	return buf.Bytes(), err
+}
+
+// svgCanvas writes svg elements, texts use a monospace font with the metrics of the bitmap font
+type svgCanvas struct {
+	width, height int
+	body          strings.Builder
+}
+
+func newSVGCanvas(width, height int) *svgCanvas {
+	return &svgCanvas{width: width, height: height}
+}
+
+func svgColor(c color.RGBA) string {
+	if c.A == 255 {
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return fmt.Sprintf("rgba(%d,%d,%d,%.3f)", c.R, c.G, c.B, float64(c.A)/255)
+}
+
+func (s *svgCanvas) fillRect(x0, y0, x1, y1 float64, c color.RGBA) {
+	fmt.Fprintf(&s.body, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`+"\n", x0, y0, x1-x0, y1-y0, svgColor(c))
+}
+
+func (s *svgCanvas) line(x0, y0, x1, y1 float64, c color.RGBA, width float64) {
+	fmt.Fprintf(&s.body, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%.1f"/>`+"\n", x0, y0, x1, y1, svgColor(c), width)
+}
+
+func (s *svgCanvas) polyline(points [][2]float64, c color.RGBA, width float64) {
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = fmt.Sprintf("%.1f,%.1f", p[0], p[1])
+	}
+	fmt.Fprintf(&s.body, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%.1f"/>`+"\n", strings.Join(coords, " "), svgColor(c), width)
+}
+
+func (s *svgCanvas) text(x, y float64, text string, scale int, c color.RGBA, anchor int) {
+	anchors := []string{"start", "middle", "end"}
+	buf := &bytes.Buffer{}
+	xml.EscapeText(buf, []byte(text))
+	fmt.Fprintf(&s.body, `<text x="%.1f" y="%.1f" font-family="monospace" font-size="%d" fill="%s" text-anchor="%s" dominant-baseline="hanging">%s</text>`+"\n",
+		x, y, 10*scale, svgColor(c), anchors[anchor], buf.String())
+}
+
+func (s *svgCanvas) wedge(cx, cy, r, start, end float64, c color.RGBA) {
+	if end-start >= 2*math.Pi-1e-9 {
+		fmt.Fprintf(&s.body, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s"/>`+"\n", cx, cy, r, svgColor(c))
+		return
+	}
+	largeArc := 0
+	if end-start > math.Pi {
+		largeArc = 1
+	}
+	fmt.Fprintf(&s.body, `<path d="M%.1f,%.1f L%.1f,%.1f A%.1f,%.1f 0 %d 1 %.1f,%.1f Z" fill="%s"/>`+"\n",
+		cx, cy, cx+r*math.Sin(start), cy-r*math.Cos(start), r, r, largeArc, cx+r*math.Sin(end), cy-r*math.Cos(end), svgColor(c))
+}
+
+func (s *svgCanvas) encode() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", s.width, s.height, s.width, s.height)
+	buf.WriteString(s.body.String())
+	buf.WriteString("</svg>\n")
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,597 @@
+// Package charts renders the charts of the charts page as png or svg images without a browser
+package charts
+
+import (
+	"eth2-exporter/types"
+	"fmt"
+	"image/color"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultWidth  = 1200
+	DefaultHeight = 600
+	MinSize       = 200
+	MaxSize       = 2400
+
+	padding = 16.0
+)
+
+var (
+	background = color.RGBA{255, 255, 255, 255}
+	foreground = color.RGBA{51, 51, 51, 255}
+	muted      = color.RGBA{102, 102, 102, 255}
+	gridColor  = color.RGBA{230, 230, 230, 255}
+	axisColor  = color.RGBA{204, 214, 235, 255}
+	// the default colors of highcharts, which renders the charts page
+	palette = []color.RGBA{
+		{124, 181, 236, 255},
+		{67, 67, 72, 255},
+		{144, 237, 125, 255},
+		{247, 163, 92, 255},
+		{128, 133, 233, 255},
+		{241, 92, 128, 255},
+		{228, 211, 84, 255},
+		{43, 144, 143, 255},
+		{244, 91, 91, 255},
+		{145, 232, 225, 255},
+	}
+)
+
+// RenderPNG renders the chart as png image
+func RenderPNG(data *types.GenericChartData, width, height int) ([]byte, error) {
+	return render(newPNGCanvas(width, height), data, width, height)
+}
+
+// RenderSVG renders the chart as svg image
+func RenderSVG(data *types.GenericChartData, width, height int) ([]byte, error) {
+	return render(newSVGCanvas(width, height), data, width, height)
+}
+
+// series is a series of a chart with either points (x, y) or named values
+type series struct {
+	name   string
+	stack  string
+	color  color.RGBA
+	points [][]float64
+	items  []item
+}
+
+type item struct {
+	name  string
+	value float64
+}
+
+// rect is an area of the canvas
+type rect struct {
+	x0, y0, x1, y1 float64
+}
+
+func (r rect) width() float64  { return r.x1 - r.x0 }
+func (r rect) height() float64 { return r.y1 - r.y0 }
+
+func render(c canvas, data *types.GenericChartData, width, height int) ([]byte, error) {
+	if width < MinSize || width > MaxSize || height < MinSize || height > MaxSize {
+		return nil, fmt.Errorf("the size of a chart must be between %v and %v pixels", MinSize, MaxSize)
+	}
+
+	chartSeries := make([]*series, 0, len(data.Series))
+	for i, s := range data.Series {
+		points, items, err := seriesData(s.Data)
+		if err != nil {
+			return nil, fmt.Errorf("error reading series %v: %v", s.Name, err)
+		}
+		chartSeries = append(chartSeries, &series{
+			name:   s.Name,
+			stack:  s.Stack,
+			color:  palette[i%len(palette)],
+			points: points,
+			items:  items,
+		})
+	}
+
+	c.fillRect(0, 0, float64(width), float64(height), background)
+
+	y := padding
+	if data.Title != "" {
+		c.text(float64(width)/2, y, data.Title, 2, foreground, anchorMiddle)
+		y += lineHeight * 2
+	}
+	for _, line := range wrapText(data.Subtitle, float64(width)-2*padding, 1) {
+		c.text(float64(width)/2, y, line, 1, muted, anchorMiddle)
+		y += lineHeight
+	}
+
+	area := rect{padding, y + padding, float64(width) - padding, float64(height) - padding}
+	switch data.Type {
+	case "pie":
+		renderPie(c, area, chartSeries)
+	case "wordcloud":
+		renderBars(c, area, chartSeries)
+	default:
+		renderXY(c, area, data, chartSeries)
+	}
+
+	return c.encode()
+}
+
+// seriesData reads the data of a series, which is either a list of points or a list of structs with a name and a
+// value (y or weight)
+func seriesData(data interface{}) ([][]float64, []item, error) {
+	if points, ok := data.([][]float64); ok {
+		return points, nil, nil
+	}
+
+	v := reflect.ValueOf(data)
+	if v.Kind() != reflect.Slice {
+		return nil, nil, fmt.Errorf("unsupported data of type %T", data)
+	}
+	items := make([]item, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		e := reflect.Indirect(v.Index(i))
+		if e.Kind() != reflect.Struct {
+			return nil, nil, fmt.Errorf("unsupported data of type %T", data)
+		}
+		name := e.FieldByName("Name")
+		value := e.FieldByName("Y")
+		if !value.IsValid() {
+			value = e.FieldByName("Weight")
+		}
+		if !name.IsValid() || name.Kind() != reflect.String || !value.IsValid() {
+			return nil, nil, fmt.Errorf("unsupported data of type %T", data)
+		}
+		it := item{name: name.String()}
+		switch value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			it.value = float64(value.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			it.value = float64(value.Uint())
+		case reflect.Float32, reflect.Float64:
+			it.value = value.Float()
+		default:
+			return nil, nil, fmt.Errorf("unsupported data of type %T", data)
+		}
+		items = append(items, it)
+	}
+	return nil, items, nil
+}
+
+// renderXY renders line and column series, columns of series with the same stack are stacked if the stacking mode
+// is normal
+func renderXY(c canvas, area rect, data *types.GenericChartData, chartSeries []*series) {
+	area.y1 -= legendHeight(area, chartSeries)
+	renderLegend(c, rect{area.x0, area.y1, area.x1, area.y1 + legendHeight(area, chartSeries)}, chartSeries)
+
+	xs := distinctX(chartSeries)
+	if len(xs) == 0 {
+		renderNoData(c, area)
+		return
+	}
+
+	isColumn := data.Type == "column"
+	stacked := isColumn && data.StackingMode == "normal"
+
+	// columns are grouped by their stack, unstacked columns are placed next to each other
+	groups := []string{}
+	groupOf := make(map[*series]int, len(chartSeries))
+	for i, s := range chartSeries {
+		key := fmt.Sprintf("series-%d", i)
+		if stacked {
+			key = "stack-" + s.stack
+		}
+		idx := -1
+		for j, g := range groups {
+			if g == key {
+				idx = j
+			}
+		}
+		if idx == -1 {
+			groups = append(groups, key)
+			idx = len(groups) - 1
+		}
+		groupOf[s] = idx
+	}
+
+	// the value range includes the sums of stacked columns
+	yMin, yMax := math.Inf(1), math.Inf(-1)
+	sums := make([]map[float64][2]float64, len(groups))
+	for i := range sums {
+		sums[i] = make(map[float64][2]float64)
+	}
+	for _, s := range chartSeries {
+		for _, p := range s.points {
+			if len(p) < 2 {
+				continue
+			}
+			v := p[1]
+			if stacked {
+				sum := sums[groupOf[s]][p[0]]
+				if v >= 0 {
+					sum[0] += v
+					v = sum[0]
+				} else {
+					sum[1] += v
+					v = sum[1]
+				}
+				sums[groupOf[s]][p[0]] = sum
+			}
+			yMin = math.Min(yMin, v)
+			yMax = math.Max(yMax, v)
+		}
+	}
+	if isColumn {
+		yMin = math.Min(yMin, 0)
+		yMax = math.Max(yMax, 0)
+	}
+	yTicks := niceTicks(yMin, yMax, int(area.height()/60)+2)
+	yStep := yTicks[1] - yTicks[0]
+	yMin, yMax = yTicks[0], yTicks[len(yTicks)-1]
+
+	yLabels := make([]string, len(yTicks))
+	labelWidth := 0.0
+	for i, t := range yTicks {
+		yLabels[i] = formatNumber(t, yStep)
+		labelWidth = math.Max(labelWidth, textWidth(yLabels[i], 1))
+	}
+
+	isTime := !data.IsNormalChart
+	plot := rect{area.x0 + labelWidth + 8, area.y0 + 2*lineHeight, area.x1, area.y1 - 2*lineHeight}
+	if data.XAxisTitle != "" && !isTime {
+		plot.y1 -= lineHeight
+	}
+
+	// columns are centered on their x value, so the range is extended by half a column
+	xMin, xMax := xs[0], xs[len(xs)-1]
+	minDelta := xMax - xMin
+	for i := 1; i < len(xs); i++ {
+		minDelta = math.Min(minDelta, xs[i]-xs[i-1])
+	}
+	if minDelta == 0 {
+		minDelta = 1
+	}
+	if isColumn {
+		xMin -= minDelta / 2
+		xMax += minDelta / 2
+	} else if xMin == xMax {
+		xMin--
+		xMax++
+	}
+	xPos := func(x float64) float64 { return plot.x0 + (x-xMin)/(xMax-xMin)*plot.width() }
+	yPos := func(y float64) float64 { return plot.y1 - (y-yMin)/(yMax-yMin)*plot.height() }
+
+	if data.YAxisTitle != "" {
+		c.text(area.x0, area.y0, data.YAxisTitle, 1, muted, anchorStart)
+	}
+	for i, t := range yTicks {
+		c.line(plot.x0, yPos(t), plot.x1, yPos(t), gridColor, 1)
+		c.text(plot.x0-6, yPos(t)-glyphHeight/2, yLabels[i], 1, muted, anchorEnd)
+	}
+
+	var xTicks []float64
+	var xLabels []string
+	if isTime {
+		xTicks, xLabels = timeTicks(xMin, xMax, int(plot.width()/120)+1)
+	} else {
+		xTicks = niceTicks(xMin, xMax, int(plot.width()/100)+2)
+		xLabels = make([]string, len(xTicks))
+		for i, t := range xTicks {
+			xLabels[i] = formatNumber(t, xTicks[1]-xTicks[0])
+		}
+	}
+	for i, t := range xTicks {
+		if t < xMin || t > xMax {
+			continue
+		}
+		c.line(xPos(t), plot.y1, xPos(t), plot.y1+5, axisColor, 1)
+		c.text(xPos(t), plot.y1+8, xLabels[i], 1, muted, anchorMiddle)
+	}
+	if data.XAxisTitle != "" && !isTime {
+		c.text(plot.x0+plot.width()/2, plot.y1+8+lineHeight, data.XAxisTitle, 1, muted, anchorMiddle)
+	}
+
+	columnWidth := math.Max(1, minDelta/(xMax-xMin)*plot.width()*0.8)
+	groupWidth := columnWidth / float64(len(groups))
+	offsets := make([]map[float64][2]float64, len(groups))
+	for i := range offsets {
+		offsets[i] = make(map[float64][2]float64)
+	}
+	for _, s := range chartSeries {
+		if !isColumn {
+			points := make([][2]float64, 0, len(s.points))
+			for _, p := range s.points {
+				if len(p) >= 2 {
+					points = append(points, [2]float64{xPos(p[0]), yPos(p[1])})
+				}
+			}
+			c.polyline(points, s.color, 2)
+			continue
+		}
+
+		g := groupOf[s]
+		for _, p := range s.points {
+			if len(p) < 2 {
+				continue
+			}
+			from, to := 0.0, p[1]
+			if stacked {
+				offset := offsets[g][p[0]]
+				if p[1] >= 0 {
+					from, to = offset[0], offset[0]+p[1]
+					offset[0] = to
+				} else {
+					from, to = offset[1], offset[1]+p[1]
+					offset[1] = to
+				}
+				offsets[g][p[0]] = offset
+			}
+			x0 := xPos(p[0]) - columnWidth/2 + float64(g)*groupWidth
+			c.fillRect(x0, math.Min(yPos(from), yPos(to)), x0+math.Max(1, groupWidth), math.Max(yPos(from), yPos(to)), s.color)
+		}
+	}
+
+	c.line(plot.x0, plot.y1, plot.x1, plot.y1, axisColor, 1)
+}
+
+// renderPie renders the named values of the first series as pie with a legend of the values
+func renderPie(c canvas, area rect, chartSeries []*series) {
+	if len(chartSeries) == 0 || len(chartSeries[0].items) == 0 {
+		renderNoData(c, area)
+		return
+	}
+	items := chartSeries[0].items
+
+	total := 0.0
+	for _, it := range items {
+		total += math.Max(0, it.value)
+	}
+	if total == 0 {
+		renderNoData(c, area)
+		return
+	}
+
+	r := math.Min(area.width()/2, area.height()) / 2
+	cx, cy := area.x0+area.width()/4, area.y0+area.height()/2
+	start := 0.0
+	for i, it := range items {
+		end := start + math.Max(0, it.value)/total*2*math.Pi
+		c.wedge(cx, cy, r, start, end, palette[i%len(palette)])
+		start = end
+	}
+
+	y := area.y0
+	x := area.x0 + area.width()/2
+	for i, it := range items {
+		if y+lineHeight > area.y1 {
+			break
+		}
+		c.fillRect(x, y, x+glyphHeight, y+glyphHeight, palette[i%len(palette)])
+		label := fmt.Sprintf("%v: %v (%.2f%%)", it.name, formatNumber(it.value, 1), it.value/total*100)
+		c.text(x+glyphHeight+6, y, truncateText(label, area.x1-x-glyphHeight-6, 1), 1, foreground, anchorStart)
+		y += lineHeight + 2
+	}
+}
+
+// renderBars renders the named values of the first series as horizontal bars sorted by value
+func renderBars(c canvas, area rect, chartSeries []*series) {
+	if len(chartSeries) == 0 || len(chartSeries[0].items) == 0 {
+		renderNoData(c, area)
+		return
+	}
+	items := make([]item, len(chartSeries[0].items))
+	copy(items, chartSeries[0].items)
+	sort.SliceStable(items, func(i, j int) bool { return items[i].value > items[j].value })
+
+	rowHeight := lineHeight + 4.0
+	if n := int(area.height() / rowHeight); len(items) > n {
+		items = items[:n]
+	}
+
+	labelWidth := 0.0
+	for _, it := range items {
+		labelWidth = math.Max(labelWidth, textWidth(it.name, 1))
+	}
+	labelWidth = math.Min(labelWidth, area.width()/3)
+	maxValue := math.Max(items[0].value, 1)
+
+	barsWidth := area.width() - labelWidth - 16 - textWidth(formatNumber(maxValue, 1), 1) - 8
+	for i, it := range items {
+		y := area.y0 + float64(i)*rowHeight
+		c.text(area.x0+labelWidth, y, truncateText(it.name, labelWidth, 1), 1, foreground, anchorEnd)
+		x0 := area.x0 + labelWidth + 8
+		x1 := x0 + math.Max(1, it.value/maxValue*barsWidth)
+		c.fillRect(x0, y-1, x1, y+glyphHeight+1, palette[0])
+		c.text(x1+8, y, formatNumber(it.value, 1), 1, muted, anchorStart)
+	}
+}
+
+// legendHeight returns the height of the legend of the series in the area
+func legendHeight(area rect, chartSeries []*series) float64 {
+	if len(chartSeries) == 0 {
+		return 0
+	}
+	return float64(len(legendRows(area, chartSeries))) * (lineHeight + 4)
+}
+
+// legendRows distributes the series over the rows of the legend
+func legendRows(area rect, chartSeries []*series) [][]*series {
+	rows := [][]*series{}
+	row := []*series{}
+	width := 0.0
+	for _, s := range chartSeries {
+		w := legendItemWidth(s)
+		if len(row) > 0 && width+w > area.width() {
+			rows = append(rows, row)
+			row, width = []*series{}, 0
+		}
+		row = append(row, s)
+		width += w
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func legendItemWidth(s *series) float64 {
+	return glyphHeight + 6 + textWidth(s.name, 1) + 20
+}
+
+func renderLegend(c canvas, area rect, chartSeries []*series) {
+	y := area.y0 + 4
+	for _, row := range legendRows(area, chartSeries) {
+		width := 0.0
+		for _, s := range row {
+			width += legendItemWidth(s)
+		}
+		x := area.x0 + (area.width()-width)/2
+		for _, s := range row {
+			c.fillRect(x, y, x+glyphHeight, y+glyphHeight, s.color)
+			c.text(x+glyphHeight+6, y, s.name, 1, foreground, anchorStart)
+			x += legendItemWidth(s)
+		}
+		y += lineHeight + 4
+	}
+}
+
+func renderNoData(c canvas, area rect) {
+	c.text(area.x0+area.width()/2, area.y0+area.height()/2, "No data available", 1, muted, anchorMiddle)
+}
+
+// distinctX returns the sorted distinct x values of the series
+func distinctX(chartSeries []*series) []float64 {
+	seen := make(map[float64]bool)
+	xs := []float64{}
+	for _, s := range chartSeries {
+		for _, p := range s.points {
+			if len(p) >= 2 && !seen[p[0]] {
+				seen[p[0]] = true
+				xs = append(xs, p[0])
+			}
+		}
+	}
+	sort.Float64s(xs)
+	return xs
+}
+
+// niceTicks returns about n ticks with a step of 1, 2 or 5 times a power of ten that cover the range
+func niceTicks(min, max float64, n int) []float64 {
+	if n < 2 {
+		n = 2
+	}
+	if min == max {
+		if min == 0 {
+			max = 1
+		} else {
+			min, max = min-math.Abs(min)/2, max+math.Abs(max)/2
+		}
+	}
+	raw := (max - min) / float64(n-1)
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := magnitude
+	for _, m := range []float64{1, 2, 5, 10} {
+		step = m * magnitude
+		if step >= raw {
+			break
+		}
+	}
+	ticks := []float64{}
+	for t := math.Floor(min/step) * step; t < max+step/2; t += step {
+		ticks = append(ticks, t)
+	}
+	if len(ticks) < 2 {
+		ticks = append(ticks, ticks[0]+step)
+	}
+	return ticks
+}
+
+// timeTicks returns about n ticks for the range of unix timestamps in milliseconds with their labels
+func timeTicks(min, max float64, n int) ([]float64, []string) {
+	steps := []time.Duration{
+		time.Minute * 10, time.Minute * 30, time.Hour, time.Hour * 2, time.Hour * 6, time.Hour * 12,
+		time.Hour * 24, time.Hour * 24 * 2, time.Hour * 24 * 7, time.Hour * 24 * 14, time.Hour * 24 * 30,
+		time.Hour * 24 * 91, time.Hour * 24 * 182, time.Hour * 24 * 365,
+	}
+	span := time.Duration(max-min) * time.Millisecond
+	step := steps[len(steps)-1]
+	for _, s := range steps {
+		if span/s <= time.Duration(n) {
+			step = s
+			break
+		}
+	}
+
+	format := "2006-01-02"
+	if step < time.Hour*24 {
+		format = "01-02 15:04"
+	}
+
+	ticks := []float64{}
+	labels := []string{}
+	start := time.Unix(0, int64(min)*int64(time.Millisecond)).UTC().Truncate(step)
+	for t := start; float64(t.UnixNano()/int64(time.Millisecond)) <= max; t = t.Add(step) {
+		ticks = append(ticks, float64(t.UnixNano()/int64(time.Millisecond)))
+		labels = append(labels, t.Format(format))
+	}
+	return ticks, labels
+}
+
+// formatNumber formats the value with the precision of the step, large values are abbreviated
+func formatNumber(v, step float64) string {
+	suffix := ""
+	abs := math.Max(math.Abs(v), math.Abs(step))
+	switch {
+	case abs >= 1e9:
+		v, step, suffix = v/1e9, step/1e9, "G"
+	case abs >= 1e6:
+		v, step, suffix = v/1e6, step/1e6, "M"
+	case abs >= 1e4:
+		v, step, suffix = v/1e3, step/1e3, "k"
+	}
+	decimals := 0
+	if step > 0 && step < 1 {
+		decimals = int(math.Ceil(-math.Log10(step)))
+	}
+	if decimals > 6 {
+		decimals = 6
+	}
+	return strconv.FormatFloat(v, 'f', decimals, 64) + suffix
+}
+
+// wrapText splits the text into lines that fit the width
+func wrapText(s string, width float64, scale int) []string {
+	lines := []string{}
+	line := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && textWidth(candidate, scale) > width {
+			lines = append(lines, line)
+			candidate = word
+		}
+		line = candidate
+	}
+	if line != "" {
+		lines = append(lines, truncateText(line, width, scale))
+	}
+	return lines
+}
+
+// truncateText shortens the text to fit the width
+func truncateText(s string, width float64, scale int) string {
+	runes := []rune(s)
+	if textWidth(s, scale) <= width {
+		return s
+	}
+	n := int(width/float64(glyphAdvance*scale)) - 2
+	if n < 1 {
+		return ""
+	}
+	return string(runes[:n]) + ".."
+}
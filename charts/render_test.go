@@ -0,0 +1,79 @@
+package charts
+
+import (
+	"bytes"
+	"eth2-exporter/types"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	type pieData struct {
+		Name string
+		Y    int64
+	}
+
+	tests := []*types.GenericChartData{
+		{
+			Title:        "Blocks",
+			Subtitle:     "History of daily blocks proposed.",
+			YAxisTitle:   "# of Blocks",
+			Type:         "column",
+			StackingMode: "normal",
+			Series: []*types.GenericChartDataSeries{
+				{Name: "Proposed", Data: [][]float64{{1606780800000, 6900}, {1606867200000, 7012}, {1606953600000, 7100}}},
+				{Name: "Missed", Data: [][]float64{{1606780800000, 300}, {1606867200000, 188}, {1606953600000, 100}}},
+			},
+		},
+		{
+			Title:         "Balance Distribution",
+			XAxisTitle:    "Balance",
+			Type:          "line",
+			IsNormalChart: true,
+			Series: []*types.GenericChartDataSeries{
+				{Name: "Validators", Data: [][]float64{{31.5, 10}, {32, 20000}, {32.5, 3000}}},
+			},
+		},
+		{
+			Title: "Empty",
+			Type:  "line",
+			Series: []*types.GenericChartDataSeries{
+				{Name: "Validators", Data: [][]float64{}},
+			},
+		},
+		{
+			Title: "Pools & Clients",
+			Type:  "pie",
+			Series: []*types.GenericChartDataSeries{
+				{Name: "Clients", Data: []pieData{{"Lighthouse", 400}, {"Prysm", 1200}, {"Teku", 100}}},
+			},
+		},
+	}
+
+	for _, data := range tests {
+		img, err := RenderPNG(data, DefaultWidth, DefaultHeight)
+		if err != nil {
+			t.Fatalf("error rendering %v as png: %v", data.Title, err)
+		}
+		cfg, err := png.DecodeConfig(bytes.NewReader(img))
+		if err != nil {
+			t.Fatalf("error decoding %v: %v", data.Title, err)
+		}
+		if cfg.Width != DefaultWidth || cfg.Height != DefaultHeight {
+			t.Errorf("png of %v has size %vx%v, want %vx%v", data.Title, cfg.Width, cfg.Height, DefaultWidth, DefaultHeight)
+		}
+
+		svg, err := RenderSVG(data, DefaultWidth, DefaultHeight)
+		if err != nil {
+			t.Fatalf("error rendering %v as svg: %v", data.Title, err)
+		}
+		if !strings.Contains(string(svg), strings.Replace(data.Title, "&", "&amp;", -1)) {
+			t.Errorf("svg of %v does not contain its title", data.Title)
+		}
+	}
+
+	if _, err := RenderPNG(tests[0], 10, 10); err == nil {
+		t.Errorf("expected an error for a too small chart")
+	}
+}
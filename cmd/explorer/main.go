@@ -2,15 +2,21 @@ package main
 
 import (
 	"encoding/hex"
+	"eth2-exporter/cache"
 	"eth2-exporter/db"
+	"eth2-exporter/db/migrations"
 	"eth2-exporter/exporter"
+	"eth2-exporter/feed"
 	"eth2-exporter/handlers"
+	"eth2-exporter/metrics"
 	"eth2-exporter/rpc"
 	"eth2-exporter/services"
 	"eth2-exporter/types"
 	"eth2-exporter/utils"
 	"flag"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -44,29 +50,51 @@ func main() {
 	defer db.DB.Close()
 
 	logrus.Infof("database connection established")
+	if cfg.Database.Migrate {
+		err = db.MigrateUp(db.DB, migrations.Explorer)
+		if err != nil {
+			logrus.Fatalf("error migrating database: %v", err)
+		}
+	}
 	if utils.Config.Chain.SlotsPerEpoch == 0 || utils.Config.Chain.SecondsPerSlot == 0 {
 		logrus.Fatal("invalid chain configuration specified, you must specify the slots per epoch, seconds per slot and genesis timestamp in the config file")
 	}
 
+	if utils.Config.Metrics.Enabled {
+		go metrics.Serve(utils.Config.Metrics.Address)
+	}
+
 	if utils.Config.Indexer.Enabled {
 		var rpcClient rpc.Client
 
-		if utils.Config.Indexer.Node.Type == "prysm" {
-			if utils.Config.Indexer.Node.PageSize == 0 {
-				logrus.Printf("setting default rpc page size to 500")
-				utils.Config.Indexer.Node.PageSize = 500
+		if utils.Config.Indexer.Node.PageSize == 0 {
+			logrus.Printf("setting default rpc page size to 500")
+			utils.Config.Indexer.Node.PageSize = 500
+		}
+
+		if len(utils.Config.Indexer.Nodes) > 0 {
+			nodes := make([]*rpc.MultiClientNode, 0, len(utils.Config.Indexer.Nodes))
+			for i, node := range utils.Config.Indexer.Nodes {
+				client, err := newRPCClient(node.Type, node.Host+":"+node.Port)
+				if err != nil {
+					logrus.Fatal(err)
+				}
+				name := node.Name
+				if name == "" {
+					name = fmt.Sprintf("%v-%v", node.Type, i)
+				}
+				nodes = append(nodes, &rpc.MultiClientNode{Name: name, Client: rpc.NewInstrumentedClient(name, client)})
 			}
-			rpcClient, err = rpc.NewPrysmClient(cfg.Indexer.Node.Host + ":" + cfg.Indexer.Node.Port)
+			rpcClient, err = rpc.NewMultiClient(nodes, utils.Config.Indexer.CrossCheckNodes)
 			if err != nil {
 				logrus.Fatal(err)
 			}
-		} else if utils.Config.Indexer.Node.Type == "lighthouse" {
-			rpcClient, err = rpc.NewLighthouseClient(cfg.Indexer.Node.Host + ":" + cfg.Indexer.Node.Port)
+		} else {
+			client, err := newRPCClient(utils.Config.Indexer.Node.Type, cfg.Indexer.Node.Host+":"+cfg.Indexer.Node.Port)
 			if err != nil {
 				logrus.Fatal(err)
 			}
-		} else {
-			logrus.Fatalf("invalid note type %v specified. supported node types are prysm and lighthouse", utils.Config.Indexer.Node.Type)
+			rpcClient = rpc.NewInstrumentedClient(utils.Config.Indexer.Node.Type, client)
 		}
 
 		if utils.Config.Indexer.OneTimeExport.Enabled {
@@ -97,6 +125,11 @@ func main() {
 
 		router := mux.NewRouter()
 
+		apiCache, err := cache.New(cfg.Frontend.ApiCache.Type, cfg.Frontend.ApiCache.Size)
+		if err != nil {
+			logrus.Fatalf("error creating api cache: %v", err)
+		}
+
 		apiV1Router := mux.NewRouter().PathPrefix("/api/v1").Subrouter()
 		router.PathPrefix("/api/v1/docs/").Handler(httpSwagger.WrapHandler)
 		apiV1Router.HandleFunc("/epoch/{epoch}", handlers.ApiEpoch).Methods("GET", "OPTIONS")
@@ -117,10 +150,28 @@ func main() {
 		apiV1Router.HandleFunc("/validator/{indexOrPubkey}/deposits", handlers.ApiValidatorDeposits).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/validator/eth1/{address}", handlers.ApiValidatorByEth1Address).Methods("GET", "OPTIONS")
 		apiV1Router.HandleFunc("/chart/{chart}", handlers.ApiChart).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/chart/{chart}/data", handlers.ApiChartData).Methods("GET", "OPTIONS")
+		apiV1Router.HandleFunc("/reorgs", handlers.ApiReorgs).Methods("GET", "OPTIONS")
+		apiRateLimit := handlers.ApiRateLimitMiddleware()
 		apiV1Router.Use(utils.CORSMiddleware)
+		apiV1Router.Use(apiRateLimit)
+		apiV1Router.Use(handlers.ApiCacheMiddleware(apiCache))
 		router.PathPrefix("/api/v1").Handler(apiV1Router)
 
+		apiV2Router := mux.NewRouter().PathPrefix("/api/v2").Subrouter()
+		handlers.RegisterApiV2Routes(apiV2Router)
+		apiV2Router.Use(utils.CORSMiddleware)
+		apiV2Router.Use(apiRateLimit)
+		apiV2Router.Use(handlers.ApiCacheMiddleware(apiCache))
+		router.PathPrefix("/api/v2").Handler(apiV2Router)
+
 		router.HandleFunc("/api/healthz", handlers.ApiHealthz).Methods("GET")
+		router.Handle("/api/status", apiRateLimit(http.HandlerFunc(handlers.ApiStatus))).Methods("GET")
+
+		feed.SetConnectionLimits(cfg.Frontend.Feed.MaxConnections, cfg.Frontend.Feed.MaxConnectionsPerClient)
+		feed.Start(cfg.Database.Username, cfg.Database.Password, cfg.Database.Host, cfg.Database.Port, cfg.Database.Name)
+		router.Handle("/api/feed/ws", apiRateLimit(http.HandlerFunc(handlers.ApiFeedWebSocket))).Methods("GET")
+		router.Handle("/api/feed/events", apiRateLimit(http.HandlerFunc(handlers.ApiFeedEvents))).Methods("GET")
 
 		if !utils.Config.Frontend.OnlyAPI {
 			if utils.Config.Frontend.SiteDomain == "" {
@@ -130,6 +181,12 @@ func main() {
 			defer db.FrontendDB.Close()
 
 			logrus.Infof("frontend database connection established")
+			if cfg.Database.Migrate {
+				err = db.MigrateUp(db.FrontendDB, migrations.Frontend)
+				if err != nil {
+					logrus.Fatalf("error migrating frontend database: %v", err)
+				}
+			}
 			services.Init() // Init frontend services
 
 			logrus.Infof("frontend services initiated")
@@ -150,12 +207,14 @@ func main() {
 			router.HandleFunc("/blocks/data", handlers.BlocksData).Methods("GET")
 			router.HandleFunc("/vis", handlers.Vis).Methods("GET")
 			router.HandleFunc("/charts", handlers.Charts).Methods("GET")
-			router.HandleFunc("/charts/{chart}", handlers.GenericChart).Methods("GET")
+			router.Handle("/charts/{chart}", handlers.ChartQueryRateLimit(apiRateLimit)(http.HandlerFunc(handlers.GenericChart))).Methods("GET")
 			router.HandleFunc("/vis/blocks", handlers.VisBlocks).Methods("GET")
 			router.HandleFunc("/vis/votes", handlers.VisVotes).Methods("GET")
 			router.HandleFunc("/epoch/{epoch}", handlers.Epoch).Methods("GET")
 			router.HandleFunc("/epochs", handlers.Epochs).Methods("GET")
 			router.HandleFunc("/epochs/data", handlers.EpochsData).Methods("GET")
+			router.HandleFunc("/reorgs", handlers.Reorgs).Methods("GET")
+			router.HandleFunc("/reorgs/data", handlers.ReorgsData).Methods("GET")
 
 			router.HandleFunc("/validator/{index}", handlers.Validator).Methods("GET")
 			router.HandleFunc("/validator/{pubkey}/add", handlers.UserValidatorWatchlistAdd).Methods("POST")
@@ -183,6 +242,9 @@ func main() {
 			router.HandleFunc("/dashboard/data/proposals", handlers.DashboardDataProposals).Methods("GET")
 			router.HandleFunc("/dashboard/data/validators", handlers.DashboardDataValidators).Methods("GET")
 			router.HandleFunc("/dashboard/data/earnings", handlers.DashboardDataEarnings).Methods("GET")
+			router.HandleFunc("/dashboard/data/rewards", handlers.DashboardDataRewards).Methods("GET")
+			router.HandleFunc("/dashboard/data/effectiveness", handlers.DashboardDataEffectiveness).Methods("GET")
+			router.HandleFunc("/dashboard/data/income/export", handlers.DashboardDataIncomeExport).Methods("GET")
 			router.HandleFunc("/graffitiwall", handlers.Graffitiwall).Methods("GET")
 			router.HandleFunc("/calculator", handlers.StakingCalculator).Methods("GET")
 			router.HandleFunc("/search", handlers.Search).Methods("POST")
@@ -194,6 +256,8 @@ func main() {
 
 			router.HandleFunc("/login", handlers.Login).Methods("GET")
 			router.HandleFunc("/login", handlers.LoginPost).Methods("POST")
+			router.HandleFunc("/login/2fa", handlers.LoginTwoFactor).Methods("GET")
+			router.HandleFunc("/login/2fa", handlers.LoginTwoFactorPost).Methods("POST")
 			router.HandleFunc("/logout", handlers.Logout).Methods("GET")
 			router.HandleFunc("/register", handlers.Register).Methods("GET")
 			router.HandleFunc("/register", handlers.RegisterPost).Methods("POST")
@@ -224,10 +288,21 @@ func main() {
 			authRouter.HandleFunc("/settings/password", handlers.UserUpdatePasswordPost).Methods("POST")
 			authRouter.HandleFunc("/settings/delete", handlers.UserDeletePost).Methods("POST")
 			authRouter.HandleFunc("/settings/email", handlers.UserUpdateEmailPost).Methods("POST")
+			authRouter.HandleFunc("/settings/apikeys", handlers.UserApiKeyCreatePost).Methods("POST")
+			authRouter.HandleFunc("/settings/apikeys/revoke", handlers.UserApiKeyRevokePost).Methods("POST")
+			authRouter.HandleFunc("/settings/devices/revoke", handlers.UserDeviceRevokePost).Methods("POST")
+			authRouter.HandleFunc("/settings/2fa/setup", handlers.UserTwoFactorSetupPost).Methods("POST")
+			authRouter.HandleFunc("/settings/2fa/enable", handlers.UserTwoFactorEnablePost).Methods("POST")
+			authRouter.HandleFunc("/settings/2fa/disable", handlers.UserTwoFactorDisablePost).Methods("POST")
+			authRouter.HandleFunc("/settings/2fa/recovery", handlers.UserRecoveryCodesPost).Methods("POST")
+			authRouter.HandleFunc("/apiusage", handlers.UserApiUsage).Methods("GET")
 			authRouter.HandleFunc("/notifications", handlers.UserNotifications).Methods("GET")
 			authRouter.HandleFunc("/notifications/data", handlers.UserNotificationsData).Methods("GET")
 			authRouter.HandleFunc("/notifications/subscribe", handlers.UserNotificationsSubscribe).Methods("POST")
 			authRouter.HandleFunc("/notifications/unsubscribe", handlers.UserNotificationsUnsubscribe).Methods("POST")
+			authRouter.HandleFunc("/notifications/channels", handlers.UserNotificationsChannels).Methods("POST")
+			authRouter.HandleFunc("/notifications/webhook", handlers.UserNotificationsWebhookPost).Methods("POST")
+			authRouter.HandleFunc("/notifications/webhook/delete", handlers.UserNotificationsWebhookDeletePost).Methods("POST")
 			authRouter.HandleFunc("/subscriptions/data", handlers.UserSubscriptionsData).Methods("GET")
 
 			authRouter.HandleFunc("/dashboard/save", handlers.UserDashboardWatchlistAdd).Methods("POST")
@@ -239,14 +314,53 @@ func main() {
 				),
 			)
 
+			adminRouter := mux.NewRouter().PathPrefix("/admin").Subrouter()
+			adminRouter.HandleFunc("/status", handlers.AdminStatus).Methods("GET")
+			adminRouter.HandleFunc("/exportqueue", handlers.AdminExportQueue).Methods("GET")
+			adminRouter.HandleFunc("/exportqueue/data", handlers.AdminExportQueueData).Methods("GET")
+			adminRouter.HandleFunc("/exportqueue/requeue", handlers.AdminExportQueueRequeuePost).Methods("POST")
+
+			router.PathPrefix("/admin").Handler(
+				negroni.New(
+					negroni.HandlerFunc(handlers.AdminAuthMiddleware),
+					negroni.Wrap(csrfHandler(adminRouter)),
+				),
+			)
+
 			router.HandleFunc("/confirmation", handlers.Confirmation).Methods("GET")
 
+			// oauth token endpoints and the user-scoped api routes authenticated with the issued access tokens
+			apiV1Router.HandleFunc("/oauth/token", handlers.ApiOAuthToken).Methods("POST", "OPTIONS")
+			apiV1Router.HandleFunc("/oauth/revoke", handlers.ApiOAuthRevoke).Methods("POST", "OPTIONS")
+			apiV1UserRouter := apiV1Router.PathPrefix("/user").Subrouter()
+			// the preflight requests of all user routes are answered by the cors middleware
+			apiV1UserRouter.PathPrefix("/").Methods("OPTIONS").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+			apiV1UserRouter.HandleFunc("/device/notifications", handlers.ApiUserDeviceNotifications).Methods("POST")
+			apiV1UserRouter.HandleFunc("/watchlist", handlers.ApiUserWatchlist).Methods("GET")
+			apiV1UserRouter.HandleFunc("/watchlist", handlers.ApiUserWatchlistAdd).Methods("POST")
+			apiV1UserRouter.HandleFunc("/watchlist/{indexOrPubkey}", handlers.ApiUserWatchlistRemove).Methods("DELETE")
+			apiV1UserRouter.HandleFunc("/tags", handlers.ApiUserTags).Methods("GET")
+			apiV1UserRouter.HandleFunc("/tags/{tag}", handlers.ApiUserTagsAdd).Methods("POST")
+			apiV1UserRouter.HandleFunc("/tags/{tag}", handlers.ApiUserTagsRemove).Methods("DELETE")
+			apiV1UserRouter.HandleFunc("/subscriptions", handlers.ApiUserSubscriptions).Methods("GET")
+			apiV1UserRouter.HandleFunc("/subscriptions", handlers.ApiUserSubscriptionsAdd).Methods("POST")
+			apiV1UserRouter.HandleFunc("/subscriptions/{id}", handlers.ApiUserSubscriptionsDelete).Methods("DELETE")
+			apiV1UserRouter.Use(handlers.ApiUserAuthMiddleware)
+
 			// router.HandleFunc("/user/validators", handlers.UserValidators).Methods("GET")
 
 			router.PathPrefix("/").Handler(http.FileServer(http.Dir("static")))
+
+			authRouter.Use(handlers.HttpRouteMiddleware)
+			adminRouter.Use(handlers.HttpRouteMiddleware)
 		}
 
+		router.Use(handlers.HttpRouteMiddleware)
+		apiV1Router.Use(handlers.HttpRouteMiddleware)
+		apiV2Router.Use(handlers.HttpRouteMiddleware)
+
 		n := negroni.New(negroni.NewRecovery())
+		n.UseFunc(handlers.HttpMetricsMiddleware)
 
 		// Customize the logging middleware to include a proper module entry for the frontend
 		//frontendLogger := negronilogrus.NewMiddleware()
@@ -260,7 +374,15 @@ func main() {
 		//}
 		//n.Use(frontendLogger)
 
-		n.Use(gzip.Gzip(gzip.DefaultCompression))
+		compression := gzip.Gzip(gzip.DefaultCompression)
+		n.UseFunc(func(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+			// the push feed writes to the hijacked connection and must not be compressed
+			if strings.HasPrefix(r.URL.Path, "/api/feed/") {
+				next(w, r)
+				return
+			}
+			compression.ServeHTTP(w, r, next)
+		})
 
 		pa := &proxyaddr.ProxyAddr{}
 		pa.Init(proxyaddr.CIDRLoopback)
@@ -288,3 +410,17 @@ func main() {
 
 	logrus.Println("exiting...")
 }
+
+// newRPCClient creates a client for a backend node of the given type
+func newRPCClient(nodeType, endpoint string) (rpc.Client, error) {
+	switch nodeType {
+	case "prysm":
+		return rpc.NewPrysmClient(endpoint)
+	case "lighthouse":
+		return rpc.NewLighthouseClient(endpoint)
+	case "standard":
+		return rpc.NewStandardClient(endpoint)
+	default:
+		return nil, fmt.Errorf("invalid node type %v specified. supported node types are prysm, lighthouse and standard", nodeType)
+	}
+}
@@ -0,0 +1,107 @@
+// Command migrate applies or reverts the schema migrations of the explorer and frontend database, each database has a
+// migration set of its own.
+//
+// Usage: migrate -config config.yml [-frontend] status|up|down|goto|baseline
+//
+// up applies all pending migrations, down reverts the latest migration and goto migrates to the version passed with
+// -version. baseline marks the baseline migration as applied for databases that have been created from tables.sql
+// before migrations were introduced, the later migrations are applied by a subsequent up.
+package main
+
+import (
+	"eth2-exporter/db"
+	"eth2-exporter/db/migrations"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"flag"
+	"fmt"
+	"os"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	configPath := flag.String("config", "", "Path to the config file")
+	frontend := flag.Bool("frontend", false, "Migrate the frontend database instead of the explorer database")
+	version := flag.Int("version", -1, "Target version of the goto command")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %v [flags] status|up|down|goto|baseline\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	logrus.Printf("config file path: %v", *configPath)
+	cfg := &types.Config{}
+	err := utils.ReadConfig(cfg, *configPath)
+
+	if err != nil {
+		logrus.Fatalf("error reading config file: %v", err)
+	}
+	utils.Config = cfg
+
+	var dbConn *sqlx.DB
+	set := migrations.Explorer
+	if *frontend {
+		db.MustInitFrontendDB(cfg.Frontend.Database.Username, cfg.Frontend.Database.Password, cfg.Frontend.Database.Host, cfg.Frontend.Database.Port, cfg.Frontend.Database.Name, cfg.Frontend.SessionSecret)
+		dbConn = db.FrontendDB
+		set = migrations.Frontend
+	} else {
+		db.MustInitDB(cfg.Database.Username, cfg.Database.Password, cfg.Database.Host, cfg.Database.Port, cfg.Database.Name)
+		dbConn = db.DB
+	}
+	defer dbConn.Close()
+
+	current, err := db.GetMigrationVersion(dbConn, set)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	switch flag.Arg(0) {
+	case "status":
+		all, err := migrations.All(set)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		for _, m := range all {
+			state := "pending"
+			if m.Version <= current {
+				state = "applied"
+			}
+			fmt.Printf("%05d_%v\t%v\n", m.Version, m.Name, state)
+		}
+		return
+	case "up":
+		err = db.MigrateUp(dbConn, set)
+	case "down":
+		if current == 0 {
+			logrus.Fatal("no migration has been applied")
+		}
+		err = db.MigrateTo(dbConn, set, current-1)
+	case "goto":
+		if *version < 0 {
+			logrus.Fatal("the goto command requires the -version flag")
+		}
+		err = db.MigrateTo(dbConn, set, *version)
+	case "baseline":
+		err = db.MigrateBaseline(dbConn, set)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	current, err = db.GetMigrationVersion(dbConn, set)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	logrus.Infof("%v schema version: %v", set, current)
+}
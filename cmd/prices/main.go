@@ -0,0 +1,102 @@
+// Command prices imports the daily prices of 1 ETH in a currency from a csv file into the prices table, which is used
+// for the fiat values of the income export. Each row of the file holds a day (YYYY-MM-DD) and the price, a header
+// row is skipped.
+package main
+
+import (
+	"encoding/csv"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	configPath := flag.String("config", "", "Path to the config file")
+	currency := flag.String("currency", "USD", "Currency of the prices (ISO 4217 code)")
+	filePath := flag.String("file", "", "Path to the csv file with the columns day (YYYY-MM-DD) and price")
+	flag.Parse()
+
+	if *filePath == "" {
+		logrus.Fatal("no price file provided")
+	}
+
+	logrus.Printf("config file path: %v", *configPath)
+	cfg := &types.Config{}
+	err := utils.ReadConfig(cfg, *configPath)
+
+	if err != nil {
+		logrus.Fatalf("error reading config file: %v", err)
+	}
+	utils.Config = cfg
+
+	f, err := os.Open(*filePath)
+	if err != nil {
+		logrus.Fatalf("error opening price file: %v", err)
+	}
+	defer f.Close()
+
+	prices, err := readPrices(f, strings.ToUpper(*currency))
+	if err != nil {
+		logrus.Fatalf("error reading price file: %v", err)
+	}
+
+	db.MustInitDB(cfg.Database.Username, cfg.Database.Password, cfg.Database.Host, cfg.Database.Port, cfg.Database.Name)
+	defer db.DB.Close()
+
+	err = db.SavePrices(prices)
+	if err != nil {
+		logrus.Fatalf("error saving prices: %v", err)
+	}
+	logrus.Infof("imported %v %v prices", len(prices), strings.ToUpper(*currency))
+}
+
+// readPrices parses the rows of the csv, if a day is listed more than once the last price is used
+func readPrices(r io.Reader, currency string) ([]*types.Price, error) {
+	c := csv.NewReader(r)
+	c.FieldsPerRecord = 2
+	c.TrimLeadingSpace = true
+
+	byDay := make(map[time.Time]*types.Price)
+	prices := []*types.Price{}
+	for line := 1; ; line++ {
+		record, err := c.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		day, err := time.Parse("2006-01-02", record[0])
+		if err != nil {
+			if line == 1 {
+				// header row
+				continue
+			}
+			return nil, fmt.Errorf("invalid day %q in line %v", record[0], line)
+		}
+		price, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price %q in line %v", record[1], line)
+		}
+
+		if p, found := byDay[day]; found {
+			p.Price = price
+			continue
+		}
+		p := &types.Price{Day: day, Currency: currency, Price: price}
+		byDay[day] = p
+		prices = append(prices, p)
+	}
+	return prices, nil
+}
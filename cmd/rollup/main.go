@@ -0,0 +1,51 @@
+// Command rollup rebuilds the epoch_stats, daily_stats and eth1_deposits_daily tables from the exported history, e.g.
+// after the tables have been added or the computation of the statistics has changed.
+package main
+
+import (
+	"eth2-exporter/db"
+	"eth2-exporter/exporter"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"flag"
+	"math"
+	"time"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	configPath := flag.String("config", "", "Path to the config file")
+	startEpoch := flag.Uint64("start-epoch", 0, "First epoch to rebuild")
+	endEpoch := flag.Uint64("end-epoch", math.MaxInt64, "Last epoch to rebuild")
+	flag.Parse()
+
+	if *startEpoch > *endEpoch {
+		logrus.Fatal("the start epoch must not be after the end epoch")
+	}
+
+	logrus.Printf("config file path: %v", *configPath)
+	cfg := &types.Config{}
+	err := utils.ReadConfig(cfg, *configPath)
+
+	if err != nil {
+		logrus.Fatalf("error reading config file: %v", err)
+	}
+	utils.Config = cfg
+
+	db.MustInitDB(cfg.Database.Username, cfg.Database.Password, cfg.Database.Host, cfg.Database.Port, cfg.Database.Name)
+	defer db.DB.Close()
+
+	epochs, err := db.GetEpochsBetween(*startEpoch, *endEpoch)
+	if err != nil {
+		logrus.Fatalf("error retrieving epochs: %v", err)
+	}
+
+	start := time.Now()
+	err = exporter.UpdateRollups(epochs, true)
+	if err != nil {
+		logrus.Fatalf("error rebuilding rollups: %v", err)
+	}
+	logrus.Infof("rebuilt the rollups of %v epochs in %v", len(epochs), time.Since(start))
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"database/sql"
+	"eth2-exporter/types"
+	"time"
+)
+
+// GetApiCacheEntry returns the cached api response stored for key, nil is returned if there is no entry or it has expired
+func GetApiCacheEntry(key string) (*types.ApiCacheEntry, error) {
+	entry := &types.ApiCacheEntry{}
+	err := DB.Get(entry, "SELECT key, content_type, body, expires_ts FROM api_cache WHERE key = $1 AND (expires_ts IS NULL OR expires_ts > NOW())", key)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// SaveApiCacheEntry stores an api response, a nil expiry keeps the entry forever
+func SaveApiCacheEntry(entry *types.ApiCacheEntry) error {
+	_, err := DB.Exec(`
+		INSERT INTO api_cache (key, content_type, body, expires_ts)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key) DO UPDATE SET
+			content_type = excluded.content_type,
+			body         = excluded.body,
+			expires_ts   = excluded.expires_ts`,
+		entry.Key, entry.ContentType, entry.Body, entry.ExpiresTs)
+	return err
+}
+
+// DeleteExpiredApiCacheEntries removes all expired api responses
+func DeleteExpiredApiCacheEntries() error {
+	_, err := DB.Exec("DELETE FROM api_cache WHERE expires_ts < $1", time.Now())
+	return err
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"database/sql"
+	"eth2-exporter/types"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// CreateApiKey stores a new api key of a user
+func CreateApiKey(apiKey *types.ApiKey) error {
+	return DB.QueryRow(`
+		INSERT INTO api_keys (user_id, api_key, name, plan, active, created_ts)
+		VALUES ($1, $2, $3, $4, true, NOW())
+		RETURNING id, created_ts`,
+		apiKey.UserID, apiKey.Key, apiKey.Name, apiKey.Plan).Scan(&apiKey.ID, &apiKey.CreatedTs)
+}
+
+// GetApiKey returns the active api key matching key, nil is returned if the key does not exist or has been revoked
+func GetApiKey(key string) (*types.ApiKey, error) {
+	apiKey := &types.ApiKey{}
+	err := DB.Get(apiKey, "SELECT id, user_id, api_key, name, plan, active, created_ts, revoked_ts FROM api_keys WHERE api_key = $1 AND active", key)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return apiKey, nil
+}
+
+// GetUserApiKeys returns all api keys of a user, including revoked ones
+func GetUserApiKeys(userID uint64) ([]*types.ApiKey, error) {
+	apiKeys := []*types.ApiKey{}
+	err := DB.Select(&apiKeys, "SELECT id, user_id, api_key, name, plan, active, created_ts, revoked_ts FROM api_keys WHERE user_id = $1 ORDER BY active DESC, created_ts DESC", userID)
+	return apiKeys, err
+}
+
+// GetUserActiveApiKeysCount returns the number of api keys of a user that have not been revoked
+func GetUserActiveApiKeysCount(userID uint64) (uint64, error) {
+	var count uint64
+	err := DB.Get(&count, "SELECT COUNT(*) FROM api_keys WHERE user_id = $1 AND active", userID)
+	return count, err
+}
+
+// RevokeApiKey deactivates an api key of a user, false is returned if the user has no active key with that id
+func RevokeApiKey(userID, id uint64) (bool, error) {
+	res, err := DB.Exec("UPDATE api_keys SET active = false, revoked_ts = NOW() WHERE id = $1 AND user_id = $2 AND active", id, userID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	return rows > 0, err
+}
+
+// SaveApiStatistics adds the passed call counts to the api statistics
+func SaveApiStatistics(stats []*types.ApiStatistic) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	ts := make([]time.Time, len(stats))
+	keys := make([]string, len(stats))
+	calls := make([]string, len(stats))
+	counts := make([]int64, len(stats))
+	for i, stat := range stats {
+		ts[i] = stat.Ts
+		keys[i] = stat.ApiKey
+		calls[i] = stat.Call
+		counts[i] = int64(stat.Count)
+	}
+
+	_, err := DB.Exec(`
+		INSERT INTO api_statistics (ts, apikey, call, count)
+		SELECT * FROM UNNEST($1::timestamp[], $2::varchar[], $3::varchar[], $4::int[])
+		ON CONFLICT (ts, apikey, call) DO UPDATE SET count = api_statistics.count + excluded.count`,
+		pq.Array(ts), pq.StringArray(keys), pq.StringArray(calls), pq.Int64Array(counts))
+	return err
+}
+
+// GetUserApiStatistics returns the daily call counts of all api keys of a user since the passed time
+func GetUserApiStatistics(userID uint64, since time.Time) ([]*types.ApiStatistic, error) {
+	stats := []*types.ApiStatistic{}
+	err := DB.Select(&stats, `
+		SELECT s.ts, s.apikey, s.call, s.count
+		FROM api_statistics s
+		INNER JOIN api_keys k ON k.api_key = s.apikey
+		WHERE k.user_id = $1 AND s.ts >= $2
+		ORDER BY s.ts DESC, s.apikey, s.call`, userID, since)
+	return stats, err
+}
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// maxSqlNumber is the largest value of a bigint column, it is used as the start of the first page
+const maxSqlNumber = uint64(9223372036854775807)
+
+// GetValidatorIndices returns the indices of the validators matching the indices or pubkeys
+func GetValidatorIndices(indices []uint64, pubkeys pq.ByteaArray) ([]uint64, error) {
+	var validators []uint64
+	err := DB.Select(&validators, "SELECT validatorindex FROM validators WHERE validatorindex = ANY($1) OR pubkey = ANY($2) ORDER BY validatorindex", pq.Array(indices), pubkeys)
+	return validators, err
+}
+
+// GetApiV2Validators returns the validators with the indices
+func GetApiV2Validators(indices []uint64) ([]*types.ApiV2Validator, error) {
+	validators := []*types.ApiV2Validator{}
+	err := DB.Select(&validators, `
+		SELECT
+			validatorindex,
+			'0x' || ENCODE(pubkey, 'hex') AS pubkey,
+			'0x' || ENCODE(withdrawalcredentials, 'hex') AS withdrawalcredentials,
+			COALESCE(name, '') AS name,
+			balance,
+			effectivebalance,
+			slashed,
+			activationeligibilityepoch,
+			activationepoch,
+			exitepoch,
+			withdrawableepoch,
+			lastattestationslot
+		FROM validators
+		WHERE validatorindex = ANY($1)
+		ORDER BY validatorindex`, pq.Array(indices))
+	return validators, err
+}
+
+// GetApiV2ValidatorPerformances returns the performance of the validators with the indices
+func GetApiV2ValidatorPerformances(indices []uint64) ([]*types.ApiV2ValidatorPerformance, error) {
+	performances := []*types.ApiV2ValidatorPerformance{}
+	err := DB.Select(&performances, `
+		SELECT validatorindex, balance, performance1d, performance7d, performance31d, performance365d
+		FROM validator_performance
+		WHERE validatorindex = ANY($1)
+		ORDER BY validatorindex`, pq.Array(indices))
+	return performances, err
+}
+
+// GetApiV2ValidatorBalances returns up to limit balances of the validators with the indices, the latest epoch first.
+// If after is set only the balances after the cursor are returned.
+func GetApiV2ValidatorBalances(indices []uint64, after *types.ApiV2Cursor, limit uint64) ([]*types.ApiV2ValidatorBalance, error) {
+	if after == nil {
+		after = &types.ApiV2Cursor{Key: maxSqlNumber}
+	}
+	balances := []*types.ApiV2ValidatorBalance{}
+	err := DB.Select(&balances, `
+		SELECT epoch, validatorindex, balance, effectivebalance
+		FROM validator_balances
+		WHERE validatorindex = ANY($1) AND (epoch < $2 OR (epoch = $2 AND validatorindex > $3))
+		ORDER BY epoch DESC, validatorindex
+		LIMIT $4`, pq.Array(indices), after.Key, after.ValidatorIndex, limit)
+	return balances, err
+}
+
+// GetApiV2ValidatorAttestations returns up to limit attestation assignments of the validators with the indices,
+// the latest epoch first. If after is set only the assignments after the cursor are returned.
+func GetApiV2ValidatorAttestations(indices []uint64, after *types.ApiV2Cursor, limit uint64) ([]*types.ApiV2ValidatorAttestation, error) {
+	if after == nil {
+		after = &types.ApiV2Cursor{Key: maxSqlNumber}
+	}
+	rows := []struct {
+		types.ApiV2ValidatorAttestation
+		StatusCode uint64 `db:"statuscode"`
+	}{}
+	err := DB.Select(&rows, `
+		SELECT
+			epoch,
+			validatorindex,
+			attesterslot,
+			committeeindex,
+			status AS statuscode,
+			NULLIF(inclusionslot, 0) AS inclusionslot
+		FROM attestation_assignments
+		WHERE validatorindex = ANY($1) AND (epoch < $2 OR (epoch = $2 AND validatorindex > $3))
+		ORDER BY epoch DESC, validatorindex
+		LIMIT $4`, pq.Array(indices), after.Key, after.ValidatorIndex, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	currentSlot := utils.TimeToSlot(uint64(time.Now().Unix()))
+	attestations := make([]*types.ApiV2ValidatorAttestation, len(rows))
+	for i := range rows {
+		attestations[i] = &rows[i].ApiV2ValidatorAttestation
+		attestations[i].Status = apiV2AttestationStatus(rows[i].StatusCode, rows[i].AttesterSlot, currentSlot)
+	}
+	return attestations, nil
+}
+
+// apiV2AttestationStatus returns the api status of an attestation assignment. The exporter only records whether an
+// attestation has been included, an assignment that has not been included by the end of its inclusion window (one
+// epoch after the attester slot) is missed.
+func apiV2AttestationStatus(status, attesterSlot, currentSlot uint64) string {
+	if status == 1 {
+		return "executed"
+	}
+	if attesterSlot+utils.Config.Chain.SlotsPerEpoch < currentSlot {
+		return "missed"
+	}
+	return "scheduled"
+}
+
+// GetApiV2ValidatorProposals returns up to limit proposal assignments of the validators with the indices, the
+// latest slot first. If after is set only the assignments after the cursor are returned.
+func GetApiV2ValidatorProposals(indices []uint64, after *types.ApiV2Cursor, limit uint64) ([]*types.ApiV2ValidatorProposal, error) {
+	if after == nil {
+		after = &types.ApiV2Cursor{Key: maxSqlNumber}
+	}
+	proposals := []*types.ApiV2ValidatorProposal{}
+	err := DB.Select(&proposals, `
+		SELECT
+			pa.epoch,
+			pa.validatorindex,
+			pa.proposerslot,
+			CASE
+				WHEN pa.status = 1 AND b.status = '3' THEN 'orphaned'
+				WHEN pa.status = 1 THEN 'proposed'
+				WHEN pa.status = 2 THEN 'missed'
+				ELSE 'scheduled'
+			END AS status,
+			'0x' || ENCODE(b.blockroot, 'hex') AS blockroot
+		FROM proposal_assignments pa
+		LEFT JOIN LATERAL (
+			SELECT blockroot, status
+			FROM blocks
+			WHERE slot = pa.proposerslot AND proposer = pa.validatorindex AND status IN ('1', '3')
+			ORDER BY status
+			LIMIT 1
+		) b ON true
+		WHERE pa.validatorindex = ANY($1) AND (pa.proposerslot < $2 OR (pa.proposerslot = $2 AND pa.validatorindex > $3))
+		ORDER BY pa.proposerslot DESC, pa.validatorindex
+		LIMIT $4`, pq.Array(indices), after.Key, after.ValidatorIndex, limit)
+	return proposals, err
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"testing"
+)
+
+func TestApiV2AttestationStatus(t *testing.T) {
+	utils.Config = &types.Config{}
+	utils.Config.Chain.SlotsPerEpoch = 32
+
+	tests := []struct {
+		status, attesterSlot, currentSlot uint64
+		expected                          string
+	}{
+		{1, 100, 101, "executed"},
+		{1, 100, 1000, "executed"},
+		{0, 100, 101, "scheduled"},
+		{0, 100, 132, "scheduled"},
+		{0, 100, 133, "missed"},
+		{0, 100, 1000, "missed"},
+		{0, 1000, 100, "scheduled"},
+	}
+	for _, test := range tests {
+		status := apiV2AttestationStatus(test.status, test.attesterSlot, test.currentSlot)
+		if status != test.expected {
+			t.Errorf("unexpected status of %+v: got %v, want %v", test, status, test.expected)
+		}
+	}
+}
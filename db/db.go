@@ -3,6 +3,7 @@ package db
 import (
 	"bytes"
 	"database/sql"
+	"eth2-exporter/metrics"
 	"eth2-exporter/types"
 	"eth2-exporter/utils"
 	"fmt"
@@ -53,6 +54,7 @@ func mustInitDB(username, password, host, port, name string) *sqlx.DB {
 
 func MustInitDB(username, password, host, port, name string) {
 	DB = mustInitDB(username, password, host, port, name)
+	metrics.RegisterDBStats("explorer", DB.DB)
 }
 
 func GetEth1Deposits(address string, length, start uint64) ([]*types.EthOneDepositsData, error) {
@@ -431,7 +433,7 @@ func GetAllEpochs() ([]uint64, error) {
 func GetLastPendingAndProposedBlocks(startEpoch, endEpoch uint64) ([]*types.MinimalBlock, error) {
 	var blocks []*types.MinimalBlock
 
-	err := DB.Select(&blocks, "SELECT epoch, slot, blockroot FROM blocks WHERE epoch >= $1 AND epoch <= $2 AND blockroot != '\x01' ORDER BY slot DESC", startEpoch, endEpoch)
+	err := DB.Select(&blocks, "SELECT epoch, slot, blockroot, parentroot, status FROM blocks WHERE epoch >= $1 AND epoch <= $2 AND blockroot != '\x01' ORDER BY slot DESC", startEpoch, endEpoch)
 
 	if err != nil {
 		return nil, fmt.Errorf("error retrieving last blocks (%v-%v) from DB: %v", startEpoch, endEpoch, err)
@@ -461,6 +463,14 @@ func GetValidatorPublicKey(index uint64) ([]byte, error) {
 	return publicKey, err
 }
 
+// GetValidatorPublicKeys will return the public keys of the validators with the passed indices, unknown indices are skipped
+func GetValidatorPublicKeys(indices []uint64) ([][]byte, error) {
+	var publicKeys [][]byte
+	err := DB.Select(&publicKeys, "SELECT pubkey FROM validators WHERE validatorindex = ANY($1) ORDER BY validatorindex", pq.Array(indices))
+
+	return publicKeys, err
+}
+
 // GetValidatorIndex will return the validator-index for a public key from the database
 func GetValidatorIndex(publicKey []byte) (uint64, error) {
 	var index uint64
@@ -485,31 +495,33 @@ func GetValidatorDeposits(publicKey []byte) (*types.ValidatorDeposits, error) {
 	return deposits, nil
 }
 
-// UpdateCanonicalBlocks will update the blocks for an epoch range in the database
-func UpdateCanonicalBlocks(startEpoch, endEpoch uint64, orphanedBlocks [][]byte) error {
+// UpdateCanonicalBlocks will update the blocks for an epoch range in the database and returns the blocks that have
+// not been marked as orphaned before
+func UpdateCanonicalBlocks(startEpoch, endEpoch uint64, orphanedBlocks [][]byte) ([]*types.MinimalBlock, error) {
 	if len(orphanedBlocks) == 0 {
-		return nil
+		return nil, nil
 	}
 
-	tx, err := DB.Begin()
+	tx, err := DB.Beginx()
 	if err != nil {
-		return fmt.Errorf("error starting db transactions: %v", err)
+		return nil, fmt.Errorf("error starting db transactions: %v", err)
 	}
 	defer tx.Rollback()
 
-	_, err = tx.Exec("UPDATE blocks SET status = 1 WHERE epoch >= $1 AND epoch <= $2 AND (status = '1' OR status = '3')", startEpoch, endEpoch)
+	_, err = tx.Exec("UPDATE blocks SET status = 1 WHERE epoch >= $1 AND epoch <= $2 AND status = '3' AND NOT blockroot = ANY($3)", startEpoch, endEpoch, pq.ByteaArray(orphanedBlocks))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	for _, orphanedBlock := range orphanedBlocks {
-		logger.Printf("marking block %x as orphaned", orphanedBlock)
-		_, err = tx.Exec("UPDATE blocks SET status = '3' WHERE blockroot = $1", orphanedBlock)
-		if err != nil {
-			return err
-		}
+	newlyOrphaned := []*types.MinimalBlock{}
+	err = tx.Select(&newlyOrphaned, `
+		UPDATE blocks SET status = '3'
+		WHERE blockroot = ANY($1) AND status <> '3'
+		RETURNING epoch, slot, blockroot, parentroot, status, proposer`, pq.ByteaArray(orphanedBlocks))
+	if err != nil {
+		return nil, err
 	}
-	return tx.Commit()
+	return newlyOrphaned, tx.Commit()
 }
 
 // SaveValidatorQueue will save the validator queue into the database
@@ -528,6 +540,23 @@ func SaveValidatorQueue(validators *types.ValidatorQueue) error {
 
 // SaveEpoch will stave the epoch data into the database
 func SaveEpoch(data *types.EpochData) error {
+	// partitions are created before the transaction starts as creating them locks the partitioned table
+	balancesPartition, err := ensurePartitions("validator_balances", data.Epoch)
+	if err != nil {
+		return fmt.Errorf("error creating validator balances partitions: %v", err)
+	}
+	_, err = ensurePartitions("attestation_assignments", data.Epoch)
+	if err != nil {
+		return fmt.Errorf("error creating attestation assignments partitions: %v", err)
+	}
+	// the blocks of the epoch include attestations for the previous epoch as well
+	if data.Epoch > 0 {
+		_, err = ensurePartitions("attestation_assignments", data.Epoch-1)
+		if err != nil {
+			return fmt.Errorf("error creating attestation assignments partitions: %v", err)
+		}
+	}
+
 	tx, err := DB.Begin()
 	if err != nil {
 		return fmt.Errorf("error starting db transactions: %v", err)
@@ -562,10 +591,21 @@ func SaveEpoch(data *types.EpochData) error {
 		return fmt.Errorf("error saving validator assignments to db: %v", err)
 	}
 
-	logger.Infof("exporting validator balance data")
-	err = saveValidatorBalances(data.Epoch, data.Validators, tx)
+	// the per-epoch balances of a downsampled partition are not restored, the genesis balances are kept by the downsampling
+	if !balancesPartition.Downsampled() {
+		logger.Infof("exporting validator balance data")
+		err = saveValidatorBalances(data.Epoch, data.Validators, tx)
+		if err != nil {
+			return fmt.Errorf("error saving validator balances to db: %v", err)
+		}
+	} else {
+		logger.Infof("skipping validator balance data of epoch %v as it has been downsampled", data.Epoch)
+	}
+
+	logger.Infof("exporting daily validator statistics")
+	err = saveValidatorStatsDaily(data.Epoch, data.Validators, tx)
 	if err != nil {
-		return fmt.Errorf("error saving validator balances to db: %v", err)
+		return fmt.Errorf("error saving daily validator statistics to db: %v", err)
 	}
 
 	logger.Infof("exporting epoch statistics data")
@@ -574,9 +614,11 @@ func SaveEpoch(data *types.EpochData) error {
 	attestationsCount := 0
 	depositCount := 0
 	voluntaryExitCount := 0
+	blocksCount := 0
 
 	for _, slot := range data.Blocks {
 		for _, b := range slot {
+			blocksCount++
 			proposerSlashingsCount += len(b.ProposerSlashings)
 			attesterSlashingsCount += len(b.AttesterSlashings)
 			attestationsCount += len(b.Attestations)
@@ -656,6 +698,15 @@ func SaveEpoch(data *types.EpochData) error {
 		return fmt.Errorf("error committing db transaction: %v", err)
 	}
 
+	saveEpochRows.WithLabelValues("blocks").Add(float64(blocksCount))
+	saveEpochRows.WithLabelValues("validators").Add(float64(len(data.Validators)))
+	saveEpochRows.WithLabelValues("proposal_assignments").Add(float64(len(data.ValidatorAssignmentes.ProposerAssignments)))
+	saveEpochRows.WithLabelValues("attestation_assignments").Add(float64(len(data.ValidatorAssignmentes.AttestorAssignments)))
+	saveEpochRows.WithLabelValues("attestations").Add(float64(attestationsCount))
+	saveEpochRows.WithLabelValues("deposits").Add(float64(depositCount))
+	saveEpochRows.WithLabelValues("slashings").Add(float64(proposerSlashingsCount + attesterSlashingsCount))
+	saveEpochRows.WithLabelValues("voluntary_exits").Add(float64(voluntaryExitCount))
+
 	logger.Infof("export of epoch %v completed, took %v", data.Epoch, time.Since(start))
 	return nil
 }
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"eth2-exporter/types"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// GetEffectivenessExportStatus returns the next epoch whose attestation effectiveness has to be computed and the
+// latest finalized epoch
+func GetEffectivenessExportStatus() (next uint64, finalized uint64, err error) {
+	err = DB.Get(&next, "SELECT COALESCE(MAX(epoch) + 1, 0) FROM attestation_effectiveness")
+	if err != nil {
+		return 0, 0, err
+	}
+	err = DB.Get(&finalized, "SELECT COALESCE(MAX(epoch), 0) FROM epochs WHERE finalized")
+	if err != nil {
+		return 0, 0, err
+	}
+	return next, finalized, nil
+}
+
+// GetEffectivenessAssignments returns the attestation duties of the validators for the epoch
+func GetEffectivenessAssignments(epoch uint64) ([]*types.EffectivenessAssignment, error) {
+	var assignments []*types.EffectivenessAssignment
+	err := DB.Select(&assignments, `
+		SELECT DISTINCT ON (validatorindex) validatorindex, attesterslot
+		FROM attestation_assignments
+		WHERE epoch = $1
+		ORDER BY validatorindex, attesterslot`, epoch)
+	return assignments, err
+}
+
+// SaveAttestationEffectiveness stores the attestation effectiveness of the validators for an epoch and adds it to
+// the totals of the validators. Rows that have already been stored are skipped so the totals are not counted twice.
+func SaveAttestationEffectiveness(effectiveness []*types.AttestationEffectiveness) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting db transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	batchSize := 5000
+
+	for b := 0; b < len(effectiveness); b += batchSize {
+		start := b
+		end := b + batchSize
+		if len(effectiveness) < end {
+			end = len(effectiveness)
+		}
+
+		valueStrings := make([]string, 0, batchSize)
+		valueArgs := make([]interface{}, 0, batchSize*8)
+		for i, e := range effectiveness[start:end] {
+			valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", i*8+1, i*8+2, i*8+3, i*8+4, i*8+5, i*8+6, i*8+7, i*8+8))
+			valueArgs = append(valueArgs, e.Epoch, e.ValidatorIndex, e.AttesterSlot, e.InclusionSlot, e.OptimalInclusionSlot, e.CorrectSource, e.CorrectTarget, e.CorrectHead)
+		}
+		stmt := fmt.Sprintf(`
+		WITH inserted AS (
+			INSERT INTO attestation_effectiveness (epoch, validatorindex, attesterslot, inclusionslot, optimalinclusionslot, correct_source, correct_target, correct_head)
+			VALUES %s
+			ON CONFLICT (validatorindex, epoch) DO NOTHING
+			RETURNING *
+		)
+		INSERT INTO validator_effectiveness (validatorindex, attestations, included, correct_source, correct_target, correct_head, inclusion_delay, optimal_inclusion_delay, effectiveness)
+		SELECT
+			validatorindex,
+			1,
+			(inclusionslot > 0)::int,
+			correct_source::int,
+			correct_target::int,
+			correct_head::int,
+			CASE WHEN inclusionslot > 0 THEN inclusionslot - attesterslot ELSE 0 END,
+			CASE WHEN inclusionslot > 0 THEN optimalinclusionslot - attesterslot ELSE 0 END,
+			%s
+		FROM inserted
+		ON CONFLICT (validatorindex) DO UPDATE SET
+			attestations            = validator_effectiveness.attestations + EXCLUDED.attestations,
+			included                = validator_effectiveness.included + EXCLUDED.included,
+			correct_source          = validator_effectiveness.correct_source + EXCLUDED.correct_source,
+			correct_target          = validator_effectiveness.correct_target + EXCLUDED.correct_target,
+			correct_head            = validator_effectiveness.correct_head + EXCLUDED.correct_head,
+			inclusion_delay         = validator_effectiveness.inclusion_delay + EXCLUDED.inclusion_delay,
+			optimal_inclusion_delay = validator_effectiveness.optimal_inclusion_delay + EXCLUDED.optimal_inclusion_delay,
+			effectiveness           = (validator_effectiveness.effectiveness * validator_effectiveness.attestations + EXCLUDED.effectiveness) / (validator_effectiveness.attestations + 1)`,
+			strings.Join(valueStrings, ","), effectivenessExpr)
+		_, err := tx.Exec(stmt, valueArgs...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// effectivenessExpr is the sql expression of the effectiveness of a row of the attestation_effectiveness table
+const effectivenessExpr = `CASE WHEN inclusionslot > attesterslot THEN (optimalinclusionslot - attesterslot)::float / (inclusionslot - attesterslot) ELSE 0 END`
+
+// GetAttestationEffectiveness returns up to limit attestation effectiveness rows of the validators with the
+// indices, the latest epoch first. If after is set only the rows after the cursor are returned.
+func GetAttestationEffectiveness(indices []uint64, after *types.ApiV2Cursor, limit uint64) ([]*types.AttestationEffectiveness, error) {
+	if after == nil {
+		after = &types.ApiV2Cursor{Key: maxSqlNumber}
+	}
+	effectiveness := []*types.AttestationEffectiveness{}
+	err := DB.Select(&effectiveness, `
+		SELECT epoch, validatorindex, attesterslot, inclusionslot, optimalinclusionslot, correct_source, correct_target, correct_head
+		FROM attestation_effectiveness
+		WHERE validatorindex = ANY($1) AND (epoch < $2 OR (epoch = $2 AND validatorindex > $3))
+		ORDER BY epoch DESC, validatorindex
+		LIMIT $4`, pq.Array(indices), after.Key, after.ValidatorIndex, limit)
+	return effectiveness, err
+}
+
+// GetValidatorEffectiveness returns the attestation effectiveness of the validators with the indices since the
+// epoch
+func GetValidatorEffectiveness(indices []uint64, since int64) (*types.ValidatorEffectiveness, error) {
+	effectiveness := &types.ValidatorEffectiveness{}
+	err := DB.Get(effectiveness, `
+		SELECT
+			COUNT(*) AS attestations,
+			COUNT(*) FILTER (WHERE inclusionslot > 0) AS included,
+			COUNT(*) FILTER (WHERE correct_source) AS correct_source,
+			COUNT(*) FILTER (WHERE correct_target) AS correct_target,
+			COUNT(*) FILTER (WHERE correct_head) AS correct_head,
+			COALESCE(AVG(inclusionslot - attesterslot) FILTER (WHERE inclusionslot > 0), 0) AS inclusion_delay,
+			COALESCE(AVG(optimalinclusionslot - attesterslot) FILTER (WHERE inclusionslot > 0), 0) AS optimal_inclusion_delay,
+			COALESCE(AVG(`+effectivenessExpr+`), 0) AS effectiveness
+		FROM attestation_effectiveness
+		WHERE validatorindex = ANY($1) AND epoch >= $2`, pq.Array(indices), since)
+	return effectiveness, err
+}
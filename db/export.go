@@ -0,0 +1,142 @@
+package db
+
+import (
+	"database/sql"
+	"eth2-exporter/types"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// GetExportProgress returns the progress of the export run with the given name, nil is returned if there is no unfinished run
+func GetExportProgress(name string) (*types.ExportProgress, error) {
+	progress := &types.ExportProgress{}
+	err := DB.Get(progress, "SELECT name, startepoch, endepoch, lastcommittedepoch FROM export_progress WHERE name = $1", name)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+// SaveExportProgress stores the progress of an export run
+func SaveExportProgress(progress *types.ExportProgress) error {
+	_, err := DB.Exec(`
+		INSERT INTO export_progress (name, startepoch, endepoch, lastcommittedepoch, updated_ts)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (name) DO UPDATE SET
+			startepoch         = excluded.startepoch,
+			endepoch           = excluded.endepoch,
+			lastcommittedepoch = excluded.lastcommittedepoch,
+			updated_ts         = excluded.updated_ts`,
+		progress.Name, progress.StartEpoch, progress.EndEpoch, progress.LastCommittedEpoch)
+	return err
+}
+
+// DeleteExportProgress removes the progress of a finished export run
+func DeleteExportProgress(name string) error {
+	_, err := DB.Exec("DELETE FROM export_progress WHERE name = $1", name)
+	return err
+}
+
+// EnqueueExportJobs adds the given epochs to the export queue. Epochs that have already been exported are queued again,
+// pending epochs keep their backoff and dead-lettered epochs are left untouched until they are requeued explicitly.
+func EnqueueExportJobs(epochs []uint64) error {
+	if len(epochs) == 0 {
+		return nil
+	}
+	_, err := DB.Exec(`
+		INSERT INTO export_queue (epoch, status, attempts, last_error, next_attempt_ts, created_ts, updated_ts)
+		SELECT epoch, 'pending', 0, '', NOW(), NOW(), NOW() FROM UNNEST($1::int[]) AS epoch
+		ON CONFLICT (epoch) DO UPDATE SET
+			status          = 'pending',
+			attempts        = 0,
+			last_error      = '',
+			next_attempt_ts = excluded.next_attempt_ts,
+			updated_ts      = excluded.updated_ts
+		WHERE export_queue.status = 'done'`, pq.Array(epochs))
+	return err
+}
+
+// GetDueExportJobs returns all pending jobs of the export queue whose next attempt is due, ordered by epoch
+func GetDueExportJobs() ([]*types.ExportJob, error) {
+	jobs := []*types.ExportJob{}
+	err := DB.Select(&jobs, `
+		SELECT epoch, status, attempts, last_error, next_attempt_ts, created_ts, updated_ts
+		FROM export_queue
+		WHERE status = 'pending' AND next_attempt_ts <= NOW()
+		ORDER BY epoch`)
+	return jobs, err
+}
+
+// GetExportJobs returns all jobs of the export queue with the given status, ordered by epoch
+func GetExportJobs(status string) ([]*types.ExportJob, error) {
+	jobs := []*types.ExportJob{}
+	err := DB.Select(&jobs, `
+		SELECT epoch, status, attempts, last_error, next_attempt_ts, created_ts, updated_ts
+		FROM export_queue
+		WHERE status = $1
+		ORDER BY epoch`, status)
+	return jobs, err
+}
+
+// CountExportJobs returns the number of jobs of the export queue with the given status
+func CountExportJobs(status string) (uint64, error) {
+	var count uint64
+	err := DB.Get(&count, "SELECT COUNT(*) FROM export_queue WHERE status = $1", status)
+	return count, err
+}
+
+// GetExportJobEpochs returns the epochs of up to limit jobs of the export queue with the given status, ordered by epoch
+func GetExportJobEpochs(status string, limit uint64) ([]uint64, error) {
+	epochs := []uint64{}
+	err := DB.Select(&epochs, "SELECT epoch FROM export_queue WHERE status = $1 ORDER BY epoch LIMIT $2", status, limit)
+	return epochs, err
+}
+
+// SetExportJobsStatus sets the status of the given jobs of the export queue
+func SetExportJobsStatus(epochs []uint64, status string) error {
+	_, err := DB.Exec("UPDATE export_queue SET status = $1, updated_ts = NOW() WHERE epoch = ANY($2)", status, pq.Array(epochs))
+	return err
+}
+
+// SetExportJobFailed records a failed attempt of an export job, status is either pending or failed (dead-lettered)
+func SetExportJobFailed(epoch uint64, status, lastError string, nextAttempt time.Time) error {
+	_, err := DB.Exec(`
+		UPDATE export_queue SET
+			status          = $2,
+			attempts        = attempts + 1,
+			last_error      = $3,
+			next_attempt_ts = $4,
+			updated_ts      = NOW()
+		WHERE epoch = $1`, epoch, status, lastError, nextAttempt)
+	return err
+}
+
+// ResetInProgressExportJobs marks all jobs that are still in progress as pending, this is required after the indexer has been stopped during an export
+func ResetInProgressExportJobs() error {
+	_, err := DB.Exec("UPDATE export_queue SET status = 'pending', updated_ts = NOW() WHERE status = 'inprogress'")
+	return err
+}
+
+// RequeueFailedExportJobs moves the given dead-lettered jobs back to the pending state and resets their attempts.
+// If no epochs are passed all failed jobs are requeued. The number of requeued jobs is returned.
+func RequeueFailedExportJobs(epochs []uint64) (int64, error) {
+	var res sql.Result
+	var err error
+	if len(epochs) == 0 {
+		res, err = DB.Exec(`
+			UPDATE export_queue SET status = 'pending', attempts = 0, next_attempt_ts = NOW(), updated_ts = NOW()
+			WHERE status = 'failed'`)
+	} else {
+		res, err = DB.Exec(`
+			UPDATE export_queue SET status = 'pending', attempts = 0, next_attempt_ts = NOW(), updated_ts = NOW()
+			WHERE status = 'failed' AND epoch = ANY($1)`, pq.Array(epochs))
+	}
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"encoding/json"
+	"eth2-exporter/types"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// feedChannel is the postgres notification channel the exporter publishes the events of the push feed on
+const feedChannel = "explorer_feed"
+
+// maxFeedPayload is the maximum size of a notification payload accepted by postgres
+const maxFeedPayload = 8000
+
+// PublishFeedEvents sends the events to the frontends listening on the push feed
+func PublishFeedEvents(events []*types.FeedEvent) error {
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("error marshalling %v event: %v", e.Topic, err)
+		}
+		if len(payload) >= maxFeedPayload {
+			logger.Warnf("skipping %v event of %v bytes: payload exceeds the notification limit", e.Topic, len(payload))
+			continue
+		}
+		_, err = DB.Exec("SELECT pg_notify($1, $2)", feedChannel, string(payload))
+		if err != nil {
+			return fmt.Errorf("error publishing %v event: %v", e.Topic, err)
+		}
+	}
+	return nil
+}
+
+// ListenFeedEvents receives the events of the push feed from the explorer database and passes them to handler. It
+// reconnects automatically and never returns.
+func ListenFeedEvents(username, password, host, port, name string, handler func(*types.FeedEvent)) {
+	dsn := &url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(username, password),
+		Host:     net.JoinHostPort(host, port),
+		Path:     "/" + name,
+		RawQuery: "sslmode=disable",
+	}
+	listener := pq.NewListener(dsn.String(), time.Second*10, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.Errorf("error listening for feed events: %v", err)
+		}
+	})
+	err := listener.Listen(feedChannel)
+	if err != nil {
+		logger.Errorf("error listening on channel %v: %v", feedChannel, err)
+	}
+
+	for {
+		select {
+		case n := <-listener.Notify:
+			// a nil notification signals a reconnect, events published in the meantime are lost
+			if n == nil {
+				continue
+			}
+			e := &types.FeedEvent{}
+			err := json.Unmarshal([]byte(n.Extra), e)
+			if err != nil {
+				logger.Errorf("error unmarshalling feed event: %v", err)
+				continue
+			}
+			handler(e)
+		case <-time.After(time.Minute):
+			go listener.Ping()
+		}
+	}
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"errors"
+	"eth2-exporter/metrics"
 	"eth2-exporter/types"
 
 	"github.com/jmoiron/sqlx"
@@ -12,6 +13,7 @@ var FrontendDB *sqlx.DB
 
 func MustInitFrontendDB(username, password, host, port, name, sessionSecret string) {
 	FrontendDB = mustInitDB(username, password, host, port, name)
+	metrics.RegisterDBStats("frontend", FrontendDB.DB)
 }
 
 // GetUserEmailById returns the email of a user.
@@ -40,8 +42,8 @@ func UpdatePassword(userId uint64, hash []byte) error {
 }
 
 // AddAuthorizeCode registers a code that can be used in exchange for an access token
-func AddAuthorizeCode(userId uint64, code string, appId uint64) error {
-	_, err := FrontendDB.Exec("INSERT INTO oauth_codes (user_id, code, app_id, created_ts) VALUES($1, $2, $3, 'now')", userId, code, appId)
+func AddAuthorizeCode(userId uint64, code string, appId uint64, codeChallenge, deviceName string) error {
+	_, err := FrontendDB.Exec("INSERT INTO oauth_codes (user_id, code, app_id, code_challenge, device_name, created_ts) VALUES($1, $2, $3, $4, $5, 'now')", userId, code, appId, codeChallenge, deviceName)
 	return err
 }
 
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// GetValidatorBalancesAtEpochs returns the balances of the validators with the indices at the epochs. If the balances
+// of an epoch have been downsampled the latest balance of the day before the epoch is returned for it.
+func GetValidatorBalancesAtEpochs(indices []uint64, epochs []uint64) ([]*types.ApiV2ValidatorBalance, error) {
+	epochsPerDay := 86400/(utils.Config.Chain.SecondsPerSlot*utils.Config.Chain.SlotsPerEpoch) + 1
+	balances := []*types.ApiV2ValidatorBalance{}
+	err := DB.Select(&balances, `
+		SELECT e.epoch, vb.validatorindex, vb.balance, vb.effectivebalance
+		FROM UNNEST($2::int[]) e(epoch)
+		CROSS JOIN UNNEST($1::int[]) v(validatorindex)
+		INNER JOIN LATERAL (
+			SELECT validatorindex, balance, effectivebalance
+			FROM validator_balances
+			WHERE validatorindex = v.validatorindex AND epoch <= e.epoch AND epoch > e.epoch - $3
+			ORDER BY epoch DESC
+			LIMIT 1
+		) vb ON true
+		ORDER BY e.epoch, vb.validatorindex`, pq.Array(indices), pq.Array(epochs), epochsPerDay)
+	return balances, err
+}
+
+// GetValidatorDepositAmounts returns the amounts of the deposits to the validators with the indices that have been
+// included in canonical blocks between startEpoch and endEpoch (inclusive)
+func GetValidatorDepositAmounts(indices []uint64, startEpoch, endEpoch uint64) ([]*types.ValidatorDepositAmount, error) {
+	deposits := []*types.ValidatorDepositAmount{}
+	err := DB.Select(&deposits, `
+		SELECT b.epoch, v.validatorindex, bd.amount
+		FROM blocks_deposits bd
+		INNER JOIN blocks b ON b.slot = bd.block_slot AND b.status = '1'
+		INNER JOIN validators v ON v.pubkey = bd.publickey
+		WHERE v.validatorindex = ANY($1) AND b.epoch >= $2 AND b.epoch <= $3
+		ORDER BY b.epoch, v.validatorindex`, pq.Array(indices), startEpoch, endEpoch)
+	return deposits, err
+}
+
+// GetValidatorIndicesByEth1Address returns the indices of the validators that received a valid deposit from the eth1
+// address
+func GetValidatorIndicesByEth1Address(address []byte) ([]uint64, error) {
+	indices := []uint64{}
+	err := DB.Select(&indices, `
+		SELECT DISTINCT v.validatorindex
+		FROM eth1_deposits d
+		INNER JOIN validators v ON v.pubkey = d.publickey
+		WHERE d.from_address = $1 AND d.valid_signature
+		ORDER BY v.validatorindex`, address)
+	return indices, err
+}
+
+// GetPrices returns the prices of 1 ETH in the currency for the days between start and end (inclusive) by day
+// (YYYY-MM-DD)
+func GetPrices(currency string, start, end time.Time) (map[string]float64, error) {
+	rows := []*types.Price{}
+	err := DB.Select(&rows, `
+		SELECT ts, currency, price
+		FROM prices
+		WHERE currency = $1 AND ts >= $2 AND ts <= $3`, strings.ToUpper(currency), start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]float64, len(rows))
+	for _, p := range rows {
+		prices[p.Day.Format("2006-01-02")] = p.Price
+	}
+	return prices, nil
+}
+
+// SavePrices stores the prices, existing prices of the same day and currency are replaced
+func SavePrices(prices []*types.Price) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting db transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	batchSize := 5000
+
+	for b := 0; b < len(prices); b += batchSize {
+		start := b
+		end := b + batchSize
+		if len(prices) < end {
+			end = len(prices)
+		}
+
+		valueStrings := make([]string, 0, batchSize)
+		valueArgs := make([]interface{}, 0, batchSize*3)
+		for i, p := range prices[start:end] {
+			valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d)", i*3+1, i*3+2, i*3+3))
+			valueArgs = append(valueArgs, p.Day.Format("2006-01-02"), strings.ToUpper(p.Currency), p.Price)
+		}
+		stmt := fmt.Sprintf(`
+		INSERT INTO prices (ts, currency, price)
+		VALUES %s
+		ON CONFLICT (ts, currency) DO UPDATE SET price = EXCLUDED.price`, strings.Join(valueStrings, ","))
+		_, err := tx.Exec(stmt, valueArgs...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,11 @@
+package db
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var saveEpochRows = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "explorer_save_epoch_rows_total",
+	Help: "Number of rows written by the epoch exports by table.",
+}, []string{"table"})
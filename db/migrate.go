@@ -0,0 +1,215 @@
+package db
+
+import (
+	"errors"
+	"eth2-exporter/db/migrations"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// migrationsLockID is the key of the advisory lock that serializes the migrations of instances that start concurrently
+const migrationsLockID = 0x6d696772
+
+// ErrNotBaselined is returned when migrating a database that has been created without migrations
+var ErrNotBaselined = errors.New("the database contains the tables of the schema but no applied migrations, mark the existing schema as baseline with `migrate baseline` (`migrate -frontend baseline` for the frontend schema) once it matches the baseline migration")
+
+// migrationSet describes where the migrations of a set are recorded. The baseline table is created by the baseline
+// migration of the set, a database that contains it but no applied migrations has been created before migrations were
+// introduced.
+type migrationSet struct {
+	versionTable  string
+	baselineTable string
+}
+
+// migrationSets holds the migration sets by name, the explorer and frontend schema are versioned independently so
+// that both can live in the same database
+var migrationSets = map[string]migrationSet{
+	migrations.Explorer: {versionTable: "schema_migrations", baselineTable: "blocks"},
+	migrations.Frontend: {versionTable: "frontend_schema_migrations", baselineTable: "users"},
+}
+
+// loadMigrations returns the migrations of the set and where they are recorded
+func loadMigrations(set string) ([]*migrations.Migration, migrationSet, error) {
+	ms, ok := migrationSets[set]
+	if !ok {
+		return nil, ms, fmt.Errorf("unknown migration set %v", set)
+	}
+	all, err := migrations.All(set)
+	return all, ms, err
+}
+
+// MigrateUp applies all pending migrations of the set. A database that has been migrated by a newer release is left untouched so
+// that older instances keep running during a rolling deployment.
+func MigrateUp(dbConn *sqlx.DB, set string) error {
+	all, _, err := loadMigrations(set)
+	if err != nil {
+		return err
+	}
+	version, err := GetMigrationVersion(dbConn, set)
+	if err != nil {
+		return err
+	}
+	if version > len(all) {
+		logger.Warnf("%v schema version %v is newer than the latest known migration %v", set, version, len(all))
+		return nil
+	}
+	return MigrateTo(dbConn, set, len(all))
+}
+
+// MigrateTo applies or reverts migrations of the set one at a time until the schema has the target version
+func MigrateTo(dbConn *sqlx.DB, set string, target int) error {
+	all, ms, err := loadMigrations(set)
+	if err != nil {
+		return err
+	}
+	if target < 0 || target > len(all) {
+		return fmt.Errorf("invalid target version %v, the latest migration is %v", target, len(all))
+	}
+
+	for {
+		done, err := migrateStep(dbConn, all, ms, target)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// migrateStep applies or reverts a single migration towards the target version, true is returned once the target has
+// been reached
+func migrateStep(dbConn *sqlx.DB, all []*migrations.Migration, ms migrationSet, target int) (bool, error) {
+	tx, err := dbConn.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("error starting db transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	// the version is read after acquiring the lock as another instance may have migrated in the meantime
+	version, err := lockMigrations(tx, ms)
+	if err != nil {
+		return false, err
+	}
+	if version > len(all) {
+		return false, fmt.Errorf("database schema version %v is newer than the latest known migration %v", version, len(all))
+	}
+	if version == target {
+		return true, tx.Commit()
+	}
+
+	if version < target {
+		if version == 0 {
+			exists := false
+			err = tx.Get(&exists, "SELECT to_regclass($1) IS NOT NULL", ms.baselineTable)
+			if err != nil {
+				return false, fmt.Errorf("error checking for existing tables: %v", err)
+			}
+			if exists {
+				return false, ErrNotBaselined
+			}
+		}
+
+		m := all[version]
+		_, err = tx.Exec(m.Up)
+		if err != nil {
+			return false, fmt.Errorf("error applying migration %v_%v: %v", m.Version, m.Name, err)
+		}
+		_, err = tx.Exec(fmt.Sprintf("INSERT INTO %v (version, name, applied_ts) VALUES ($1, $2, NOW())", ms.versionTable), m.Version, m.Name)
+		if err != nil {
+			return false, fmt.Errorf("error recording migration %v_%v: %v", m.Version, m.Name, err)
+		}
+		logger.Infof("applied migration %v_%v", m.Version, m.Name)
+	} else {
+		m := all[version-1]
+		_, err = tx.Exec(m.Down)
+		if err != nil {
+			return false, fmt.Errorf("error reverting migration %v_%v: %v", m.Version, m.Name, err)
+		}
+		_, err = tx.Exec(fmt.Sprintf("DELETE FROM %v WHERE version = $1", ms.versionTable), m.Version)
+		if err != nil {
+			return false, fmt.Errorf("error recording revert of migration %v_%v: %v", m.Version, m.Name, err)
+		}
+		logger.Infof("reverted migration %v_%v", m.Version, m.Name)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("error committing migration: %v", err)
+	}
+	return false, nil
+}
+
+// MigrateBaseline marks the baseline migration of the set as applied without running it, this is done once for databases that
+// have been created from the schema before migrations were introduced
+func MigrateBaseline(dbConn *sqlx.DB, set string) error {
+	all, ms, err := loadMigrations(set)
+	if err != nil {
+		return err
+	}
+
+	tx, err := dbConn.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting db transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	version, err := lockMigrations(tx, ms)
+	if err != nil {
+		return err
+	}
+	if version != 0 {
+		return fmt.Errorf("the database already has the %v schema version %v", set, version)
+	}
+
+	_, err = tx.Exec(fmt.Sprintf("INSERT INTO %v (version, name, applied_ts) VALUES ($1, $2, NOW())", ms.versionTable), all[0].Version, all[0].Name)
+	if err != nil {
+		return fmt.Errorf("error recording baseline migration: %v", err)
+	}
+	return tx.Commit()
+}
+
+// GetMigrationVersion returns the version of the latest applied migration of the set, 0 if no migration has been
+// applied
+func GetMigrationVersion(dbConn *sqlx.DB, set string) (int, error) {
+	ms, ok := migrationSets[set]
+	if !ok {
+		return 0, fmt.Errorf("unknown migration set %v", set)
+	}
+	exists := false
+	err := dbConn.Get(&exists, "SELECT to_regclass($1) IS NOT NULL", ms.versionTable)
+	if err != nil {
+		return 0, fmt.Errorf("error checking for %v table: %v", ms.versionTable, err)
+	}
+	if !exists {
+		return 0, nil
+	}
+	return getMigrationVersion(dbConn, ms)
+}
+
+// lockMigrations acquires the migrations lock for the transaction, creates the version table of the set if necessary
+// and returns the current schema version
+func lockMigrations(tx *sqlx.Tx, ms migrationSet) (int, error) {
+	_, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", migrationsLockID)
+	if err != nil {
+		return 0, fmt.Errorf("error acquiring migrations lock: %v", err)
+	}
+	_, err = tx.Exec(fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %v (
+			version    int                         not null,
+			name       character varying(100)      not null,
+			applied_ts timestamp without time zone not null,
+			primary key (version)
+		)`, ms.versionTable))
+	if err != nil {
+		return 0, fmt.Errorf("error creating %v table: %v", ms.versionTable, err)
+	}
+	return getMigrationVersion(tx, ms)
+}
+
+func getMigrationVersion(q sqlx.Queryer, ms migrationSet) (int, error) {
+	version := 0
+	err := sqlx.Get(q, &version, fmt.Sprintf("SELECT COALESCE(MAX(version), 0) FROM %v", ms.versionTable))
+	if err != nil {
+		return 0, fmt.Errorf("error retrieving schema version: %v", err)
+	}
+	return version, nil
+}
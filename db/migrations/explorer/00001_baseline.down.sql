@@ -0,0 +1,22 @@
+drop table if exists api_statistics;
+drop table if exists chart_images;
+drop table if exists mails_sent;
+drop table if exists eth1_deposits;
+drop table if exists graffitiwall;
+drop table if exists network_liveness;
+drop table if exists blocks_voluntaryexits;
+drop table if exists blocks_deposits;
+drop table if exists blocks_attestations;
+drop table if exists blocks_attesterslashings;
+drop table if exists blocks_proposerslashings;
+drop table if exists blocks;
+drop table if exists epochs;
+drop table if exists validatorqueue_exit;
+drop table if exists validatorqueue_activation;
+drop table if exists queue;
+drop table if exists validator_balances;
+drop table if exists attestation_assignments;
+drop table if exists proposal_assignments;
+drop table if exists validator_performance;
+drop table if exists validator_set;
+drop table if exists validators;
@@ -4,7 +4,6 @@ It also acts as a lookup-table to store the index-pubkey association
 In order to save db space we only use the unique validator index in all other tables
 In the future it is better to replace this table with an in memory cache (redis)
 */
-drop table if exists validators;
 create table validators
 (
     validatorindex             int    not null,
@@ -24,7 +23,6 @@ create table validators
 create index idx_validators_pubkey on validators (pubkey);
 create index idx_validators_name on validators (name);
 
-drop table if exists validator_set;
 create table validator_set
 (
     epoch                      int    not null,
@@ -39,7 +37,6 @@ create table validator_set
     primary key (validatorindex, epoch)
 );
 
-drop table if exists validator_performance;
 create table validator_performance
 (
     validatorindex  int    not null,
@@ -56,7 +53,6 @@ create index idx_validator_performance_performance7d on validator_performance (p
 create index idx_validator_performance_performance31d on validator_performance (performance31d);
 create index idx_validator_performance_performance365d on validator_performance (performance365d);
 
-drop table if exists proposal_assignments;
 create table proposal_assignments
 (
     epoch          int not null,
@@ -67,7 +63,6 @@ create table proposal_assignments
 );
 create index idx_proposal_assignments_epoch on proposal_assignments (epoch);
 
-drop table if exists attestation_assignments;
 create table attestation_assignments
 (
     epoch          int not null,
@@ -81,7 +76,6 @@ create table attestation_assignments
 create index idx_attestation_assignments_validatorindex on attestation_assignments (validatorindex);
 create index idx_attestation_assignments_epoch on attestation_assignments (epoch);
 
-drop table if exists validator_balances;
 create table validator_balances
 (
     epoch            int    not null,
@@ -92,7 +86,6 @@ create table validator_balances
 );
 create index idx_validator_balances_epoch on validator_balances (epoch);
 
-drop table if exists queue;
 create table queue
 (
     ts                        timestamp without time zone,
@@ -101,7 +94,6 @@ create table queue
     primary key (ts)
 );
 
-drop table if exists validatorqueue_activation;
 create table validatorqueue_activation
 (
     index     int   not null,
@@ -109,7 +101,6 @@ create table validatorqueue_activation
     primary key (index, publickey)
 );
 
-drop table if exists validatorqueue_exit;
 create table validatorqueue_exit
 (
     index     int   not null,
@@ -117,7 +108,6 @@ create table validatorqueue_exit
     primary key (index, publickey)
 );
 
-drop table if exists epochs;
 create table epochs
 (
     epoch                   int    not null,
@@ -137,7 +127,6 @@ create table epochs
     primary key (epoch)
 );
 
-drop table if exists blocks;
 create table blocks
 (
     epoch                  int   not null,
@@ -163,7 +152,6 @@ create table blocks
 );
 create index idx_blocks_proposer on blocks (proposer);
 
-drop table if exists blocks_proposerslashings;
 create table blocks_proposerslashings
 (
     block_slot         int    not null,
@@ -182,7 +170,6 @@ create table blocks_proposerslashings
     primary key (block_slot, block_index)
 );
 
-drop table if exists blocks_attesterslashings;
 create table blocks_attesterslashings
 (
     block_slot                   int       not null,
@@ -208,7 +195,6 @@ create table blocks_attesterslashings
     primary key (block_slot, block_index)
 );
 
-drop table if exists blocks_attestations;
 create table blocks_attestations
 (
     block_slot      int   not null,
@@ -229,7 +215,6 @@ create index idx_blocks_attestations_beaconblockroot on blocks_attestations (bea
 create index idx_blocks_attestations_source_root on blocks_attestations (source_root);
 create index idx_blocks_attestations_target_root on blocks_attestations (target_root);
 
-drop table if exists blocks_deposits;
 create table blocks_deposits
 (
     block_slot            int    not null,
@@ -242,7 +227,6 @@ create table blocks_deposits
     primary key (block_slot, block_index)
 );
 
-drop table if exists blocks_voluntaryexits;
 create table blocks_voluntaryexits
 (
     block_slot     int   not null,
@@ -253,7 +237,6 @@ create table blocks_voluntaryexits
     primary key (block_slot, block_index)
 );
 
-drop table if exists network_liveness;
 create table network_liveness
 (
     ts                     timestamp without time zone,
@@ -264,7 +247,6 @@ create table network_liveness
     primary key (ts)
 );
 
-drop table if exists graffitiwall;
 create table graffitiwall
 (
     x         int  not null,
@@ -275,7 +257,6 @@ create table graffitiwall
     primary key (x, y)
 );
 
-drop table if exists eth1_deposits;
 create table eth1_deposits
 (
     tx_hash                bytea                       not null,
@@ -295,84 +276,6 @@ create table eth1_deposits
 );
 create index idx_eth1_deposits on eth1_deposits (publickey);
 
-drop table if exists users;
-create table users
-(
-    id                      serial                 not null unique,
-    password                character varying(256) not null,
-    email                   character varying(100) not null unique,
-    email_confirmed         bool                   not null default 'f',
-    email_confirmation_hash character varying(40) unique,
-    email_confirmation_ts   timestamp without time zone,
-    password_reset_hash     character varying(40),
-    password_reset_ts       timestamp without time zone,
-    register_ts             timestamp without time zone,
-    primary key (id, email)
-);
-
-drop table if exists oauth_apps;
-create table oauth_apps
-(
-    id                    serial                      not null,
-    owner_id              int                         not null,
-    redirect_uri          character varying(100)      not null unique,
-    app_name              character varying(35)       not null,
-    active                bool                        not null default 't',
-    created_ts            timestamp without time zone not null,
-    primary key (id, redirect_uri)
-);
-
-drop table if exists oauth_codes;
-create table oauth_codes
-(
-    id              serial                      not null,
-    user_id         int                         not null,
-    code            character varying(64)       not null,
-    consumed        bool                        not null default 'f',
-    app_id          int                         not null,
-    created_ts      timestamp without time zone not null,
-    primary key (user_id, code)
-);
-
-drop table if exists users_devices;
-create table users_devices
-(
-    id                    serial                      not null,
-    user_id               int                         not null,
-    refresh_token         character varying(64)       not null,
-    device_name           character varying(20)       not null,
-    notification_token    character varying(500),
-    notify_enabled        bool                        not null default 'f',
-    active                bool                        not null default 't',
-    app_id                int                         not null,
-    created_ts            timestamp without time zone not null,
-    primary key (user_id, refresh_token)
-);
-
-drop table if exists users_subscriptions;
-create table users_subscriptions
-(
-    id              serial                      not null,
-    user_id         int                         not null,
-    event_name      character varying(100)      not null,
-    event_filter    text                        not null default '',
-    last_sent_ts    timestamp without time zone,
-    last_sent_epoch int,
-    created_ts      timestamp without time zone not null,
-    created_epoch   int                         not null,
-    primary key (user_id, event_name, event_filter)
-);
-
-drop table if exists users_validators_tags;
-create table users_validators_tags
-(
-    user_id             int                    not null,
-    validator_publickey bytea                  not null,
-    tag                 character varying(100) not null,
-    primary key (user_id, validator_publickey, tag)
-);
-
-drop table if exists mails_sent;
 create table mails_sent
 (
     email character varying(100)      not null,
@@ -381,14 +284,12 @@ create table mails_sent
     primary key (email, ts)
 );
 
-drop table if exists chart_images;
 create table chart_images
 (
     name  varchar(100) not null primary key,
     image bytea        not null
 );
 
-drop table if exists api_statistics;
 create table api_statistics
 (
     ts     timestamp without time zone not null,
@@ -0,0 +1,9 @@
+create table export_progress
+(
+    name               varchar(40)                 not null, /* Name of the export run, e.g. fullindex */
+    startepoch         int                         not null,
+    endepoch           int                         not null,
+    lastcommittedepoch int,                                  /* Last epoch of the run that has been committed in order */
+    updated_ts         timestamp without time zone not null,
+    primary key (name)
+);
@@ -0,0 +1,12 @@
+create table export_queue
+(
+    epoch           int                         not null,
+    status          varchar(20)                 not null, /* Can be pending, inprogress, failed (dead-lettered) or done */
+    attempts        int                         not null default 0,
+    last_error      text                        not null default '',
+    next_attempt_ts timestamp without time zone not null,
+    created_ts      timestamp without time zone not null,
+    updated_ts      timestamp without time zone not null,
+    primary key (epoch)
+);
+create index idx_export_queue_status on export_queue (status, next_attempt_ts);
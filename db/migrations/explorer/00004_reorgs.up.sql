@@ -0,0 +1,16 @@
+create table reorgs
+(
+    id              serial                      not null,
+    ts              timestamp without time zone not null, /* Time the reorg has been detected */
+    epoch           int                         not null, /* Epoch of the first affected slot */
+    depth           int                         not null, /* Number of canonical blocks that have been orphaned */
+    old_head_slot   int                         not null,
+    old_head_root   bytea                       not null,
+    new_head_slot   int                         not null,
+    new_head_root   bytea                       not null,
+    affected_slots  int[]                       not null,
+    orphaned_blocks bytea[]                     not null,
+    primary key (id)
+);
+create unique index idx_reorgs_heads on reorgs (old_head_root, new_head_root);
+create index idx_reorgs_ts on reorgs (ts);
@@ -0,0 +1,9 @@
+create table api_cache
+(
+    key          varchar(64)                 not null, /* sha256 of the cache key */
+    content_type varchar(100)                not null,
+    body         bytea                       not null,
+    expires_ts   timestamp without time zone,          /* Null for responses of finalized data that never expire */
+    primary key (key)
+);
+create index idx_api_cache_expires_ts on api_cache (expires_ts);
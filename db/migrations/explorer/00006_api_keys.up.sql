@@ -0,0 +1,13 @@
+create table api_keys
+(
+    id         serial                      not null,
+    user_id    int                         not null,
+    api_key    varchar(64)                 not null unique,
+    name       varchar(40)                 not null default '',
+    plan       varchar(20)                 not null default 'free', /* Can be free, sapphire, emerald or diamond */
+    active     bool                        not null default 't',
+    created_ts timestamp without time zone not null,
+    revoked_ts timestamp without time zone,
+    primary key (id)
+);
+create index idx_api_keys_user_id on api_keys (user_id);
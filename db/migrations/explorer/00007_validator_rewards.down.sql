@@ -0,0 +1 @@
+drop table if exists validator_rewards;
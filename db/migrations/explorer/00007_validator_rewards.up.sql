@@ -0,0 +1,14 @@
+create table validator_rewards
+(
+    epoch                  int    not null,
+    validatorindex         int    not null,
+    source_reward          bigint not null,
+    target_reward          bigint not null,
+    head_reward            bigint not null,
+    inclusion_delay_reward bigint not null,
+    proposer_reward        bigint not null,
+    inactivity_penalty     bigint not null,
+    slashing_penalty       bigint not null,
+    primary key (validatorindex, epoch)
+);
+create index idx_validator_rewards_epoch on validator_rewards (epoch);
@@ -0,0 +1,7 @@
+create table prices
+(
+    ts       date        not null,
+    currency varchar(10) not null, /* ISO 4217 code, e.g. USD or EUR */
+    price    float       not null, /* Price of 1 ETH at the end of the day (UTC) */
+    primary key (ts, currency)
+);
@@ -0,0 +1 @@
+drop table if exists validator_stats_daily;
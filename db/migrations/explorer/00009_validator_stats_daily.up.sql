@@ -0,0 +1,12 @@
+create table validator_stats_daily
+(
+    validatorindex int    not null,
+    day            int    not null, /* Number of 24 hour periods since genesis */
+    start_epoch    int    not null, /* First exported epoch of the day at which the validator has been activated */
+    start_balance  bigint not null,
+    end_epoch      int    not null, /* Last exported epoch of the day */
+    end_balance    bigint not null,
+    deposits       bigint not null default 0, /* Deposits that have been processed during the day after the activation */
+    primary key (validatorindex, day)
+);
+create index idx_validator_stats_daily_day on validator_stats_daily (day);
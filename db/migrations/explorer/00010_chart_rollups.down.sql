@@ -0,0 +1,7 @@
+drop table if exists eth1_deposits_daily;
+drop table if exists daily_stats;
+drop table if exists epoch_stats;
+
+drop index if exists idx_eth1_deposits_block_ts;
+drop index if exists idx_network_liveness_headepoch;
+drop index if exists idx_validators_activationepoch;
@@ -0,0 +1,51 @@
+create index idx_validators_activationepoch on validators (activationepoch);
+create index idx_network_liveness_headepoch on network_liveness (headepoch);
+create index idx_eth1_deposits_block_ts on eth1_deposits (block_ts);
+
+create table epoch_stats
+(
+    epoch                   int                         not null,
+    ts                      timestamp without time zone not null, /* Start of the epoch */
+    proposed_blocks         int                         not null,
+    missed_blocks           int                         not null,
+    orphaned_blocks         int                         not null,
+    validatorscount         int                         not null,
+    eligibleether           bigint                      not null,
+    votedether              bigint                      not null,
+    totalvalidatorbalance   bigint                      not null,
+    averagevalidatorbalance bigint                      not null,
+    globalparticipationrate float                       not null,
+    inclusion_distance      float,                                /* Average inclusion distance of the attestations of the epoch, null if none has been included */
+    finality_delay          int,                                  /* Head epoch minus finalized epoch when the head reached the epoch, null if unknown */
+    activation_deposits     bigint                      not null, /* Balances of the validators that have been activated in the epoch at their activation */
+    extra_deposits          bigint                      not null, /* Deposits to activated validators that have been included in the epoch */
+    deposits                bigint                      not null, /* All deposits that have been included in the epoch */
+    finalized               bool                        not null, /* The statistics of finalized epochs are not updated anymore */
+    primary key (epoch)
+);
+create index idx_epoch_stats_ts on epoch_stats (ts);
+
+create table daily_stats
+(
+    day                     date   not null, /* UTC */
+    first_epoch             int    not null,
+    last_epoch              int    not null,
+    proposed_blocks         int    not null,
+    missed_blocks           int    not null,
+    orphaned_blocks         int    not null,
+    validatorscount         int    not null, /* At the last epoch of the day */
+    eligibleether           bigint not null, /* At the last epoch of the day */
+    totalvalidatorbalance   bigint not null, /* At the last epoch of the day */
+    averagevalidatorbalance bigint not null, /* At the last epoch of the day */
+    rewards                 bigint not null, /* Rewards of all validators since genesis up to the last epoch of the day */
+    deposits                bigint not null, /* All deposits that have been included during the day */
+    primary key (day)
+);
+
+create table eth1_deposits_daily
+(
+    day            date   not null, /* UTC */
+    valid_amount   bigint not null,
+    invalid_amount bigint not null,
+    primary key (day)
+);
@@ -0,0 +1,5 @@
+create table chart_images
+(
+    name  varchar(100) not null primary key,
+    image bytea        not null
+);
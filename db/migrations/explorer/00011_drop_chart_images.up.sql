@@ -0,0 +1,2 @@
+/* The chart images are rendered and cached on demand by the api */
+drop table if exists chart_images;
@@ -0,0 +1,2 @@
+drop table if exists validator_effectiveness;
+drop table if exists attestation_effectiveness;
@@ -0,0 +1,28 @@
+create table attestation_effectiveness
+(
+    epoch                int     not null,
+    validatorindex       int     not null,
+    attesterslot         int     not null,
+    inclusionslot        int     not null, /* 0 if the attestation has not been included in a canonical block */
+    optimalinclusionslot int     not null, /* first slot after the attester slot with a canonical block */
+    correct_source       boolean not null,
+    correct_target       boolean not null,
+    correct_head         boolean not null,
+    primary key (validatorindex, epoch)
+);
+create index idx_attestation_effectiveness_epoch on attestation_effectiveness (epoch);
+
+create table validator_effectiveness
+(
+    validatorindex          int    not null,
+    attestations            int    not null,
+    included                int    not null,
+    correct_source          int    not null,
+    correct_target          int    not null,
+    correct_head            int    not null,
+    inclusion_delay         bigint not null, /* sum of the inclusion delays of the included attestations */
+    optimal_inclusion_delay bigint not null, /* sum of the optimal inclusion delays of the included attestations */
+    effectiveness           float  not null, /* average optimal / actual inclusion delay, 0 for missed attestations */
+    primary key (validatorindex)
+);
+create index idx_validator_effectiveness_effectiveness on validator_effectiveness (effectiveness);
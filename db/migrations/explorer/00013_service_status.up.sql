@@ -0,0 +1,10 @@
+create table service_status
+(
+    name            varchar(50)                 not null,
+    last_success_ts timestamp without time zone,
+    last_failure_ts timestamp without time zone,
+    last_error      text                        not null default '',
+    height          bigint                      not null default 0, /* Block or epoch the service has processed */
+    head            bigint                      not null default 0, /* Head of the chain the service follows */
+    primary key (name)
+);
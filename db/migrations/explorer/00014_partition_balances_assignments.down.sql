@@ -0,0 +1,33 @@
+/*
+ * Copies the rows of all partitions back into unpartitioned tables. Downsampled balances stay downsampled and archived
+ * attestation assignments are not restored.
+ */
+
+create table validator_balances_unpartitioned
+(
+    epoch            int    not null,
+    validatorindex   int    not null,
+    balance          bigint not null,
+    effectivebalance bigint not null
+);
+insert into validator_balances_unpartitioned select epoch, validatorindex, balance, effectivebalance from validator_balances;
+drop table validator_balances;
+alter table validator_balances_unpartitioned rename to validator_balances;
+alter table validator_balances add primary key (validatorindex, epoch);
+create index idx_validator_balances_epoch on validator_balances (epoch);
+
+create table attestation_assignments_unpartitioned
+(
+    epoch          int not null,
+    validatorindex int not null,
+    attesterslot   int not null,
+    committeeindex int not null,
+    status         int not null, /* Can be 0 = scheduled, 1 executed, 2 missed */
+    inclusionslot  int not null default 0 /* Slot this attestation was included for the first time */
+);
+insert into attestation_assignments_unpartitioned select epoch, validatorindex, attesterslot, committeeindex, status, inclusionslot from attestation_assignments;
+drop table attestation_assignments;
+alter table attestation_assignments_unpartitioned rename to attestation_assignments;
+alter table attestation_assignments add primary key (epoch, validatorindex, attesterslot, committeeindex);
+create index idx_attestation_assignments_validatorindex on attestation_assignments (validatorindex);
+create index idx_attestation_assignments_epoch on attestation_assignments (epoch);
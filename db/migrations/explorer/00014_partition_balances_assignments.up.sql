@@ -0,0 +1,57 @@
+/*
+ * validator_balances and attestation_assignments are range partitioned by epoch. The existing rows are kept in a
+ * single legacy partition that covers all epochs up to the latest stored epoch, further partitions are created by the
+ * exporter. Requires PostgreSQL 11 or newer.
+ */
+
+alter table validator_balances rename to validator_balances_legacy;
+alter table validator_balances_legacy rename constraint validator_balances_pkey to validator_balances_legacy_pkey;
+alter index idx_validator_balances_epoch rename to idx_validator_balances_legacy_epoch;
+
+create table validator_balances
+(
+    epoch            int    not null,
+    validatorindex   int    not null,
+    balance          bigint not null,
+    effectivebalance bigint not null,
+    primary key (validatorindex, epoch)
+) partition by range (epoch);
+create index idx_validator_balances_epoch on validator_balances (epoch);
+
+alter table attestation_assignments rename to attestation_assignments_legacy;
+alter table attestation_assignments_legacy rename constraint attestation_assignments_pkey to attestation_assignments_legacy_pkey;
+alter index idx_attestation_assignments_validatorindex rename to idx_attestation_assignments_legacy_validatorindex;
+alter index idx_attestation_assignments_epoch rename to idx_attestation_assignments_legacy_epoch;
+
+create table attestation_assignments
+(
+    epoch          int not null,
+    validatorindex int not null,
+    attesterslot   int not null,
+    committeeindex int not null,
+    status         int not null, /* Can be 0 = scheduled, 1 executed, 2 missed */
+    inclusionslot  int not null default 0, /* Slot this attestation was included for the first time */
+    primary key (epoch, validatorindex, attesterslot, committeeindex)
+) partition by range (epoch);
+create index idx_attestation_assignments_validatorindex on attestation_assignments (validatorindex);
+create index idx_attestation_assignments_epoch on attestation_assignments (epoch);
+
+do $$
+declare
+    max_epoch int;
+begin
+    select max(epoch) into max_epoch from validator_balances_legacy;
+    if max_epoch is null then
+        drop table validator_balances_legacy;
+    else
+        execute format('alter table validator_balances attach partition validator_balances_legacy for values from (0) to (%s)', max_epoch + 1);
+    end if;
+
+    select max(epoch) into max_epoch from attestation_assignments_legacy;
+    if max_epoch is null then
+        drop table attestation_assignments_legacy;
+    else
+        execute format('alter table attestation_assignments attach partition attestation_assignments_legacy for values from (0) to (%s)', max_epoch + 1);
+    end if;
+end
+$$;
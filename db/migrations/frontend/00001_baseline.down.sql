@@ -0,0 +1,6 @@
+drop table if exists users_validators_tags;
+drop table if exists users_subscriptions;
+drop table if exists users_devices;
+drop table if exists oauth_codes;
+drop table if exists oauth_apps;
+drop table if exists users;
@@ -0,0 +1,69 @@
+create table users
+(
+    id                      serial                 not null unique,
+    password                character varying(256) not null,
+    email                   character varying(100) not null unique,
+    email_confirmed         bool                   not null default 'f',
+    email_confirmation_hash character varying(40) unique,
+    email_confirmation_ts   timestamp without time zone,
+    password_reset_hash     character varying(40),
+    password_reset_ts       timestamp without time zone,
+    register_ts             timestamp without time zone,
+    primary key (id, email)
+);
+
+create table oauth_apps
+(
+    id                    serial                      not null,
+    owner_id              int                         not null,
+    redirect_uri          character varying(100)      not null unique,
+    app_name              character varying(35)       not null,
+    active                bool                        not null default 't',
+    created_ts            timestamp without time zone not null,
+    primary key (id, redirect_uri)
+);
+
+create table oauth_codes
+(
+    id              serial                      not null,
+    user_id         int                         not null,
+    code            character varying(64)       not null,
+    consumed        bool                        not null default 'f',
+    app_id          int                         not null,
+    created_ts      timestamp without time zone not null,
+    primary key (user_id, code)
+);
+
+create table users_devices
+(
+    id                    serial                      not null,
+    user_id               int                         not null,
+    refresh_token         character varying(64)       not null,
+    device_name           character varying(20)       not null,
+    notification_token    character varying(500),
+    notify_enabled        bool                        not null default 'f',
+    active                bool                        not null default 't',
+    app_id                int                         not null,
+    created_ts            timestamp without time zone not null,
+    primary key (user_id, refresh_token)
+);
+
+create table users_subscriptions
+(
+    id              serial                      not null,
+    user_id         int                         not null,
+    event_name      character varying(100)      not null,
+    event_filter    text                        not null default '',
+    last_sent_ts    timestamp without time zone,
+    last_sent_epoch int,
+    created_ts      timestamp without time zone not null,
+    created_epoch   int                         not null,
+    primary key (user_id, event_name, event_filter)
+);
+
+create table users_validators_tags
+(
+    user_id             int                    not null,
+    validator_publickey bytea                  not null,
+    tag                 character varying(100) not null,
+    primary key (user_id, validator_publickey, tag)
@@ -0,0 +1,3 @@
+drop table if exists notification_deliveries;
+drop table if exists users_webhooks;
+alter table users_subscriptions drop column if exists channels;
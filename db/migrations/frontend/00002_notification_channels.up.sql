@@ -0,0 +1,24 @@
+alter table users_subscriptions add column channels text[] not null default '{email}'; /* Notification channels of the subscription: email, webhook and/or push */
+
+create table users_webhooks
+(
+    user_id    int                         not null,
+    url        character varying(500)      not null,
+    secret     character varying(64)       not null, /* Key of the HMAC-SHA256 signature of the webhook requests */
+    created_ts timestamp without time zone not null,
+    primary key (user_id)
+);
+
+create table notification_deliveries
+(
+    id               serial                      not null,
+    user_id          int                         not null,
+    channel          varchar(20)                 not null, /* Can be email, webhook or push */
+    target           text                        not null default '',
+    status           varchar(20)                 not null, /* Can be sent, failed or ratelimited */
+    error            text                        not null default '',
+    subscription_ids int[]                       not null,
+    ts               timestamp without time zone not null,
+    primary key (id)
+);
+create index idx_notification_deliveries_user_channel_ts on notification_deliveries (user_id, channel, ts);
@@ -0,0 +1 @@
+drop table if exists network_notification_states;
@@ -0,0 +1,9 @@
+create table network_notification_states
+(
+    name          varchar(50)                 not null, /* Can be activation_queue_full, exit_queue_full or liveness_lost */
+    active        bool                        not null,
+    value         float                       not null,
+    changed_epoch int                         not null default 0,
+    changed_ts    timestamp without time zone,           /* Null until the state has changed for the first time */
+    primary key (name)
+);
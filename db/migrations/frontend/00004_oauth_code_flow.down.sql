@@ -0,0 +1,8 @@
+drop table if exists oauth_access_tokens;
+
+drop index if exists idx_users_devices_refresh_token;
+alter table users_devices drop column if exists last_used_ts;
+
+drop index if exists idx_oauth_codes_code;
+alter table oauth_codes drop column if exists device_name;
+alter table oauth_codes drop column if exists code_challenge;
@@ -0,0 +1,16 @@
+alter table oauth_codes add column code_challenge character varying(64) not null default ''; /* S256 pkce challenge of the authorization request */
+alter table oauth_codes add column device_name character varying(20) not null default '';
+create unique index idx_oauth_codes_code on oauth_codes (code);
+
+alter table users_devices add column last_used_ts timestamp without time zone;
+create unique index idx_users_devices_refresh_token on users_devices (refresh_token);
+
+create table oauth_access_tokens
+(
+    access_token character varying(64)       not null, /* sha256 of the token handed out to the device */
+    device_id    int                         not null,
+    user_id      int                         not null,
+    expires_ts   timestamp without time zone not null,
+    primary key (access_token)
+);
+create index idx_oauth_access_tokens_device_id on oauth_access_tokens (device_id);
@@ -0,0 +1,10 @@
+alter table users add column totp_secret character varying(32); /* base32 encoded totp secret, set during the enrollment */
+alter table users add column totp_enabled bool not null default 'f';
+alter table users add column totp_last_step bigint not null default 0; /* time step of the last accepted code to prevent its reuse */
+
+create table users_recovery_codes
+(
+    user_id   int                   not null,
+    code_hash character varying(64) not null, /* sha256 of the single-use recovery code */
+    primary key (user_id, code_hash)
+);
@@ -0,0 +1,52 @@
+//go:build ignore
+// +build ignore
+
+// gen compiles the sql files of the migrations into sources.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	files, err := filepath.Glob("*/*.sql")
+	if err != nil {
+		log.Fatal(err)
+	}
+	sort.Strings(files)
+
+	buf := &bytes.Buffer{}
+	buf.WriteString("// Code generated by go generate; DO NOT EDIT.\n\npackage migrations\n\n")
+	buf.WriteString("// sources maps the file paths of the migrations, relative to this directory, to their sql\n")
+	buf.WriteString("var sources = map[string]string{\n")
+	for _, file := range files {
+		sql, err := ioutil.ReadFile(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		name := filepath.ToSlash(file)
+		if strings.Contains(string(sql), "`") {
+			fmt.Fprintf(buf, "%q: %v,\n", name, strconv.Quote(string(sql)))
+		} else {
+			fmt.Fprintf(buf, "%q: `%s`,\n", name, sql)
+		}
+	}
+	buf.WriteString("}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = ioutil.WriteFile("sources.go", src, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
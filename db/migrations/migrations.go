@@ -0,0 +1,86 @@
+// Package migrations holds the versioned schema migrations of the explorer and the frontend database. The migrations of
+// each database are a set of their own in the explorer and frontend directory, every migration consists of an
+// NNNNN_name.up.sql and an NNNNN_name.down.sql file. The files are compiled into the binary by running
+// `go generate ./db/migrations` after adding or changing a migration.
+package migrations
+
+//go:generate go run gen.go
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// The migration sets, named after the directory of their files
+const (
+	Explorer = "explorer"
+	Frontend = "frontend"
+)
+
+// Migration is a schema change that is applied by Up and reverted by Down
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+var fileRegex = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// All returns the migrations of the set ordered by version, the versions start at 1 and have no gaps
+func All(set string) ([]*Migration, error) {
+	byVersion := map[int]*Migration{}
+	for name, sql := range sources {
+		dir, file := path.Split(name)
+		if dir != set+"/" {
+			continue
+		}
+		match := fileRegex.FindStringSubmatch(file)
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %v", file)
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid version of migration %v: %v", file, err)
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migrations %v_%v and %v_%v have the same version", version, m.Name, version, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = sql
+		} else {
+			m.Down = sql
+		}
+	}
+
+	if len(byVersion) == 0 {
+		return nil, fmt.Errorf("unknown migration set %v", set)
+	}
+
+	all := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		all = append(all, m)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Version < all[j].Version
+	})
+
+	for i, m := range all {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("missing migration of version %v", i+1)
+		}
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %v_%v needs an up and a down file", m.Version, m.Name)
+		}
+	}
+	return all, nil
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	for _, set := range []string{Explorer, Frontend} {
+		all, err := All(set)
+		if err != nil {
+			t.Fatalf("error loading %v migrations: %v", set, err)
+		}
+		if len(all) == 0 || all[0].Name != "baseline" {
+			t.Fatalf("expected the baseline as first %v migration", set)
+		}
+	}
+	if _, err := All("unknown"); err == nil {
+		t.Errorf("expected an error for an unknown migration set")
+	}
+}
+
+func TestSourcesUpToDate(t *testing.T) {
+	files, err := filepath.Glob("*/*.sql")
+	if err != nil {
+		t.Fatalf("error listing migration files: %v", err)
+	}
+	if len(files) != len(sources) {
+		t.Errorf("found %v migration files but %v compiled sources, run go generate", len(files), len(sources))
+	}
+	for _, file := range files {
+		sql, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatalf("error reading migration file: %v", err)
+		}
+		if sources[filepath.ToSlash(file)] != string(sql) {
+			t.Errorf("compiled source of %v is outdated, run go generate", file)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package db
+
+import (
+	"database/sql"
+	"eth2-exporter/types"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// GetNotificationRecipients returns the email, webhook and push device tokens of the passed users
+func GetNotificationRecipients(userIDs []uint64) (map[uint64]*types.NotificationRecipient, error) {
+	recipients := make(map[uint64]*types.NotificationRecipient, len(userIDs))
+
+	var users []struct {
+		ID            uint64         `db:"id"`
+		Email         string         `db:"email"`
+		WebhookURL    sql.NullString `db:"url"`
+		WebhookSecret sql.NullString `db:"secret"`
+	}
+	err := FrontendDB.Select(&users, `
+		SELECT u.id, u.email, w.url, w.secret
+		FROM users u
+		LEFT JOIN users_webhooks w ON w.user_id = u.id
+		WHERE u.id = ANY($1)`, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		recipients[u.ID] = &types.NotificationRecipient{
+			UserID:        u.ID,
+			Email:         u.Email,
+			WebhookURL:    u.WebhookURL.String,
+			WebhookSecret: u.WebhookSecret.String,
+		}
+	}
+
+	var devices []struct {
+		UserID uint64 `db:"user_id"`
+		Token  string `db:"notification_token"`
+	}
+	err = FrontendDB.Select(&devices, `
+		SELECT user_id, notification_token
+		FROM users_devices
+		WHERE user_id = ANY($1) AND active AND notify_enabled AND COALESCE(notification_token, '') != ''`, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range devices {
+		if recipient, exists := recipients[d.UserID]; exists {
+			recipient.DeviceTokens = append(recipient.DeviceTokens, d.Token)
+		}
+	}
+
+	return recipients, nil
+}
+
+// GetSubscriptionsChannels returns the notification channels of the passed subscriptions
+func GetSubscriptionsChannels(subscriptionIDs []uint64) (map[uint64][]types.NotificationChannel, error) {
+	var subs []struct {
+		ID       uint64         `db:"id"`
+		Channels pq.StringArray `db:"channels"`
+	}
+	err := FrontendDB.Select(&subs, "SELECT id, channels FROM users_subscriptions WHERE id = ANY($1)", pq.Array(subscriptionIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	channels := make(map[uint64][]types.NotificationChannel, len(subs))
+	for _, sub := range subs {
+		for _, c := range sub.Channels {
+			channels[sub.ID] = append(channels[sub.ID], types.NotificationChannel(c))
+		}
+	}
+	return channels, nil
+}
+
+// UpdateSubscriptionChannels sets the notification channels of a subscription
+func UpdateSubscriptionChannels(userID uint64, eventName types.EventName, eventFilter string, channels []types.NotificationChannel) error {
+	names := make([]string, len(channels))
+	for i, c := range channels {
+		names[i] = string(c)
+	}
+	_, err := FrontendDB.Exec("UPDATE users_subscriptions SET channels = $1 WHERE user_id = $2 AND event_name = $3 AND event_filter = $4", pq.StringArray(names), userID, eventName, eventFilter)
+	return err
+}
+
+// SaveNotificationDelivery adds an entry to the delivery log of the notification channels
+func SaveNotificationDelivery(delivery *types.NotificationDelivery) error {
+	_, err := FrontendDB.Exec(`
+		INSERT INTO notification_deliveries (user_id, channel, target, status, error, subscription_ids, ts)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		delivery.UserID, delivery.Channel, delivery.Target, delivery.Status, delivery.Error, delivery.SubscriptionIDs, delivery.Ts)
+	return err
+}
+
+// GetNotificationDeliveriesCount returns the number of notifications sent to a user on a channel since the passed time
+// and the time of the oldest of these notifications
+func GetNotificationDeliveriesCount(userID uint64, channel types.NotificationChannel, since time.Time) (int, time.Time, error) {
+	res := struct {
+		Count  int          `db:"count"`
+		Oldest sql.NullTime `db:"oldest"`
+	}{}
+	err := FrontendDB.Get(&res, "SELECT COUNT(*) AS count, MIN(ts) AS oldest FROM notification_deliveries WHERE user_id = $1 AND channel = $2 AND status = 'sent' AND ts > $3", userID, channel, since)
+	return res.Count, res.Oldest.Time, err
+}
+
+// GetUserNotificationDeliveries returns the latest entries of the delivery log of a user
+func GetUserNotificationDeliveries(userID uint64, limit uint64) ([]*types.NotificationDelivery, error) {
+	deliveries := []*types.NotificationDelivery{}
+	err := FrontendDB.Select(&deliveries, `
+		SELECT id, user_id, channel, target, status, error, subscription_ids, ts
+		FROM notification_deliveries
+		WHERE user_id = $1
+		ORDER BY ts DESC
+		LIMIT $2`, userID, limit)
+	return deliveries, err
+}
+
+// GetUserWebhook returns the webhook of a user, nil is returned if the user has not configured a webhook
+func GetUserWebhook(userID uint64) (*types.UserWebhook, error) {
+	webhook := &types.UserWebhook{}
+	err := FrontendDB.Get(webhook, "SELECT user_id, url, secret, created_ts FROM users_webhooks WHERE user_id = $1", userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// SaveUserWebhook sets the webhook of a user
+func SaveUserWebhook(webhook *types.UserWebhook) error {
+	_, err := FrontendDB.Exec(`
+		INSERT INTO users_webhooks (user_id, url, secret, created_ts)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			url        = excluded.url,
+			secret     = excluded.secret,
+			created_ts = excluded.created_ts`,
+		webhook.UserID, webhook.URL, webhook.Secret)
+	return err
+}
+
+// DeleteUserWebhook removes the webhook of a user
+func DeleteUserWebhook(userID uint64) error {
+	_, err := FrontendDB.Exec("DELETE FROM users_webhooks WHERE user_id = $1", userID)
+	return err
+}
+
+// GetUserPushDevicesCount returns the number of devices of a user that receive push notifications
+func GetUserPushDevicesCount(userID uint64) (int, error) {
+	count := 0
+	err := FrontendDB.Get(&count, "SELECT COUNT(*) FROM users_devices WHERE user_id = $1 AND active AND notify_enabled AND COALESCE(notification_token, '') != ''", userID)
+	return count, err
+}
+
+// GetNetworkNotificationState returns the last state of a network metric, nil is returned if the metric has not been checked yet
+func GetNetworkNotificationState(name string) (*types.NetworkNotificationState, error) {
+	state := &types.NetworkNotificationState{}
+	err := FrontendDB.Get(state, "SELECT name, active, value, changed_epoch, changed_ts FROM network_notification_states WHERE name = $1", name)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// SaveNetworkNotificationState stores the state of a network metric
+func SaveNetworkNotificationState(state *types.NetworkNotificationState) error {
+	_, err := FrontendDB.Exec(`
+		INSERT INTO network_notification_states (name, active, value, changed_epoch, changed_ts)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (name) DO UPDATE SET
+			active        = excluded.active,
+			value         = excluded.value,
+			changed_epoch = excluded.changed_epoch,
+			changed_ts    = excluded.changed_ts`,
+		state.Name, state.Active, state.Value, state.ChangedEpoch, state.ChangedTs)
+	return err
+}
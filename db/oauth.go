@@ -0,0 +1,175 @@
+package db
+
+import (
+	"database/sql"
+	"eth2-exporter/types"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ConsumeAuthorizeCode marks an authorization code as consumed and returns it, nil is returned if the code does not
+// exist, has already been consumed or is older than maxAge
+func ConsumeAuthorizeCode(code string, maxAge time.Duration) (*types.OAuthCode, error) {
+	c := &types.OAuthCode{}
+	err := FrontendDB.Get(c, `
+		UPDATE oauth_codes SET consumed = true
+		WHERE code = $1 AND NOT consumed AND created_ts > NOW() - $2 * INTERVAL '1 second'
+		RETURNING id, user_id, app_id, code_challenge, device_name, created_ts`, code, maxAge.Seconds())
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// AddUserDevice registers a device the user has authorized together with the hashes of its refresh and access token
+func AddUserDevice(device *types.UserDevice, refreshToken, accessToken string, accessTokenTTL time.Duration) error {
+	tx, err := FrontendDB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO users_devices (user_id, refresh_token, device_name, app_id, created_ts)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, active, created_ts`,
+		device.UserID, refreshToken, device.DeviceName, device.AppID).Scan(&device.ID, &device.Active, &device.CreatedTs)
+	if err != nil {
+		return err
+	}
+
+	err = setAccessToken(tx, device, accessToken, accessTokenTTL)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RotateUserDeviceTokens replaces the refresh and access token of the active device of an app that holds refreshToken,
+// nil is returned if there is no such device
+func RotateUserDeviceTokens(appID uint64, refreshToken, newRefreshToken, newAccessToken string, accessTokenTTL time.Duration) (*types.UserDevice, error) {
+	tx, err := FrontendDB.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	device := &types.UserDevice{}
+	err = tx.Get(device, `
+		UPDATE users_devices SET refresh_token = $3, last_used_ts = NOW()
+		WHERE refresh_token = $2 AND app_id = $1 AND active AND app_id IN (SELECT id FROM oauth_apps WHERE active)
+		RETURNING id, user_id, app_id, device_name, notify_enabled, active, created_ts, last_used_ts`,
+		appID, refreshToken, newRefreshToken)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = setAccessToken(tx, device, newAccessToken, accessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	return device, tx.Commit()
+}
+
+// setAccessToken stores the hash of a new access token of a device, the previous access tokens of the device are removed
+func setAccessToken(tx *sqlx.Tx, device *types.UserDevice, accessToken string, ttl time.Duration) error {
+	_, err := tx.Exec("DELETE FROM oauth_access_tokens WHERE device_id = $1", device.ID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+		INSERT INTO oauth_access_tokens (access_token, device_id, user_id, expires_ts)
+		VALUES ($1, $2, $3, NOW() + $4 * INTERVAL '1 second')`,
+		accessToken, device.ID, device.UserID, ttl.Seconds())
+	return err
+}
+
+// GetAccessTokenDevice returns the active device that holds an unexpired access token, nil is returned if there is none
+func GetAccessTokenDevice(accessToken string) (*types.UserDevice, error) {
+	device := &types.UserDevice{}
+	err := FrontendDB.Get(device, `
+		SELECT d.id, d.user_id, d.app_id, d.device_name, d.notify_enabled, d.active, d.created_ts, d.last_used_ts
+		FROM oauth_access_tokens t
+		INNER JOIN users_devices d ON d.id = t.device_id
+		WHERE t.access_token = $1 AND t.expires_ts > NOW() AND d.active`, accessToken)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+// GetUserDevices returns the active devices of a user
+func GetUserDevices(userID uint64) ([]*types.UserDevice, error) {
+	devices := []*types.UserDevice{}
+	err := FrontendDB.Select(&devices, `
+		SELECT d.id, d.user_id, d.app_id, COALESCE(a.app_name, '') AS app_name, d.device_name, d.notify_enabled, d.active, d.created_ts, d.last_used_ts
+		FROM users_devices d
+		LEFT JOIN oauth_apps a ON a.id = d.app_id
+		WHERE d.user_id = $1 AND d.active
+		ORDER BY d.created_ts DESC`, userID)
+	return devices, err
+}
+
+// RevokeUserDevice deactivates a device of a user and removes its access tokens, false is returned if the user has no
+// active device with that id
+func RevokeUserDevice(userID, id uint64) (bool, error) {
+	tx, err := FrontendDB.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE users_devices SET active = false WHERE id = $1 AND user_id = $2 AND active", id, userID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil || rows == 0 {
+		return false, err
+	}
+
+	_, err = tx.Exec("DELETE FROM oauth_access_tokens WHERE device_id = $1", id)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// RevokeOAuthToken revokes a refresh or access token issued to an app (RFC 7009), revoking a refresh token
+// deactivates the whole device. Unknown tokens are ignored.
+func RevokeOAuthToken(appID uint64, token string) error {
+	tx, err := FrontendDB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		DELETE FROM oauth_access_tokens t
+		USING users_devices d
+		WHERE t.device_id = d.id AND d.app_id = $1 AND (t.access_token = $2 OR d.refresh_token = $2)`, appID, token)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE users_devices SET active = false WHERE refresh_token = $2 AND app_id = $1", appID, token)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateUserDeviceNotifications sets the push notification token of a device and whether it receives notifications
+func UpdateUserDeviceNotifications(deviceID uint64, notificationToken string, enabled bool) error {
+	_, err := FrontendDB.Exec("UPDATE users_devices SET notification_token = $2, notify_enabled = $3 WHERE id = $1", deviceID, notificationToken, enabled)
+	return err
+}
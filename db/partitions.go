@@ -0,0 +1,378 @@
+package db
+
+import (
+	"eth2-exporter/utils"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// partitionsLockID is the key of the advisory lock that serializes the creation and removal of partitions
+const partitionsLockID = 0x70617274
+
+// partitionBoundRegexp matches the bound of a range partition as returned by pg_get_expr
+var partitionBoundRegexp = regexp.MustCompile(`^FOR VALUES FROM \((\d+)\) TO \((\d+)\)$`)
+
+// partition is a range partition of validator_balances or attestation_assignments with the epochs from From up to To
+// (exclusive)
+type partition struct {
+	Name string
+	From uint64
+	To   uint64
+}
+
+// Downsampled returns true if the partition holds one row per validator and day instead of one per epoch
+func (p *partition) Downsampled() bool {
+	return strings.HasSuffix(p.Name, "_daily")
+}
+
+// partitionCache holds the partitions of the tables known to this instance, it is filled by ensurePartitions and
+// reset whenever partitions are replaced or removed
+var partitionCache = struct {
+	sync.Mutex
+	tables map[string][]*partition
+}{tables: make(map[string][]*partition)}
+
+// partitionDays returns the number of days covered by a partition
+func partitionDays() uint64 {
+	if utils.Config.Indexer.Retention.PartitionDays < 1 {
+		return 7
+	}
+	return utils.Config.Indexer.Retention.PartitionDays
+}
+
+// partitionStart returns the first epoch of the partition period the epoch belongs to
+func partitionStart(epoch uint64) uint64 {
+	days := partitionDays()
+	return utils.DayToEpoch(utils.EpochToDay(epoch) / days * days)
+}
+
+// partitionEnd returns the first epoch after the partition period the epoch belongs to
+func partitionEnd(epoch uint64) uint64 {
+	days := partitionDays()
+	return utils.DayToEpoch((utils.EpochToDay(epoch)/days + 1) * days)
+}
+
+// RetentionCutoff returns the first epoch whose rows are kept with a retention of the given number of days, 0 if the
+// rows are kept forever
+func RetentionCutoff(days uint64) uint64 {
+	if days == 0 {
+		return 0
+	}
+	return uint64(utils.TimeToEpoch(time.Now().Add(time.Hour * -24 * time.Duration(days))))
+}
+
+// parsePartitionBound returns the epoch range of a partition bound
+func parsePartitionBound(bound string) (from, to uint64, err error) {
+	match := partitionBoundRegexp.FindStringSubmatch(bound)
+	if match == nil {
+		return 0, 0, fmt.Errorf("unsupported partition bound %q", bound)
+	}
+	from, err = strconv.ParseUint(match[1], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	to, err = strconv.ParseUint(match[2], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return from, to, nil
+}
+
+// getPartitions returns the partitions of the table sorted by epoch
+func getPartitions(q sqlx.Queryer, table string) ([]*partition, error) {
+	rows := []struct {
+		Name  string `db:"name"`
+		Bound string `db:"bound"`
+	}{}
+	err := sqlx.Select(q, &rows, `
+		SELECT c.relname AS name, pg_get_expr(c.relpartbound, c.oid) AS bound
+		FROM pg_inherits i
+		INNER JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = $1::text::regclass`, table)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving partitions of %v: %v", table, err)
+	}
+
+	partitions := make([]*partition, 0, len(rows))
+	for _, r := range rows {
+		from, to, err := parsePartitionBound(r.Bound)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing bound of partition %v: %v", r.Name, err)
+		}
+		partitions = append(partitions, &partition{Name: r.Name, From: from, To: to})
+	}
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].From < partitions[j].From })
+	return partitions, nil
+}
+
+// findPartition returns the partition that contains the epoch, nil if there is none
+func findPartition(partitions []*partition, epoch uint64) *partition {
+	for _, p := range partitions {
+		if p.From <= epoch && epoch < p.To {
+			return p
+		}
+	}
+	return nil
+}
+
+// planPartitions returns the ranges of the partitions that have to be created so that the epochs from up to to
+// (exclusive) are covered. New partitions end at the next partition period boundary or at the next existing
+// partition, whichever comes first.
+func planPartitions(existing []*partition, from, to uint64) []*partition {
+	planned := []*partition{}
+	for epoch := from; epoch < to; {
+		if p := findPartition(existing, epoch); p != nil {
+			epoch = p.To
+			continue
+		}
+		end := partitionEnd(epoch)
+		for _, p := range existing {
+			if p.From > epoch && p.From < end {
+				end = p.From
+			}
+		}
+		planned = append(planned, &partition{From: epoch, To: end})
+		epoch = end
+	}
+	return planned
+}
+
+// ensurePartitions creates the partitions of the table for the partition period of the epoch and the following one
+// and returns the partition of the epoch. Partitions are created for epochs before the retention period as well, the
+// retention policy downsamples or removes them once the exporters have processed them.
+func ensurePartitions(table string, epoch uint64) (*partition, error) {
+	partitionCache.Lock()
+	defer partitionCache.Unlock()
+
+	partitions, cached := partitionCache.tables[table]
+	if cached && len(planPartitions(partitions, epoch, partitionEnd(epoch)+1)) == 0 {
+		return findPartition(partitions, epoch), nil
+	}
+
+	tx, err := DB.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("error starting db transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("SELECT pg_advisory_xact_lock($1)", partitionsLockID)
+	if err != nil {
+		return nil, fmt.Errorf("error acquiring partitions lock: %v", err)
+	}
+	partitions, err = getPartitions(tx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	created := planPartitions(partitions, partitionStart(epoch), partitionEnd(epoch)+1)
+	for _, p := range created {
+		p.Name = fmt.Sprintf("%v_%v", table, p.From)
+		_, err = tx.Exec(fmt.Sprintf("CREATE TABLE %v PARTITION OF %v FOR VALUES FROM (%v) TO (%v)", p.Name, table, p.From, p.To))
+		if err != nil {
+			return nil, fmt.Errorf("error creating partition %v: %v", p.Name, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("error committing db transaction: %v", err)
+	}
+	for _, p := range created {
+		logger.Infof("created partition %v for the epochs %v to %v", p.Name, p.From, p.To-1)
+	}
+
+	partitions = append(partitions, created...)
+	sort.Slice(partitions, func(i, j int) bool { return partitions[i].From < partitions[j].From })
+	partitionCache.tables[table] = partitions
+
+	return findPartition(partitions, epoch), nil
+}
+
+// resetPartitionCache makes ensurePartitions read the partitions of the table from the database again
+func resetPartitionCache(table string) {
+	partitionCache.Lock()
+	delete(partitionCache.tables, table)
+	partitionCache.Unlock()
+}
+
+// GetRetentionHorizon returns the first epoch whose per-epoch balances and attestation assignments are still needed
+// by the exporters: the next epoch of the rewards and effectiveness exporters or the first epoch whose statistics are
+// not final yet, whichever comes first. The daily validator statistics have to be complete as well, the horizon is 0
+// until they have been backfilled.
+func GetRetentionHorizon() (uint64, error) {
+	firstDay, lastDay, err := GetValidatorStatsDailyBackfillRange()
+	if err != nil {
+		return 0, fmt.Errorf("error retrieving daily validator statistics backfill range: %v", err)
+	}
+	if firstDay < lastDay {
+		return 0, nil
+	}
+
+	var horizon uint64
+	err = DB.Get(&horizon, `
+		SELECT LEAST(
+			(SELECT COALESCE(MAX(epoch) + 1, 0) FROM validator_rewards),
+			(SELECT COALESCE(MAX(epoch) + 1, 0) FROM attestation_effectiveness),
+			COALESCE(
+				(SELECT MIN(e.epoch) FROM epochs e LEFT JOIN epoch_stats s ON s.epoch = e.epoch WHERE s.epoch IS NULL OR NOT s.finalized),
+				(SELECT COALESCE(MAX(epoch) + 1, 0) FROM epochs)
+			)
+		)`)
+	if err != nil {
+		return 0, fmt.Errorf("error retrieving retention horizon: %v", err)
+	}
+	return horizon, nil
+}
+
+// DownsampleValidatorBalances replaces the per-epoch partitions of validator_balances that end at or before the epoch
+// by partitions that only hold the balances of the last epoch of every day and of the genesis epoch. It returns the
+// number of downsampled partitions.
+func DownsampleValidatorBalances(before uint64) (int, error) {
+	partitions, err := getPartitions(DB, "validator_balances")
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, p := range partitions {
+		if p.To > before || p.Downsampled() {
+			continue
+		}
+		err = downsampleValidatorBalancesPartition(p)
+		if err != nil {
+			return count, fmt.Errorf("error downsampling partition %v: %v", p.Name, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+func downsampleValidatorBalancesPartition(p *partition) error {
+	defer resetPartitionCache("validator_balances")
+
+	tx, err := DB.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting db transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("SELECT pg_advisory_xact_lock($1)", partitionsLockID)
+	if err != nil {
+		return fmt.Errorf("error acquiring partitions lock: %v", err)
+	}
+
+	daily := p.Name + "_daily"
+	_, err = tx.Exec(fmt.Sprintf("CREATE TABLE %v (LIKE validator_balances)", daily))
+	if err != nil {
+		return err
+	}
+	// the daily rows are copied before the partition is detached, so the balances can be read in the meantime
+	_, err = tx.Exec(fmt.Sprintf(`
+		INSERT INTO %v (epoch, validatorindex, balance, effectivebalance)
+		SELECT DISTINCT ON (validatorindex, epoch * $1 / 86400) epoch, validatorindex, balance, effectivebalance
+		FROM %v
+		ORDER BY validatorindex, epoch * $1 / 86400, epoch DESC`, daily, p.Name),
+		utils.Config.Chain.SecondsPerSlot*utils.Config.Chain.SlotsPerEpoch)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf(`
+		INSERT INTO %v (epoch, validatorindex, balance, effectivebalance)
+		SELECT epoch, validatorindex, balance, effectivebalance
+		FROM %v
+		WHERE epoch = 0 AND NOT EXISTS (SELECT 1 FROM %v d WHERE d.epoch = 0)`, daily, p.Name, daily))
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE validator_balances DETACH PARTITION %v", p.Name))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf("DROP TABLE %v", p.Name))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE validator_balances ATTACH PARTITION %v FOR VALUES FROM (%v) TO (%v)", daily, p.From, p.To))
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing db transaction: %v", err)
+	}
+	logger.Infof("downsampled the validator balances of the epochs %v to %v", p.From, p.To-1)
+	return nil
+}
+
+// RemoveAttestationAssignments detaches the partitions of attestation_assignments that end at or before the epoch and
+// drops them or moves them to the archive schema. It returns the number of removed partitions.
+func RemoveAttestationAssignments(before uint64, archive bool) (int, error) {
+	partitions, err := getPartitions(DB, "attestation_assignments")
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, p := range partitions {
+		if p.To > before {
+			continue
+		}
+		err = removeAttestationAssignmentsPartition(p, archive)
+		if err != nil {
+			return count, fmt.Errorf("error removing partition %v: %v", p.Name, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+func removeAttestationAssignmentsPartition(p *partition, archive bool) error {
+	defer resetPartitionCache("attestation_assignments")
+
+	tx, err := DB.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting db transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("SELECT pg_advisory_xact_lock($1)", partitionsLockID)
+	if err != nil {
+		return fmt.Errorf("error acquiring partitions lock: %v", err)
+	}
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE attestation_assignments DETACH PARTITION %v", p.Name))
+	if err != nil {
+		return err
+	}
+	if archive {
+		_, err = tx.Exec("CREATE SCHEMA IF NOT EXISTS archive")
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %v SET SCHEMA archive", p.Name))
+	} else {
+		_, err = tx.Exec(fmt.Sprintf("DROP TABLE %v", p.Name))
+	}
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("error committing db transaction: %v", err)
+	}
+	if archive {
+		logger.Infof("archived the attestation assignments of the epochs %v to %v", p.From, p.To-1)
+	} else {
+		logger.Infof("dropped the attestation assignments of the epochs %v to %v", p.From, p.To-1)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"testing"
+)
+
+func TestParsePartitionBound(t *testing.T) {
+	from, to, err := parsePartitionBound("FOR VALUES FROM (1575) TO (3150)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != 1575 || to != 3150 {
+		t.Errorf("unexpected bound: got %v to %v, want 1575 to 3150", from, to)
+	}
+
+	_, _, err = parsePartitionBound("FOR VALUES FROM (MINVALUE) TO (3150)")
+	if err == nil {
+		t.Error("expected an error for an unsupported bound")
+	}
+}
+
+func TestPlanPartitions(t *testing.T) {
+	utils.Config = &types.Config{}
+	utils.Config.Chain.SecondsPerSlot = 12
+	utils.Config.Chain.SlotsPerEpoch = 32
+	utils.Config.Indexer.Retention.PartitionDays = 7
+
+	// 225 epochs per day, 1575 epochs per partition
+	existing := []*partition{{Name: "validator_balances_legacy", From: 0, To: 2000}}
+	planned := planPartitions(existing, partitionStart(1990), partitionEnd(1990)+1)
+	expected := []partition{{From: 2000, To: 3150}, {From: 3150, To: 4725}}
+	if len(planned) != len(expected) {
+		t.Fatalf("expected %v partitions, got %v", len(expected), len(planned))
+	}
+	for i, p := range planned {
+		if p.From != expected[i].From || p.To != expected[i].To {
+			t.Errorf("unexpected partition %v: got %v to %v, want %v to %v", i, p.From, p.To, expected[i].From, expected[i].To)
+		}
+	}
+
+	// gaps before existing partitions end at the existing partition
+	existing = []*partition{{Name: "validator_balances_3150", From: 3150, To: 4725}}
+	planned = planPartitions(existing, partitionStart(2000), 4725)
+	if len(planned) != 1 || planned[0].From != 1575 || planned[0].To != 3150 {
+		t.Errorf("unexpected partitions for the gap: %+v", planned)
+	}
+
+	if planned := planPartitions(existing, 3200, 4000); len(planned) != 0 {
+		t.Errorf("expected no partitions for a covered range, got %v", len(planned))
+	}
+}
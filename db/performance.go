@@ -0,0 +1,223 @@
+package db
+
+import (
+	"database/sql"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const validatorStatsDailyUpsert = `
+	ON CONFLICT (validatorindex, day) DO UPDATE SET
+		start_epoch   = LEAST(validator_stats_daily.start_epoch, excluded.start_epoch),
+		start_balance = CASE WHEN excluded.start_epoch <= validator_stats_daily.start_epoch THEN excluded.start_balance ELSE validator_stats_daily.start_balance END,
+		end_epoch     = GREATEST(validator_stats_daily.end_epoch, excluded.end_epoch),
+		end_balance   = CASE WHEN excluded.end_epoch >= validator_stats_daily.end_epoch THEN excluded.end_balance ELSE validator_stats_daily.end_balance END`
+
+// saveValidatorStatsDaily updates the daily rollup of the activated validators with their balances at the epoch.
+// Exporting an epoch more than once yields the same result.
+func saveValidatorStatsDaily(epoch uint64, validators []*types.Validator, tx *sql.Tx) error {
+	day := utils.EpochToDay(epoch)
+	batchSize := 10000
+
+	activated := make([]*types.Validator, 0, len(validators))
+	for _, v := range validators {
+		if v.ActivationEpoch <= epoch {
+			activated = append(activated, v)
+		}
+	}
+
+	for b := 0; b < len(activated); b += batchSize {
+		start := b
+		end := b + batchSize
+		if len(activated) < end {
+			end = len(activated)
+		}
+
+		valueStrings := make([]string, 0, batchSize)
+		valueArgs := make([]interface{}, 0, batchSize*6)
+		for i, v := range activated[start:end] {
+			valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d)", i*6+1, i*6+2, i*6+3, i*6+4, i*6+5, i*6+6))
+			valueArgs = append(valueArgs, v.Index, day, epoch, v.Balance, epoch, v.Balance)
+		}
+		stmt := fmt.Sprintf(`
+		INSERT INTO validator_stats_daily (validatorindex, day, start_epoch, start_balance, end_epoch, end_balance)
+		VALUES %s`+validatorStatsDailyUpsert, strings.Join(valueStrings, ","))
+		_, err := tx.Exec(stmt, valueArgs...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return saveValidatorStatsDailyDeposits(day, tx)
+}
+
+// saveValidatorStatsDailyDeposits recomputes the deposits of the day from the canonical blocks
+func saveValidatorStatsDailyDeposits(day uint64, tx *sql.Tx) error {
+	slotsPerEpoch := utils.Config.Chain.SlotsPerEpoch
+	startSlot := utils.DayToEpoch(day) * slotsPerEpoch
+	endSlot := utils.DayToEpoch(day+1)*slotsPerEpoch - 1
+
+	_, err := tx.Exec("UPDATE validator_stats_daily SET deposits = 0 WHERE day = $1 AND deposits != 0", day)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE validator_stats_daily s
+		SET deposits = d.amount
+		FROM (
+			SELECT v.validatorindex, SUM(bd.amount) AS amount
+			FROM blocks_deposits bd
+			INNER JOIN blocks b ON b.slot = bd.block_slot AND b.status = '1'
+			INNER JOIN validators v ON v.pubkey = bd.publickey AND b.epoch > v.activationepoch
+			WHERE bd.block_slot >= $1 AND bd.block_slot <= $2
+			GROUP BY v.validatorindex
+		) d
+		WHERE s.validatorindex = d.validatorindex AND s.day = $3`, startSlot, endSlot, day)
+	return err
+}
+
+// GetValidatorStatsDailyBackfillRange returns the range of days that have to be added to the daily rollup from the
+// stored validator balances: the days of the exported epochs up to and including the first day of the rollup
+func GetValidatorStatsDailyBackfillRange() (firstDay, lastDay uint64, err error) {
+	var first, last sql.NullInt64
+	err = DB.QueryRow(`
+		SELECT
+			(SELECT MIN(epoch) FROM epochs),
+			COALESCE((SELECT MIN(day) FROM validator_stats_daily), (SELECT MAX(epoch) FROM epochs) * $1 / 86400)`,
+		utils.Config.Chain.SecondsPerSlot*utils.Config.Chain.SlotsPerEpoch).Scan(&first, &last)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !first.Valid || !last.Valid {
+		return 1, 0, nil
+	}
+	return utils.EpochToDay(uint64(first.Int64)), uint64(last.Int64), nil
+}
+
+// BackfillValidatorStatsDaily adds the day to the daily rollup using the stored validator balances of the first and
+// last exported epoch of the day
+func BackfillValidatorStatsDaily(day uint64) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting db transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	var first, last sql.NullInt64
+	err = tx.QueryRow("SELECT MIN(epoch), MAX(epoch) FROM epochs WHERE epoch >= $1 AND epoch < $2", utils.DayToEpoch(day), utils.DayToEpoch(day+1)).Scan(&first, &last)
+	if err != nil {
+		return err
+	}
+	if !first.Valid {
+		return nil
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO validator_stats_daily (validatorindex, day, start_epoch, start_balance, end_epoch, end_balance)
+		SELECT v.validatorindex, $3, vs.epoch, vs.balance, ve.epoch, ve.balance
+		FROM validators v
+		INNER JOIN validator_balances vs ON vs.validatorindex = v.validatorindex AND vs.epoch = GREATEST($1, v.activationepoch)
+		INNER JOIN validator_balances ve ON ve.validatorindex = v.validatorindex AND ve.epoch = $2
+		WHERE v.activationepoch <= $2`+validatorStatsDailyUpsert, first.Int64, last.Int64, day)
+	if err != nil {
+		return err
+	}
+
+	err = saveValidatorStatsDailyDeposits(day, tx)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetBalancesAtEpochs returns the balances of all validators at the epochs
+func GetBalancesAtEpochs(epochs []uint64) ([]*types.ValidatorBalance, error) {
+	balances := []*types.ValidatorBalance{}
+	err := DB.Select(&balances, `
+		SELECT epoch, validatorindex, balance
+		FROM validator_balances
+		WHERE epoch = ANY($1)`, pq.Array(epochs))
+	return balances, err
+}
+
+// GetDailyStartBalances returns the balances of all validators at the start of the day from the daily rollup
+func GetDailyStartBalances(day uint64) ([]*types.ValidatorBalance, error) {
+	balances := []*types.ValidatorBalance{}
+	err := DB.Select(&balances, `
+		SELECT start_epoch AS epoch, validatorindex, start_balance AS balance
+		FROM validator_stats_daily
+		WHERE day = $1`, day)
+	return balances, err
+}
+
+// GetActivationBalances returns the balances of the validators that have been activated after the epoch at their
+// activation from the daily rollup
+func GetActivationBalances(epoch uint64) ([]*types.ValidatorBalance, error) {
+	balances := []*types.ValidatorBalance{}
+	err := DB.Select(&balances, `
+		SELECT s.start_epoch AS epoch, s.validatorindex, s.start_balance AS balance
+		FROM validators v
+		INNER JOIN validator_stats_daily s ON s.validatorindex = v.validatorindex AND s.day = v.activationepoch * $2 / 86400
+		WHERE v.activationepoch > $1`, epoch, utils.Config.Chain.SecondsPerSlot*utils.Config.Chain.SlotsPerEpoch)
+	return balances, err
+}
+
+// GetDepositsAfterActivation returns the deposits that have been included in canonical blocks after the epoch and
+// after the activation of the validator
+func GetDepositsAfterActivation(epoch uint64) ([]*types.ValidatorDepositAmount, error) {
+	deposits := []*types.ValidatorDepositAmount{}
+	err := DB.Select(&deposits, `
+		SELECT b.epoch, v.validatorindex, bd.amount
+		FROM blocks_deposits bd
+		INNER JOIN blocks b ON b.slot = bd.block_slot AND b.status = '1'
+		INNER JOIN validators v ON v.pubkey = bd.publickey AND b.epoch > v.activationepoch
+		WHERE bd.block_slot >= $1`, (epoch+1)*utils.Config.Chain.SlotsPerEpoch)
+	return deposits, err
+}
+
+// SaveValidatorPerformance stores the performance of the validators, the rows of other validators are kept
+func SaveValidatorPerformance(performance []*types.ValidatorPerformance) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting db transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	batchSize := 10000
+
+	for b := 0; b < len(performance); b += batchSize {
+		start := b
+		end := b + batchSize
+		if len(performance) < end {
+			end = len(performance)
+		}
+
+		valueStrings := make([]string, 0, batchSize)
+		valueArgs := make([]interface{}, 0, batchSize*6)
+		for i, p := range performance[start:end] {
+			valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d)", i*6+1, i*6+2, i*6+3, i*6+4, i*6+5, i*6+6))
+			valueArgs = append(valueArgs, p.Index, p.Balance, p.Performance1d, p.Performance7d, p.Performance31d, p.Performance365d)
+		}
+		stmt := fmt.Sprintf(`
+		INSERT INTO validator_performance (validatorindex, balance, performance1d, performance7d, performance31d, performance365d)
+		VALUES %s
+		ON CONFLICT (validatorindex) DO UPDATE SET
+			balance         = excluded.balance,
+			performance1d   = excluded.performance1d,
+			performance7d   = excluded.performance7d,
+			performance31d  = excluded.performance31d,
+			performance365d = excluded.performance365d`, strings.Join(valueStrings, ","))
+		_, err := tx.Exec(stmt, valueArgs...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,33 @@
+package db
+
+import (
+	"eth2-exporter/types"
+)
+
+// SaveReorg stores a detected reorg, a reorg between the same old and new head is only stored once
+func SaveReorg(reorg *types.Reorg) error {
+	_, err := DB.Exec(`
+		INSERT INTO reorgs (ts, epoch, depth, old_head_slot, old_head_root, new_head_slot, new_head_root, affected_slots, orphaned_blocks)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (old_head_root, new_head_root) DO NOTHING`,
+		reorg.Ts, reorg.Epoch, reorg.Depth, reorg.OldHeadSlot, reorg.OldHeadRoot, reorg.NewHeadSlot, reorg.NewHeadRoot, reorg.AffectedSlots, reorg.OrphanedBlocks)
+	return err
+}
+
+// GetReorgs returns the most recent reorgs, starting at offset
+func GetReorgs(limit, offset uint64) ([]*types.Reorg, error) {
+	reorgs := []*types.Reorg{}
+	err := DB.Select(&reorgs, `
+		SELECT id, ts, epoch, depth, old_head_slot, old_head_root, new_head_slot, new_head_root, affected_slots, orphaned_blocks
+		FROM reorgs
+		ORDER BY id DESC
+		LIMIT $1 OFFSET $2`, limit, offset)
+	return reorgs, err
+}
+
+// GetReorgsCount returns the number of reorgs stored in the database
+func GetReorgsCount() (uint64, error) {
+	var count uint64
+	err := DB.Get(&count, "SELECT COUNT(*) FROM reorgs")
+	return count, err
+}
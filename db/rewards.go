@@ -0,0 +1,170 @@
+package db
+
+import (
+	"eth2-exporter/types"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// GetRewardsExportStatus returns the next epoch whose rewards have to be computed and the latest finalized epoch
+func GetRewardsExportStatus() (next uint64, finalized uint64, err error) {
+	err = DB.Get(&next, "SELECT COALESCE(MAX(epoch) + 1, 0) FROM validator_rewards")
+	if err != nil {
+		return 0, 0, err
+	}
+	err = DB.Get(&finalized, "SELECT COALESCE(MAX(epoch), 0) FROM epochs WHERE finalized")
+	if err != nil {
+		return 0, 0, err
+	}
+	return next, finalized, nil
+}
+
+// GetRewardsValidators returns the effective balance and the lifecycle epochs of all validators at the epoch
+func GetRewardsValidators(epoch uint64) ([]*types.RewardsValidator, error) {
+	var validators []*types.RewardsValidator
+	err := DB.Select(&validators, `
+		SELECT v.validatorindex, vb.effectivebalance, v.activationepoch, v.exitepoch, v.withdrawableepoch
+		FROM validator_balances vb
+		INNER JOIN validators v ON v.validatorindex = vb.validatorindex
+		WHERE vb.epoch = $1`, epoch)
+	return validators, err
+}
+
+// GetRewardsAttestations returns the attestations for the slots between startSlot and endSlot (inclusive) that
+// have been included in canonical blocks
+func GetRewardsAttestations(startSlot, endSlot uint64) ([]*types.RewardsAttestation, error) {
+	var attestations []*types.RewardsAttestation
+	err := DB.Select(&attestations, `
+		SELECT ba.block_slot, b.proposer, ba.slot, ba.beaconblockroot, ba.source_epoch, ba.source_root, ba.target_root, ba.validators
+		FROM blocks_attestations ba
+		INNER JOIN blocks b ON b.slot = ba.block_slot AND b.status = '1'
+		WHERE ba.slot >= $1 AND ba.slot <= $2
+		ORDER BY ba.block_slot, ba.block_index`, startSlot, endSlot)
+	return attestations, err
+}
+
+// GetCanonicalBlocks returns the canonical blocks between startSlot and endSlot (inclusive) sorted by slot. The
+// last canonical block before startSlot is included as well, since it is the block root of the empty slots at the
+// beginning of the range.
+func GetCanonicalBlocks(startSlot, endSlot uint64) ([]*types.MinimalBlock, error) {
+	var blocks []*types.MinimalBlock
+	err := DB.Select(&blocks, `
+		SELECT epoch, slot, blockroot, parentroot, status
+		FROM blocks
+		WHERE status = '1' AND slot <= $2 AND slot >= COALESCE((SELECT MAX(slot) FROM blocks WHERE status = '1' AND slot <= $1), 0)
+		ORDER BY slot`, startSlot, endSlot)
+	return blocks, err
+}
+
+// GetRewardsSlashings returns the validators that got slashed by slashings included in canonical blocks between
+// startEpoch and endEpoch (inclusive)
+func GetRewardsSlashings(startEpoch, endEpoch uint64) ([]*types.RewardsSlashing, error) {
+	var slashings []*types.RewardsSlashing
+	err := DB.Select(&slashings, `
+		SELECT b.epoch, b.proposer, s.proposerindex AS validatorindex
+		FROM blocks_proposerslashings s
+		INNER JOIN blocks b ON b.slot = s.block_slot AND b.status = '1'
+		WHERE b.epoch >= $1 AND b.epoch <= $2
+		UNION
+		SELECT b.epoch, b.proposer, i AS validatorindex
+		FROM blocks_attesterslashings s
+		INNER JOIN blocks b ON b.slot = s.block_slot AND b.status = '1'
+		CROSS JOIN UNNEST(s.attestation1_indices) i
+		WHERE b.epoch >= $1 AND b.epoch <= $2 AND i = ANY(s.attestation2_indices)`, startEpoch, endEpoch)
+	return slashings, err
+}
+
+// GetFinalityDelay returns the number of epochs between the epoch and the finalized epoch once the chain head
+// advanced past the epoch, zero is returned if the network liveness of that time is unknown
+func GetFinalityDelay(epoch uint64) (uint64, error) {
+	var delay uint64
+	err := DB.Get(&delay, `
+		SELECT COALESCE((
+			SELECT GREATEST($1 - finalizedepoch, 0)
+			FROM network_liveness
+			WHERE headepoch = $1 + 1
+			ORDER BY ts
+			LIMIT 1
+		), 0)`, epoch)
+	return delay, err
+}
+
+// SaveValidatorRewards stores the rewards of the validators for an epoch
+func SaveValidatorRewards(rewards []*types.ValidatorRewards) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting db transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	batchSize := 5000
+
+	for b := 0; b < len(rewards); b += batchSize {
+		start := b
+		end := b + batchSize
+		if len(rewards) < end {
+			end = len(rewards)
+		}
+
+		valueStrings := make([]string, 0, batchSize)
+		valueArgs := make([]interface{}, 0, batchSize*9)
+		for i, r := range rewards[start:end] {
+			valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)", i*9+1, i*9+2, i*9+3, i*9+4, i*9+5, i*9+6, i*9+7, i*9+8, i*9+9))
+			valueArgs = append(valueArgs, r.Epoch, r.ValidatorIndex, r.SourceReward, r.TargetReward, r.HeadReward, r.InclusionDelayReward, r.ProposerReward, r.InactivityPenalty, r.SlashingPenalty)
+		}
+		stmt := fmt.Sprintf(`
+		INSERT INTO validator_rewards (epoch, validatorindex, source_reward, target_reward, head_reward, inclusion_delay_reward, proposer_reward, inactivity_penalty, slashing_penalty)
+		VALUES %s
+		ON CONFLICT (validatorindex, epoch) DO UPDATE SET
+			source_reward          = EXCLUDED.source_reward,
+			target_reward          = EXCLUDED.target_reward,
+			head_reward            = EXCLUDED.head_reward,
+			inclusion_delay_reward = EXCLUDED.inclusion_delay_reward,
+			proposer_reward        = EXCLUDED.proposer_reward,
+			inactivity_penalty     = EXCLUDED.inactivity_penalty,
+			slashing_penalty       = EXCLUDED.slashing_penalty`, strings.Join(valueStrings, ","))
+		_, err := tx.Exec(stmt, valueArgs...)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetValidatorRewards returns up to limit rewards of the validators with the indices, the latest epoch first.
+// If after is set only the rewards after the cursor are returned.
+func GetValidatorRewards(indices []uint64, after *types.ApiV2Cursor, limit uint64) ([]*types.ValidatorRewards, error) {
+	if after == nil {
+		after = &types.ApiV2Cursor{Key: maxSqlNumber}
+	}
+	rewards := []*types.ValidatorRewards{}
+	err := DB.Select(&rewards, `
+		SELECT epoch, validatorindex, source_reward, target_reward, head_reward, inclusion_delay_reward, proposer_reward, inactivity_penalty, slashing_penalty
+		FROM validator_rewards
+		WHERE validatorindex = ANY($1) AND (epoch < $2 OR (epoch = $2 AND validatorindex > $3))
+		ORDER BY epoch DESC, validatorindex
+		LIMIT $4`, pq.Array(indices), after.Key, after.ValidatorIndex, limit)
+	return rewards, err
+}
+
+// GetValidatorRewardsSum returns the sum of the rewards of the validators with the indices since the epoch
+func GetValidatorRewardsSum(indices []uint64, since int64) (*types.ValidatorRewards, error) {
+	sum := &types.ValidatorRewards{}
+	err := DB.Get(sum, `
+		SELECT
+			COALESCE(MIN(epoch), 0) AS epoch,
+			0 AS validatorindex,
+			COALESCE(SUM(source_reward), 0) AS source_reward,
+			COALESCE(SUM(target_reward), 0) AS target_reward,
+			COALESCE(SUM(head_reward), 0) AS head_reward,
+			COALESCE(SUM(inclusion_delay_reward), 0) AS inclusion_delay_reward,
+			COALESCE(SUM(proposer_reward), 0) AS proposer_reward,
+			COALESCE(SUM(inactivity_penalty), 0) AS inactivity_penalty,
+			COALESCE(SUM(slashing_penalty), 0) AS slashing_penalty
+		FROM validator_rewards
+		WHERE validatorindex = ANY($1) AND epoch >= $2`, pq.Array(indices), since)
+	return sum, err
+}
@@ -0,0 +1,212 @@
+package db
+
+import (
+	"eth2-exporter/utils"
+	"time"
+)
+
+// GetPendingRollupEpochs returns the exported epochs whose statistics are missing or have been computed before the
+// epoch has been finalized
+func GetPendingRollupEpochs() ([]uint64, error) {
+	var epochs []uint64
+	err := DB.Select(&epochs, `
+		SELECT e.epoch
+		FROM epochs e
+		LEFT JOIN epoch_stats s ON s.epoch = e.epoch
+		WHERE s.epoch IS NULL OR NOT s.finalized
+		ORDER BY e.epoch`)
+	return epochs, err
+}
+
+// GetEpochsBetween returns the exported epochs between startEpoch and endEpoch (inclusive)
+func GetEpochsBetween(startEpoch, endEpoch uint64) ([]uint64, error) {
+	var epochs []uint64
+	err := DB.Select(&epochs, "SELECT epoch FROM epochs WHERE epoch >= $1 AND epoch <= $2 ORDER BY epoch", startEpoch, endEpoch)
+	return epochs, err
+}
+
+// GetLatestFinalizedEpoch returns the latest finalized epoch known from the exported epochs or the network liveness
+func GetLatestFinalizedEpoch() (uint64, error) {
+	var epoch uint64
+	err := DB.Get(&epoch, `
+		SELECT GREATEST(
+			COALESCE((SELECT MAX(epoch) FROM epochs WHERE finalized), 0),
+			COALESCE((SELECT MAX(finalizedepoch) FROM network_liveness), 0)
+		)`)
+	return epoch, err
+}
+
+// SaveEpochStats computes the statistics of an exported epoch. The statistics are marked as final if the epoch is
+// not after finalizedEpoch, they are not updated by the rollup anymore afterwards. Statistics that depend on
+// attestation assignments keep their previous value once the assignments have been removed by the retention policy,
+// activation balances are taken from the daily validator statistics once the balances have been downsampled.
+func SaveEpochStats(epoch, finalizedEpoch uint64) error {
+	slotsPerEpoch := utils.Config.Chain.SlotsPerEpoch
+	startSlot := epoch * slotsPerEpoch
+	endSlot := startSlot + slotsPerEpoch - 1
+
+	_, err := DB.Exec(`
+		INSERT INTO epoch_stats (
+			epoch,
+			ts,
+			proposed_blocks,
+			missed_blocks,
+			orphaned_blocks,
+			validatorscount,
+			eligibleether,
+			votedether,
+			totalvalidatorbalance,
+			averagevalidatorbalance,
+			globalparticipationrate,
+			inclusion_distance,
+			finality_delay,
+			activation_deposits,
+			extra_deposits,
+			deposits,
+			finalized
+		)
+		SELECT
+			e.epoch,
+			$2,
+			(SELECT COUNT(*) FROM blocks WHERE slot >= $3 AND slot <= $4 AND status = '1'),
+			(SELECT COUNT(*) FROM blocks WHERE slot >= $3 AND slot <= $4 AND status = '2'),
+			(SELECT COUNT(*) FROM blocks WHERE slot >= $3 AND slot <= $4 AND status = '3'),
+			e.validatorscount,
+			COALESCE(e.eligibleether, 0),
+			COALESCE(e.votedether, 0),
+			e.totalvalidatorbalance,
+			e.averagevalidatorbalance,
+			COALESCE(e.globalparticipationrate, 0),
+			(
+				SELECT AVG(a.inclusionslot - a.attesterslot)::float
+				FROM attestation_assignments a
+				INNER JOIN blocks b ON b.slot = a.attesterslot AND b.status = '1'
+				WHERE a.epoch = $1 AND a.inclusionslot > 0
+			),
+			(SELECT headepoch - finalizedepoch FROM network_liveness WHERE headepoch = $1 ORDER BY ts LIMIT 1),
+			(
+				SELECT COALESCE(SUM(COALESCE(vb.balance, s.start_balance, 32e9)), 0)
+				FROM validators v
+				LEFT JOIN validator_balances vb ON vb.validatorindex = v.validatorindex AND vb.epoch = v.activationepoch
+				LEFT JOIN validator_stats_daily s ON s.validatorindex = v.validatorindex AND s.day = $6 AND s.start_epoch = v.activationepoch
+				WHERE v.activationepoch = $1
+			),
+			(
+				SELECT COALESCE(SUM(d.amount), 0)
+				FROM blocks_deposits d
+				INNER JOIN blocks b ON b.slot = d.block_slot AND b.status = '1'
+				INNER JOIN validators v ON v.pubkey = d.publickey AND v.activationepoch < $1
+				WHERE d.block_slot >= $3 AND d.block_slot <= $4
+			),
+			(
+				SELECT COALESCE(SUM(d.amount), 0)
+				FROM blocks_deposits d
+				INNER JOIN blocks b ON b.slot = d.block_slot AND b.status = '1'
+				WHERE d.block_slot >= $3 AND d.block_slot <= $4
+			),
+			e.epoch <= $5
+		FROM epochs e
+		WHERE e.epoch = $1
+		ON CONFLICT (epoch) DO UPDATE SET
+			ts                      = excluded.ts,
+			proposed_blocks         = excluded.proposed_blocks,
+			missed_blocks           = excluded.missed_blocks,
+			orphaned_blocks         = excluded.orphaned_blocks,
+			validatorscount         = excluded.validatorscount,
+			eligibleether           = excluded.eligibleether,
+			votedether              = excluded.votedether,
+			totalvalidatorbalance   = excluded.totalvalidatorbalance,
+			averagevalidatorbalance = excluded.averagevalidatorbalance,
+			globalparticipationrate = excluded.globalparticipationrate,
+			inclusion_distance      = COALESCE(excluded.inclusion_distance, epoch_stats.inclusion_distance),
+			finality_delay          = excluded.finality_delay,
+			activation_deposits     = excluded.activation_deposits,
+			extra_deposits          = excluded.extra_deposits,
+			deposits                = excluded.deposits,
+			finalized               = excluded.finalized`,
+		epoch, utils.EpochToTime(epoch).UTC(), startSlot, endSlot, finalizedEpoch, utils.EpochToDay(epoch))
+	return err
+}
+
+// SaveDailyStats aggregates the statistics of the epochs that start at the day (UTC)
+func SaveDailyStats(day time.Time) error {
+	_, err := DB.Exec(`
+		WITH
+			day AS (
+				SELECT * FROM epoch_stats WHERE ts >= $1::timestamp AND ts < $1::timestamp + interval '1 day'
+			),
+			last AS (
+				SELECT * FROM day ORDER BY epoch DESC LIMIT 1
+			)
+		INSERT INTO daily_stats (
+			day,
+			first_epoch,
+			last_epoch,
+			proposed_blocks,
+			missed_blocks,
+			orphaned_blocks,
+			validatorscount,
+			eligibleether,
+			totalvalidatorbalance,
+			averagevalidatorbalance,
+			rewards,
+			deposits
+		)
+		SELECT
+			$1::date,
+			(SELECT MIN(epoch) FROM day),
+			last.epoch,
+			(SELECT SUM(proposed_blocks) FROM day),
+			(SELECT SUM(missed_blocks) FROM day),
+			(SELECT SUM(orphaned_blocks) FROM day),
+			last.validatorscount,
+			last.eligibleether,
+			last.totalvalidatorbalance,
+			last.averagevalidatorbalance,
+			last.totalvalidatorbalance - (SELECT SUM(activation_deposits + extra_deposits) FROM epoch_stats WHERE epoch <= last.epoch),
+			(SELECT SUM(deposits) FROM day)
+		FROM last
+		ON CONFLICT (day) DO UPDATE SET
+			first_epoch             = excluded.first_epoch,
+			last_epoch              = excluded.last_epoch,
+			proposed_blocks         = excluded.proposed_blocks,
+			missed_blocks           = excluded.missed_blocks,
+			orphaned_blocks         = excluded.orphaned_blocks,
+			validatorscount         = excluded.validatorscount,
+			eligibleether           = excluded.eligibleether,
+			totalvalidatorbalance   = excluded.totalvalidatorbalance,
+			averagevalidatorbalance = excluded.averagevalidatorbalance,
+			rewards                 = excluded.rewards,
+			deposits                = excluded.deposits`, day)
+	return err
+}
+
+// SaveEth1DepositsDaily aggregates the eth1 deposits per day (UTC). If all is false only the days since the day
+// before the latest aggregated day are updated, since deposits are exported with a delay.
+func SaveEth1DepositsDaily(all bool) error {
+	since := time.Time{}
+	if !all {
+		var latest *time.Time
+		err := DB.Get(&latest, "SELECT MAX(day) FROM eth1_deposits_daily")
+		if err != nil {
+			return err
+		}
+		if latest != nil {
+			since = latest.AddDate(0, 0, -1)
+		}
+	}
+
+	_, err := DB.Exec(`
+		INSERT INTO eth1_deposits_daily (day, valid_amount, invalid_amount)
+		SELECT
+			block_ts::date AS day,
+			COALESCE(SUM(amount) FILTER (WHERE valid_signature), 0),
+			COALESCE(SUM(amount) FILTER (WHERE NOT valid_signature), 0)
+		FROM eth1_deposits
+		WHERE block_ts >= $1
+		GROUP BY day
+		ON CONFLICT (day) DO UPDATE SET
+			valid_amount   = excluded.valid_amount,
+			invalid_amount = excluded.invalid_amount`, since)
+	return err
+}
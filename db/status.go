@@ -0,0 +1,68 @@
+package db
+
+import "eth2-exporter/types"
+
+// SaveServiceRun records a run of a background service, runErr is the error of a failed run
+func SaveServiceRun(name string, runErr error) error {
+	if runErr != nil {
+		_, err := DB.Exec(`
+			INSERT INTO service_status (name, last_failure_ts, last_error)
+			VALUES ($1, NOW(), $2)
+			ON CONFLICT (name) DO UPDATE SET
+				last_failure_ts = EXCLUDED.last_failure_ts,
+				last_error      = EXCLUDED.last_error`, name, runErr.Error())
+		return err
+	}
+	_, err := DB.Exec(`
+		INSERT INTO service_status (name, last_success_ts)
+		VALUES ($1, NOW())
+		ON CONFLICT (name) DO UPDATE SET last_success_ts = EXCLUDED.last_success_ts`, name)
+	return err
+}
+
+// SaveServiceProgress records a successful run of a background service that follows a chain, height is the block or
+// epoch the service has processed and head the head of the chain
+func SaveServiceProgress(name string, height, head uint64) error {
+	_, err := DB.Exec(`
+		INSERT INTO service_status (name, last_success_ts, height, head)
+		VALUES ($1, NOW(), $2, $3)
+		ON CONFLICT (name) DO UPDATE SET
+			last_success_ts = EXCLUDED.last_success_ts,
+			height          = EXCLUDED.height,
+			head            = EXCLUDED.head`, name, height, head)
+	return err
+}
+
+// ReportServiceRun records a run of a background service for the status endpoint, errors are only logged as they must
+// not interrupt the service
+func ReportServiceRun(name string, runErr error) {
+	err := SaveServiceRun(name, runErr)
+	if err != nil {
+		logger.Errorf("error saving status of service %v: %v", name, err)
+	}
+}
+
+// ReportServiceProgress records a successful run of a background service that follows a chain for the status endpoint
+func ReportServiceProgress(name string, height, head uint64) {
+	err := SaveServiceProgress(name, height, head)
+	if err != nil {
+		logger.Errorf("error saving status of service %v: %v", name, err)
+	}
+}
+
+// GetServiceStatus returns the last runs of all background services that have reported so far
+func GetServiceStatus() ([]*types.ServiceStatus, error) {
+	status := []*types.ServiceStatus{}
+	err := DB.Select(&status, `
+		SELECT name, last_success_ts, last_failure_ts, last_error, height, head
+		FROM service_status
+		ORDER BY name`)
+	return status, err
+}
+
+// GetDatabaseSize returns the size of the database in bytes
+func GetDatabaseSize() (uint64, error) {
+	var size uint64
+	err := DB.Get(&size, "SELECT pg_database_size(current_database())")
+	return size, err
+}
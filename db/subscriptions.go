@@ -140,6 +140,72 @@ func GetTaggedValidators(filter WatchlistFilter) ([]*types.TaggedValidators, err
 	return list, err
 }
 
+// GetUserTaggedValidators returns the validators a user has tagged together with all their tags. If tag is not empty
+// only the validators with that tag are returned.
+func GetUserTaggedValidators(userID uint64, tag types.Tag) ([]*types.ApiUserValidator, error) {
+	validators := []*types.ApiUserValidator{}
+	err := DB.Select(&validators, `
+		SELECT
+			'0x' || ENCODE(t.validator_publickey, 'hex') AS pubkey,
+			v.validatorindex,
+			v.balance,
+			ARRAY_AGG(t.tag ORDER BY t.tag) AS tags
+		FROM users_validators_tags t
+		LEFT JOIN validators v ON v.pubkey = t.validator_publickey
+		WHERE t.user_id = $1 AND ($2 = '' OR t.validator_publickey IN (
+			SELECT validator_publickey FROM users_validators_tags WHERE user_id = $1 AND tag = $2))
+		GROUP BY t.validator_publickey, v.validatorindex, v.balance
+		ORDER BY v.validatorindex, t.validator_publickey`, userID, string(tag))
+	return validators, err
+}
+
+// AddValidatorsTag tags the validators of a user, validators that already have the tag are skipped
+func AddValidatorsTag(userID uint64, tag types.Tag, publicKeys [][]byte) error {
+	_, err := DB.Exec(`
+		INSERT INTO users_validators_tags (user_id, validator_publickey, tag)
+		SELECT $1, UNNEST($2::bytea[]), $3
+		ON CONFLICT DO NOTHING`, userID, pq.ByteaArray(publicKeys), string(tag))
+	return err
+}
+
+// RemoveValidatorsTag removes a tag from the validators of a user, the tag is removed from all validators if
+// publicKeys is nil. The number of removed tags is returned.
+func RemoveValidatorsTag(userID uint64, tag types.Tag, publicKeys [][]byte) (int64, error) {
+	var res sql.Result
+	var err error
+	if publicKeys == nil {
+		res, err = DB.Exec("DELETE FROM users_validators_tags WHERE user_id = $1 AND tag = $2", userID, string(tag))
+	} else {
+		res, err = DB.Exec("DELETE FROM users_validators_tags WHERE user_id = $1 AND tag = $2 AND validator_publickey = ANY($3)", userID, string(tag), pq.ByteaArray(publicKeys))
+	}
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// SaveUserSubscription adds a subscription of a user, the channels are updated if the user is already subscribed to
+// the event with the same filter
+func SaveUserSubscription(sub *types.Subscription) error {
+	now := time.Now()
+	return DB.QueryRowx(`
+		INSERT INTO users_subscriptions (user_id, event_name, event_filter, created_ts, created_epoch, channels)
+		VALUES ($1, $2, $3, TO_TIMESTAMP($4), $5, $6)
+		ON CONFLICT (user_id, event_name, event_filter) DO UPDATE SET channels = excluded.channels
+		RETURNING *`,
+		sub.UserID, sub.EventName, sub.EventFilter, now.Unix(), utils.TimeToEpoch(now), sub.Channels).StructScan(sub)
+}
+
+// DeleteUserSubscription removes a subscription of a user, false is returned if the user has no subscription with that id
+func DeleteUserSubscription(userID, id uint64) (bool, error) {
+	res, err := DB.Exec("DELETE FROM users_subscriptions WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	return rows > 0, err
+}
+
 // GetSubscriptionsFilter can be passed to GetSubscriptions() to filter subscriptions.
 type GetSubscriptionsFilter struct {
 	EventNames    *[]types.EventName
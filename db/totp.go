@@ -0,0 +1,111 @@
+package db
+
+import (
+	"eth2-exporter/types"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// GetUserTOTP returns the totp two-factor authentication settings of a user
+func GetUserTOTP(userID uint64) (*types.UserTOTP, error) {
+	totp := &types.UserTOTP{}
+	err := FrontendDB.Get(totp, "SELECT totp_secret, totp_enabled, totp_last_step FROM users WHERE id = $1", userID)
+	return totp, err
+}
+
+// SetUserTOTPSecret stores the secret of a pending totp enrollment, the secret of an enabled enrollment is never replaced
+func SetUserTOTPSecret(userID uint64, secret string) error {
+	_, err := FrontendDB.Exec("UPDATE users SET totp_secret = $2 WHERE id = $1 AND NOT totp_enabled", userID, secret)
+	return err
+}
+
+// EnableUserTOTP completes the totp enrollment of a user and stores the hashes of the recovery codes
+func EnableUserTOTP(userID uint64, recoveryCodeHashes []string) error {
+	tx, err := FrontendDB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE users SET totp_enabled = true WHERE id = $1 AND totp_secret IS NOT NULL", userID)
+	if err != nil {
+		return err
+	}
+	err = replaceRecoveryCodes(tx, userID, recoveryCodeHashes)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DisableUserTOTP removes the totp secret and the recovery codes of a user
+func DisableUserTOTP(userID uint64) error {
+	tx, err := FrontendDB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE users SET totp_secret = NULL, totp_enabled = false, totp_last_step = 0 WHERE id = $1", userID)
+	if err != nil {
+		return err
+	}
+	err = replaceRecoveryCodes(tx, userID, nil)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseUserTOTPStep records the time step of an accepted totp code, false is returned if a code of the same or a later
+// time step has already been used
+func UseUserTOTPStep(userID, step uint64) (bool, error) {
+	res, err := FrontendDB.Exec("UPDATE users SET totp_last_step = $2 WHERE id = $1 AND totp_last_step < $2", userID, step)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	return rows > 0, err
+}
+
+// UseUserRecoveryCode removes a recovery code of a user, false is returned if the user has no such code
+func UseUserRecoveryCode(userID uint64, codeHash string) (bool, error) {
+	res, err := FrontendDB.Exec("DELETE FROM users_recovery_codes WHERE user_id = $1 AND code_hash = $2", userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	return rows > 0, err
+}
+
+// ReplaceUserRecoveryCodes replaces the recovery codes of a user
+func ReplaceUserRecoveryCodes(userID uint64, codeHashes []string) error {
+	tx, err := FrontendDB.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = replaceRecoveryCodes(tx, userID, codeHashes)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetUserRecoveryCodesCount returns the number of unused recovery codes of a user
+func GetUserRecoveryCodesCount(userID uint64) (int, error) {
+	count := 0
+	err := FrontendDB.Get(&count, "SELECT COUNT(*) FROM users_recovery_codes WHERE user_id = $1", userID)
+	return count, err
+}
+
+func replaceRecoveryCodes(tx *sqlx.Tx, userID uint64, codeHashes []string) error {
+	_, err := tx.Exec("DELETE FROM users_recovery_codes WHERE user_id = $1", userID)
+	if err != nil || len(codeHashes) == 0 {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO users_recovery_codes (user_id, code_hash) SELECT $1, UNNEST($2::text[])", userID, pq.StringArray(codeHashes))
+	return err
+}
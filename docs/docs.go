@@ -24,6 +24,83 @@ var doc = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/feed/events": {
+            "get": {
+                "description": "Streams the events as server-sent events, the event name is the topic and the data is the json event.\nThe validator filter only applies to events that concern validators (block, orphaned_block and slashing).",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Feed"
+                ],
+                "summary": "Push feed of new blocks, orphaned blocks, epochs, finality changes and slashings via server-sent events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated list of topics: block, orphaned_block, epoch, finality, slashing (default all)",
+                        "name": "topics",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated list of up to 100 validator indices (default all)",
+                        "name": "validators",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many open connections of the client or of all clients",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/feed/ws": {
+            "get": {
+                "description": "Streams the events as json text messages of the form {\"topic\": \"block\", \"validators\": [1], \"data\": {...}}.\nThe initial filter is taken from the query parameters, it can be replaced by sending {\"topics\": [\"block\"], \"validators\": [1, 2]}.\nThe validator filter only applies to events that concern validators (block, orphaned_block and slashing).",
+                "tags": [
+                    "Feed"
+                ],
+                "summary": "Push feed of new blocks, orphaned blocks, epochs, finality changes and slashings via websocket",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated list of topics: block, orphaned_block, epoch, finality, slashing (default all)",
+                        "name": "topics",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated list of up to 100 validator indices (default all)",
+                        "name": "validators",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many open connections of the client or of all clients",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/api/healthz": {
             "get": {
                 "description": "Health endpoint for montitoring if the explorer is in sync",
@@ -44,6 +121,32 @@ var doc = `{
                 }
             }
         },
+        "/api/status": {
+            "get": {
+                "description": "Returns the lag of the indexer, the finality and the eth1 deposit exporter, the last runs of the background services, the failed epochs of the export queue and the health of the database. Error messages of the services are not included, admins can retrieve the full status at /admin/status.\nEach component gets its own health verdict (healthy, unhealthy or unknown), the status code is 503 if any component is unhealthy.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Sync and indexing status of the explorer",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/block/{slotOrHash}": {
             "get": {
                 "description": "Returns a block by its slot or root hash",
@@ -219,6 +322,71 @@ var doc = `{
             }
         },
         "/api/v1/chart/{chart}": {
+            "get": {
+                "produces": [
+                    "image/png",
+                    "image/svg+xml"
+                ],
+                "tags": [
+                    "Charts"
+                ],
+                "summary": "Returns charts from the page https://beaconcha.in/charts as PNG or SVG image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Chart name (see https://github.com/gobitfly/eth2-beaconchain-explorer/blob/master/services/charts_updater.go#L20 for all available names)",
+                        "name": "chart",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Image format: png (default) or svg",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Width of the image in pixels, defaults to 1200",
+                        "name": "width",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Height of the image in pixels, defaults to 600",
+                        "name": "height",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Start of the time range as unix timestamp, defaults to the start of the chart",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End of the time range as unix timestamp, defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Size of the buckets of time series: epoch, hour, day or week, ignored by distribution charts",
+                        "name": "bucket",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/chart/{chart}/data": {
             "get": {
                 "produces": [
                     "application/json"
@@ -226,7 +394,7 @@ var doc = `{
                 "tags": [
                     "Charts"
                 ],
-                "summary": "Returns charts from the page https://beaconcha.in/charts as PNG",
+                "summary": "Returns the series of the charts from the page https://beaconcha.in/charts",
                 "parameters": [
                     {
                         "type": "string",
@@ -234,6 +402,24 @@ var doc = `{
                         "name": "chart",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Start of the time range as unix timestamp, defaults to the start of the chart",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End of the time range as unix timestamp, defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Size of the buckets of time series: epoch, hour, day or week, ignored by distribution charts",
+                        "name": "bucket",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -332,6 +518,406 @@ var doc = `{
                 }
             }
         },
+        "/api/v1/oauth/revoke": {
+            "post": {
+                "description": "Token revocation endpoint (RFC 7009). Revoking a refresh token signs the device out, its access token becomes invalid as well.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Revoke a refresh token or an access token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the registered app",
+                        "name": "client_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Refresh token or access token",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/oauth/token": {
+            "post": {
+                "description": "Token endpoint of the oauth authorization code flow (RFC 6749) for third-party and mobile apps.\nAuthorization codes are issued by /user/authorize and have to be redeemed with the pkce code_verifier (RFC 7636) within 10 minutes.\nAccess tokens expire after one hour. Every use of a refresh token returns a new refresh token and invalidates the old one.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Exchange an authorization code or a refresh token for an access token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization_code or refresh_token",
+                        "name": "grant_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Id of the registered app",
+                        "name": "client_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authorization code (authorization_code grant)",
+                        "name": "code",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Redirect uri of the app (authorization_code grant)",
+                        "name": "redirect_uri",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pkce code verifier (authorization_code grant)",
+                        "name": "code_verifier",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Refresh token (refresh_token grant)",
+                        "name": "refresh_token",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/device/notifications": {
+            "post": {
+                "description": "Sets the firebase cloud messaging token of the device the access token has been issued to.\nNotifications of subscriptions with the push channel are only sent to devices that have enabled notifications.\nRequires an access token of the oauth flow in the Authorization header (Bearer).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Register the push notification token of the authorized device",
+                "parameters": [
+                    {
+                        "description": "{\"notification_token\": \"<fcm token>\", \"notify_enabled\": true}",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/subscriptions": {
+            "get": {
+                "description": "Returns the subscriptions of the user with their event filters and notification channels.\nRequires an access token of the oauth flow in the Authorization header (Bearer).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Get the notification subscriptions of the user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Subscribes the user to an event. Validator events are filtered by the index or pubkey of the validator, network events can not be filtered.\nThe channels default to email, subscribing again to the same event and filter updates the channels.\nRequires an access token of the oauth flow in the Authorization header (Bearer).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Subscribe the user to notifications of an event",
+                "parameters": [
+                    {
+                        "description": "{\"event_name\": \"validator_balance_decreased\", \"event_filter\": \"1\", \"channels\": [\"email\", \"push\"]}",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/subscriptions/{id}": {
+            "delete": {
+                "description": "Requires an access token of the oauth flow in the Authorization header (Bearer).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Delete a notification subscription of the user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Id of the subscription",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/tags": {
+            "get": {
+                "description": "Returns all validators the user has tagged (including the watchlist) together with their tags.\nRequires an access token of the oauth flow in the Authorization header (Bearer).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Get the tagged validators of the user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only return the validators with this tag",
+                        "name": "tag",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/tags/{tag}": {
+            "post": {
+                "description": "Adds a tag to up to 100 validators and returns all validators with the tag. The watchlist tag is reserved, use the watchlist routes instead.\nRequires an access token of the oauth flow in the Authorization header (Bearer).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Tag validators of the user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tag of up to 100 characters",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "{\"validators\": [\"1\", \"2\"]}",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes a tag from the passed validators or from all validators if none are passed.\nRequires an access token of the oauth flow in the Authorization header (Bearer).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Remove a tag from validators of the user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tag",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Up to 100 validator indices or pubkeys, comma separated",
+                        "name": "validators",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/watchlist": {
+            "get": {
+                "description": "Returns the validators on the watchlist of the user together with all their tags.\nRequires an access token of the oauth flow in the Authorization header (Bearer).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Get the watchlist of the user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Adds up to 100 validators to the watchlist and returns the updated watchlist.\nRequires an access token of the oauth flow in the Authorization header (Bearer).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Add validators to the watchlist of the user",
+                "parameters": [
+                    {
+                        "description": "{\"validators\": [\"1\", \"0xa1d1ad0714035353258038e964ae9675dc0252ee22cea896825c01458e1807bfad2f9969338798548d9858a571f7425c\"]}",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user/watchlist/{indexOrPubkey}": {
+            "delete": {
+                "description": "Removes a validator from the watchlist, the subscriptions for the validator are removed as well. Returns the updated watchlist.\nRequires an access token of the oauth flow in the Authorization header (Bearer).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "User"
+                ],
+                "summary": "Remove a validator from the watchlist of the user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Validator index or pubkey",
+                        "name": "indexOrPubkey",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/validator/eth1/{address}": {
             "get": {
                 "produces": [
@@ -0,0 +1,151 @@
+package exporter
+
+import (
+	"bytes"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// epochEffectivenessData holds the data that is needed to compute the attestation effectiveness of the validators
+// for an epoch
+type epochEffectivenessData struct {
+	Epoch         uint64
+	SlotsPerEpoch uint64
+	Assignments   []*types.EffectivenessAssignment
+	Attestations  []*types.RewardsAttestation // attestations for the slots of the epoch included in canonical blocks
+	Blocks        []*types.MinimalBlock       // canonical blocks from the earliest source checkpoint up to the last inclusion slot
+}
+
+func effectivenessExporter() {
+	epochDuration := time.Second * time.Duration(utils.Config.Chain.SecondsPerSlot*utils.Config.Chain.SlotsPerEpoch)
+	for {
+		err := exportEffectiveness()
+		if err != nil {
+			logger.Errorf("error exporting attestation effectiveness: %v", err)
+		}
+		time.Sleep(epochDuration)
+	}
+}
+
+// exportEffectiveness computes the attestation effectiveness of all epochs before the last finalized epoch, the
+// attestations of these epochs can not be included in a block that might still be orphaned
+func exportEffectiveness() error {
+	next, finalized, err := db.GetEffectivenessExportStatus()
+	if err != nil {
+		return fmt.Errorf("error retrieving attestation effectiveness export status: %v", err)
+	}
+
+	for epoch := next; epoch < finalized; epoch++ {
+		start := time.Now()
+		data, err := getEpochEffectivenessData(epoch)
+		if err != nil {
+			return err
+		}
+		if len(data.Assignments) == 0 {
+			// the next run resumes after the latest exported epoch, the epoch is retried once it has been exported
+			return fmt.Errorf("error exporting attestation effectiveness of epoch %v: no attestation assignments available", epoch)
+		}
+
+		effectiveness := computeEpochEffectiveness(data)
+		err = db.SaveAttestationEffectiveness(effectiveness)
+		if err != nil {
+			return fmt.Errorf("error saving attestation effectiveness of epoch %v: %v", epoch, err)
+		}
+		logger.Infof("exported attestation effectiveness of %v validators for epoch %v in %v", len(effectiveness), epoch, time.Since(start))
+	}
+	return nil
+}
+
+func getEpochEffectivenessData(epoch uint64) (*epochEffectivenessData, error) {
+	slotsPerEpoch := utils.Config.Chain.SlotsPerEpoch
+	data := &epochEffectivenessData{Epoch: epoch, SlotsPerEpoch: slotsPerEpoch}
+	startSlot := epoch * slotsPerEpoch
+	endSlot := startSlot + slotsPerEpoch - 1
+
+	var err error
+	data.Assignments, err = db.GetEffectivenessAssignments(epoch)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving attestation assignments of epoch %v: %v", epoch, err)
+	}
+
+	data.Attestations, err = db.GetRewardsAttestations(startSlot, endSlot)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving attestations of epoch %v: %v", epoch, err)
+	}
+
+	// the source checkpoint is usually the previous epoch but lies further back while the chain does not justify
+	sourceEpoch := epoch
+	for _, a := range data.Attestations {
+		if a.SourceEpoch < sourceEpoch {
+			sourceEpoch = a.SourceEpoch
+		}
+	}
+	data.Blocks, err = db.GetCanonicalBlocks(sourceEpoch*slotsPerEpoch, (epoch+2)*slotsPerEpoch-1)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving canonical blocks of epoch %v: %v", epoch, err)
+	}
+
+	return data, nil
+}
+
+// computeEpochEffectiveness compares the votes of the attestations of the validators for an epoch with the canonical
+// chain and determines their earliest inclusion. Only attestations that are included within an epoch after their
+// slot are considered, like for the rewards.
+func computeEpochEffectiveness(data *epochEffectivenessData) []*types.AttestationEffectiveness {
+	// the optimal inclusion slot is the first slot after the attester slot with a canonical block
+	optimalInclusionSlot := func(slot uint64) uint64 {
+		for _, b := range data.Blocks {
+			if b.Slot > slot {
+				return b.Slot
+			}
+		}
+		return slot + 1
+	}
+
+	effectiveness := make(map[uint64]*types.AttestationEffectiveness, len(data.Assignments))
+	for _, a := range data.Assignments {
+		effectiveness[a.ValidatorIndex] = &types.AttestationEffectiveness{
+			Epoch:                data.Epoch,
+			ValidatorIndex:       a.ValidatorIndex,
+			AttesterSlot:         a.AttesterSlot,
+			OptimalInclusionSlot: optimalInclusionSlot(a.AttesterSlot),
+		}
+	}
+
+	targetRoot := canonicalBlockRoot(data.Blocks, data.Epoch*data.SlotsPerEpoch)
+	lastInclusionSlot := (data.Epoch+2)*data.SlotsPerEpoch - 1
+	for _, a := range data.Attestations {
+		if a.BlockSlot <= a.Slot || a.BlockSlot > lastInclusionSlot {
+			continue
+		}
+		sourceRoot := canonicalBlockRoot(data.Blocks, a.SourceEpoch*data.SlotsPerEpoch)
+		correctSource := sourceRoot != nil && bytes.Equal(a.SourceRoot, sourceRoot)
+		correctTarget := targetRoot != nil && bytes.Equal(a.TargetRoot, targetRoot)
+		correctHead := bytes.Equal(a.BeaconBlockRoot, canonicalBlockRoot(data.Blocks, a.Slot))
+		for _, i := range a.Validators {
+			e := effectiveness[uint64(i)]
+			if e == nil || e.AttesterSlot != a.Slot {
+				continue
+			}
+			if e.InclusionSlot == 0 || a.BlockSlot < e.InclusionSlot {
+				e.InclusionSlot = a.BlockSlot
+			}
+			e.CorrectSource = e.CorrectSource || correctSource
+			e.CorrectTarget = e.CorrectTarget || correctTarget
+			e.CorrectHead = e.CorrectHead || correctHead
+		}
+	}
+
+	result := make([]*types.AttestationEffectiveness, 0, len(effectiveness))
+	for _, e := range effectiveness {
+		result = append(result, e)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ValidatorIndex < result[j].ValidatorIndex
+	})
+	return result
+}
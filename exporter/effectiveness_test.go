@@ -0,0 +1,53 @@
+package exporter
+
+import (
+	"eth2-exporter/types"
+	"testing"
+)
+
+func TestComputeEpochEffectiveness(t *testing.T) {
+	data := &epochEffectivenessData{
+		Epoch:         1,
+		SlotsPerEpoch: 4,
+		Assignments: []*types.EffectivenessAssignment{
+			{ValidatorIndex: 0, AttesterSlot: 5},
+			{ValidatorIndex: 1, AttesterSlot: 4},
+			{ValidatorIndex: 2, AttesterSlot: 7},
+			{ValidatorIndex: 3, AttesterSlot: 6},
+		},
+		Blocks: []*types.MinimalBlock{testBlock(0, 0xa0, 0x00), testBlock(3, 0xa3, 0xa0), testBlock(4, 0xa4, 0xa3), testBlock(6, 0xa6, 0xa4), testBlock(8, 0xa8, 0xa6)},
+		Attestations: []*types.RewardsAttestation{
+			// validator 0 votes correctly and is included in the next slot
+			{BlockSlot: 6, Slot: 5, BeaconBlockRoot: []byte{0xa4}, SourceRoot: []byte{0xa0}, TargetRoot: []byte{0xa4}, Validators: []int64{0}},
+			// validator 1 votes for a wrong head and is included twice, the earliest inclusion counts
+			{BlockSlot: 9, Slot: 4, BeaconBlockRoot: []byte{0xa3}, SourceRoot: []byte{0xa0}, TargetRoot: []byte{0xa4}, Validators: []int64{1}},
+			{BlockSlot: 8, Slot: 4, BeaconBlockRoot: []byte{0xa3}, SourceRoot: []byte{0xa0}, TargetRoot: []byte{0xa4}, Validators: []int64{1}},
+			// included too late
+			{BlockSlot: 12, Slot: 7, BeaconBlockRoot: []byte{0xa6}, SourceRoot: []byte{0xa0}, TargetRoot: []byte{0xa4}, Validators: []int64{2}},
+			// validator 3 votes for a wrong source and target but the correct head
+			{BlockSlot: 8, Slot: 6, BeaconBlockRoot: []byte{0xa6}, SourceRoot: []byte{0xff}, TargetRoot: []byte{0xa3}, Validators: []int64{3}},
+		},
+	}
+
+	effectiveness := computeEpochEffectiveness(data)
+	expected := []types.AttestationEffectiveness{
+		{Epoch: 1, ValidatorIndex: 0, AttesterSlot: 5, InclusionSlot: 6, OptimalInclusionSlot: 6, CorrectSource: true, CorrectTarget: true, CorrectHead: true},
+		{Epoch: 1, ValidatorIndex: 1, AttesterSlot: 4, InclusionSlot: 8, OptimalInclusionSlot: 6, CorrectSource: true, CorrectTarget: true},
+		{Epoch: 1, ValidatorIndex: 2, AttesterSlot: 7, OptimalInclusionSlot: 8},
+		{Epoch: 1, ValidatorIndex: 3, AttesterSlot: 6, InclusionSlot: 8, OptimalInclusionSlot: 8, CorrectHead: true},
+	}
+	if len(effectiveness) != len(expected) {
+		t.Fatalf("expected effectiveness of %v validators, got %v", len(expected), len(effectiveness))
+	}
+	for i, e := range effectiveness {
+		if *e != expected[i] {
+			t.Errorf("unexpected effectiveness of validator %v: got %+v, want %+v", i, *e, expected[i])
+		}
+	}
+
+	for i, want := range []float64{1, 0.5, 0, 1} {
+		if got := effectiveness[i].Effectiveness(); got != want {
+			t.Errorf("unexpected effectiveness of validator %v: got %v, want %v", i, got, want)
+		}
+	}
+}
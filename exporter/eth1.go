@@ -62,12 +62,14 @@ func eth1DepositsExporter() {
 		err = db.DB.Get(&lastDepositBlock, "select coalesce(max(block_number),0) from eth1_deposits")
 		if err != nil {
 			logger.WithError(err).Errorf("error retrieving highest block_number of eth1-deposits from db")
+			db.ReportServiceRun(types.ServiceEth1Deposits, err)
 			time.Sleep(time.Second * 5)
 			continue
 		}
 		header, err := eth1Client.HeaderByNumber(context.Background(), nil)
 		if err != nil {
 			logger.WithError(err).Errorf("error getting header from eth1-client")
+			db.ReportServiceRun(types.ServiceEth1Deposits, err)
 			time.Sleep(time.Second * 5)
 			continue
 		}
@@ -108,6 +110,7 @@ func eth1DepositsExporter() {
 			}
 			if err != nil {
 				logger.WithError(err).WithField("fromBlock", fromBlock).WithField("toBlock", toBlock).Errorf("error fetching eth1-deposits")
+				db.ReportServiceRun(types.ServiceEth1Deposits, err)
 				time.Sleep(time.Second * 5)
 				continue
 			}
@@ -116,12 +119,14 @@ func eth1DepositsExporter() {
 		err = saveEth1Deposits(depositsToSave)
 		if err != nil {
 			logger.WithError(err).Errorf("error saving eth1-deposits")
+			db.ReportServiceRun(types.ServiceEth1Deposits, err)
 			time.Sleep(time.Second * 5)
 			continue
 		}
 
 		// make sure we are progressing even if there are no deposits in the last batch
 		lastFetchedBlock = toBlock
+		db.ReportServiceProgress(types.ServiceEth1Deposits, toBlock, blockHeight)
 
 		logger.WithFields(logrus.Fields{
 			"duration":      time.Since(t0),
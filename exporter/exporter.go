@@ -15,17 +15,16 @@ import (
 
 var logger = logrus.New().WithField("module", "exporter")
 
-// If exporting an epoch fails for 10 consecutive times exporting this epoch will be disabled
-// This is a workaround for a bug in the prysm archive node that causes epochs without blocks
-// to not be archived properly (see https://github.com/prysmaticlabs/prysm/issues/4165)
-var epochBlacklist = make(map[uint64]uint64)
-
 // Start will start the export of data from rpc into the database
 func Start(client rpc.Client) error {
 	go performanceDataUpdater()
+	go rollupUpdater()
+	go rewardsExporter()
+	go effectivenessExporter()
 	go networkLivenessUpdater(client)
 	go eth1DepositsExporter()
 	go genesisDepositsExporter()
+	go retentionUpdater()
 
 	// wait until the beacon-node is available
 	for {
@@ -37,6 +36,12 @@ func Start(client rpc.Client) error {
 		time.Sleep(time.Second * 10)
 	}
 
+	// Jobs that were in progress when the indexer was stopped have to be picked up again
+	err := db.ResetInProgressExportJobs()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
 	if utils.Config.Indexer.FullIndexOnStartup {
 		logger.Printf("performing one time full db reindex")
 		head, err := client.GetChainHead()
@@ -44,13 +49,11 @@ func Start(client rpc.Client) error {
 			logger.Fatal(err)
 		}
 
-		for epoch := uint64(1); epoch <= head.HeadEpoch; epoch++ {
-			err := ExportEpoch(epoch, client)
-
-			if err != nil {
-				logger.Error(err)
-			}
+		failed, err := exportEpochRange(1, head.HeadEpoch, client, "fullindex")
+		if err != nil {
+			logger.Fatal(err)
 		}
+		queueFailedEpochs(failed)
 	}
 
 	if utils.Config.Indexer.IndexMissingEpochsOnStartup {
@@ -73,13 +76,11 @@ func Start(client rpc.Client) error {
 			if epochs[i] != epochs[i+1]-1 && epochs[i] != epochs[i+1] {
 				logger.Println("Epochs between", epochs[i], "and", epochs[i+1], "are missing!")
 
+				missingEpochs := make([]uint64, 0, epochs[i+1]-epochs[i]+1)
 				for epoch := epochs[i]; epoch <= epochs[i+1]; epoch++ {
-					err := ExportEpoch(epoch, client)
-					if err != nil {
-						logger.Error(err)
-					}
-					logger.Printf("finished export for epoch %v", epoch)
+					missingEpochs = append(missingEpochs, epoch)
 				}
+				queueFailedEpochs(exportEpochs(missingEpochs, client, ""))
 			}
 		}
 	}
@@ -149,16 +150,7 @@ func Start(client rpc.Client) error {
 			return keys[i] < keys[j]
 		})
 
-		for _, epoch := range keys {
-			err = ExportEpoch(epoch, client)
-
-			if err != nil {
-				logger.Errorf("error exporting epoch: %v", err)
-				if utils.EpochToTime(epoch).Before(time.Now().Add(time.Hour * -24)) {
-					epochBlacklist[epoch]++
-				}
-			}
-		}
+		queueFailedEpochs(exportEpochs(keys, client, ""))
 	}
 
 	if utils.Config.Indexer.UpdateAllEpochStatistics {
@@ -276,23 +268,20 @@ func Start(client rpc.Client) error {
 			return keys[i] < keys[j]
 		})
 
-		for _, epoch := range keys {
-			if epochBlacklist[epoch] > 3 {
-				logger.Printf("skipping export of epoch %v as it has errored %d times", epoch, epochBlacklist[epoch])
-				continue
-			}
-
-			logger.Printf("exporting epoch %v", epoch)
+		err = db.EnqueueExportJobs(keys)
+		if err != nil {
+			logger.Errorf("error adding epochs to the export queue: %v", err)
+			continue
+		}
 
-			err = ExportEpoch(epoch, client)
+		err = exportQueuedEpochs(client)
+		if err != nil {
+			logger.Errorf("error exporting queued epochs: %v", err)
+		}
 
-			if err != nil {
-				logger.Errorf("error exporting epoch: %v", err)
-				if utils.EpochToTime(epoch).Before(time.Now().Add(time.Hour * -24)) {
-					epochBlacklist[epoch]++
-				}
-			}
-			logger.Printf("finished export for epoch %v", epoch)
+		err = exportReorg(dbBlocks, nodeBlocks)
+		if err != nil {
+			logger.Errorf("error exporting reorg: %v", err)
 		}
 
 		logger.Infof("marking orphaned blocks of epochs %v-%v", startEpoch, head.HeadEpoch)
@@ -329,32 +318,56 @@ func Start(client rpc.Client) error {
 
 // MarkOrphanedBlocks will mark the orphaned blocks in the database
 func MarkOrphanedBlocks(startEpoch, endEpoch uint64, blocks []*types.MinimalBlock) error {
-	blocksMap := make(map[string]bool)
+	canonical := canonicalBlocks(blocks)
 
+	orphanedBlocks := make([][]byte, 0)
 	for _, block := range blocks {
-		blocksMap[fmt.Sprintf("%x", block.BlockRoot)] = false
+		if !canonical[fmt.Sprintf("%x", block.BlockRoot)] { // Block is not part of the canonical chain
+			logger.Errorf("block %x at slot %v in epoch %v has been orphaned", block.BlockRoot, block.Slot, block.Epoch)
+			orphanedBlocks = append(orphanedBlocks, block.BlockRoot)
+		}
 	}
 
-	orphanedBlocks := make([][]byte, 0)
+	newlyOrphaned, err := db.UpdateCanonicalBlocks(startEpoch, endEpoch, orphanedBlocks)
+	if err != nil {
+		return err
+	}
+
+	events := make([]*types.FeedEvent, 0, len(newlyOrphaned))
+	for _, block := range newlyOrphaned {
+		events = append(events, newFeedEvent(types.FeedTopicOrphanedBlock, []uint64{block.Proposer}, &types.FeedBlock{
+			Epoch:     block.Epoch,
+			Slot:      block.Slot,
+			BlockRoot: fmt.Sprintf("%#x", block.BlockRoot),
+			Proposer:  block.Proposer,
+		}))
+	}
+	publishFeedEvents(events)
+	return nil
+}
+
+// canonicalBlocks follows the parent roots of the passed (ascending by slot) blocks starting at the last block
+// and returns the roots of all blocks that are part of the canonical chain
+func canonicalBlocks(blocks []*types.MinimalBlock) map[string]bool {
+	canonical := make(map[string]bool)
+
 	parentRoot := ""
 	for i := len(blocks) - 1; i >= 0; i-- {
 		blockRoot := fmt.Sprintf("%x", blocks[i].BlockRoot)
 
 		if i == len(blocks)-1 { // First block is always canon
 			parentRoot = fmt.Sprintf("%x", blocks[i].ParentRoot)
-			blocksMap[blockRoot] = true
+			canonical[blockRoot] = true
 			continue
 		}
 		if parentRoot != blockRoot { // Block is not part of the canonical chain
-			logger.Errorf("block %x at slot %v in epoch %v has been orphaned", blocks[i].BlockRoot, blocks[i].Slot, blocks[i].Epoch)
-			orphanedBlocks = append(orphanedBlocks, blocks[i].BlockRoot)
 			continue
 		}
-		blocksMap[blockRoot] = true
+		canonical[blockRoot] = true
 		parentRoot = fmt.Sprintf("%x", blocks[i].ParentRoot)
 	}
 
-	return db.UpdateCanonicalBlocks(startEpoch, endEpoch, orphanedBlocks)
+	return canonical
 }
 
 // GetLastBlocks will get all blocks for a range of epochs
@@ -388,22 +401,64 @@ func GetLastBlocks(startEpoch, endEpoch uint64, client rpc.Client) ([]*types.Min
 
 // ExportEpoch will export an epoch from rpc into the database
 func ExportEpoch(epoch uint64, client rpc.Client) error {
+	data, err := getEpochData(epoch, client)
+	if err != nil {
+		return err
+	}
+
+	return saveEpoch(data)
+}
+
+func getEpochData(epoch uint64, client rpc.Client) (*types.EpochData, error) {
 	start := time.Now()
 
 	logger.Printf("retrieving data for epoch %v", epoch)
 	data, err := client.GetEpochData(epoch)
+	exportEpochDuration.WithLabelValues("fetch").Observe(time.Since(start).Seconds())
 
 	if err != nil {
-		return fmt.Errorf("error retrieving epoch data: %v", err)
+		exportEpochErrors.WithLabelValues("fetch").Inc()
+		return nil, fmt.Errorf("error retrieving epoch data: %v", err)
 	}
 
 	logger.Printf("data for epoch %v retrieved, took %v", epoch, time.Since(start))
 
 	if len(data.Validators) == 0 {
-		return fmt.Errorf("error retrieving epoch data: no validators received for epoch")
+		exportEpochErrors.WithLabelValues("fetch").Inc()
+		return nil, fmt.Errorf("error retrieving epoch data: no validators received for epoch")
+	}
+
+	return data, nil
+}
+
+// saveEpoch commits the epoch data to the database and publishes the new blocks, slashings and epochs of recent
+// epochs on the push feed
+func saveEpoch(data *types.EpochData) error {
+	var known map[string]bool
+	var newEpoch bool
+	publish := isRecentEpoch(data.Epoch)
+	if publish {
+		var err error
+		known, newEpoch, err = getFeedState(data.Epoch)
+		if err != nil {
+			logger.Errorf("error retrieving feed state of epoch %v, skipping its feed events: %v", data.Epoch, err)
+			publish = false
+		}
+	}
+
+	start := time.Now()
+	err := db.SaveEpoch(data)
+	exportEpochDuration.WithLabelValues("save").Observe(time.Since(start).Seconds())
+	if err != nil {
+		exportEpochErrors.WithLabelValues("save").Inc()
+		return err
 	}
+	queueValidatorPerformanceUpdate(data)
 
-	return db.SaveEpoch(data)
+	if publish {
+		publishFeedEvents(epochFeedEvents(data, known, newEpoch))
+	}
+	return nil
 }
 
 func exportValidatorQueue(client rpc.Client) error {
@@ -432,195 +487,6 @@ func updateEpochStatus(client rpc.Client, startEpoch, endEpoch uint64) error {
 	return nil
 }
 
-func performanceDataUpdater() {
-	for true {
-		time.Sleep(time.Hour)
-		logger.Info("updating validator performance data")
-		err := updateValidatorPerformance()
-
-		if err != nil {
-			logger.Errorf("error updating validator performance data: %v", err)
-		} else {
-			logger.Info("validator performance data update completed")
-		}
-	}
-}
-
-func updateValidatorPerformance() error {
-	tx, err := db.DB.Beginx()
-	if err != nil {
-		return fmt.Errorf("error starting db transaction: %w", err)
-	}
-	defer tx.Rollback()
-
-	_, err = tx.Exec("TRUNCATE validator_performance")
-	if err != nil {
-		return fmt.Errorf("error truncating validator performance table: %w", err)
-	}
-
-	var currentEpoch uint64
-
-	err = tx.Get(&currentEpoch, "SELECT MAX(epoch) FROM validator_balances")
-	if err != nil {
-		return fmt.Errorf("error retrieving latest epoch from validator_balances table: %w", err)
-	}
-
-	now := utils.EpochToTime(currentEpoch)
-	epoch1d := utils.TimeToEpoch(now.Add(time.Hour * 24 * -1))
-	epoch7d := utils.TimeToEpoch(now.Add(time.Hour * 24 * 7 * -1))
-	epoch31d := utils.TimeToEpoch(now.Add(time.Hour * 24 * 31 * -1))
-	epoch365d := utils.TimeToEpoch(now.Add(time.Hour * 24 * 356 * -1))
-
-	if epoch1d < 0 {
-		epoch1d = 0
-	}
-	if epoch7d < 0 {
-		epoch7d = 0
-	}
-	if epoch31d < 0 {
-		epoch31d = 0
-	}
-	if epoch365d < 0 {
-		epoch365d = 0
-	}
-
-	var startBalances []struct {
-		Index           uint64
-		Balance         uint64
-		Activationepoch int64
-	}
-	err = tx.Select(&startBalances, `
-		SELECT 
-			validator_balances.validatorindex as index,
-			validator_balances.balance,
-			validators.activationepoch
-		FROM validators
-			LEFT JOIN validator_balances
-				ON validators.activationepoch = validator_balances.epoch
-				AND validators.validatorindex = validator_balances.validatorindex
-		WHERE validator_balances.validatorindex IS NOT NULL`)
-	if err != nil {
-		return fmt.Errorf("error retrieving initial validator balances data: %w", err)
-	}
-
-	startEpochMap := make(map[uint64]int64)
-	startBalanceMap := make(map[uint64]uint64)
-	for _, balance := range startBalances {
-		startEpochMap[balance.Index] = balance.Activationepoch
-		startBalanceMap[balance.Index] = balance.Balance
-	}
-
-	var balances []*types.ValidatorBalance
-	err = tx.Select(&balances, `
-		SELECT
-			validator_balances.epoch,
-			validator_balances.validatorindex,
-			validator_balances.balance
-		FROM validator_balances
-		WHERE validator_balances.epoch IN ($1, $2, $3, $4, $5)`,
-		currentEpoch, epoch1d, epoch7d, epoch31d, epoch365d)
-	if err != nil {
-		return fmt.Errorf("error retrieving validator performance data: %w", err)
-	}
-
-	performance := make(map[uint64]map[int64]int64)
-	for _, balance := range balances {
-		if performance[balance.Index] == nil {
-			performance[balance.Index] = make(map[int64]int64)
-		}
-		performance[balance.Index][int64(balance.Epoch)] = int64(balance.Balance)
-	}
-
-	deposits := []struct {
-		Validatorindex uint64
-		Epoch          int64
-		Amount         int64
-	}{}
-
-	err = tx.Select(&deposits, `
-		SELECT
-			v.validatorindex,
-			(d.block_slot/32) AS epoch,
-			SUM(d.amount) AS amount
-		FROM validators v
-			INNER JOIN blocks_deposits d
-				ON d.publickey = v.pubkey
-				AND (d.block_slot/32) > v.activationepoch
-		GROUP BY (d.block_slot/32), v.validatorindex
-		ORDER BY epoch`)
-	if err != nil {
-		return fmt.Errorf("error retrieving validator deposits data: %w", err)
-	}
-
-	depositsMap := make(map[uint64]map[int64]int64)
-	for _, d := range deposits {
-		if _, exists := depositsMap[d.Validatorindex]; !exists {
-			depositsMap[d.Validatorindex] = make(map[int64]int64)
-		}
-		depositsMap[d.Validatorindex][d.Epoch] = d.Amount
-	}
-
-	for validator, balances := range performance {
-
-		currentBalance := balances[int64(currentEpoch)]
-		startBalance := int64(startBalanceMap[validator])
-
-		if currentBalance == 0 || startBalance == 0 {
-			continue
-		}
-
-		balance1d := balances[epoch1d]
-		if balance1d == 0 || startEpochMap[validator] > epoch1d {
-			balance1d = startBalance
-		}
-		balance7d := balances[epoch7d]
-		if balance7d == 0 || startEpochMap[validator] > epoch7d {
-			balance7d = startBalance
-		}
-		balance31d := balances[epoch31d]
-		if balance31d == 0 || startEpochMap[validator] > epoch31d {
-			balance31d = startBalance
-		}
-		balance365d := balances[epoch365d]
-		if balance365d == 0 || startEpochMap[validator] > epoch365d {
-			balance365d = startBalance
-		}
-
-		performance1d := currentBalance - balance1d
-		performance7d := currentBalance - balance7d
-		performance31d := currentBalance - balance31d
-		performance365d := currentBalance - balance365d
-
-		if depositsMap[validator] != nil {
-			for depositEpoch, depositAmount := range depositsMap[validator] {
-				if depositEpoch > epoch1d {
-					performance1d -= depositAmount
-				}
-				if depositEpoch > epoch7d {
-					performance7d -= depositAmount
-				}
-				if depositEpoch > epoch31d {
-					performance31d -= depositAmount
-				}
-				if depositEpoch > epoch365d {
-					performance365d -= depositAmount
-				}
-			}
-		}
-
-		_, err := tx.Exec(`
-			INSERT INTO validator_performance (validatorindex, balance, performance1d, performance7d, performance31d, performance365d)
-			VALUES ($1, $2, $3, $4, $5, $6)`,
-			validator, currentBalance, performance1d, performance7d, performance31d, performance365d)
-
-		if err != nil {
-			return fmt.Errorf("error saving validator performance data: %w", err)
-		}
-	}
-
-	return tx.Commit()
-}
-
 func networkLivenessUpdater(client rpc.Client) {
 	var prevHeadEpoch uint64
 	err := db.DB.Get(&prevHeadEpoch, "SELECT COALESCE(MAX(headepoch), 0) FROM network_liveness")
@@ -630,15 +496,40 @@ func networkLivenessUpdater(client rpc.Client) {
 
 	epochDuration := time.Second * time.Duration(utils.Config.Chain.SecondsPerSlot*utils.Config.Chain.SlotsPerEpoch)
 	slotDuration := time.Second * time.Duration(utils.Config.Chain.SecondsPerSlot)
+	var prevHead *types.ChainHead
 
 	for {
 		head, err := client.GetChainHead()
 		if err != nil {
 			logger.Errorf("error getting chainhead when exporting networkliveness: %v", err)
+			db.ReportServiceRun(types.ServiceIndexer, err)
 			time.Sleep(slotDuration)
 			continue
 		}
 
+		chainEpoch.WithLabelValues("head").Set(float64(head.HeadEpoch))
+		chainEpoch.WithLabelValues("finalized").Set(float64(head.FinalizedEpoch))
+		chainEpoch.WithLabelValues("justified").Set(float64(head.JustifiedEpoch))
+		chainEpoch.WithLabelValues("previous_justified").Set(float64(head.PreviousJustifiedEpoch))
+
+		if prevHead != nil && (prevHead.FinalizedEpoch != head.FinalizedEpoch || prevHead.JustifiedEpoch != head.JustifiedEpoch) {
+			publishFeedEvents([]*types.FeedEvent{newFeedEvent(types.FeedTopicFinality, nil, &types.FeedFinality{
+				HeadEpoch:              head.HeadEpoch,
+				FinalizedEpoch:         head.FinalizedEpoch,
+				JustifiedEpoch:         head.JustifiedEpoch,
+				PreviousJustifiedEpoch: head.PreviousJustifiedEpoch,
+			})})
+		}
+		prevHead = head
+
+		latestEpoch, err := db.GetLatestEpoch()
+		if err != nil {
+			logger.Errorf("error getting latest epoch when exporting networkliveness: %v", err)
+			db.ReportServiceRun(types.ServiceIndexer, err)
+		} else {
+			db.ReportServiceProgress(types.ServiceIndexer, latestEpoch, head.HeadEpoch)
+		}
+
 		if prevHeadEpoch == head.HeadEpoch {
 			time.Sleep(slotDuration)
 			continue
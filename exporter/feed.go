@@ -0,0 +1,139 @@
+package exporter
+
+import (
+	"encoding/json"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// feedLookback limits the push feed to recent epochs so that a resync does not flood the subscribers
+const feedLookback = time.Hour
+
+func isRecentEpoch(epoch uint64) bool {
+	return time.Since(utils.EpochToTime(epoch)) < feedLookback
+}
+
+func newFeedEvent(topic string, validators []uint64, data interface{}) *types.FeedEvent {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logger.Errorf("error marshalling %v event: %v", topic, err)
+	}
+	return &types.FeedEvent{Topic: topic, Validators: validators, Data: payload}
+}
+
+func publishFeedEvents(events []*types.FeedEvent) {
+	if len(events) == 0 {
+		return
+	}
+	err := db.PublishFeedEvents(events)
+	if err != nil {
+		logger.Errorf("error publishing feed events: %v", err)
+	}
+}
+
+// getFeedState returns the roots of the blocks of the epoch that are already stored and whether the epoch is
+// exported for the first time
+func getFeedState(epoch uint64) (map[string]bool, bool, error) {
+	blocks, err := db.GetBlocks(epoch, epoch)
+	if err != nil {
+		return nil, false, err
+	}
+	known := make(map[string]bool, len(blocks))
+	for _, b := range blocks {
+		known[fmt.Sprintf("%x", b.BlockRoot)] = true
+	}
+	latestEpoch, err := db.GetLatestEpoch()
+	if err != nil {
+		return nil, false, err
+	}
+	return known, epoch > latestEpoch, nil
+}
+
+// epochFeedEvents returns the events of the proposed blocks of the epoch that are not known yet and their slashings,
+// plus an epoch event if the epoch is new
+func epochFeedEvents(data *types.EpochData, known map[string]bool, newEpoch bool) []*types.FeedEvent {
+	events := make([]*types.FeedEvent, 0)
+
+	if newEpoch {
+		var validatorsCount uint64
+		for _, v := range data.Validators {
+			if v.ExitEpoch > data.Epoch && v.ActivationEpoch <= data.Epoch {
+				validatorsCount++
+			}
+		}
+		events = append(events, newFeedEvent(types.FeedTopicEpoch, nil, &types.FeedEpoch{
+			Epoch:           data.Epoch,
+			Ts:              utils.EpochToTime(data.Epoch),
+			ValidatorsCount: validatorsCount,
+		}))
+	}
+
+	slots := make([]uint64, 0, len(data.Blocks))
+	for slot := range data.Blocks {
+		slots = append(slots, slot)
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i] < slots[j]
+	})
+
+	for _, slot := range slots {
+		for _, b := range data.Blocks[slot] {
+			if b.Status != 1 || known[fmt.Sprintf("%x", b.BlockRoot)] {
+				continue
+			}
+			events = append(events, newFeedEvent(types.FeedTopicBlock, []uint64{b.Proposer}, &types.FeedBlock{
+				Epoch:     data.Epoch,
+				Slot:      b.Slot,
+				BlockRoot: fmt.Sprintf("%#x", b.BlockRoot),
+				Proposer:  b.Proposer,
+			}))
+
+			for _, s := range b.ProposerSlashings {
+				events = append(events, newFeedEvent(types.FeedTopicSlashing, []uint64{b.Proposer, s.ProposerIndex}, &types.FeedSlashing{
+					Epoch:             data.Epoch,
+					Slot:              b.Slot,
+					Type:              "proposer",
+					Slasher:           b.Proposer,
+					SlashedValidators: []uint64{s.ProposerIndex},
+				}))
+			}
+			for _, s := range b.AttesterSlashings {
+				slashed := slashedAttesters(s)
+				events = append(events, newFeedEvent(types.FeedTopicSlashing, append([]uint64{b.Proposer}, slashed...), &types.FeedSlashing{
+					Epoch:             data.Epoch,
+					Slot:              b.Slot,
+					Type:              "attester",
+					Slasher:           b.Proposer,
+					SlashedValidators: slashed,
+				}))
+			}
+		}
+	}
+	return events
+}
+
+// slashedAttesters returns the validators that attested to both conflicting attestations of an attester slashing
+func slashedAttesters(s *types.AttesterSlashing) []uint64 {
+	slashed := make([]uint64, 0)
+	if s.Attestation1 == nil || s.Attestation2 == nil {
+		return slashed
+	}
+	attesters := make(map[uint64]bool, len(s.Attestation1.AttestingIndices))
+	for _, i := range s.Attestation1.AttestingIndices {
+		attesters[i] = true
+	}
+	for _, i := range s.Attestation2.AttestingIndices {
+		if attesters[i] {
+			slashed = append(slashed, i)
+			attesters[i] = false
+		}
+	}
+	sort.Slice(slashed, func(i, j int) bool {
+		return slashed[i] < slashed[j]
+	})
+	return slashed
+}
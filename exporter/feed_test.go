@@ -0,0 +1,67 @@
+package exporter
+
+import (
+	"encoding/json"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"testing"
+)
+
+func TestEpochFeedEvents(t *testing.T) {
+	utils.Config = &types.Config{}
+	utils.Config.Chain.SlotsPerEpoch = 4
+	utils.Config.Chain.SecondsPerSlot = 12
+
+	data := &types.EpochData{
+		Epoch: 1,
+		Validators: []*types.Validator{
+			{ActivationEpoch: 0, ExitEpoch: 10},
+			{ActivationEpoch: 0, ExitEpoch: 1},
+			{ActivationEpoch: 2, ExitEpoch: 10},
+		},
+		Blocks: map[uint64]map[string]*types.Block{
+			5: {"b5": {Slot: 5, Status: 1, Proposer: 7, BlockRoot: []byte{0xb5}}},
+			4: {"b4": {Slot: 4, Status: 1, Proposer: 3, BlockRoot: []byte{0xb4},
+				AttesterSlashings: []*types.AttesterSlashing{{
+					Attestation1: &types.IndexedAttestation{AttestingIndices: []uint64{9, 2, 4}},
+					Attestation2: &types.IndexedAttestation{AttestingIndices: []uint64{4, 9, 11}},
+				}},
+			}},
+			6: {"0x0": {Slot: 6, Status: 2, Proposer: 8}},
+		},
+	}
+
+	events := epochFeedEvents(data, map[string]bool{"b5": true}, true)
+
+	topics := []string{types.FeedTopicEpoch, types.FeedTopicBlock, types.FeedTopicSlashing}
+	if len(events) != len(topics) {
+		t.Fatalf("got %v events, want %v", len(events), len(topics))
+	}
+	for i, topic := range topics {
+		if events[i].Topic != topic {
+			t.Errorf("event %v has topic %v, want %v", i, events[i].Topic, topic)
+		}
+	}
+
+	epoch := &types.FeedEpoch{}
+	json.Unmarshal(events[0].Data, epoch)
+	if epoch.Epoch != 1 || epoch.ValidatorsCount != 1 {
+		t.Errorf("epoch event = %+v, want epoch 1 with 1 active validator", epoch)
+	}
+
+	block := &types.FeedBlock{}
+	json.Unmarshal(events[1].Data, block)
+	if block.Slot != 4 || block.BlockRoot != "0xb4" || events[1].Validators[0] != 3 {
+		t.Errorf("block event = %+v for validators %v, want slot 4 proposed by 3", block, events[1].Validators)
+	}
+
+	slashing := &types.FeedSlashing{}
+	json.Unmarshal(events[2].Data, slashing)
+	if slashing.Type != "attester" || len(slashing.SlashedValidators) != 2 || slashing.SlashedValidators[0] != 4 || slashing.SlashedValidators[1] != 9 {
+		t.Errorf("slashing event = %+v, want attesters 4 and 9 slashed", slashing)
+	}
+
+	if events := epochFeedEvents(data, map[string]bool{"b4": true, "b5": true}, false); len(events) != 0 {
+		t.Errorf("got %v events for a known epoch, want none", len(events))
+	}
+}
@@ -0,0 +1,22 @@
+package exporter
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	exportEpochDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "explorer_export_epoch_duration_seconds",
+		Help:    "Duration of the epoch exports by stage (fetch from the node or save to the database).",
+		Buckets: []float64{.5, 1, 2.5, 5, 10, 25, 50, 100, 250},
+	}, []string{"stage"})
+	exportEpochErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "explorer_export_epoch_errors_total",
+		Help: "Number of failed epoch exports by stage.",
+	}, []string{"stage"})
+	chainEpoch = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "explorer_chain_epoch",
+		Help: "Head, finalized and justified epoch of the chain as seen by the indexer.",
+	}, []string{"checkpoint"})
+)
@@ -0,0 +1,179 @@
+package exporter
+
+import (
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"time"
+)
+
+// performanceWindows are the lengths in days of the rolling income windows of the validator_performance table
+var performanceWindows = []uint64{1, 7, 31, 365}
+
+// performanceUpdates holds the latest exported epoch whose validator performance has not been computed yet
+var performanceUpdates = make(chan *types.EpochData, 1)
+
+// queueValidatorPerformanceUpdate schedules the update of the validator performance after an epoch has been
+// exported. A pending update is replaced, so bursts of exported epochs only lead to a single update.
+func queueValidatorPerformanceUpdate(data *types.EpochData) {
+	for {
+		select {
+		case performanceUpdates <- data:
+			return
+		default:
+		}
+		select {
+		case <-performanceUpdates:
+		default:
+		}
+	}
+}
+
+func performanceDataUpdater() {
+	backfillValidatorStatsDaily()
+
+	for data := range performanceUpdates {
+		latest, err := db.GetLatestEpoch()
+		if err != nil {
+			logger.Errorf("error updating validator performance data: %v", err)
+			db.ReportServiceRun(types.ServicePerformance, err)
+			continue
+		}
+		if data.Epoch < latest {
+			// epochs are exported again when their status changes, only the head moves the performance forward
+			continue
+		}
+
+		start := time.Now()
+		err = updateValidatorPerformance(data.Epoch, data.Validators)
+		db.ReportServiceRun(types.ServicePerformance, err)
+		if err != nil {
+			logger.Errorf("error updating validator performance data of epoch %v: %v", data.Epoch, err)
+			continue
+		}
+		logger.Infof("updated validator performance data of epoch %v in %v", data.Epoch, time.Since(start))
+	}
+}
+
+// backfillValidatorStatsDaily adds the days that have been exported before the daily rollup existed
+func backfillValidatorStatsDaily() {
+	firstDay, lastDay, err := db.GetValidatorStatsDailyBackfillRange()
+	if err != nil {
+		logger.Errorf("error retrieving the backfill range of the daily validator statistics: %v", err)
+		return
+	}
+
+	for day := firstDay; day <= lastDay; day++ {
+		start := time.Now()
+		err := db.BackfillValidatorStatsDaily(day)
+		if err != nil {
+			logger.Errorf("error backfilling the daily validator statistics of day %v: %v", day, err)
+			return
+		}
+		logger.Infof("backfilled the daily validator statistics of day %v of %v in %v", day, lastDay, time.Since(start))
+	}
+}
+
+// updateValidatorPerformance computes the income of the validators over the performance windows up to the epoch.
+// The balances at the start of the windows are read from validator_balances, if they are not available anymore the
+// balances at the start of the day are taken from the daily rollup.
+func updateValidatorPerformance(epoch uint64, validators []*types.Validator) error {
+	epochsPerDay := 86400 / (utils.Config.Chain.SecondsPerSlot * utils.Config.Chain.SlotsPerEpoch)
+
+	cutoffs := make([]uint64, len(performanceWindows))
+	for i, days := range performanceWindows {
+		if epoch > days*epochsPerDay {
+			cutoffs[i] = epoch - days*epochsPerDay
+		}
+	}
+
+	balances, err := db.GetBalancesAtEpochs(cutoffs)
+	if err != nil {
+		return fmt.Errorf("error retrieving validator balances: %v", err)
+	}
+	baselines := make(map[uint64]map[uint64]uint64, len(cutoffs))
+	for _, b := range balances {
+		if baselines[b.Epoch] == nil {
+			baselines[b.Epoch] = make(map[uint64]uint64, len(validators))
+		}
+		baselines[b.Epoch][b.Index] = b.Balance
+	}
+	for _, cutoff := range cutoffs {
+		if baselines[cutoff] != nil {
+			continue
+		}
+		balances, err := db.GetDailyStartBalances(utils.EpochToDay(cutoff))
+		if err != nil {
+			return fmt.Errorf("error retrieving daily validator statistics: %v", err)
+		}
+		baselines[cutoff] = make(map[uint64]uint64, len(balances))
+		for _, b := range balances {
+			baselines[cutoff][b.Index] = b.Balance
+		}
+	}
+
+	oldestCutoff := cutoffs[len(cutoffs)-1]
+	activations, err := db.GetActivationBalances(oldestCutoff)
+	if err != nil {
+		return fmt.Errorf("error retrieving activation balances: %v", err)
+	}
+	activationBalances := make(map[uint64]uint64, len(activations))
+	for _, b := range activations {
+		activationBalances[b.Index] = b.Balance
+	}
+
+	deposits, err := db.GetDepositsAfterActivation(oldestCutoff)
+	if err != nil {
+		return fmt.Errorf("error retrieving validator deposits: %v", err)
+	}
+
+	performance := computeValidatorPerformance(epoch, validators, cutoffs, baselines, activationBalances, deposits)
+	return db.SaveValidatorPerformance(performance)
+}
+
+// computeValidatorPerformance returns the income of the activated validators since the cutoff epochs. The income of
+// a window that starts before the activation of a validator is measured from the balance at its activation, deposits
+// after the activation are not counted as income. Validators without a known start balance are omitted.
+func computeValidatorPerformance(epoch uint64, validators []*types.Validator, cutoffs []uint64, baselines map[uint64]map[uint64]uint64, activationBalances map[uint64]uint64, deposits []*types.ValidatorDepositAmount) []*types.ValidatorPerformance {
+	depositsByValidator := make(map[uint64][]*types.ValidatorDepositAmount)
+	for _, d := range deposits {
+		depositsByValidator[d.ValidatorIndex] = append(depositsByValidator[d.ValidatorIndex], d)
+	}
+
+	performance := make([]*types.ValidatorPerformance, 0, len(validators))
+validators:
+	for _, v := range validators {
+		if v.ActivationEpoch > epoch || v.Balance == 0 {
+			continue
+		}
+
+		income := make([]int64, len(cutoffs))
+		for i, cutoff := range cutoffs {
+			baseline := baselines[cutoff][v.Index]
+			if baseline == 0 || v.ActivationEpoch > cutoff {
+				baseline = activationBalances[v.Index]
+			}
+			if baseline == 0 {
+				continue validators
+			}
+
+			income[i] = int64(v.Balance) - int64(baseline)
+			for _, d := range depositsByValidator[v.Index] {
+				if d.Epoch > cutoff {
+					income[i] -= int64(d.Amount)
+				}
+			}
+		}
+
+		performance = append(performance, &types.ValidatorPerformance{
+			Index:           v.Index,
+			Balance:         v.Balance,
+			Performance1d:   income[0],
+			Performance7d:   income[1],
+			Performance31d:  income[2],
+			Performance365d: income[3],
+		})
+	}
+	return performance
+}
@@ -0,0 +1,45 @@
+package exporter
+
+import (
+	"eth2-exporter/types"
+	"testing"
+)
+
+func TestComputeValidatorPerformance(t *testing.T) {
+	epoch := uint64(1000)
+	cutoffs := []uint64{900, 700, 300, 0}
+	validators := []*types.Validator{
+		// active since genesis, deposited 1 ETH at epoch 800
+		{Index: 0, Balance: 33000005000, ActivationEpoch: 0},
+		// activated at epoch 500
+		{Index: 1, Balance: 32000002000, ActivationEpoch: 500},
+		// pending
+		{Index: 2, Balance: 32000000000, ActivationEpoch: 1 << 62},
+		// activated at epoch 500 but the activation balance is unknown
+		{Index: 3, Balance: 32000002000, ActivationEpoch: 500},
+	}
+	baselines := map[uint64]map[uint64]uint64{
+		900: {0: 33000004000, 1: 32000001000, 2: 32000000000, 3: 32000001000},
+		700: {0: 32000003000, 1: 32000000500, 2: 32000000000, 3: 32000000500},
+		300: {0: 32000001000, 2: 32000000000},
+		0:   {0: 32000000000, 2: 32000000000},
+	}
+	activationBalances := map[uint64]uint64{1: 32000000000}
+	deposits := []*types.ValidatorDepositAmount{{Epoch: 800, ValidatorIndex: 0, Amount: 1000000000}}
+
+	performance := computeValidatorPerformance(epoch, validators, cutoffs, baselines, activationBalances, deposits)
+	if len(performance) != 2 {
+		t.Fatalf("expected the performance of 2 validators, got %v", len(performance))
+	}
+
+	expected := []types.ValidatorPerformance{
+		{Index: 0, Balance: 33000005000, Performance1d: 1000, Performance7d: 2000, Performance31d: 4000, Performance365d: 5000},
+		{Index: 1, Balance: 32000002000, Performance1d: 1000, Performance7d: 1500, Performance31d: 2000, Performance365d: 2000},
+	}
+	for i, p := range performance {
+		e := expected[i]
+		if p.Index != e.Index || p.Balance != e.Balance || p.Performance1d != e.Performance1d || p.Performance7d != e.Performance7d || p.Performance31d != e.Performance31d || p.Performance365d != e.Performance365d {
+			t.Errorf("unexpected performance of validator %v: got %+v, want %+v", i, *p, e)
+		}
+	}
+}
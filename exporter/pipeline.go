@@ -0,0 +1,117 @@
+package exporter
+
+import (
+	"database/sql"
+	"eth2-exporter/db"
+	"eth2-exporter/rpc"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"time"
+)
+
+type epochDataResult struct {
+	data *types.EpochData
+	err  error
+}
+
+// exportEpochs exports the passed (sorted) epochs. The epoch data is retrieved by a bounded pool of workers
+// while the epochs are committed to the database strictly in order. If progressName is set the last committed
+// epoch is stored in the database so that an interrupted run can be resumed. The returned map contains the
+// epochs that could not be exported.
+func exportEpochs(epochs []uint64, client rpc.Client, progressName string) map[uint64]error {
+	failed := make(map[uint64]error)
+	if len(epochs) == 0 {
+		return failed
+	}
+
+	concurrency := utils.Config.Indexer.ExportConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// Every pending result holds a token, this limits the amount of fetched but not yet committed epochs
+	tokens := make(chan struct{}, concurrency)
+	results := make([]chan *epochDataResult, len(epochs))
+	for i := range results {
+		results[i] = make(chan *epochDataResult, 1)
+	}
+
+	go func() {
+		for i, epoch := range epochs {
+			tokens <- struct{}{}
+			go func(i int, epoch uint64) {
+				data, err := getEpochData(epoch, client)
+				results[i] <- &epochDataResult{data: data, err: err}
+			}(i, epoch)
+		}
+	}()
+
+	progress := &types.ExportProgress{Name: progressName, StartEpoch: epochs[0], EndEpoch: epochs[len(epochs)-1]}
+	inOrder := true
+	start := time.Now()
+	lastLog := start
+
+	for i, epoch := range epochs {
+		res := <-results[i]
+		<-tokens
+
+		err := res.err
+		if err == nil {
+			err = saveEpoch(res.data)
+		}
+		if err != nil {
+			logger.Errorf("error exporting epoch %v: %v", epoch, err)
+			failed[epoch] = err
+			inOrder = false
+		} else if progressName != "" && inOrder {
+			progress.LastCommittedEpoch = sql.NullInt64{Int64: int64(epoch), Valid: true}
+			err = db.SaveExportProgress(progress)
+			if err != nil {
+				logger.Errorf("error saving progress of export %v: %v", progressName, err)
+			}
+		}
+
+		done := i + 1
+		if time.Since(lastLog) > time.Second*30 || done == len(epochs) {
+			lastLog = time.Now()
+			rate := float64(done) / time.Since(start).Seconds()
+			eta := time.Duration(float64(len(epochs)-done)/rate) * time.Second
+			logger.Infof("exported %v of %v epochs (%.2f%%), %.2f epochs/s, %v failed, eta %v", done, len(epochs), float64(done)*100/float64(len(epochs)), rate, len(failed), eta)
+		}
+	}
+
+	if progressName != "" && len(failed) == 0 {
+		err := db.DeleteExportProgress(progressName)
+		if err != nil {
+			logger.Errorf("error deleting progress of export %v: %v", progressName, err)
+		}
+	}
+
+	return failed
+}
+
+// exportEpochRange exports all epochs between startEpoch and endEpoch. If an unfinished run with the same
+// progressName is found in the database the export resumes after the last committed epoch of that run.
+func exportEpochRange(startEpoch, endEpoch uint64, client rpc.Client, progressName string) (map[uint64]error, error) {
+	progress, err := db.GetExportProgress(progressName)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving progress of export %v: %v", progressName, err)
+	}
+
+	if progress != nil && progress.StartEpoch == startEpoch && progress.LastCommittedEpoch.Valid {
+		logger.Infof("resuming export %v after epoch %v", progressName, progress.LastCommittedEpoch.Int64)
+		startEpoch = uint64(progress.LastCommittedEpoch.Int64) + 1
+	}
+
+	if startEpoch > endEpoch {
+		return make(map[uint64]error), db.DeleteExportProgress(progressName)
+	}
+
+	epochs := make([]uint64, 0, endEpoch-startEpoch+1)
+	for epoch := startEpoch; epoch <= endEpoch; epoch++ {
+		epochs = append(epochs, epoch)
+	}
+
+	return exportEpochs(epochs, client, progressName), nil
+}
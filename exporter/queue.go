@@ -0,0 +1,107 @@
+package exporter
+
+import (
+	"eth2-exporter/db"
+	"eth2-exporter/rpc"
+	"eth2-exporter/utils"
+	"fmt"
+	"time"
+)
+
+// exportQueuedEpochs exports all epochs of the export queue that are due. Successfully exported epochs are marked as done,
+// failed epochs are retried with an exponential backoff and dead-lettered once they have exceeded the maximum amount of attempts.
+func exportQueuedEpochs(client rpc.Client) error {
+	jobs, err := db.GetDueExportJobs()
+	if err != nil {
+		return fmt.Errorf("error retrieving due export jobs: %v", err)
+	}
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	epochs := make([]uint64, 0, len(jobs))
+	for _, job := range jobs {
+		epochs = append(epochs, job.Epoch)
+	}
+
+	err = db.SetExportJobsStatus(epochs, "inprogress")
+	if err != nil {
+		return fmt.Errorf("error marking export jobs as in progress: %v", err)
+	}
+
+	logger.Printf("exporting %v queued epochs", len(epochs))
+	failed := exportEpochs(epochs, client, "")
+
+	done := make([]uint64, 0, len(epochs))
+	for _, job := range jobs {
+		exportErr, found := failed[job.Epoch]
+		if !found {
+			done = append(done, job.Epoch)
+			continue
+		}
+
+		attempts := job.Attempts + 1
+		status := "pending"
+		// Recent epochs may fail because the node has not yet processed them, only older epochs are dead-lettered
+		if attempts >= exportQueueMaxAttempts() && utils.EpochToTime(job.Epoch).Before(time.Now().Add(time.Hour*-24)) {
+			logger.Errorf("giving up on export of epoch %v after %v attempts: %v", job.Epoch, attempts, exportErr)
+			status = "failed"
+		}
+
+		err = db.SetExportJobFailed(job.Epoch, status, exportErr.Error(), time.Now().Add(exportRetryBackoff(attempts)))
+		if err != nil {
+			logger.Errorf("error updating export job of epoch %v: %v", job.Epoch, err)
+		}
+	}
+
+	err = db.SetExportJobsStatus(done, "done")
+	if err != nil {
+		return fmt.Errorf("error marking export jobs as done: %v", err)
+	}
+
+	return nil
+}
+
+// queueFailedEpochs adds the epochs that failed during an export outside of the queue to the export queue
+func queueFailedEpochs(failed map[uint64]error) {
+	if len(failed) == 0 {
+		return
+	}
+
+	epochs := make([]uint64, 0, len(failed))
+	for epoch := range failed {
+		epochs = append(epochs, epoch)
+	}
+
+	err := db.EnqueueExportJobs(epochs)
+	if err != nil {
+		logger.Errorf("error queuing %v failed epochs for export: %v", len(epochs), err)
+	}
+}
+
+func exportQueueMaxAttempts() int {
+	if utils.Config.Indexer.ExportQueue.MaxAttempts < 1 {
+		return 5
+	}
+	return utils.Config.Indexer.ExportQueue.MaxAttempts
+}
+
+// exportRetryBackoff returns the delay before an epoch that has failed the given number of attempts is retried
+func exportRetryBackoff(attempts int) time.Duration {
+	backoff := time.Second * time.Duration(utils.Config.Indexer.ExportQueue.RetryBackoff)
+	if backoff <= 0 {
+		backoff = time.Second * 10
+	}
+	maxBackoff := time.Second * time.Duration(utils.Config.Indexer.ExportQueue.MaxRetryBackoff)
+	if maxBackoff <= 0 {
+		maxBackoff = time.Hour
+	}
+
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,27 @@
+package exporter
+
+import (
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"testing"
+	"time"
+)
+
+func TestExportRetryBackoff(t *testing.T) {
+	utils.Config = &types.Config{}
+	utils.Config.Indexer.ExportQueue.RetryBackoff = 10
+	utils.Config.Indexer.ExportQueue.MaxRetryBackoff = 60
+
+	expected := map[int]time.Duration{
+		1:  time.Second * 10,
+		2:  time.Second * 20,
+		3:  time.Second * 40,
+		4:  time.Second * 60,
+		50: time.Second * 60,
+	}
+	for attempts, backoff := range expected {
+		if got := exportRetryBackoff(attempts); got != backoff {
+			t.Errorf("expected a backoff of %v after %v attempts, got %v", backoff, attempts, got)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package exporter
+
+import (
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// exportReorg detects a reorg by comparing the canonical blocks stored in the database with the canonical chain
+// of the node and saves it to the database
+func exportReorg(dbBlocks, nodeBlocks []*types.MinimalBlock) error {
+	reorg := detectReorg(dbBlocks, nodeBlocks)
+	if reorg == nil {
+		return nil
+	}
+
+	logger.Warnf("detected reorg of depth %v at epoch %v: old head %x at slot %v, new head %x at slot %v", reorg.Depth, reorg.Epoch, reorg.OldHeadRoot, reorg.OldHeadSlot, reorg.NewHeadRoot, reorg.NewHeadSlot)
+	return db.SaveReorg(reorg)
+}
+
+// detectReorg returns the reorg that replaced canonical blocks of the database with a different chain on the node,
+// nil is returned if all canonical blocks of the database are still part of the canonical chain of the node.
+// The node blocks have to be sorted ascending by slot.
+func detectReorg(dbBlocks, nodeBlocks []*types.MinimalBlock) *types.Reorg {
+	if len(dbBlocks) == 0 || len(nodeBlocks) == 0 {
+		return nil
+	}
+
+	canonical := canonicalBlocks(nodeBlocks)
+	newHead := nodeBlocks[len(nodeBlocks)-1]
+
+	var oldHead *types.MinimalBlock
+	dbCanonical := make(map[string]bool)
+	orphaned := make([]*types.MinimalBlock, 0)
+	for _, block := range dbBlocks {
+		if block.Status != "1" {
+			continue
+		}
+		if oldHead == nil || block.Slot > oldHead.Slot {
+			oldHead = block
+		}
+		// The node may lag behind the database, blocks after its head can not be checked yet
+		if block.Slot > newHead.Slot {
+			continue
+		}
+		blockRoot := fmt.Sprintf("%x", block.BlockRoot)
+		dbCanonical[blockRoot] = true
+		if !canonical[blockRoot] {
+			orphaned = append(orphaned, block)
+		}
+	}
+
+	if len(orphaned) == 0 {
+		return nil
+	}
+
+	sort.Slice(orphaned, func(i, j int) bool {
+		return orphaned[i].Slot < orphaned[j].Slot
+	})
+	forkSlot := orphaned[0].Slot
+
+	affectedSlots := make(map[uint64]bool)
+	for _, block := range orphaned {
+		affectedSlots[block.Slot] = true
+	}
+	for _, block := range nodeBlocks {
+		blockRoot := fmt.Sprintf("%x", block.BlockRoot)
+		if block.Slot >= forkSlot && canonical[blockRoot] && !dbCanonical[blockRoot] {
+			affectedSlots[block.Slot] = true
+		}
+	}
+
+	reorg := &types.Reorg{
+		Ts:             time.Now(),
+		Epoch:          forkSlot / utils.Config.Chain.SlotsPerEpoch,
+		Depth:          uint64(len(orphaned)),
+		OldHeadSlot:    oldHead.Slot,
+		OldHeadRoot:    oldHead.BlockRoot,
+		NewHeadSlot:    newHead.Slot,
+		NewHeadRoot:    newHead.BlockRoot,
+		AffectedSlots:  make([]int64, 0, len(affectedSlots)),
+		OrphanedBlocks: make([][]byte, 0, len(orphaned)),
+	}
+	for slot := range affectedSlots {
+		reorg.AffectedSlots = append(reorg.AffectedSlots, int64(slot))
+	}
+	sort.Slice(reorg.AffectedSlots, func(i, j int) bool {
+		return reorg.AffectedSlots[i] < reorg.AffectedSlots[j]
+	})
+	for _, block := range orphaned {
+		reorg.OrphanedBlocks = append(reorg.OrphanedBlocks, block.BlockRoot)
+	}
+
+	return reorg
+}
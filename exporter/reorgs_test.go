@@ -0,0 +1,47 @@
+package exporter
+
+import (
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"testing"
+)
+
+func testBlock(slot uint64, root, parentRoot byte) *types.MinimalBlock {
+	return &types.MinimalBlock{Epoch: slot / 4, Slot: slot, BlockRoot: []byte{root}, ParentRoot: []byte{parentRoot}, Status: "1"}
+}
+
+func TestDetectReorg(t *testing.T) {
+	utils.Config = &types.Config{}
+	utils.Config.Chain.SlotsPerEpoch = 4
+
+	dbBlocks := []*types.MinimalBlock{testBlock(3, 0xa3, 0xa2), testBlock(2, 0xa2, 0xa1), testBlock(1, 0xa1, 0xa0)}
+
+	nodeBlocks := []*types.MinimalBlock{testBlock(1, 0xa1, 0xa0), testBlock(2, 0xa2, 0xa1), testBlock(3, 0xa3, 0xa2), testBlock(4, 0xb4, 0xa2), testBlock(5, 0xb5, 0xb4)}
+	reorg := detectReorg(dbBlocks, nodeBlocks)
+	if reorg == nil {
+		t.Fatalf("expected a reorg to be detected")
+	}
+	if reorg.Depth != 1 || reorg.Epoch != 0 {
+		t.Errorf("expected a reorg of depth 1 at epoch 0, got depth %v at epoch %v", reorg.Depth, reorg.Epoch)
+	}
+	if reorg.OldHeadSlot != 3 || reorg.OldHeadRoot[0] != 0xa3 || reorg.NewHeadSlot != 5 || reorg.NewHeadRoot[0] != 0xb5 {
+		t.Errorf("unexpected heads: old %v %x, new %v %x", reorg.OldHeadSlot, reorg.OldHeadRoot, reorg.NewHeadSlot, reorg.NewHeadRoot)
+	}
+	if len(reorg.AffectedSlots) != 3 || reorg.AffectedSlots[0] != 3 || reorg.AffectedSlots[1] != 4 || reorg.AffectedSlots[2] != 5 {
+		t.Errorf("unexpected affected slots %v", reorg.AffectedSlots)
+	}
+	if len(reorg.OrphanedBlocks) != 1 || reorg.OrphanedBlocks[0][0] != 0xa3 {
+		t.Errorf("unexpected orphaned blocks %x", reorg.OrphanedBlocks)
+	}
+
+	nodeBlocks = []*types.MinimalBlock{testBlock(1, 0xa1, 0xa0), testBlock(2, 0xa2, 0xa1), testBlock(3, 0xa3, 0xa2), testBlock(4, 0xa4, 0xa3)}
+	if reorg := detectReorg(dbBlocks, nodeBlocks); reorg != nil {
+		t.Errorf("expected no reorg if the chain has only been extended, got %+v", reorg)
+	}
+
+	// a lagging node must not report the blocks after its head as reorged
+	nodeBlocks = []*types.MinimalBlock{testBlock(1, 0xa1, 0xa0), testBlock(2, 0xa2, 0xa1)}
+	if reorg := detectReorg(dbBlocks, nodeBlocks); reorg != nil {
+		t.Errorf("expected no reorg for a lagging node, got %+v", reorg)
+	}
+}
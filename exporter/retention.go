@@ -0,0 +1,66 @@
+package exporter
+
+import (
+	"eth2-exporter/db"
+	"eth2-exporter/utils"
+	"fmt"
+	"time"
+)
+
+// retentionUpdater applies the retention policy of the indexer to the partitions of validator_balances and
+// attestation_assignments
+func retentionUpdater() {
+	for {
+		start := time.Now()
+		err := applyRetention()
+		if err != nil {
+			logger.Errorf("error applying retention policy: %v", err)
+		} else {
+			logger.Infof("applied retention policy in %v", time.Since(start))
+		}
+		time.Sleep(time.Hour)
+	}
+}
+
+// applyRetention downsamples the balances and removes the attestation assignments of the partitions that end before
+// the retention period. Partitions that are still needed by the rewards, effectiveness or rollup exporters are kept.
+func applyRetention() error {
+	retention := utils.Config.Indexer.Retention
+	if retention.BalancesDays == 0 && retention.AttestationAssignmentsDays == 0 {
+		return nil
+	}
+
+	horizon, err := db.GetRetentionHorizon()
+	if err != nil {
+		return err
+	}
+
+	if retention.BalancesDays > 0 {
+		before := db.RetentionCutoff(retention.BalancesDays)
+		if horizon < before {
+			before = horizon
+		}
+		count, err := db.DownsampleValidatorBalances(before)
+		if err != nil {
+			return fmt.Errorf("error downsampling validator balances: %v", err)
+		}
+		if count > 0 {
+			logger.Infof("downsampled %v partitions of validator balances before epoch %v", count, before)
+		}
+	}
+
+	if retention.AttestationAssignmentsDays > 0 {
+		before := db.RetentionCutoff(retention.AttestationAssignmentsDays)
+		if horizon < before {
+			before = horizon
+		}
+		count, err := db.RemoveAttestationAssignments(before, retention.ArchiveAttestationAssignments)
+		if err != nil {
+			return fmt.Errorf("error removing attestation assignments: %v", err)
+		}
+		if count > 0 {
+			logger.Infof("removed %v partitions of attestation assignments before epoch %v", count, before)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,333 @@
+package exporter
+
+import (
+	"bytes"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Phase 0 constants of the beacon chain spec that determine the rewards and penalties
+const (
+	baseRewardFactor               = 64
+	baseRewardsPerEpoch            = 4
+	proposerRewardQuotient         = 8
+	inactivityPenaltyQuotient      = 1 << 26
+	minEpochsToInactivityPenalty   = 4
+	minSlashingPenaltyQuotient     = 128
+	whistleblowerRewardQuotient    = 512
+	proportionalSlashingMultiplier = 1
+	epochsPerSlashingsVector       = 8192
+	effectiveBalanceIncrement      = 1000000000
+)
+
+// epochRewardsData holds the data that is needed to compute the rewards of the validators for an epoch
+type epochRewardsData struct {
+	Epoch         uint64
+	SlotsPerEpoch uint64
+	Validators    []*types.RewardsValidator   // effective balances at the epoch
+	Attestations  []*types.RewardsAttestation // attestations for the slots of the epoch included in canonical blocks
+	Blocks        []*types.MinimalBlock       // canonical blocks of the epoch and the last canonical block before it
+	Slashings     []*types.RewardsSlashing    // slashings of the epochsPerSlashingsVector epochs up to the next epoch
+	FinalityDelay uint64                      // epochs between the epoch and the finalized epoch when its rewards were processed
+}
+
+func rewardsExporter() {
+	epochDuration := time.Second * time.Duration(utils.Config.Chain.SecondsPerSlot*utils.Config.Chain.SlotsPerEpoch)
+	for {
+		err := exportRewards()
+		if err != nil {
+			logger.Errorf("error exporting validator rewards: %v", err)
+		}
+		time.Sleep(epochDuration)
+	}
+}
+
+// exportRewards computes the rewards of all epochs whose attestations can not be included anymore in a block that
+// might still be orphaned, that is all epochs before the last finalized epoch
+func exportRewards() error {
+	next, finalized, err := db.GetRewardsExportStatus()
+	if err != nil {
+		return fmt.Errorf("error retrieving rewards export status: %v", err)
+	}
+
+	for epoch := next; epoch < finalized; epoch++ {
+		start := time.Now()
+		data, err := getEpochRewardsData(epoch)
+		if err != nil {
+			return err
+		}
+		if len(data.Validators) == 0 {
+			// the next run resumes after the latest exported epoch, the epoch is retried once it has been exported
+			return fmt.Errorf("error exporting rewards of epoch %v: no validator balances available", epoch)
+		}
+
+		rewards := computeEpochRewards(data)
+		err = db.SaveValidatorRewards(rewards)
+		if err != nil {
+			return fmt.Errorf("error saving validator rewards of epoch %v: %v", epoch, err)
+		}
+		logger.Infof("exported rewards of %v validators for epoch %v in %v", len(rewards), epoch, time.Since(start))
+	}
+	return nil
+}
+
+func getEpochRewardsData(epoch uint64) (*epochRewardsData, error) {
+	slotsPerEpoch := utils.Config.Chain.SlotsPerEpoch
+	data := &epochRewardsData{Epoch: epoch, SlotsPerEpoch: slotsPerEpoch}
+	startSlot := epoch * slotsPerEpoch
+	endSlot := startSlot + slotsPerEpoch - 1
+
+	var err error
+	data.Validators, err = db.GetRewardsValidators(epoch)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving validators of epoch %v: %v", epoch, err)
+	}
+
+	data.Attestations, err = db.GetRewardsAttestations(startSlot, endSlot)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving attestations of epoch %v: %v", epoch, err)
+	}
+
+	data.Blocks, err = db.GetCanonicalBlocks(startSlot, endSlot)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving canonical blocks of epoch %v: %v", epoch, err)
+	}
+
+	slashingsStart := uint64(0)
+	if epoch+1 >= epochsPerSlashingsVector {
+		slashingsStart = epoch + 2 - epochsPerSlashingsVector
+	}
+	data.Slashings, err = db.GetRewardsSlashings(slashingsStart, epoch+1)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving slashings of epoch %v: %v", epoch, err)
+	}
+
+	data.FinalityDelay, err = db.GetFinalityDelay(epoch)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving finality delay of epoch %v: %v", epoch, err)
+	}
+
+	return data, nil
+}
+
+// computeEpochRewards computes the rewards and penalties of the validators for an epoch following the phase 0 spec
+// (process_rewards_and_penalties and process_slashings). The total active balance is taken from the effective
+// balances at the epoch instead of the following epoch, so the results can differ by a few Gwei from the balances.
+func computeEpochRewards(data *epochRewardsData) []*types.ValidatorRewards {
+	rewards := make(map[uint64]*types.ValidatorRewards)
+	get := func(index uint64) *types.ValidatorRewards {
+		r := rewards[index]
+		if r == nil {
+			r = &types.ValidatorRewards{Epoch: data.Epoch, ValidatorIndex: index}
+			rewards[index] = r
+		}
+		return r
+	}
+
+	validators := make(map[uint64]*types.RewardsValidator, len(data.Validators))
+	totalBalance := uint64(0)
+	for _, v := range data.Validators {
+		validators[v.Index] = v
+		if v.ActivationEpoch <= data.Epoch && data.Epoch < v.ExitEpoch {
+			totalBalance += v.EffectiveBalance
+		}
+	}
+	if totalBalance < effectiveBalanceIncrement {
+		totalBalance = effectiveBalanceIncrement
+	}
+	sqrtTotalBalance := integerSquareRoot(totalBalance)
+	baseReward := func(v *types.RewardsValidator) uint64 {
+		return v.EffectiveBalance * baseRewardFactor / sqrtTotalBalance / baseRewardsPerEpoch
+	}
+
+	// validators that are slashed when the rewards of the epoch are processed do not receive attestation rewards
+	slashedEpoch := make(map[uint64]uint64)
+	for _, s := range data.Slashings {
+		if e, found := slashedEpoch[s.ValidatorIndex]; !found || s.Epoch < e {
+			slashedEpoch[s.ValidatorIndex] = s.Epoch
+		}
+	}
+	isSlashed := func(index uint64) bool {
+		e, found := slashedEpoch[index]
+		return found && e <= data.Epoch+1
+	}
+
+	targetRoot := canonicalBlockRoot(data.Blocks, data.Epoch*data.SlotsPerEpoch)
+
+	type inclusion struct {
+		delay    uint64
+		proposer uint64
+	}
+	inclusions := make(map[uint64]*inclusion)
+	targetAttesters := make(map[uint64]bool)
+	headAttesters := make(map[uint64]bool)
+	lastInclusionSlot := (data.Epoch+2)*data.SlotsPerEpoch - 1
+	for _, a := range data.Attestations {
+		if a.BlockSlot <= a.Slot || a.BlockSlot > lastInclusionSlot {
+			continue
+		}
+		delay := a.BlockSlot - a.Slot
+		matchingTarget := targetRoot != nil && bytes.Equal(a.TargetRoot, targetRoot)
+		matchingHead := matchingTarget && bytes.Equal(a.BeaconBlockRoot, canonicalBlockRoot(data.Blocks, a.Slot))
+		for _, i := range a.Validators {
+			index := uint64(i)
+			if isSlashed(index) {
+				continue
+			}
+			if inc := inclusions[index]; inc == nil || delay < inc.delay {
+				inclusions[index] = &inclusion{delay: delay, proposer: a.Proposer}
+			}
+			if matchingTarget {
+				targetAttesters[index] = true
+			}
+			if matchingHead {
+				headAttesters[index] = true
+			}
+		}
+	}
+
+	attestingBalance := func(attesters map[uint64]bool) uint64 {
+		balance := uint64(0)
+		for index := range attesters {
+			if v := validators[index]; v != nil {
+				balance += v.EffectiveBalance
+			}
+		}
+		if balance < effectiveBalanceIncrement {
+			balance = effectiveBalanceIncrement
+		}
+		return balance
+	}
+	sourceAttesters := make(map[uint64]bool, len(inclusions))
+	for index := range inclusions {
+		sourceAttesters[index] = true
+	}
+	sourceBalance := attestingBalance(sourceAttesters)
+	targetBalance := attestingBalance(targetAttesters)
+	headBalance := attestingBalance(headAttesters)
+
+	inactivityLeak := data.FinalityDelay > minEpochsToInactivityPenalty
+	componentReward := func(base, balance uint64) int64 {
+		if inactivityLeak {
+			// attesters receive the full reward during a leak to compensate the inactivity penalty
+			return int64(base)
+		}
+		return int64(base * (balance / effectiveBalanceIncrement) / (totalBalance / effectiveBalanceIncrement))
+	}
+
+	for _, v := range data.Validators {
+		eligible := (v.ActivationEpoch <= data.Epoch && data.Epoch < v.ExitEpoch) || (isSlashed(v.Index) && data.Epoch+1 < v.WithdrawableEpoch)
+		if !eligible {
+			continue
+		}
+		base := baseReward(v)
+		r := get(v.Index)
+
+		if sourceAttesters[v.Index] {
+			r.SourceReward = componentReward(base, sourceBalance)
+		} else {
+			r.SourceReward = -int64(base)
+		}
+		if targetAttesters[v.Index] {
+			r.TargetReward = componentReward(base, targetBalance)
+		} else {
+			r.TargetReward = -int64(base)
+		}
+		if headAttesters[v.Index] {
+			r.HeadReward = componentReward(base, headBalance)
+		} else {
+			r.HeadReward = -int64(base)
+		}
+
+		if inc := inclusions[v.Index]; inc != nil {
+			proposerReward := base / proposerRewardQuotient
+			get(inc.proposer).ProposerReward += int64(proposerReward)
+			r.InclusionDelayReward = int64((base - proposerReward) / inc.delay)
+		}
+
+		if inactivityLeak {
+			r.InactivityPenalty = int64(baseRewardsPerEpoch*base - base/proposerRewardQuotient)
+			if !targetAttesters[v.Index] {
+				r.InactivityPenalty += int64(v.EffectiveBalance * data.FinalityDelay / inactivityPenaltyQuotient)
+			}
+		}
+	}
+
+	// slashings: the initial penalty and whistleblower reward when the slashing is included and the penalty
+	// proportional to the slashed balance of the surrounding epochs halfway to the withdrawable epoch. The
+	// proportional penalty is applied in the same epoch transition as the rewards of the epoch, so the current epoch
+	// of process_slashings is the following epoch.
+	currentEpoch := data.Epoch + 1
+	slashedBalance := uint64(0)
+	for index, e := range slashedEpoch {
+		if e <= currentEpoch && e+epochsPerSlashingsVector > currentEpoch {
+			if v := validators[index]; v != nil {
+				slashedBalance += v.EffectiveBalance
+			}
+		}
+	}
+	for index, e := range slashedEpoch {
+		v := validators[index]
+		if v == nil {
+			continue
+		}
+		if e == data.Epoch {
+			get(index).SlashingPenalty += int64(v.EffectiveBalance / minSlashingPenaltyQuotient)
+		}
+		if e <= currentEpoch && currentEpoch+epochsPerSlashingsVector/2 == v.WithdrawableEpoch {
+			adjusted := slashedBalance * proportionalSlashingMultiplier
+			if adjusted > totalBalance {
+				adjusted = totalBalance
+			}
+			increments := v.EffectiveBalance / effectiveBalanceIncrement
+			get(index).SlashingPenalty += int64(increments * adjusted / totalBalance * effectiveBalanceIncrement)
+		}
+	}
+	for _, s := range data.Slashings {
+		if s.Epoch != data.Epoch || slashedEpoch[s.ValidatorIndex] != s.Epoch {
+			continue
+		}
+		if v := validators[s.ValidatorIndex]; v != nil {
+			get(s.Proposer).ProposerReward += int64(v.EffectiveBalance / whistleblowerRewardQuotient)
+		}
+	}
+
+	result := make([]*types.ValidatorRewards, 0, len(rewards))
+	for _, r := range rewards {
+		result = append(result, r)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ValidatorIndex < result[j].ValidatorIndex
+	})
+	return result
+}
+
+// canonicalBlockRoot returns the root of the canonical block at the slot, empty slots have the root of the previous
+// block. The blocks must be sorted by slot.
+func canonicalBlockRoot(blocks []*types.MinimalBlock, slot uint64) []byte {
+	var root []byte
+	for _, b := range blocks {
+		if b.Slot > slot {
+			break
+		}
+		root = b.BlockRoot
+	}
+	return root
+}
+
+// integerSquareRoot returns the largest integer x with x*x <= n
+func integerSquareRoot(n uint64) uint64 {
+	x := uint64(math.Sqrt(float64(n)))
+	for x*x > n {
+		x--
+	}
+	for (x+1)*(x+1) <= n {
+		x++
+	}
+	return x
+}
@@ -0,0 +1,81 @@
+package exporter
+
+import (
+	"eth2-exporter/types"
+	"testing"
+)
+
+func TestComputeEpochRewards(t *testing.T) {
+	validators := make([]*types.RewardsValidator, 4)
+	for i := range validators {
+		validators[i] = &types.RewardsValidator{Index: uint64(i), EffectiveBalance: 32000000000, ExitEpoch: 1 << 62, WithdrawableEpoch: 1 << 62}
+	}
+	validators[3].WithdrawableEpoch = 1 + epochsPerSlashingsVector
+
+	data := &epochRewardsData{
+		Epoch:         1,
+		SlotsPerEpoch: 4,
+		Validators:    validators,
+		Blocks:        []*types.MinimalBlock{testBlock(3, 0xa3, 0xa2), testBlock(4, 0xa4, 0xa3), testBlock(6, 0xa6, 0xa4)},
+		Attestations: []*types.RewardsAttestation{
+			// validator 0 votes for the correct target and head and is included in the next slot
+			{BlockSlot: 6, Proposer: 2, Slot: 5, BeaconBlockRoot: []byte{0xa4}, TargetRoot: []byte{0xa4}, Validators: []int64{0}},
+			// validator 1 votes for a wrong head and is included twice, the earliest inclusion counts
+			{BlockSlot: 9, Proposer: 1, Slot: 6, BeaconBlockRoot: []byte{0xa4}, TargetRoot: []byte{0xa4}, Validators: []int64{1}},
+			{BlockSlot: 8, Proposer: 0, Slot: 6, BeaconBlockRoot: []byte{0xa4}, TargetRoot: []byte{0xa4}, Validators: []int64{1, 3}},
+			// included too late
+			{BlockSlot: 12, Proposer: 0, Slot: 7, BeaconBlockRoot: []byte{0xa6}, TargetRoot: []byte{0xa4}, Validators: []int64{2}},
+		},
+		// validator 3 is slashed by validator 0 in epoch 1
+		Slashings: []*types.RewardsSlashing{{Epoch: 1, Proposer: 0, ValidatorIndex: 3}},
+	}
+
+	rewards := computeEpochRewards(data)
+	if len(rewards) != 4 {
+		t.Fatalf("expected rewards of 4 validators, got %v", len(rewards))
+	}
+
+	// total balance 128 ETH, base reward 32e9 * 64 / isqrt(128e9) / 4
+	base := int64(1431087)
+	expected := []types.ValidatorRewards{
+		{Epoch: 1, ValidatorIndex: 0, SourceReward: base * 64 / 128, TargetReward: base * 64 / 128, HeadReward: base * 32 / 128, InclusionDelayReward: base - base/8, ProposerReward: base/8 + 32000000000/whistleblowerRewardQuotient},
+		{Epoch: 1, ValidatorIndex: 1, SourceReward: base * 64 / 128, TargetReward: base * 64 / 128, HeadReward: -base, InclusionDelayReward: (base - base/8) / 2},
+		{Epoch: 1, ValidatorIndex: 2, SourceReward: -base, TargetReward: -base, HeadReward: -base, ProposerReward: base / 8},
+		{Epoch: 1, ValidatorIndex: 3, SourceReward: -base, TargetReward: -base, HeadReward: -base, SlashingPenalty: 32000000000 / minSlashingPenaltyQuotient},
+	}
+	for i, r := range rewards {
+		if *r != expected[i] {
+			t.Errorf("unexpected rewards of validator %v: got %+v, want %+v", i, *r, expected[i])
+		}
+	}
+
+	// during an inactivity leak attesters receive the full base reward and all validators pay the inactivity penalty
+	data.FinalityDelay = 10
+	data.Slashings = nil
+	rewards = computeEpochRewards(data)
+	if rewards[0].SourceReward != base || rewards[0].InactivityPenalty != 4*base-base/8 {
+		t.Errorf("unexpected rewards of validator 0 during a leak: %+v", *rewards[0])
+	}
+	if rewards[2].InactivityPenalty != 4*base-base/8+32000000000*10/inactivityPenaltyQuotient {
+		t.Errorf("unexpected inactivity penalty of validator 2 during a leak: %+v", *rewards[2])
+	}
+
+	// validator 3 is withdrawable epochsPerSlashingsVector epochs after its slashing, the proportional penalty is
+	// applied in the transition to the epoch halfway to it, which processes the rewards of the epoch before
+	data = &epochRewardsData{
+		Epoch:         epochsPerSlashingsVector / 2,
+		SlotsPerEpoch: 4,
+		Validators:    validators,
+		Slashings:     []*types.RewardsSlashing{{Epoch: 1, Proposer: 0, ValidatorIndex: 3}},
+	}
+	rewards = computeEpochRewards(data)
+	// 32 increments * 32 ETH slashed / 128 ETH total balance
+	if rewards[3].SlashingPenalty != 8000000000 {
+		t.Errorf("unexpected slashing penalty of validator 3 at epoch %v: %+v", data.Epoch, *rewards[3])
+	}
+	data.Epoch++
+	rewards = computeEpochRewards(data)
+	if rewards[3].SlashingPenalty != 0 {
+		t.Errorf("unexpected slashing penalty of validator 3 at epoch %v: %+v", data.Epoch, *rewards[3])
+	}
+}
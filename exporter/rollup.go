@@ -0,0 +1,73 @@
+package exporter
+
+import (
+	"eth2-exporter/db"
+	"eth2-exporter/utils"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// rollupUpdater keeps the epoch_stats, daily_stats and eth1_deposits_daily tables up to date. The statistics of an
+// epoch are recomputed until the epoch has been finalized.
+func rollupUpdater() {
+	epochDuration := time.Second * time.Duration(utils.Config.Chain.SecondsPerSlot*utils.Config.Chain.SlotsPerEpoch)
+
+	for {
+		epochs, err := db.GetPendingRollupEpochs()
+		if err != nil {
+			logger.Errorf("error retrieving pending rollup epochs: %v", err)
+		} else {
+			start := time.Now()
+			err = UpdateRollups(epochs, false)
+			if err != nil {
+				logger.Errorf("error updating rollups: %v", err)
+			} else {
+				logger.Infof("updated the rollups of %v epochs in %v", len(epochs), time.Since(start))
+			}
+		}
+		time.Sleep(epochDuration)
+	}
+}
+
+// UpdateRollups recomputes the statistics of the epochs and of the days (UTC) they belong to. The daily eth1 deposits
+// are rebuilt completely if rebuildEth1Deposits is set, otherwise only the latest days are updated.
+func UpdateRollups(epochs []uint64, rebuildEth1Deposits bool) error {
+	finalizedEpoch, err := db.GetLatestFinalizedEpoch()
+	if err != nil {
+		return fmt.Errorf("error retrieving latest finalized epoch: %v", err)
+	}
+
+	days := make(map[time.Time]bool)
+	for i, epoch := range epochs {
+		err := db.SaveEpochStats(epoch, finalizedEpoch)
+		if err != nil {
+			return fmt.Errorf("error saving statistics of epoch %v: %v", epoch, err)
+		}
+		ts := utils.EpochToTime(epoch).UTC()
+		days[time.Date(ts.Year(), ts.Month(), ts.Day(), 0, 0, 0, 0, time.UTC)] = true
+
+		if (i+1)%1000 == 0 {
+			logger.Infof("saved the statistics of %v of %v epochs", i+1, len(epochs))
+		}
+	}
+
+	// the rewards of a day depend on the previous days, so the days are updated in order
+	sortedDays := make([]time.Time, 0, len(days))
+	for day := range days {
+		sortedDays = append(sortedDays, day)
+	}
+	sort.Slice(sortedDays, func(i, j int) bool { return sortedDays[i].Before(sortedDays[j]) })
+	for _, day := range sortedDays {
+		err := db.SaveDailyStats(day)
+		if err != nil {
+			return fmt.Errorf("error saving statistics of day %v: %v", day.Format("2006-01-02"), err)
+		}
+	}
+
+	err = db.SaveEth1DepositsDaily(rebuildEth1Deposits)
+	if err != nil {
+		return fmt.Errorf("error saving daily eth1 deposits: %v", err)
+	}
+	return nil
+}
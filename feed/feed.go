@@ -0,0 +1,188 @@
+// Package feed pushes the events the exporter publishes on the explorer database to websocket and server-sent
+// events subscribers
+package feed
+
+import (
+	"errors"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.New().WithField("module", "feed")
+
+// MaxFilterValidators is the maximum number of validators a subscription can filter on
+const MaxFilterValidators = 100
+
+// subscriptionBuffer is the number of events buffered per subscription, subscriptions that fall further behind are
+// closed
+const subscriptionBuffer = 256
+
+// ErrTooManyConnections is returned when the connection limit of the feed or of the client has been reached
+var ErrTooManyConnections = errors.New("too many open feed connections")
+
+// default connection limits, used if none are configured
+const (
+	defaultMaxConnections          = 10000
+	defaultMaxConnectionsPerClient = 5
+)
+
+var connectionsMux = &sync.Mutex{}
+var maxConnections = defaultMaxConnections
+var maxConnectionsPerClient = defaultMaxConnectionsPerClient
+var connections = 0
+var clientConnections = make(map[string]int)
+
+// SetConnectionLimits sets the maximum number of open connections overall and per client, 0 keeps the default
+func SetConnectionLimits(max, perClient int) {
+	connectionsMux.Lock()
+	defer connectionsMux.Unlock()
+	if max > 0 {
+		maxConnections = max
+	}
+	if perClient > 0 {
+		maxConnectionsPerClient = perClient
+	}
+}
+
+// AcquireConnection reserves a connection for the client, it has to be released with ReleaseConnection once the
+// connection has been closed
+func AcquireConnection(client string) error {
+	connectionsMux.Lock()
+	defer connectionsMux.Unlock()
+	if connections >= maxConnections || clientConnections[client] >= maxConnectionsPerClient {
+		return ErrTooManyConnections
+	}
+	connections++
+	clientConnections[client]++
+	return nil
+}
+
+// ReleaseConnection releases a connection reserved by AcquireConnection
+func ReleaseConnection(client string) {
+	connectionsMux.Lock()
+	defer connectionsMux.Unlock()
+	connections--
+	clientConnections[client]--
+	if clientConnections[client] <= 0 {
+		delete(clientConnections, client)
+	}
+}
+
+// Filter selects the events of a subscription. An empty topic set matches all topics, the validator set only applies
+// to events that concern validators (blocks, orphaned blocks and slashings).
+type Filter struct {
+	Topics     map[string]bool
+	Validators map[uint64]bool
+}
+
+// NewFilter returns a filter for the topics and validators
+func NewFilter(topics []string, validators []uint64) (*Filter, error) {
+	if len(validators) > MaxFilterValidators {
+		return nil, fmt.Errorf("only up to %v validators are allowed", MaxFilterValidators)
+	}
+
+	f := &Filter{Topics: make(map[string]bool, len(topics)), Validators: make(map[uint64]bool, len(validators))}
+	for _, t := range topics {
+		valid := false
+		for _, topic := range types.FeedTopics {
+			if t == topic {
+				valid = true
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid topic %v, valid topics are %v", t, types.FeedTopics)
+		}
+		f.Topics[t] = true
+	}
+	for _, v := range validators {
+		f.Validators[v] = true
+	}
+	return f, nil
+}
+
+// Match returns true if the event passes the filter
+func (f *Filter) Match(e *types.FeedEvent) bool {
+	if len(f.Topics) > 0 && !f.Topics[e.Topic] {
+		return false
+	}
+	if len(f.Validators) == 0 || len(e.Validators) == 0 {
+		return true
+	}
+	for _, v := range e.Validators {
+		if f.Validators[v] {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscription receives the events that match its filter on Events. The channel is closed if the subscriber can not
+// keep up.
+type Subscription struct {
+	Events chan *types.FeedEvent
+
+	mu     sync.Mutex
+	filter *Filter
+}
+
+// SetFilter replaces the filter of the subscription
+func (s *Subscription) SetFilter(filter *Filter) {
+	s.mu.Lock()
+	s.filter = filter
+	s.mu.Unlock()
+}
+
+func (s *Subscription) match(e *types.FeedEvent) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.filter.Match(e)
+}
+
+var subscriptionsMux = &sync.Mutex{}
+var subscriptions = make(map[*Subscription]bool)
+
+// Subscribe creates a subscription for the events matching the filter
+func Subscribe(filter *Filter) *Subscription {
+	s := &Subscription{Events: make(chan *types.FeedEvent, subscriptionBuffer), filter: filter}
+	subscriptionsMux.Lock()
+	subscriptions[s] = true
+	subscriptionsMux.Unlock()
+	return s
+}
+
+// Unsubscribe removes the subscription
+func Unsubscribe(s *Subscription) {
+	subscriptionsMux.Lock()
+	defer subscriptionsMux.Unlock()
+	if subscriptions[s] {
+		delete(subscriptions, s)
+		close(s.Events)
+	}
+}
+
+// Broadcast passes the event to all subscriptions whose filter it matches
+func Broadcast(e *types.FeedEvent) {
+	subscriptionsMux.Lock()
+	defer subscriptionsMux.Unlock()
+	for s := range subscriptions {
+		if !s.match(e) {
+			continue
+		}
+		select {
+		case s.Events <- e:
+		default:
+			logger.Warnf("closing feed subscription: %v events behind", len(s.Events))
+			delete(subscriptions, s)
+			close(s.Events)
+		}
+	}
+}
+
+// Start listens for the events published on the explorer database and broadcasts them to the subscriptions
+func Start(username, password, host, port, name string) {
+	go db.ListenFeedEvents(username, password, host, port, name, Broadcast)
+}
@@ -0,0 +1,154 @@
+package feed
+
+import (
+	"encoding/json"
+	"eth2-exporter/types"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestFilter(t *testing.T) {
+	block := &types.FeedEvent{Topic: types.FeedTopicBlock, Validators: []uint64{5}}
+	epoch := &types.FeedEvent{Topic: types.FeedTopicEpoch}
+
+	tests := []struct {
+		topics     []string
+		validators []uint64
+		block      bool
+		epoch      bool
+	}{
+		{topics: nil, validators: nil, block: true, epoch: true},
+		{topics: []string{"block"}, validators: nil, block: true, epoch: false},
+		{topics: nil, validators: []uint64{5, 6}, block: true, epoch: true},
+		{topics: nil, validators: []uint64{6}, block: false, epoch: true},
+		{topics: []string{"epoch", "slashing"}, validators: []uint64{5}, block: false, epoch: true},
+	}
+
+	for _, tt := range tests {
+		f, err := NewFilter(tt.topics, tt.validators)
+		if err != nil {
+			t.Fatalf("error creating filter for %v %v: %v", tt.topics, tt.validators, err)
+		}
+		if got := f.Match(block); got != tt.block {
+			t.Errorf("filter %v %v matches block event = %v, want %v", tt.topics, tt.validators, got, tt.block)
+		}
+		if got := f.Match(epoch); got != tt.epoch {
+			t.Errorf("filter %v %v matches epoch event = %v, want %v", tt.topics, tt.validators, got, tt.epoch)
+		}
+	}
+
+	if _, err := NewFilter([]string{"blocks"}, nil); err == nil {
+		t.Errorf("expected an error for an invalid topic")
+	}
+	if _, err := NewFilter(nil, make([]uint64, MaxFilterValidators+1)); err == nil {
+		t.Errorf("expected an error for too many validators")
+	}
+}
+
+func TestConnectionLimits(t *testing.T) {
+	SetConnectionLimits(3, 2)
+	defer SetConnectionLimits(defaultMaxConnections, defaultMaxConnectionsPerClient)
+
+	for _, client := range []string{"a", "a", "b"} {
+		if err := AcquireConnection(client); err != nil {
+			t.Fatalf("error acquiring connection of client %v: %v", client, err)
+		}
+	}
+	if AcquireConnection("a") != ErrTooManyConnections {
+		t.Errorf("expected the connection limit of client a to be reached")
+	}
+	if AcquireConnection("c") != ErrTooManyConnections {
+		t.Errorf("expected the overall connection limit to be reached")
+	}
+
+	ReleaseConnection("b")
+	if err := AcquireConnection("c"); err != nil {
+		t.Errorf("error acquiring connection after a release: %v", err)
+	}
+	for _, client := range []string{"a", "a", "c"} {
+		ReleaseConnection(client)
+	}
+	if connections != 0 || len(clientConnections) != 0 {
+		t.Errorf("expected all connections to be released, got %v: %v", connections, clientConnections)
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	filter, _ := NewFilter([]string{types.FeedTopicBlock}, nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ServeWebSocket(w, r, filter)
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("error connecting: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+
+	// the subscription is registered after the handshake, wait for it before broadcasting
+	waitForSubscriptions(t, 1)
+	Broadcast(&types.FeedEvent{Topic: types.FeedTopicEpoch, Data: json.RawMessage(`{"epoch":1}`)})
+	Broadcast(&types.FeedEvent{Topic: types.FeedTopicBlock, Validators: []uint64{3}, Data: json.RawMessage(`{"slot":42}`)})
+
+	e := readEvent(t, conn)
+	if e.Topic != types.FeedTopicBlock || string(e.Data) != `{"slot":42}` {
+		t.Errorf("received %v %s, want the block event", e.Topic, e.Data)
+	}
+
+	// replace the filter by the epoch topic
+	err = conn.WriteMessage(websocket.TextMessage, []byte(`{"topics":["epoch"]}`))
+	if err != nil {
+		t.Fatalf("error writing message: %v", err)
+	}
+	time.Sleep(time.Millisecond * 100)
+	Broadcast(&types.FeedEvent{Topic: types.FeedTopicBlock, Data: json.RawMessage(`{"slot":43}`)})
+	Broadcast(&types.FeedEvent{Topic: types.FeedTopicEpoch, Data: json.RawMessage(`{"epoch":2}`)})
+
+	e = readEvent(t, conn)
+	if e.Topic != types.FeedTopicEpoch || string(e.Data) != `{"epoch":2}` {
+		t.Errorf("received %v %s, want the epoch event", e.Topic, e.Data)
+	}
+
+	err = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	if err != nil {
+		t.Fatalf("error writing close message: %v", err)
+	}
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("error = %v, want a normal close", err)
+	}
+	waitForSubscriptions(t, 0)
+}
+
+func waitForSubscriptions(t *testing.T, n int) {
+	for i := 0; i < 50; i++ {
+		subscriptionsMux.Lock()
+		count := len(subscriptions)
+		subscriptionsMux.Unlock()
+		if count == n {
+			return
+		}
+		time.Sleep(time.Millisecond * 20)
+	}
+	t.Fatalf("expected %v subscriptions", n)
+}
+
+func readEvent(t *testing.T, conn *websocket.Conn) *types.FeedEvent {
+	_, payload, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("error reading message: %v", err)
+	}
+	e := &types.FeedEvent{}
+	err = json.Unmarshal(payload, e)
+	if err != nil {
+		t.Fatalf("error unmarshalling event: %v", err)
+	}
+	return e
+}
@@ -0,0 +1,76 @@
+package feed
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// ServeEventStream streams the events matching the filter as server-sent events, the topic is used as event name.
+// The connection is hijacked so that the write timeout of the http server does not end the stream.
+func ServeEventStream(w http.ResponseWriter, r *http.Request, filter *Filter) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		logger.Errorf("error serving event stream: response writer does not support hijacking")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		logger.Errorf("error hijacking event stream connection: %v", err)
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Time{})
+
+	sub := Subscribe(filter)
+	defer Unsubscribe(sub)
+
+	write := func(format string, a ...interface{}) error {
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		_, err := fmt.Fprintf(conn, format, a...)
+		return err
+	}
+
+	err = write("HTTP/1.1 200 OK\r\nContent-Type: text/event-stream\r\nCache-Control: no-cache\r\nConnection: close\r\nAccess-Control-Allow-Origin: *\r\n\r\n")
+	if err != nil {
+		return
+	}
+
+	// the client does not send anything after the request, a finished read means it has disconnected
+	closed := make(chan struct{})
+	go func() {
+		io.Copy(ioutil.Discard, conn)
+		close(closed)
+	}()
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case e, ok := <-sub.Events:
+			if !ok {
+				write("event: error\ndata: subscription fell behind\n\n")
+				return
+			}
+			payload, err := json.Marshal(e)
+			if err != nil {
+				logger.Errorf("error marshalling %v event: %v", e.Topic, err)
+				continue
+			}
+			if write("event: %s\ndata: %s\n\n", e.Topic, payload) != nil {
+				return
+			}
+		case <-ping.C:
+			if write(": ping\n\n") != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
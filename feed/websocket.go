@@ -0,0 +1,120 @@
+package feed
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	maxMessageSize = 4096 // maximum size of the subscription messages of the clients
+	pingInterval   = time.Second * 30
+	readTimeout    = time.Second * 90
+	writeTimeout   = time.Second * 10
+)
+
+// upgrader accepts connections of any origin, the feed is public and does not use cookies
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// subscriptionMessage replaces the filter of a websocket subscription
+type subscriptionMessage struct {
+	Topics     []string `json:"topics"`
+	Validators []uint64 `json:"validators"`
+}
+
+// ServeWebSocket upgrades the request to a websocket connection and streams the events matching the filter as json
+// text messages. Clients can replace the filter by sending a message of the form
+// {"topics": ["block", "slashing"], "validators": [1, 2]}.
+func ServeWebSocket(w http.ResponseWriter, r *http.Request, filter *Filter) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Warnf("error upgrading websocket connection: %v", err)
+		return
+	}
+	defer conn.Close()
+	// the server timeouts do not apply to the long-lived connection
+	conn.UnderlyingConn().SetDeadline(time.Time{})
+
+	sub := Subscribe(filter)
+	defer Unsubscribe(sub)
+
+	// control frames are written with WriteControl, which may be called concurrently with the writes of the events
+	conn.SetReadLimit(maxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+	conn.SetPingHandler(func(data string) error {
+		conn.SetReadDeadline(time.Now().Add(readTimeout))
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(writeTimeout))
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				done <- err
+				return
+			}
+			conn.SetReadDeadline(time.Now().Add(readTimeout))
+			req := &subscriptionMessage{}
+			err = json.Unmarshal(msg, req)
+			if err != nil {
+				done <- fmt.Errorf("invalid subscription message: %v", err)
+				return
+			}
+			f, err := NewFilter(req.Topics, req.Validators)
+			if err != nil {
+				done <- err
+				return
+			}
+			sub.SetFilter(f)
+		}
+	}()
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case e, ok := <-sub.Events:
+			if !ok {
+				writeClose(conn, websocket.CloseTryAgainLater, "subscription fell behind")
+				return
+			}
+			payload, err := json.Marshal(e)
+			if err != nil {
+				logger.Errorf("error marshalling %v event: %v", e.Topic, err)
+				continue
+			}
+			conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if conn.WriteMessage(websocket.TextMessage, payload) != nil {
+				return
+			}
+		case <-ping.C:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)) != nil {
+				return
+			}
+		case err := <-done:
+			// close frames of the client have already been answered by the default close handler
+			if _, ok := err.(*websocket.CloseError); !ok {
+				writeClose(conn, websocket.ClosePolicyViolation, err.Error())
+			}
+			return
+		}
+	}
+}
+
+func writeClose(conn *websocket.Conn, code int, reason string) error {
+	// the payload of control frames is limited to 125 bytes
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	return conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(writeTimeout))
+}
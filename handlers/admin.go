@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"eth2-exporter/db"
+	"eth2-exporter/services"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"eth2-exporter/version"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/csrf"
+)
+
+var adminExportQueueTemplate = template.Must(template.New("admin").Funcs(utils.GetTemplateFuncs()).ParseFiles("templates/layout.html", "templates/admin/exportqueue.html"))
+
+// AdminAuthMiddleware only lets users pass whose email is listed in the admins of the frontend config
+func AdminAuthMiddleware(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	user := getUser(w, r)
+	if !user.Authenticated {
+		utils.SetFlash(w, r, authSessionName, "Error: Please login first")
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	if !isAdmin(user) {
+		logger.Errorf("user %v is not authorized to access %v", user.UserID, r.URL.String())
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	next(w, r)
+}
+
+func isAdmin(user *types.User) bool {
+	if len(utils.Config.Frontend.Admins) == 0 {
+		return false
+	}
+
+	email, err := db.GetUserEmailById(user.UserID)
+	if err != nil {
+		logger.Errorf("error retrieving the email for user %v: %v", user.UserID, err)
+		return false
+	}
+
+	for _, admin := range utils.Config.Frontend.Admins {
+		if strings.EqualFold(strings.TrimSpace(admin), email) {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminStatus returns the status of the explorer including the error messages of the services and the database size
+func AdminStatus(w http.ResponseWriter, r *http.Request) {
+	writeStatus(w, r, services.GetStatus())
+}
+
+// AdminExportQueue renders the export queue admin template
+func AdminExportQueue(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	failed, err := db.GetExportJobs("failed")
+	if err != nil {
+		logger.Errorf("error retrieving failed export jobs: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	pending, err := db.GetExportJobs("pending")
+	if err != nil {
+		logger.Errorf("error retrieving pending export jobs: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	data := &types.PageData{
+		Meta: &types.Meta{
+			Title:       utils.Config.Frontend.SiteName + " - Export queue",
+			Description: "beaconcha.in makes the Ethereum 2.0. beacon chain accessible to non-technical end users",
+			Path:        "/admin/exportqueue",
+			GATag:       utils.Config.Frontend.GATag,
+		},
+		Active: "admin",
+		Data: &types.AdminExportQueuePageData{
+			Failed:    failed,
+			Pending:   pending,
+			CsrfField: csrf.TemplateField(r),
+		},
+		User:                  getUser(w, r),
+		Version:               version.Version,
+		ChainSlotsPerEpoch:    utils.Config.Chain.SlotsPerEpoch,
+		ChainSecondsPerSlot:   utils.Config.Chain.SecondsPerSlot,
+		ChainGenesisTimestamp: utils.Config.Chain.GenesisTimestamp,
+		CurrentEpoch:          services.LatestEpoch(),
+		CurrentSlot:           services.LatestSlot(),
+		FinalizationDelay:     services.FinalizationDelay(),
+		Mainnet:               utils.Config.Chain.Mainnet,
+		DepositContract:       utils.Config.Indexer.Eth1DepositContractAddress,
+	}
+
+	err = adminExportQueueTemplate.ExecuteTemplate(w, "layout", data)
+	if err != nil {
+		logger.Errorf("error executing template for %v route: %v", r.URL.String(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
+
+// AdminExportQueueData returns the jobs of the export queue with the given status (default failed) as json
+func AdminExportQueueData(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "failed"
+	}
+	if status != "pending" && status != "inprogress" && status != "failed" && status != "done" {
+		sendErrorResponse(j, r.URL.String(), "invalid status provided")
+		return
+	}
+
+	jobs, err := db.GetExportJobs(status)
+	if err != nil {
+		logger.Errorf("error retrieving %v export jobs: %v", status, err)
+		sendErrorResponse(j, r.URL.String(), "could not retrieve db results")
+		return
+	}
+
+	data := make([]interface{}, len(jobs))
+	for i, job := range jobs {
+		data[i] = job
+	}
+	sendOKResponse(j, r.URL.String(), data)
+}
+
+// AdminExportQueueRequeuePost moves failed epochs back into the export queue. The epochs are passed as a comma
+// separated list in the epochs form value, if no epochs are passed all failed epochs are requeued.
+func AdminExportQueueRequeuePost(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	j := json.NewEncoder(w)
+
+	err := r.ParseForm()
+	if err != nil {
+		sendErrorResponse(j, r.URL.String(), "could not parse form")
+		return
+	}
+
+	epochs := make([]uint64, 0)
+	for _, param := range strings.Split(r.FormValue("epochs"), ",") {
+		param = strings.TrimSpace(param)
+		if param == "" {
+			continue
+		}
+		epoch, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			sendErrorResponse(j, r.URL.String(), "invalid epoch provided")
+			return
+		}
+		epochs = append(epochs, epoch)
+	}
+
+	requeued, err := db.RequeueFailedExportJobs(epochs)
+	if err != nil {
+		logger.Errorf("error requeuing failed export jobs %v: %v", epochs, err)
+		sendErrorResponse(j, r.URL.String(), "could not requeue epochs")
+		return
+	}
+	logger.Infof("user %v requeued %v failed epochs for export", getUser(w, r).UserID, requeued)
+
+	sendOKResponse(j, r.URL.String(), []interface{}{map[string]int64{"requeued": requeued}})
+}
@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
+	"eth2-exporter/cache"
+	"eth2-exporter/charts"
 	"eth2-exporter/db"
 	"eth2-exporter/services"
 	"eth2-exporter/types"
@@ -21,8 +23,10 @@ import (
 // @title Beaconcha.in ETH2 API
 // @version 1.0
 // @description High performance API for querying information from the Ethereum 2.0 beacon chain
-// @description The API is currently free to use. A fair use policy applies. Calls are rate limited to
-// @description 10 requests / 1 minute / IP. All API results are cached for 1 minute.
+// @description The API is free to use. Calls without an api key are rate limited to 10 requests / 1 minute / IP.
+// @description API keys can be created in the account settings and are passed in the apikey query parameter
+// @description or the X-Api-Key header. The remaining requests are returned in the X-RateLimit-* headers.
+// @description All API results are cached for 1 minute.
 // @description If you required a higher usage plan please checkout https://beaconcha.in/api/pricing.
 
 // ApiHealthz godoc
@@ -52,6 +56,33 @@ func ApiHealthz(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "OK. Last epoch is from %v ago", time.Since(epochTime))
 }
 
+// ApiStatus godoc
+// @Summary Sync and indexing status of the explorer
+// @Tags Health
+// @Description Returns the lag of the indexer, the finality and the eth1 deposit exporter, the last runs of the background services, the failed epochs of the export queue and the health of the database. Error messages of the services are not included, admins can retrieve the full status at /admin/status.
+// @Description Each component gets its own health verdict (healthy, unhealthy or unknown), the status code is 503 if any component is unhealthy.
+// @Produce  json
+// @Success 200 {object} string
+// @Failure 503 {object} string
+// @Router /api/status [get]
+func ApiStatus(w http.ResponseWriter, r *http.Request) {
+	status := services.GetStatus()
+	services.RedactStatus(status)
+	writeStatus(w, r, status)
+}
+
+// writeStatus writes the status as json, the status code is 503 if the status is unhealthy
+func writeStatus(w http.ResponseWriter, r *http.Request, status *types.ApiStatus) {
+	w.Header().Set("Content-Type", "application/json")
+	if !status.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	err := json.NewEncoder(w).Encode(status)
+	if err != nil {
+		logger.Errorf("error serializing json data for API %v route: %v", r.URL, err)
+	}
+}
+
 // ApiEpoch godoc
 // @Summary Get epoch by number
 // @Tags Epoch
@@ -585,34 +616,236 @@ func ApiValidatorProposals(w http.ResponseWriter, r *http.Request) {
 	returnQueryResults(rows, j, r)
 }
 
+// ApiReorgs godoc
+// @Summary Get the most recent reorgs
+// @Tags Reorgs
+// @Description Returns the most recent reorganizations of the canonical chain detected by the explorer
+// @Produce  json
+// @Param  limit query int false "Number of reorgs to return (max 100)"
+// @Param  offset query int false "Number of reorgs to skip"
+// @Success 200 {object} string
+// @Router /api/v1/reorgs [get]
+func ApiReorgs(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	j := json.NewEncoder(w)
+	q := r.URL.Query()
+
+	limit := uint64(100)
+	if q.Get("limit") != "" {
+		l, err := strconv.ParseUint(q.Get("limit"), 10, 64)
+		if err != nil {
+			sendErrorResponse(j, r.URL.String(), "invalid limit provided")
+			return
+		}
+		if l < limit {
+			limit = l
+		}
+	}
+	offset := uint64(0)
+	if q.Get("offset") != "" {
+		o, err := strconv.ParseUint(q.Get("offset"), 10, 64)
+		if err != nil {
+			sendErrorResponse(j, r.URL.String(), "invalid offset provided")
+			return
+		}
+		offset = o
+	}
+
+	reorgs, err := db.GetReorgs(limit, offset)
+	if err != nil {
+		sendErrorResponse(j, r.URL.String(), "could not retrieve db results")
+		return
+	}
+
+	data := make([]interface{}, len(reorgs))
+	for i, reorg := range reorgs {
+		orphanedBlocks := make([]string, len(reorg.OrphanedBlocks))
+		for j, blockRoot := range reorg.OrphanedBlocks {
+			orphanedBlocks[j] = fmt.Sprintf("%#x", blockRoot)
+		}
+		data[i] = map[string]interface{}{
+			"id":              reorg.ID,
+			"ts":              reorg.Ts,
+			"epoch":           reorg.Epoch,
+			"depth":           reorg.Depth,
+			"old_head_slot":   reorg.OldHeadSlot,
+			"old_head_root":   fmt.Sprintf("%#x", reorg.OldHeadRoot),
+			"new_head_slot":   reorg.NewHeadSlot,
+			"new_head_root":   fmt.Sprintf("%#x", reorg.NewHeadRoot),
+			"affected_slots":  reorg.AffectedSlots,
+			"orphaned_blocks": orphanedBlocks,
+		}
+	}
+
+	sendOKResponse(j, r.URL.String(), data)
+}
+
 // ApiChart godoc
-// @Summary Returns charts from the page https://beaconcha.in/charts as PNG
+// @Summary Returns charts from the page https://beaconcha.in/charts as PNG or SVG image
 // @Tags Charts
-// @Produce  json
+// @Produce  png
+// @Produce  svg
 // @Param  chart path string true "Chart name (see https://github.com/gobitfly/eth2-beaconchain-explorer/blob/master/services/charts_updater.go#L20 for all available names)"
+// @Param  format query string false "Image format: png (default) or svg"
+// @Param  width query int false "Width of the image in pixels, defaults to 1200"
+// @Param  height query int false "Height of the image in pixels, defaults to 600"
+// @Param  from query int false "Start of the time range as unix timestamp, defaults to the start of the chart"
+// @Param  to query int false "End of the time range as unix timestamp, defaults to now"
+// @Param  bucket query string false "Size of the buckets of time series: epoch, hour, day or week, ignored by distribution charts"
 // @Success 200 {object} string
 // @Router /api/v1/chart/{chart} [get]
 func ApiChart(w http.ResponseWriter, r *http.Request) {
-
 	j := json.NewEncoder(w)
-	vars := mux.Vars(r)
+	chartName := mux.Vars(r)["chart"]
+	q := r.URL.Query()
+
+	if _, ok := services.ChartHandlers[chartName]; !ok {
+		sendErrorResponse(j, r.URL.String(), "unknown chart")
+		return
+	}
 
-	chartName := vars["chart"]
+	format := q.Get("format")
+	if format == "" {
+		format = "png"
+	}
+	if format != "png" && format != "svg" {
+		sendErrorResponse(j, r.URL.String(), "invalid format, supported formats are png and svg")
+		return
+	}
+	width, err := parseChartSize(q.Get("width"), charts.DefaultWidth)
+	if err != nil {
+		sendErrorResponse(j, r.URL.String(), "invalid width: "+err.Error())
+		return
+	}
+	height, err := parseChartSize(q.Get("height"), charts.DefaultHeight)
+	if err != nil {
+		sendErrorResponse(j, r.URL.String(), "invalid height: "+err.Error())
+		return
+	}
 
-	var image []byte
-	err := db.DB.Get(&image, "SELECT image FROM chart_images WHERE name = $1", chartName)
+	query, err := parseChartQuery(chartName, q)
 	if err != nil {
+		sendErrorResponse(j, r.URL.String(), err.Error())
+		return
+	}
+
+	// images are rendered on demand and cached until the next epoch has been exported
+	key := fmt.Sprintf("%v:%v:%v:%v:%v:%v:%v:%v", services.LatestEpoch(), chartName, format, width, height, q.Get("from"), q.Get("to"), q.Get("bucket"))
+	if entry, found := chartImageCache.Get(key); found {
+		w.Header().Set("Content-Type", entry.ContentType)
+		w.Write(entry.Body)
+		return
+	}
+
+	chartData, err := getApiChartData(chartName, query, hasChartQuery(q))
+	if err == services.ErrChartRangeNotSupported || err == services.ErrTooManyChartPoints {
+		sendErrorResponse(j, r.URL.String(), err.Error())
+		return
+	}
+	if err != nil {
+		logger.Errorf("error retrieving chart data for %v route: %v", r.URL.String(), err)
 		sendErrorResponse(j, r.URL.String(), "no data available for the requested chart")
 		return
 	}
 
-	w.Header().Set("Content-Type", "image/png")
+	entry := &cache.Entry{Expires: time.Now().Add(time.Second * time.Duration(utils.Config.Chain.SecondsPerSlot*utils.Config.Chain.SlotsPerEpoch))}
+	if format == "svg" {
+		entry.ContentType = "image/svg+xml"
+		entry.Body, err = charts.RenderSVG(chartData, width, height)
+	} else {
+		entry.ContentType = "image/png"
+		entry.Body, err = charts.RenderPNG(chartData, width, height)
+	}
+	if err != nil {
+		logger.Errorf("error rendering chart %v as %v: %v", chartName, format, err)
+		sendErrorResponse(j, r.URL.String(), "error rendering chart")
+		return
+	}
+	chartImageCache.Set(key, entry)
 
-	_, err = w.Write(image)
+	w.Header().Set("Content-Type", entry.ContentType)
+	_, err = w.Write(entry.Body)
 	if err != nil {
-		sendErrorResponse(j, r.URL.String(), "error writing chart data")
+		logger.Errorf("error writing chart image for %v route: %v", r.URL.String(), err)
+		return
+	}
+}
+
+// ApiChartData godoc
+// @Summary Returns the series of the charts from the page https://beaconcha.in/charts
+// @Tags Charts
+// @Produce  json
+// @Param  chart path string true "Chart name (see https://github.com/gobitfly/eth2-beaconchain-explorer/blob/master/services/charts_updater.go#L20 for all available names)"
+// @Param  from query int false "Start of the time range as unix timestamp, defaults to the start of the chart"
+// @Param  to query int false "End of the time range as unix timestamp, defaults to now"
+// @Param  bucket query string false "Size of the buckets of time series: epoch, hour, day or week, ignored by distribution charts"
+// @Success 200 {object} string
+// @Router /api/v1/chart/{chart}/data [get]
+func ApiChartData(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	j := json.NewEncoder(w)
+	chartName := mux.Vars(r)["chart"]
+
+	if _, ok := services.ChartHandlers[chartName]; !ok {
+		sendErrorResponse(j, r.URL.String(), "unknown chart")
 		return
 	}
+
+	query, err := parseChartQuery(chartName, r.URL.Query())
+	if err != nil {
+		sendErrorResponse(j, r.URL.String(), err.Error())
+		return
+	}
+
+	chartData, err := getApiChartData(chartName, query, hasChartQuery(r.URL.Query()))
+	if err == services.ErrChartRangeNotSupported || err == services.ErrTooManyChartPoints {
+		sendErrorResponse(j, r.URL.String(), err.Error())
+		return
+	}
+	if err != nil {
+		logger.Errorf("error retrieving chart data for %v route: %v", r.URL.String(), err)
+		sendErrorResponse(j, r.URL.String(), "no data available for the requested chart")
+		return
+	}
+
+	sendOKResponse(j, r.URL.String(), []interface{}{&types.ApiChartData{
+		Chart:            chartName,
+		From:             query.From.Unix(),
+		To:               query.To.Unix(),
+		Bucket:           query.Bucket,
+		GenericChartData: chartData,
+	}})
+}
+
+// getApiChartData returns the data of the chart for the query, the data of the charts page is used for requests
+// without a custom query
+func getApiChartData(chartName string, query *types.ChartQuery, custom bool) (*types.GenericChartData, error) {
+	if !custom && services.LatestChartsPageData() != nil {
+		for _, c := range *services.LatestChartsPageData() {
+			if c.Path == chartName {
+				return c.Data, nil
+			}
+		}
+	}
+	return services.GetChartData(chartName, query)
+}
+
+// parseChartSize parses the width or height of a chart image
+func parseChartSize(value string, defaultSize int) (int, error) {
+	if value == "" {
+		return defaultSize, nil
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("must be a number")
+	}
+	if size < charts.MinSize || size > charts.MaxSize {
+		return 0, fmt.Errorf("must be between %v and %v", charts.MinSize, charts.MaxSize)
+	}
+	return size, nil
 }
 
 func returnQueryResults(rows *sql.Rows, j *json.Encoder, r *http.Request) {
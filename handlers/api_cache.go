@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"bytes"
+	"eth2-exporter/cache"
+	"eth2-exporter/services"
+	"eth2-exporter/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// apiCacheHeadTTL is the maximum time a response that depends on the head of the chain is cached
+var apiCacheHeadTTL = time.Minute
+
+// apiCacheRecorder passes the response through to the client while recording it for the cache
+type apiCacheRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *apiCacheRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *apiCacheRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// ApiCacheMiddleware caches the responses of the api routes in c. Responses for finalized epochs and blocks are
+// cached indefinitely, all other responses are cached for one minute at most and are invalidated as soon as a new epoch
+// has been exported.
+func ApiCacheMiddleware(c cache.Cache) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// responses of the user-scoped routes depend on the access token and are never shared
+			if c == nil || r.Method != "GET" || r.Header.Get("Authorization") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key, ttl := apiCacheKey(r)
+			if entry, found := c.Get(key); found {
+				w.Header().Set("Content-Type", entry.ContentType)
+				w.Header().Set("X-Cache", "HIT")
+				w.Write(entry.Body)
+				return
+			}
+
+			w.Header().Set("X-Cache", "MISS")
+			rec := &apiCacheRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			// Errors are sent with status 200 by the api handlers and must not be cached
+			if rec.status != http.StatusOK || bytes.HasPrefix(rec.body.Bytes(), []byte(`{"status":"ERROR`)) {
+				return
+			}
+
+			entry := &cache.Entry{ContentType: rec.Header().Get("Content-Type"), Body: rec.body.Bytes()}
+			if ttl > 0 {
+				entry.Expires = time.Now().Add(ttl)
+			}
+			c.Set(key, entry)
+		})
+	}
+}
+
+// apiCacheKey returns the cache key and time to live of the response for the request. Routes of finalized epochs
+// and slots are immutable and never expire, the key of all other routes contains the latest epoch so that they are
+// invalidated once the chain advances.
+func apiCacheKey(r *http.Request) (string, time.Duration) {
+	vars := mux.Vars(r)
+	uri := apiCacheURI(r)
+
+	if epoch, err := strconv.ParseUint(vars["epoch"], 10, 64); err == nil && isImmutableEpoch(epoch) {
+		return "finalized:" + uri, 0
+	}
+
+	slotParam := vars["slot"]
+	if slotParam == "" {
+		slotParam = vars["slotOrHash"]
+	}
+	if slot, err := strconv.ParseUint(slotParam, 10, 64); err == nil && isImmutableEpoch(slot/utils.Config.Chain.SlotsPerEpoch) {
+		return "finalized:" + uri, 0
+	}
+
+	return fmt.Sprintf("head:%v:%v", services.LatestEpoch(), uri), apiCacheHeadTTL
+}
+
+// apiCacheURI returns the uri of the request without the api key so that the response is shared by all clients
+func apiCacheURI(r *http.Request) string {
+	query := r.URL.Query()
+	if _, found := query["apikey"]; !found {
+		return r.URL.RequestURI()
+	}
+	query.Del("apikey")
+	if len(query) == 0 {
+		return r.URL.EscapedPath()
+	}
+	return r.URL.EscapedPath() + "?" + query.Encode()
+}
+
+// isImmutableEpoch returns true if the data of the epoch will not change anymore. The exporter still updates
+// the epoch before the last finalized epoch, only epochs before that one are considered immutable.
+func isImmutableEpoch(epoch uint64) bool {
+	finalized := services.LatestFinalizedEpoch()
+	return finalized > 1 && epoch < finalized-1
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/json"
+	"eth2-exporter/db"
+	"eth2-exporter/ratelimit"
+	"eth2-exporter/types"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// apiPlan holds the number of requests an api plan allows per minute, day and month, zero is unlimited
+type apiPlan struct {
+	Name      string
+	PerMinute uint64
+	PerDay    uint64
+	PerMonth  uint64
+}
+
+// limits returns the rate limits of the plan, the shortest period first
+func (p *apiPlan) limits() []ratelimit.Limit {
+	return []ratelimit.Limit{
+		{Requests: p.PerMinute, Period: time.Minute},
+		{Requests: p.PerDay, Period: time.Hour * 24},
+		{Requests: p.PerMonth, Period: time.Hour * 24 * 30},
+	}
+}
+
+// apiPlans are the plans listed on the pricing page. Requests without an api key are limited per ip with the free plan.
+var apiPlans = map[string]*apiPlan{
+	"free":     {Name: "Free", PerMinute: 10, PerDay: 10000, PerMonth: 30000},
+	"sapphire": {Name: "Sapphire", PerMinute: 100, PerDay: 100000, PerMonth: 500000},
+	"emerald":  {Name: "Emerald", PerDay: 200000, PerMonth: 1000000},
+	"diamond":  {Name: "Diamond", PerMonth: 4000000},
+}
+
+// apiKeyCacheTTL is the time a looked up api key is cached, revoking a key takes effect after this time at the latest
+var apiKeyCacheTTL = time.Minute
+
+type apiKeyCacheEntry struct {
+	apiKey  *types.ApiKey // nil if the key does not exist or has been revoked
+	expires time.Time
+}
+
+type apiStatisticKey struct {
+	ts     time.Time
+	apiKey string
+	call   string
+}
+
+// apiStatistics counts the api calls in memory, the counts are periodically added to the api_statistics table
+type apiStatistics struct {
+	mux    sync.Mutex
+	counts map[apiStatisticKey]uint64
+}
+
+func (s *apiStatistics) record(apiKey, call string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.counts[apiStatisticKey{ts: time.Now().UTC().Truncate(time.Hour * 24), apiKey: apiKey, call: call}]++
+}
+
+func (s *apiStatistics) flusher() {
+	for {
+		time.Sleep(time.Minute)
+
+		s.mux.Lock()
+		counts := s.counts
+		s.counts = make(map[apiStatisticKey]uint64)
+		s.mux.Unlock()
+
+		stats := make([]*types.ApiStatistic, 0, len(counts))
+		for key, count := range counts {
+			stats = append(stats, &types.ApiStatistic{Ts: key.ts, ApiKey: key.apiKey, Call: key.call, Count: count})
+		}
+
+		err := db.SaveApiStatistics(stats)
+		if err != nil {
+			logger.Errorf("error saving %v api statistics: %v", len(stats), err)
+			// Keep the counts so that they are saved with the next flush
+			s.mux.Lock()
+			for key, count := range counts {
+				s.counts[key] += count
+			}
+			s.mux.Unlock()
+		}
+	}
+}
+
+// ApiRateLimitMiddleware enforces the limits of the api plans. Requests with an api key (passed in the apikey query
+// parameter or the X-Api-Key header) are limited by the plan of the key, all other requests are limited per ip with
+// the free plan. The remaining requests are returned in the X-RateLimit headers, exceeding a limit results in a 429.
+// The calls are counted per api key, route and day in the api_statistics table.
+func ApiRateLimitMiddleware() mux.MiddlewareFunc {
+	limiter := ratelimit.NewLimiter()
+	stats := &apiStatistics{counts: make(map[apiStatisticKey]uint64)}
+	go stats.flusher()
+
+	apiKeys, err := lru.New(10000)
+	if err != nil {
+		logger.Fatalf("error creating api key cache: %v", err)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			j := json.NewEncoder(w)
+
+			key := r.URL.Query().Get("apikey")
+			if key == "" {
+				key = r.Header.Get("X-Api-Key")
+			}
+
+			plan := apiPlans["free"]
+			bucket := "ip:" + clientIP(r)
+			if key != "" {
+				apiKey, err := getApiKey(apiKeys, key)
+				if err != nil {
+					logger.Errorf("error retrieving api key: %v", err)
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusInternalServerError)
+					sendErrorResponse(j, r.URL.String(), "could not verify api key")
+					return
+				}
+				if apiKey == nil {
+					w.Header().Set("Content-Type", "application/json")
+					w.WriteHeader(http.StatusUnauthorized)
+					sendErrorResponse(j, r.URL.String(), "invalid api key")
+					return
+				}
+				if apiPlans[apiKey.Plan] != nil {
+					plan = apiPlans[apiKey.Plan]
+				}
+				bucket = "key:" + key
+			}
+
+			// The headers report the limit that is closest to being exceeded
+			var binding *ratelimit.Result
+			for _, limit := range plan.limits() {
+				res := limiter.Allow(fmt.Sprintf("%v:%v", bucket, limit.Period), limit)
+				if res.Limit == 0 {
+					continue
+				}
+				if binding == nil || !res.Allowed || res.Remaining < binding.Remaining {
+					binding = res
+				}
+				if !res.Allowed {
+					break
+				}
+			}
+
+			if binding != nil {
+				w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", binding.Limit))
+				w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", binding.Remaining))
+				w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", int64(binding.Reset.Seconds())))
+				if !binding.Allowed {
+					w.Header().Set("Content-Type", "application/json")
+					w.Header().Set("Retry-After", fmt.Sprintf("%d", int64(binding.RetryAfter.Seconds())))
+					w.WriteHeader(http.StatusTooManyRequests)
+					sendErrorResponse(j, r.URL.String(), "rate limit exceeded")
+					return
+				}
+			}
+
+			call := r.URL.Path
+			if route := mux.CurrentRoute(r); route != nil {
+				if tpl, err := route.GetPathTemplate(); err == nil {
+					call = tpl
+				}
+			}
+			stats.record(key, call)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// getApiKey returns the active api key matching key, lookups are cached for apiKeyCacheTTL
+func getApiKey(apiKeys *lru.Cache, key string) (*types.ApiKey, error) {
+	if cached, found := apiKeys.Get(key); found {
+		entry := cached.(*apiKeyCacheEntry)
+		if time.Now().Before(entry.expires) {
+			return entry.apiKey, nil
+		}
+	}
+
+	apiKey, err := db.GetApiKey(key)
+	if err != nil {
+		return nil, err
+	}
+	apiKeys.Add(key, &apiKeyCacheEntry{apiKey: apiKey, expires: time.Now().Add(apiKeyCacheTTL)})
+	return apiKey, nil
+}
+
+// clientIP returns the ip of the client, the remote address has already been replaced by the proxyaddr middleware
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return strings.TrimSpace(r.RemoteAddr)
+	}
+	return host
+}
@@ -0,0 +1,387 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+)
+
+// maxApiUserRequestSize is the maximum size of the json body of a user api request
+const maxApiUserRequestSize = 64 * 1024
+
+// maxTagLength is the length of the tag column of users_validators_tags
+const maxTagLength = 100
+
+// ApiUserWatchlist godoc
+// @Summary Get the watchlist of the user
+// @Tags User
+// @Description Returns the validators on the watchlist of the user together with all their tags.
+// @Description Requires an access token of the oauth flow in the Authorization header (Bearer).
+// @Produce json
+// @Success 200 {object} string
+// @Router /api/v1/user/watchlist [get]
+func ApiUserWatchlist(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	sendApiUserValidators(w, r, getApiUserDevice(r).UserID, types.ValidatorTagsWatchlist)
+}
+
+// ApiUserWatchlistAdd godoc
+// @Summary Add validators to the watchlist of the user
+// @Tags User
+// @Description Adds up to 100 validators to the watchlist and returns the updated watchlist.
+// @Description Requires an access token of the oauth flow in the Authorization header (Bearer).
+// @Accept json
+// @Produce json
+// @Param body body string true "{\"validators\": [\"1\", \"0xa1d1ad0714035353258038e964ae9675dc0252ee22cea896825c01458e1807bfad2f9969338798548d9858a571f7425c\"]}"
+// @Success 200 {object} string
+// @Router /api/v1/user/watchlist [post]
+func ApiUserWatchlistAdd(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID := getApiUserDevice(r).UserID
+
+	publicKeys, ok := parseApiUserValidatorsRequest(w, r)
+	if !ok {
+		return
+	}
+
+	entries := make([]db.WatchlistEntry, 0, len(publicKeys))
+	for _, publicKey := range publicKeys {
+		entries = append(entries, db.WatchlistEntry{UserId: userID, Validator_publickey: hex.EncodeToString(publicKey)})
+	}
+	err := db.AddToWatchlist(entries)
+	if err != nil {
+		logger.Errorf("error adding validators to watchlist of user %v: %v", userID, err)
+		sendApiUserError(w, r, http.StatusInternalServerError, "could not add validators to watchlist")
+		return
+	}
+
+	sendApiUserValidators(w, r, userID, types.ValidatorTagsWatchlist)
+}
+
+// ApiUserWatchlistRemove godoc
+// @Summary Remove a validator from the watchlist of the user
+// @Tags User
+// @Description Removes a validator from the watchlist, the subscriptions for the validator are removed as well. Returns the updated watchlist.
+// @Description Requires an access token of the oauth flow in the Authorization header (Bearer).
+// @Produce json
+// @Param indexOrPubkey path string true "Validator index or pubkey"
+// @Success 200 {object} string
+// @Router /api/v1/user/watchlist/{indexOrPubkey} [delete]
+func ApiUserWatchlistRemove(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID := getApiUserDevice(r).UserID
+
+	publicKeys, err := parseApiUserValidators([]string{mux.Vars(r)["indexOrPubkey"]})
+	if err != nil {
+		sendApiUserError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = db.RemoveFromWatchlist(userID, hex.EncodeToString(publicKeys[0]))
+	if err != nil {
+		logger.Errorf("error removing validator from watchlist of user %v: %v", userID, err)
+		sendApiUserError(w, r, http.StatusInternalServerError, "could not remove validator from watchlist")
+		return
+	}
+
+	sendApiUserValidators(w, r, userID, types.ValidatorTagsWatchlist)
+}
+
+// ApiUserTags godoc
+// @Summary Get the tagged validators of the user
+// @Tags User
+// @Description Returns all validators the user has tagged (including the watchlist) together with their tags.
+// @Description Requires an access token of the oauth flow in the Authorization header (Bearer).
+// @Produce json
+// @Param tag query string false "Only return the validators with this tag"
+// @Success 200 {object} string
+// @Router /api/v1/user/tags [get]
+func ApiUserTags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	sendApiUserValidators(w, r, getApiUserDevice(r).UserID, types.Tag(strings.TrimSpace(r.URL.Query().Get("tag"))))
+}
+
+// ApiUserTagsAdd godoc
+// @Summary Tag validators of the user
+// @Tags User
+// @Description Adds a tag to up to 100 validators and returns all validators with the tag. The watchlist tag is reserved, use the watchlist routes instead.
+// @Description Requires an access token of the oauth flow in the Authorization header (Bearer).
+// @Accept json
+// @Produce json
+// @Param tag path string true "Tag of up to 100 characters"
+// @Param body body string true "{\"validators\": [\"1\", \"2\"]}"
+// @Success 200 {object} string
+// @Router /api/v1/user/tags/{tag} [post]
+func ApiUserTagsAdd(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID := getApiUserDevice(r).UserID
+
+	tag, ok := parseApiUserTag(w, r)
+	if !ok {
+		return
+	}
+	publicKeys, ok := parseApiUserValidatorsRequest(w, r)
+	if !ok {
+		return
+	}
+
+	err := db.AddValidatorsTag(userID, tag, publicKeys)
+	if err != nil {
+		logger.Errorf("error adding tag %v for user %v: %v", tag, userID, err)
+		sendApiUserError(w, r, http.StatusInternalServerError, "could not tag validators")
+		return
+	}
+
+	sendApiUserValidators(w, r, userID, tag)
+}
+
+// ApiUserTagsRemove godoc
+// @Summary Remove a tag from validators of the user
+// @Tags User
+// @Description Removes a tag from the passed validators or from all validators if none are passed.
+// @Description Requires an access token of the oauth flow in the Authorization header (Bearer).
+// @Produce json
+// @Param tag path string true "Tag"
+// @Param validators query string false "Up to 100 validator indices or pubkeys, comma separated"
+// @Success 200 {object} string
+// @Router /api/v1/user/tags/{tag} [delete]
+func ApiUserTagsRemove(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID := getApiUserDevice(r).UserID
+
+	tag, ok := parseApiUserTag(w, r)
+	if !ok {
+		return
+	}
+
+	var publicKeys [][]byte
+	if validators := r.URL.Query().Get("validators"); validators != "" {
+		var err error
+		publicKeys, err = parseApiUserValidators(strings.Split(validators, ","))
+		if err != nil {
+			sendApiUserError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	_, err := db.RemoveValidatorsTag(userID, tag, publicKeys)
+	if err != nil {
+		logger.Errorf("error removing tag %v for user %v: %v", tag, userID, err)
+		sendApiUserError(w, r, http.StatusInternalServerError, "could not remove tag")
+		return
+	}
+
+	sendApiUserValidators(w, r, userID, tag)
+}
+
+// ApiUserSubscriptions godoc
+// @Summary Get the notification subscriptions of the user
+// @Tags User
+// @Description Returns the subscriptions of the user with their event filters and notification channels.
+// @Description Requires an access token of the oauth flow in the Authorization header (Bearer).
+// @Produce json
+// @Success 200 {object} string
+// @Router /api/v1/user/subscriptions [get]
+func ApiUserSubscriptions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID := getApiUserDevice(r).UserID
+
+	subs, err := db.GetSubscriptions(db.GetSubscriptionsFilter{UserIDs: &[]uint64{userID}})
+	if err != nil {
+		logger.Errorf("error retrieving subscriptions of user %v: %v", userID, err)
+		sendApiUserError(w, r, http.StatusInternalServerError, "could not retrieve subscriptions")
+		return
+	}
+
+	sendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{subs})
+}
+
+// ApiUserSubscriptionsAdd godoc
+// @Summary Subscribe the user to notifications of an event
+// @Tags User
+// @Description Subscribes the user to an event. Validator events are filtered by the index or pubkey of the validator, network events can not be filtered.
+// @Description The channels default to email, subscribing again to the same event and filter updates the channels.
+// @Description Requires an access token of the oauth flow in the Authorization header (Bearer).
+// @Accept json
+// @Produce json
+// @Param body body string true "{\"event_name\": \"validator_balance_decreased\", \"event_filter\": \"1\", \"channels\": [\"email\", \"push\"]}"
+// @Success 200 {object} string
+// @Router /api/v1/user/subscriptions [post]
+func ApiUserSubscriptionsAdd(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID := getApiUserDevice(r).UserID
+
+	req := &types.ApiUserSubscriptionRequest{}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxApiUserRequestSize)).Decode(req)
+	if err != nil {
+		sendApiUserError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	eventName, err := types.EventNameFromString(req.EventName)
+	if err != nil {
+		sendApiUserError(w, r, http.StatusBadRequest, "invalid event_name")
+		return
+	}
+
+	filter := ""
+	if !isNetworkEvent(eventName) {
+		// validator events are filtered by the hex encoded pubkey of the validator
+		publicKeys, err := parseApiUserValidators([]string{req.EventFilter})
+		if err != nil {
+			sendApiUserError(w, r, http.StatusBadRequest, "invalid event_filter: "+err.Error())
+			return
+		}
+		filter = hex.EncodeToString(publicKeys[0])
+	}
+
+	channels := make([]string, 0, len(types.NotificationChannels))
+	seen := make(map[types.NotificationChannel]bool, len(types.NotificationChannels))
+	for _, c := range req.Channels {
+		channel, err := types.NotificationChannelFromString(c)
+		if err != nil {
+			sendApiUserError(w, r, http.StatusBadRequest, fmt.Sprintf("invalid channel %v", c))
+			return
+		}
+		if !seen[channel] {
+			seen[channel] = true
+			channels = append(channels, string(channel))
+		}
+	}
+	if len(channels) == 0 {
+		channels = append(channels, string(types.EmailNotificationChannel))
+	}
+
+	sub := &types.Subscription{UserID: userID, EventName: eventName, EventFilter: filter, Channels: channels}
+	err = db.SaveUserSubscription(sub)
+	if err != nil {
+		logger.Errorf("error saving subscription for user %v eventName %v eventfilter %v: %v", userID, eventName, filter, err)
+		sendApiUserError(w, r, http.StatusInternalServerError, "could not save subscription")
+		return
+	}
+
+	sendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{sub})
+}
+
+// ApiUserSubscriptionsDelete godoc
+// @Summary Delete a notification subscription of the user
+// @Tags User
+// @Description Requires an access token of the oauth flow in the Authorization header (Bearer).
+// @Produce json
+// @Param id path string true "Id of the subscription"
+// @Success 200 {object} string
+// @Router /api/v1/user/subscriptions/{id} [delete]
+func ApiUserSubscriptionsDelete(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	userID := getApiUserDevice(r).UserID
+
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendApiUserError(w, r, http.StatusBadRequest, "invalid subscription id")
+		return
+	}
+
+	deleted, err := db.DeleteUserSubscription(userID, id)
+	if err != nil {
+		logger.Errorf("error deleting subscription %v of user %v: %v", id, userID, err)
+		sendApiUserError(w, r, http.StatusInternalServerError, "could not delete subscription")
+		return
+	}
+	if !deleted {
+		sendApiUserError(w, r, http.StatusNotFound, "subscription not found")
+		return
+	}
+
+	sendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{})
+}
+
+// parseApiUserValidators returns the public keys of the validators passed as indices or hex encoded public keys.
+// Public keys do not have to be known yet so that validators can be watched before their deposit has been processed.
+func parseApiUserValidators(validators []string) ([][]byte, error) {
+	if len(validators) == 0 {
+		return nil, fmt.Errorf("no validators passed")
+	}
+	indices, publicKeys, err := parseApiValidatorParam(strings.Join(validators, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([][]byte, 0, len(validators))
+	for _, publicKey := range publicKeys {
+		if len(publicKey) != 48 {
+			return nil, fmt.Errorf("invalid validator pubkey 0x%x", publicKey)
+		}
+		result = append(result, publicKey)
+	}
+
+	if len(indices) > 0 {
+		known, err := db.GetValidatorPublicKeys(indices)
+		if err != nil {
+			logger.Errorf("error retrieving public keys of validators %v: %v", indices, err)
+			return nil, fmt.Errorf("could not retrieve validators")
+		}
+		unique := make(map[uint64]bool, len(indices))
+		for _, index := range indices {
+			unique[index] = true
+		}
+		if len(known) != len(unique) {
+			return nil, fmt.Errorf("unknown validator index")
+		}
+		result = append(result, known...)
+	}
+	return result, nil
+}
+
+// parseApiUserValidatorsRequest parses the validators of the request body, an error is sent if they are invalid
+func parseApiUserValidatorsRequest(w http.ResponseWriter, r *http.Request) ([][]byte, bool) {
+	req := &types.ApiUserValidatorsRequest{}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxApiUserRequestSize)).Decode(req)
+	if err != nil {
+		sendApiUserError(w, r, http.StatusBadRequest, "invalid request body")
+		return nil, false
+	}
+	publicKeys, err := parseApiUserValidators(req.Validators)
+	if err != nil {
+		sendApiUserError(w, r, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	return publicKeys, true
+}
+
+// parseApiUserTag returns the tag of the route, an error is sent if it is invalid or reserved
+func parseApiUserTag(w http.ResponseWriter, r *http.Request) (types.Tag, bool) {
+	tag := strings.TrimSpace(mux.Vars(r)["tag"])
+	if tag == "" || utf8.RuneCountInString(tag) > maxTagLength {
+		sendApiUserError(w, r, http.StatusBadRequest, fmt.Sprintf("the tag must have between 1 and %v characters", maxTagLength))
+		return "", false
+	}
+	if types.Tag(tag) == types.ValidatorTagsWatchlist {
+		sendApiUserError(w, r, http.StatusBadRequest, "the watchlist tag is managed by the watchlist routes")
+		return "", false
+	}
+	return types.Tag(tag), true
+}
+
+// sendApiUserValidators sends the validators of the user with the tag or all tagged validators if tag is empty
+func sendApiUserValidators(w http.ResponseWriter, r *http.Request, userID uint64, tag types.Tag) {
+	validators, err := db.GetUserTaggedValidators(userID, tag)
+	if err != nil {
+		logger.Errorf("error retrieving tagged validators of user %v: %v", userID, err)
+		sendApiUserError(w, r, http.StatusInternalServerError, "could not retrieve validators")
+		return
+	}
+	sendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{validators})
+}
+
+func sendApiUserError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	w.WriteHeader(status)
+	sendErrorResponse(json.NewEncoder(w), r.URL.String(), message)
+}
@@ -0,0 +1,534 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"eth2-exporter/db"
+	"eth2-exporter/openapi"
+	"eth2-exporter/types"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	apiV2DefaultLimit = 100
+	apiV2MaxLimit     = 500
+)
+
+// apiV2Operation describes a route of /api/v2. The operations are used to register the routes and to generate the
+// OpenAPI spec, so that the documented response always matches the struct that is returned.
+type apiV2Operation struct {
+	Path        string
+	ID          string
+	Summary     string
+	Description string
+	Tag         string
+	Params      []*openapi.Parameter
+	Response    interface{} // an element of the data array of the response
+	Paginated   bool
+	CSV         bool // the response can be requested as text/csv with format=csv
+	Handler     http.HandlerFunc
+}
+
+var apiV2ValidatorParam = &openapi.Parameter{
+	Name:        "indexOrPubkey",
+	In:          "path",
+	Description: "Up to 100 validator indices or pubkeys, comma separated",
+	Required:    true,
+	Schema:      &openapi.Schema{Type: "string"},
+}
+
+var apiV2PaginationParams = []*openapi.Parameter{
+	{
+		Name:        "cursor",
+		In:          "query",
+		Description: "The next_cursor of the previous page, omit it to request the first page",
+		Schema:      &openapi.Schema{Type: "string"},
+	},
+	{
+		Name:        "limit",
+		In:          "query",
+		Description: fmt.Sprintf("Number of results per page (default %v, max %v)", apiV2DefaultLimit, apiV2MaxLimit),
+		Schema:      &openapi.Schema{Type: "integer", Format: "int64"},
+	},
+}
+
+var apiV2DailyIncomeParams = []*openapi.Parameter{
+	{
+		Name:        "start",
+		In:          "query",
+		Description: fmt.Sprintf("First day (YYYY-MM-DD, UTC) of the export, defaults to %v days before the end", incomeExportDefaultDays-1),
+		Schema:      &openapi.Schema{Type: "string", Format: "date"},
+	},
+	{
+		Name:        "end",
+		In:          "query",
+		Description: "Last day (YYYY-MM-DD, UTC) of the export, defaults to today",
+		Schema:      &openapi.Schema{Type: "string", Format: "date"},
+	},
+	{
+		Name:        "currency",
+		In:          "query",
+		Description: "Currency of the price and fiat income, e.g. USD",
+		Schema:      &openapi.Schema{Type: "string"},
+	},
+	{
+		Name:        "format",
+		In:          "query",
+		Description: "Format of the response, csv returns the amounts in ETH",
+		Schema:      &openapi.Schema{Type: "string", Enum: []string{"json", "csv"}},
+	},
+}
+
+var apiV2Operations = []*apiV2Operation{
+	{
+		Path:        "/validator/{indexOrPubkey}",
+		ID:          "getValidators",
+		Summary:     "Get up to 100 validators by their index or pubkey",
+		Description: "Returns the validators sorted by index, unknown validators are omitted",
+		Tag:         "Validator",
+		Params:      []*openapi.Parameter{apiV2ValidatorParam},
+		Response:    types.ApiV2Validator{},
+		Handler:     ApiV2Validators,
+	},
+	{
+		Path:     "/validator/{indexOrPubkey}/performance",
+		ID:       "getValidatorsPerformance",
+		Summary:  "Get the current performance of up to 100 validators",
+		Tag:      "Validator",
+		Params:   []*openapi.Parameter{apiV2ValidatorParam},
+		Response: types.ApiV2ValidatorPerformance{},
+		Handler:  ApiV2ValidatorPerformance,
+	},
+	{
+		Path:        "/validator/{indexOrPubkey}/balancehistory",
+		ID:          "getValidatorsBalanceHistory",
+		Summary:     "Get the balance history of up to 100 validators",
+		Description: "Returns the balances sorted by epoch (latest first) and validator index",
+		Tag:         "Validator",
+		Params:      append([]*openapi.Parameter{apiV2ValidatorParam}, apiV2PaginationParams...),
+		Response:    types.ApiV2ValidatorBalance{},
+		Paginated:   true,
+		Handler:     ApiV2ValidatorBalanceHistory,
+	},
+	{
+		Path:        "/validator/{indexOrPubkey}/attestations",
+		ID:          "getValidatorsAttestations",
+		Summary:     "Get the attestation assignments of up to 100 validators",
+		Description: "Returns the attestation assignments sorted by epoch (latest first) and validator index",
+		Tag:         "Validator",
+		Params:      append([]*openapi.Parameter{apiV2ValidatorParam}, apiV2PaginationParams...),
+		Response:    types.ApiV2ValidatorAttestation{},
+		Paginated:   true,
+		Handler:     ApiV2ValidatorAttestations,
+	},
+	{
+		Path:        "/validator/{indexOrPubkey}/proposals",
+		ID:          "getValidatorsProposals",
+		Summary:     "Get the proposal assignments of up to 100 validators",
+		Description: "Returns the proposal assignments sorted by slot (latest first) and validator index",
+		Tag:         "Validator",
+		Params:      append([]*openapi.Parameter{apiV2ValidatorParam}, apiV2PaginationParams...),
+		Response:    types.ApiV2ValidatorProposal{},
+		Paginated:   true,
+		Handler:     ApiV2ValidatorProposals,
+	},
+	{
+		Path:        "/validator/{indexOrPubkey}/rewards",
+		ID:          "getValidatorsRewards",
+		Summary:     "Get the rewards and penalties per epoch of up to 100 validators",
+		Description: "Returns the rewards breakdown sorted by epoch (latest first) and validator index. All amounts are in Gwei, the rewards of an epoch are available once the following epoch has been finalized.",
+		Tag:         "Validator",
+		Params:      append([]*openapi.Parameter{apiV2ValidatorParam}, apiV2PaginationParams...),
+		Response:    types.ValidatorRewards{},
+		Paginated:   true,
+		Handler:     ApiV2ValidatorRewards,
+	},
+	{
+		Path:        "/validator/{indexOrPubkey}/effectiveness",
+		ID:          "getValidatorsEffectiveness",
+		Summary:     "Get the attestation effectiveness per epoch of up to 100 validators",
+		Description: "Returns the inclusion and the correctness of the source, target and head votes of the attestations sorted by epoch (latest first) and validator index. The attestations of an epoch are evaluated once the following epoch has been finalized.",
+		Tag:         "Validator",
+		Params:      append([]*openapi.Parameter{apiV2ValidatorParam}, apiV2PaginationParams...),
+		Response:    types.AttestationEffectiveness{},
+		Paginated:   true,
+		Handler:     ApiV2ValidatorEffectiveness,
+	},
+	{
+		Path:        "/validator/{indexOrPubkey}/dailyincome",
+		ID:          "getValidatorsDailyIncome",
+		Summary:     "Get the daily income of up to 100 validators",
+		Description: fmt.Sprintf("Returns the balances, deposits and income per day (UTC) sorted by day and validator index for up to %v days. Amounts are in Gwei, the fiat values are only available if prices have been imported for the currency.", incomeExportMaxDays),
+		Tag:         "Validator",
+		Params:      append([]*openapi.Parameter{apiV2ValidatorParam}, apiV2DailyIncomeParams...),
+		Response:    types.ValidatorDailyIncome{},
+		CSV:         true,
+		Handler:     ApiV2ValidatorDailyIncome,
+	},
+	{
+		Path:        "/validator/eth1/{address}/dailyincome",
+		ID:          "getEth1AddressDailyIncome",
+		Summary:     "Get the daily income of the validators deposited by an eth1 address",
+		Description: fmt.Sprintf("Returns the balances, deposits and income per day (UTC) sorted by day and validator index. The export is limited to %v rows, amounts are in Gwei.", incomeExportMaxRows),
+		Tag:         "Validator",
+		Params: append([]*openapi.Parameter{{
+			Name:        "address",
+			In:          "path",
+			Description: "Hex encoded eth1 address",
+			Required:    true,
+			Schema:      &openapi.Schema{Type: "string"},
+		}}, apiV2DailyIncomeParams...),
+		Response: types.ValidatorDailyIncome{},
+		CSV:      true,
+		Handler:  ApiV2Eth1AddressDailyIncome,
+	},
+}
+
+// RegisterApiV2Routes adds the /api/v2 routes and the route of the OpenAPI spec to the router
+func RegisterApiV2Routes(router *mux.Router) {
+	for _, op := range apiV2Operations {
+		router.HandleFunc(op.Path, op.Handler).Methods("GET", "OPTIONS")
+	}
+	router.HandleFunc("/openapi.json", ApiV2OpenAPI).Methods("GET", "OPTIONS")
+}
+
+var apiV2Spec struct {
+	once sync.Once
+	json []byte
+}
+
+// ApiV2OpenAPI returns the OpenAPI spec of /api/v2, it is generated from the response structs of the routes
+func ApiV2OpenAPI(w http.ResponseWriter, r *http.Request) {
+	apiV2Spec.once.Do(func() {
+		var err error
+		apiV2Spec.json, err = json.MarshalIndent(apiV2OpenAPISpec(), "", "  ")
+		if err != nil {
+			logger.Errorf("error serializing the api v2 spec: %v", err)
+		}
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(apiV2Spec.json)
+}
+
+func apiV2OpenAPISpec() *openapi.Spec {
+	spec := openapi.New(openapi.Info{
+		Title:       "Beaconcha.in ETH2 API",
+		Version:     "2.0",
+		Description: "Responses of the api contain the status and a data array. Paginated routes return a next_cursor as long as more results are available. Errors are returned with a 4xx or 5xx status code.",
+	})
+
+	errorResponse := &openapi.Response{
+		Description: "Error",
+		Content: map[string]*openapi.MediaType{
+			"application/json": {Schema: &openapi.Schema{
+				Type: "object",
+				Properties: map[string]*openapi.Schema{
+					"status": {Type: "string", Description: "ERROR: followed by the error message"},
+					"data":   {Type: "array", Items: &openapi.Schema{}},
+				},
+				Required: []string{"status", "data"},
+			}},
+		},
+	}
+
+	for _, op := range apiV2Operations {
+		envelope := &openapi.Schema{
+			Type: "object",
+			Properties: map[string]*openapi.Schema{
+				"status": {Type: "string", Enum: []string{"OK"}},
+				"data":   {Type: "array", Items: spec.SchemaOf(op.Response)},
+			},
+			Required: []string{"status", "data"},
+		}
+		if op.Paginated {
+			envelope.Properties["next_cursor"] = &openapi.Schema{Type: "string", Description: "Cursor of the next page, omitted on the last page"}
+		}
+		content := map[string]*openapi.MediaType{"application/json": {Schema: envelope}}
+		if op.CSV {
+			content["text/csv"] = &openapi.MediaType{Schema: &openapi.Schema{Type: "string"}}
+		}
+
+		spec.AddOperation("/api/v2"+op.Path, "get", &openapi.Operation{
+			Summary:     op.Summary,
+			Description: op.Description,
+			OperationID: op.ID,
+			Tags:        []string{op.Tag},
+			Parameters:  op.Params,
+			Responses: map[string]*openapi.Response{
+				"200": {
+					Description: "OK",
+					Content:     content,
+				},
+				"default": errorResponse,
+			},
+		})
+	}
+	return spec
+}
+
+// ApiV2Validators returns up to 100 validators by their index or pubkey
+func ApiV2Validators(w http.ResponseWriter, r *http.Request) {
+	indices, ok := parseApiV2ValidatorIndices(w, r)
+	if !ok {
+		return
+	}
+
+	validators, err := db.GetApiV2Validators(indices)
+	if err != nil {
+		logger.Errorf("error retrieving validators for api v2 %v route: %v", r.URL.String(), err)
+		sendApiV2Error(w, r, http.StatusInternalServerError, "could not retrieve db results")
+		return
+	}
+	sendApiV2Response(w, r, validators, "")
+}
+
+// ApiV2ValidatorPerformance returns the current performance of up to 100 validators
+func ApiV2ValidatorPerformance(w http.ResponseWriter, r *http.Request) {
+	indices, ok := parseApiV2ValidatorIndices(w, r)
+	if !ok {
+		return
+	}
+
+	performances, err := db.GetApiV2ValidatorPerformances(indices)
+	if err != nil {
+		logger.Errorf("error retrieving validator performance for api v2 %v route: %v", r.URL.String(), err)
+		sendApiV2Error(w, r, http.StatusInternalServerError, "could not retrieve db results")
+		return
+	}
+	sendApiV2Response(w, r, performances, "")
+}
+
+// ApiV2ValidatorBalanceHistory returns a page of the balance history of up to 100 validators
+func ApiV2ValidatorBalanceHistory(w http.ResponseWriter, r *http.Request) {
+	indices, ok := parseApiV2ValidatorIndices(w, r)
+	if !ok {
+		return
+	}
+	after, limit, ok := parseApiV2Pagination(w, r)
+	if !ok {
+		return
+	}
+
+	// one additional row is requested to know whether there is a next page
+	balances, err := db.GetApiV2ValidatorBalances(indices, after, limit+1)
+	if err != nil {
+		logger.Errorf("error retrieving validator balances for api v2 %v route: %v", r.URL.String(), err)
+		sendApiV2Error(w, r, http.StatusInternalServerError, "could not retrieve db results")
+		return
+	}
+
+	next := ""
+	if uint64(len(balances)) > limit {
+		balances = balances[:limit]
+		last := balances[len(balances)-1]
+		next = encodeApiV2Cursor(&types.ApiV2Cursor{Key: last.Epoch, ValidatorIndex: last.ValidatorIndex})
+	}
+	sendApiV2Response(w, r, balances, next)
+}
+
+// ApiV2ValidatorAttestations returns a page of the attestation assignments of up to 100 validators
+func ApiV2ValidatorAttestations(w http.ResponseWriter, r *http.Request) {
+	indices, ok := parseApiV2ValidatorIndices(w, r)
+	if !ok {
+		return
+	}
+	after, limit, ok := parseApiV2Pagination(w, r)
+	if !ok {
+		return
+	}
+
+	attestations, err := db.GetApiV2ValidatorAttestations(indices, after, limit+1)
+	if err != nil {
+		logger.Errorf("error retrieving validator attestations for api v2 %v route: %v", r.URL.String(), err)
+		sendApiV2Error(w, r, http.StatusInternalServerError, "could not retrieve db results")
+		return
+	}
+
+	next := ""
+	if uint64(len(attestations)) > limit {
+		attestations = attestations[:limit]
+		last := attestations[len(attestations)-1]
+		next = encodeApiV2Cursor(&types.ApiV2Cursor{Key: last.Epoch, ValidatorIndex: last.ValidatorIndex})
+	}
+	sendApiV2Response(w, r, attestations, next)
+}
+
+// ApiV2ValidatorProposals returns a page of the proposal assignments of up to 100 validators
+func ApiV2ValidatorProposals(w http.ResponseWriter, r *http.Request) {
+	indices, ok := parseApiV2ValidatorIndices(w, r)
+	if !ok {
+		return
+	}
+	after, limit, ok := parseApiV2Pagination(w, r)
+	if !ok {
+		return
+	}
+
+	proposals, err := db.GetApiV2ValidatorProposals(indices, after, limit+1)
+	if err != nil {
+		logger.Errorf("error retrieving validator proposals for api v2 %v route: %v", r.URL.String(), err)
+		sendApiV2Error(w, r, http.StatusInternalServerError, "could not retrieve db results")
+		return
+	}
+
+	next := ""
+	if uint64(len(proposals)) > limit {
+		proposals = proposals[:limit]
+		last := proposals[len(proposals)-1]
+		next = encodeApiV2Cursor(&types.ApiV2Cursor{Key: last.Slot, ValidatorIndex: last.ValidatorIndex})
+	}
+	sendApiV2Response(w, r, proposals, next)
+}
+
+// ApiV2ValidatorRewards returns a page of the rewards breakdown of up to 100 validators
+func ApiV2ValidatorRewards(w http.ResponseWriter, r *http.Request) {
+	indices, ok := parseApiV2ValidatorIndices(w, r)
+	if !ok {
+		return
+	}
+	after, limit, ok := parseApiV2Pagination(w, r)
+	if !ok {
+		return
+	}
+
+	rewards, err := db.GetValidatorRewards(indices, after, limit+1)
+	if err != nil {
+		logger.Errorf("error retrieving validator rewards for api v2 %v route: %v", r.URL.String(), err)
+		sendApiV2Error(w, r, http.StatusInternalServerError, "could not retrieve db results")
+		return
+	}
+
+	next := ""
+	if uint64(len(rewards)) > limit {
+		rewards = rewards[:limit]
+		last := rewards[len(rewards)-1]
+		next = encodeApiV2Cursor(&types.ApiV2Cursor{Key: last.Epoch, ValidatorIndex: last.ValidatorIndex})
+	}
+	sendApiV2Response(w, r, rewards, next)
+}
+
+// ApiV2ValidatorEffectiveness returns a page of the attestation effectiveness of up to 100 validators
+func ApiV2ValidatorEffectiveness(w http.ResponseWriter, r *http.Request) {
+	indices, ok := parseApiV2ValidatorIndices(w, r)
+	if !ok {
+		return
+	}
+	after, limit, ok := parseApiV2Pagination(w, r)
+	if !ok {
+		return
+	}
+
+	effectiveness, err := db.GetAttestationEffectiveness(indices, after, limit+1)
+	if err != nil {
+		logger.Errorf("error retrieving attestation effectiveness for api v2 %v route: %v", r.URL.String(), err)
+		sendApiV2Error(w, r, http.StatusInternalServerError, "could not retrieve db results")
+		return
+	}
+
+	next := ""
+	if uint64(len(effectiveness)) > limit {
+		effectiveness = effectiveness[:limit]
+		last := effectiveness[len(effectiveness)-1]
+		next = encodeApiV2Cursor(&types.ApiV2Cursor{Key: last.Epoch, ValidatorIndex: last.ValidatorIndex})
+	}
+	sendApiV2Response(w, r, effectiveness, next)
+}
+
+// parseApiV2ValidatorIndices resolves the indices and pubkeys of the indexOrPubkey path parameter to validator
+// indices, an error response is sent if the parameter is invalid
+func parseApiV2ValidatorIndices(w http.ResponseWriter, r *http.Request) ([]uint64, bool) {
+	queryIndices, queryPubkeys, err := parseApiValidatorParam(mux.Vars(r)["indexOrPubkey"])
+	if err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+
+	if len(queryPubkeys) == 0 {
+		return queryIndices, true
+	}
+
+	indices, err := db.GetValidatorIndices(queryIndices, queryPubkeys)
+	if err != nil {
+		logger.Errorf("error retrieving validator indices for api v2 %v route: %v", r.URL.String(), err)
+		sendApiV2Error(w, r, http.StatusInternalServerError, "could not retrieve db results")
+		return nil, false
+	}
+	return indices, true
+}
+
+// parseApiV2Pagination returns the cursor and limit query parameters, an error response is sent if they are invalid
+func parseApiV2Pagination(w http.ResponseWriter, r *http.Request) (*types.ApiV2Cursor, uint64, bool) {
+	q := r.URL.Query()
+
+	limit := uint64(apiV2DefaultLimit)
+	if q.Get("limit") != "" {
+		l, err := strconv.ParseUint(q.Get("limit"), 10, 64)
+		if err != nil || l == 0 || l > apiV2MaxLimit {
+			sendApiV2Error(w, r, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %v", apiV2MaxLimit))
+			return nil, 0, false
+		}
+		limit = l
+	}
+
+	if q.Get("cursor") == "" {
+		return nil, limit, true
+	}
+	cursor, err := decodeApiV2Cursor(q.Get("cursor"))
+	if err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, "invalid cursor provided")
+		return nil, 0, false
+	}
+	return cursor, limit, true
+}
+
+// encodeApiV2Cursor returns the opaque representation of the cursor that is passed to the client
+func encodeApiV2Cursor(cursor *types.ApiV2Cursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", cursor.Key, cursor.ValidatorIndex)))
+}
+
+func decodeApiV2Cursor(s string) (*types.ApiV2Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(string(b), ":")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid cursor %q", b)
+	}
+	// the values are compared with bigint columns, larger values are rejected as invalid cursors
+	cursor := &types.ApiV2Cursor{}
+	cursor.Key, err = strconv.ParseUint(parts[0], 10, 63)
+	if err != nil {
+		return nil, err
+	}
+	cursor.ValidatorIndex, err = strconv.ParseUint(parts[1], 10, 63)
+	if err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+// sendApiV2Response sends the data, which has to be a slice, as an OK response
+func sendApiV2Response(w http.ResponseWriter, r *http.Request, data interface{}, nextCursor string) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(&types.ApiV2Response{Status: "OK", Data: data, NextCursor: nextCursor})
+	if err != nil {
+		logger.Errorf("error serializing json data for api v2 %v route: %v", r.URL.String(), err)
+	}
+}
+
+func sendApiV2Error(w http.ResponseWriter, r *http.Request, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(&types.ApiV2Response{Status: "ERROR: " + message, Data: []interface{}{}})
+	if err != nil {
+		logger.Errorf("error serializing json error for api v2 %v route: %v", r.URL.String(), err)
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 	"html/template"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
@@ -204,13 +205,14 @@ func LoginPost(w http.ResponseWriter, r *http.Request) {
 	pwd := r.FormValue("password")
 
 	user := struct {
-		ID        uint64 `db:"id"`
-		Email     string `db:"email"`
-		Password  string `db:"password"`
-		Confirmed bool   `db:"email_confirmed"`
+		ID          uint64 `db:"id"`
+		Email       string `db:"email"`
+		Password    string `db:"password"`
+		Confirmed   bool   `db:"email_confirmed"`
+		TOTPEnabled bool   `db:"totp_enabled"`
 	}{}
 
-	err = db.FrontendDB.Get(&user, "SELECT id, email, password, email_confirmed FROM users WHERE email = $1", email)
+	err = db.FrontendDB.Get(&user, "SELECT id, email, password, email_confirmed, totp_enabled FROM users WHERE email = $1", email)
 	if err != nil {
 		logger.Errorf("error retrieving password for user %v: %v", email, err)
 		session.AddFlash("Error: Invalid email or password!")
@@ -234,14 +236,38 @@ func LoginPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if user.TOTPEnabled {
+		// the session is authenticated once the second factor has been verified in LoginTwoFactorPost
+		session.Values["authenticated"] = false
+		delete(session.Values, "user_id")
+		session.Values["totp_user_id"] = user.ID
+		session.Values["totp_ts"] = time.Now().Unix()
+		session.Save(r, w)
+		http.Redirect(w, r, "/login/2fa", http.StatusSeeOther)
+		return
+	}
+
 	session.Values["authenticated"] = true
 	session.Values["user_id"] = user.ID
 	// session.AddFlash("Successfully logged in")
 
+	redirect := loginRedirect(session)
+
 	session.Save(r, w)
 	logger.Println("login succeeded with session", session.Values["authenticated"], session.Values["user_id"])
 	// Index(w, r)
-	http.Redirect(w, r, "/user/notifications", http.StatusSeeOther)
+	http.Redirect(w, r, redirect, http.StatusSeeOther)
+}
+
+// loginRedirect returns the page a user is redirected to after the login, this is the page of the user area that
+// required the login if there was one
+func loginRedirect(session *sessions.Session) string {
+	redirect := "/user/notifications"
+	if loginRedirect, ok := session.Values["login_redirect"].(string); ok && strings.HasPrefix(loginRedirect, "/user/") {
+		redirect = loginRedirect
+	}
+	delete(session.Values, "login_redirect")
+	return redirect
 }
 
 // Logout handles ending the user session.
@@ -254,6 +280,8 @@ func Logout(w http.ResponseWriter, r *http.Request) {
 	}
 	session.Values["authenticated"] = false
 	delete(session.Values, "user_id")
+	delete(session.Values, "totp_user_id")
+	delete(session.Values, "totp_ts")
 	session.Save(r, w)
 
 	http.Redirect(w, r, "/", http.StatusSeeOther)
@@ -279,8 +307,9 @@ func ResetPassword(w http.ResponseWriter, r *http.Request) {
 		ID             uint64 `db:"id"`
 		EmailConfirmed bool   `db:"email_confirmed"`
 		Email          string `db:"email"`
+		TOTPEnabled    bool   `db:"totp_enabled"`
 	}{}
-	err = db.FrontendDB.Get(&dbUser, "SELECT id, email_confirmed, email FROM users WHERE password_reset_hash = $1", hash)
+	err = db.FrontendDB.Get(&dbUser, "SELECT id, email_confirmed, email, totp_enabled FROM users WHERE password_reset_hash = $1", hash)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			session.AddFlash("Error: Invalid reset link, please retry.")
@@ -312,8 +341,13 @@ func ResetPassword(w http.ResponseWriter, r *http.Request) {
 	user.Authenticated = true
 	user.UserID = dbUser.ID
 
-	session.Values["authenticated"] = true
-	session.Values["user_id"] = user.UserID
+	if dbUser.TOTPEnabled {
+		// the reset link only allows to set a new password, the login still requires the second factor
+		session.Values["password_reset_user_id"] = user.UserID
+	} else {
+		session.Values["authenticated"] = true
+		session.Values["user_id"] = user.UserID
+	}
 
 	session.Save(r, w)
 
@@ -356,6 +390,11 @@ func ResetPasswordPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if resetUserID, ok := session.Values["password_reset_user_id"].(uint64); ok {
+		user.Authenticated = true
+		user.UserID = resetUserID
+	}
+
 	if !user.Authenticated {
 		session.AddFlash("Error: You are not authenticated (or did not use the correct reset-link).")
 		session.Save(r, w)
@@ -393,6 +432,7 @@ func ResetPasswordPost(w http.ResponseWriter, r *http.Request) {
 
 	session.Values["authenticated"] = false
 	delete(session.Values, "user_id")
+	delete(session.Values, "password_reset_user_id")
 
 	session.AddFlash("Your password has been updated successfully, please log in again!")
 
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"eth2-exporter/cache"
 	"eth2-exporter/services"
 	"eth2-exporter/types"
 	"eth2-exporter/utils"
@@ -8,6 +9,9 @@ import (
 	"fmt"
 	"html/template"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -17,6 +21,17 @@ var chartsTemplate = template.Must(template.New("charts").Funcs(utils.GetTemplat
 var genericChartTemplate = template.Must(template.New("chart").Funcs(utils.GetTemplateFuncs()).ParseFiles("templates/layout.html", "templates/genericchart.html"))
 var chartsUnavailableTemplate = template.Must(template.New("chart").Funcs(utils.GetTemplateFuncs()).ParseFiles("templates/layout.html", "templates/chartsunavailable.html"))
 
+// chartImageCache holds the chart images rendered by the api
+var chartImageCache = newChartImageCache(1000)
+
+func newChartImageCache(size int) *cache.LRUCache {
+	c, err := cache.NewLRUCache(size)
+	if err != nil {
+		logger.Fatalf("error creating chart image cache: %v", err)
+	}
+	return c
+}
+
 // Charts uses a go template for presenting the page to show charts
 func Charts(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
@@ -117,6 +132,24 @@ func GenericChart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if hasChartQuery(r.URL.Query()) {
+		query, err := parseChartQuery(chartVar, r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		chartData, err = services.GetChartData(chartVar, query)
+		if err == services.ErrChartRangeNotSupported || err == services.ErrTooManyChartPoints {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			logger.Errorf("error retrieving chart data for %v route: %v", r.URL.String(), err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	data.Meta.Title = fmt.Sprintf("%v - %v Chart - beaconcha.in - %v", chartData.Title, utils.Config.Frontend.SiteName, time.Now().Year())
 	data.Meta.Path = "/charts/" + chartVar
 	data.Data = chartData
@@ -128,3 +161,61 @@ func GenericChart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// ChartQueryRateLimit applies the rate limit to the chart pages that are requested with a custom range or bucket.
+// These are queried from the database on every request while the default charts come from the charts updater.
+func ChartQueryRateLimit(limit mux.MiddlewareFunc) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		limited := limit(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if hasChartQuery(r.URL.Query()) {
+				limited.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// hasChartQuery returns true if a time range or bucket size has been requested
+func hasChartQuery(q url.Values) bool {
+	return q.Get("from") != "" || q.Get("to") != "" || q.Get("bucket") != ""
+}
+
+// parseChartQuery parses the from and to (unix timestamps) and bucket (epoch, hour, day or week) query parameters of
+// a chart, missing parameters default to the query of the charts page
+func parseChartQuery(chart string, q url.Values) (*types.ChartQuery, error) {
+	query, err := services.DefaultChartQuery(chart)
+	if err != nil {
+		return nil, err
+	}
+
+	if q.Get("from") != "" {
+		from, err := strconv.ParseInt(q.Get("from"), 10, 64)
+		if err != nil || from < 0 {
+			return nil, fmt.Errorf("invalid from timestamp provided")
+		}
+		query.From = time.Unix(from, 0).UTC()
+	}
+	if q.Get("to") != "" {
+		to, err := strconv.ParseInt(q.Get("to"), 10, 64)
+		if err != nil || to < 0 {
+			return nil, fmt.Errorf("invalid to timestamp provided")
+		}
+		query.To = time.Unix(to, 0).UTC()
+	}
+	if !query.From.Before(query.To) {
+		return nil, fmt.Errorf("the from timestamp must be before the to timestamp")
+	}
+
+	if q.Get("bucket") != "" {
+		if !services.IsChartBucket(q.Get("bucket")) {
+			return nil, fmt.Errorf("invalid bucket provided, supported buckets are %v", strings.Join(services.ChartBuckets, ", "))
+		}
+		if query.Bucket != "" {
+			query.Bucket = q.Get("bucket")
+		}
+	}
+
+	return query, nil
+}
@@ -138,6 +138,48 @@ func GetValidatorEarnings(validators []uint64) (*types.ValidatorEarnings, error)
 	return earnings, nil
 }
 
+// GetValidatorRewardsBreakdown will return the rewards and penalties of selected validators over the last day, week and month
+func GetValidatorRewardsBreakdown(validators []uint64) (*types.ValidatorRewardsBreakdown, error) {
+	now := utils.EpochToTime(services.LatestEpoch())
+
+	breakdown := &types.ValidatorRewardsBreakdown{}
+	var err error
+	breakdown.LastDay, err = db.GetValidatorRewardsSum(validators, utils.TimeToEpoch(now.Add(time.Hour*24*1*-1)))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving rewards of last day: %w", err)
+	}
+	breakdown.LastWeek, err = db.GetValidatorRewardsSum(validators, utils.TimeToEpoch(now.Add(time.Hour*24*7*-1)))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving rewards of last week: %w", err)
+	}
+	breakdown.LastMonth, err = db.GetValidatorRewardsSum(validators, utils.TimeToEpoch(now.Add(time.Hour*24*31*-1)))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving rewards of last month: %w", err)
+	}
+	return breakdown, nil
+}
+
+// GetValidatorEffectivenessBreakdown will return the attestation effectiveness of selected validators over the last day, week and month
+func GetValidatorEffectivenessBreakdown(validators []uint64) (*types.ValidatorEffectivenessBreakdown, error) {
+	now := utils.EpochToTime(services.LatestEpoch())
+
+	breakdown := &types.ValidatorEffectivenessBreakdown{}
+	var err error
+	breakdown.LastDay, err = db.GetValidatorEffectiveness(validators, utils.TimeToEpoch(now.Add(time.Hour*24*1*-1)))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving effectiveness of last day: %w", err)
+	}
+	breakdown.LastWeek, err = db.GetValidatorEffectiveness(validators, utils.TimeToEpoch(now.Add(time.Hour*24*7*-1)))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving effectiveness of last week: %w", err)
+	}
+	breakdown.LastMonth, err = db.GetValidatorEffectiveness(validators, utils.TimeToEpoch(now.Add(time.Hour*24*31*-1)))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving effectiveness of last month: %w", err)
+	}
+	return breakdown, nil
+}
+
 // LatestState will return common information that about the current state of the eth2 chain
 func LatestState(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
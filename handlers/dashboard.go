@@ -410,3 +410,57 @@ func DashboardDataEarnings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// DashboardDataRewards returns the rewards and penalties of the validators over the last day, week and month as json
+func DashboardDataRewards(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+
+	queryValidators, err := parseValidatorsFromQueryString(q.Get("validators"))
+	if err != nil {
+		http.Error(w, "Invalid query", 400)
+		return
+	}
+
+	rewards, err := GetValidatorRewardsBreakdown(queryValidators)
+	if err != nil {
+		logger.WithError(err).WithField("route", r.URL.String()).Errorf("error retrieving validator rewards")
+		http.Error(w, "Internal server error", 503)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(rewards)
+	if err != nil {
+		logger.WithError(err).WithField("route", r.URL.String()).Errorf("error enconding json response")
+		http.Error(w, "Internal server error", 503)
+		return
+	}
+}
+
+// DashboardDataEffectiveness returns the attestation effectiveness of the validators over the last day, week and month as json
+func DashboardDataEffectiveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+
+	queryValidators, err := parseValidatorsFromQueryString(q.Get("validators"))
+	if err != nil {
+		http.Error(w, "Invalid query", 400)
+		return
+	}
+
+	effectiveness, err := GetValidatorEffectivenessBreakdown(queryValidators)
+	if err != nil {
+		logger.WithError(err).WithField("route", r.URL.String()).Errorf("error retrieving validator effectiveness")
+		http.Error(w, "Internal server error", 503)
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(effectiveness)
+	if err != nil {
+		logger.WithError(err).WithField("route", r.URL.String()).Errorf("error enconding json response")
+		http.Error(w, "Internal server error", 503)
+		return
+	}
+}
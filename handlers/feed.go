@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"eth2-exporter/feed"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ApiFeedWebSocket godoc
+// @Summary Push feed of new blocks, orphaned blocks, epochs, finality changes and slashings via websocket
+// @Tags Feed
+// @Description Streams the events as json text messages of the form {"topic": "block", "validators": [1], "data": {...}}.
+// @Description The initial filter is taken from the query parameters, it can be replaced by sending {"topics": ["block"], "validators": [1, 2]}.
+// @Description The validator filter only applies to events that concern validators (block, orphaned_block and slashing).
+// @Param  topics query string false "Comma separated list of topics: block, orphaned_block, epoch, finality, slashing (default all)"
+// @Param  validators query string false "Comma separated list of up to 100 validator indices (default all)"
+// @Success 101 {object} string
+// @Failure 429 {object} string "Too many open connections of the client or of all clients"
+// @Router /api/feed/ws [get]
+func ApiFeedWebSocket(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFeedFilter(r)
+	if err != nil {
+		sendFeedFilterError(w, r, err)
+		return
+	}
+
+	client := clientIP(r)
+	err = feed.AcquireConnection(client)
+	if err != nil {
+		sendFeedConnectionError(w, r, err)
+		return
+	}
+	defer feed.ReleaseConnection(client)
+	feed.ServeWebSocket(w, r, filter)
+}
+
+// ApiFeedEvents godoc
+// @Summary Push feed of new blocks, orphaned blocks, epochs, finality changes and slashings via server-sent events
+// @Tags Feed
+// @Description Streams the events as server-sent events, the event name is the topic and the data is the json event.
+// @Description The validator filter only applies to events that concern validators (block, orphaned_block and slashing).
+// @Produce  text/event-stream
+// @Param  topics query string false "Comma separated list of topics: block, orphaned_block, epoch, finality, slashing (default all)"
+// @Param  validators query string false "Comma separated list of up to 100 validator indices (default all)"
+// @Success 200 {object} string
+// @Failure 429 {object} string "Too many open connections of the client or of all clients"
+// @Router /api/feed/events [get]
+func ApiFeedEvents(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseFeedFilter(r)
+	if err != nil {
+		sendFeedFilterError(w, r, err)
+		return
+	}
+
+	client := clientIP(r)
+	err = feed.AcquireConnection(client)
+	if err != nil {
+		sendFeedConnectionError(w, r, err)
+		return
+	}
+	defer feed.ReleaseConnection(client)
+	feed.ServeEventStream(w, r, filter)
+}
+
+// parseFeedFilter returns the filter given by the topics and validators query parameters
+func parseFeedFilter(r *http.Request) (*feed.Filter, error) {
+	q := r.URL.Query()
+
+	var topics []string
+	if q.Get("topics") != "" {
+		topics = strings.Split(q.Get("topics"), ",")
+	}
+
+	var validators []uint64
+	if q.Get("validators") != "" {
+		for _, v := range strings.Split(q.Get("validators"), ",") {
+			index, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid validator index %v", v)
+			}
+			validators = append(validators, index)
+		}
+	}
+
+	return feed.NewFilter(topics, validators)
+}
+
+func sendFeedFilterError(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	sendErrorResponse(json.NewEncoder(w), r.URL.String(), err.Error())
+}
+
+func sendFeedConnectionError(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	sendErrorResponse(json.NewEncoder(w), r.URL.String(), err.Error())
+}
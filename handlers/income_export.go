@@ -0,0 +1,336 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"eth2-exporter/db"
+	"eth2-exporter/services"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	incomeExportDefaultDays = 31
+	incomeExportMaxDays     = 366
+	// limits the number of rows of an export, e.g. 100 validators for a year
+	incomeExportMaxRows = 100 * incomeExportMaxDays
+)
+
+var currencyRE = regexp.MustCompile("^[a-zA-Z]{3,10}$")
+
+// incomeExportQuery holds the parsed query parameters of an income export
+type incomeExportQuery struct {
+	Start    time.Time
+	End      time.Time
+	Currency string
+	Format   string
+}
+
+// Days returns the number of days of the export
+func (q *incomeExportQuery) Days() int {
+	return int(q.End.Sub(q.Start).Hours()/24) + 1
+}
+
+// parseIncomeExportQuery parses the start, end (YYYY-MM-DD, UTC), currency and format (csv or json) query parameters.
+// By default the last 31 days are exported as csv.
+func parseIncomeExportQuery(q url.Values, defaultFormat string) (*incomeExportQuery, error) {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	query := &incomeExportQuery{
+		Start:    today.AddDate(0, 0, -incomeExportDefaultDays+1),
+		End:      today,
+		Currency: strings.ToUpper(q.Get("currency")),
+		Format:   strings.ToLower(q.Get("format")),
+	}
+
+	var err error
+	if q.Get("end") != "" {
+		query.End, err = time.Parse("2006-01-02", q.Get("end"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid end date, the format is YYYY-MM-DD")
+		}
+		if query.End.After(today) {
+			query.End = today
+		}
+		query.Start = query.End.AddDate(0, 0, -incomeExportDefaultDays+1)
+	}
+	if q.Get("start") != "" {
+		query.Start, err = time.Parse("2006-01-02", q.Get("start"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid start date, the format is YYYY-MM-DD")
+		}
+	}
+	if query.Start.After(query.End) {
+		return nil, fmt.Errorf("the start date must not be after the end date")
+	}
+	if query.Days() > incomeExportMaxDays {
+		return nil, fmt.Errorf("only up to %v days can be exported at once", incomeExportMaxDays)
+	}
+
+	if query.Currency != "" && !currencyRE.MatchString(query.Currency) {
+		return nil, fmt.Errorf("invalid currency provided")
+	}
+
+	if query.Format == "" {
+		query.Format = defaultFormat
+	}
+	if query.Format != "csv" && query.Format != "json" {
+		return nil, fmt.Errorf("invalid format provided, supported formats are csv and json")
+	}
+
+	return query, nil
+}
+
+// firstEpochAtOrAfter returns the first epoch that starts at or after the time
+func firstEpochAtOrAfter(ts time.Time) uint64 {
+	epoch := uint64(utils.TimeToEpoch(ts))
+	if utils.EpochToTime(epoch).Before(ts) {
+		epoch++
+	}
+	return epoch
+}
+
+// GetValidatorDailyIncome returns the income of the validators for each day (UTC) of the query sorted by day and
+// validator index. The balance at the start of a day is the balance at the first epoch of the day, the balance of the
+// current day ends at the latest epoch. Days at which a validator did not exist yet are omitted.
+func GetValidatorDailyIncome(indices []uint64, query *incomeExportQuery) ([]*types.ValidatorDailyIncome, error) {
+	days := query.Days()
+	if len(indices) == 0 {
+		return []*types.ValidatorDailyIncome{}, nil
+	}
+
+	latestEpoch := services.LatestEpoch()
+	boundaries := make([]uint64, days+1)
+	for i := range boundaries {
+		boundaries[i] = firstEpochAtOrAfter(query.Start.AddDate(0, 0, i))
+		if boundaries[i] > latestEpoch {
+			boundaries[i] = latestEpoch
+		}
+	}
+
+	balanceRows, err := db.GetValidatorBalancesAtEpochs(indices, boundaries)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving validator balances: %v", err)
+	}
+	balances := make(map[uint64]map[uint64]uint64, len(boundaries))
+	for _, b := range balanceRows {
+		if balances[b.Epoch] == nil {
+			balances[b.Epoch] = make(map[uint64]uint64, len(indices))
+		}
+		balances[b.Epoch][b.ValidatorIndex] = b.Balance
+	}
+
+	depositRows, err := db.GetValidatorDepositAmounts(indices, boundaries[0], boundaries[days])
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving validator deposits: %v", err)
+	}
+
+	// a deposit belongs to the day whose epochs contain the epoch of the deposit
+	deposits := make([]map[uint64]uint64, days)
+	for _, d := range depositRows {
+		day := sort.Search(days, func(i int) bool { return boundaries[i+1] > d.Epoch })
+		if day == days || d.Epoch < boundaries[day] {
+			continue
+		}
+		if deposits[day] == nil {
+			deposits[day] = make(map[uint64]uint64)
+		}
+		deposits[day][d.ValidatorIndex] += d.Amount
+	}
+
+	var prices map[string]float64
+	if query.Currency != "" {
+		prices, err = db.GetPrices(query.Currency, query.Start, query.End)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving prices: %v", err)
+		}
+	}
+
+	sortedIndices := make([]uint64, len(indices))
+	copy(sortedIndices, indices)
+	sort.Slice(sortedIndices, func(i, j int) bool { return sortedIndices[i] < sortedIndices[j] })
+
+	income := make([]*types.ValidatorDailyIncome, 0, days*len(indices))
+	for i := 0; i < days; i++ {
+		day := query.Start.AddDate(0, 0, i).Format("2006-01-02")
+		price, hasPrice := prices[day]
+		for _, index := range sortedIndices {
+			row := &types.ValidatorDailyIncome{
+				Day:            day,
+				ValidatorIndex: index,
+				StartBalance:   balances[boundaries[i]][index],
+				EndBalance:     balances[boundaries[i+1]][index],
+				Deposits:       deposits[i][index],
+			}
+			if row.StartBalance == 0 && row.EndBalance == 0 && row.Deposits == 0 {
+				continue
+			}
+			row.Income = int64(row.EndBalance) - int64(row.StartBalance) - int64(row.Deposits)
+			if hasPrice {
+				p := price
+				fiat := float64(row.Income) / 1e9 * price
+				row.Price = &p
+				row.IncomeFiat = &fiat
+			}
+			income = append(income, row)
+		}
+	}
+	return income, nil
+}
+
+// formatGweiAsEth formats an amount of Gwei as ETH without losing precision
+func formatGweiAsEth(gwei int64) string {
+	sign := ""
+	abs := uint64(gwei)
+	if gwei < 0 {
+		sign = "-"
+		abs = uint64(-gwei)
+	}
+	return fmt.Sprintf("%s%d.%09d", sign, abs/1e9, abs%1e9)
+}
+
+// writeDailyIncomeCSV writes the income as csv with the amounts in ETH, the price columns are only added if a
+// currency has been requested
+func writeDailyIncomeCSV(w http.ResponseWriter, filename string, currency string, income []*types.ValidatorDailyIncome) error {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	c := csv.NewWriter(w)
+	header := []string{"day", "validator_index", "start_balance_eth", "end_balance_eth", "deposits_eth", "income_eth"}
+	if currency != "" {
+		header = append(header, "price_"+strings.ToLower(currency), "income_"+strings.ToLower(currency))
+	}
+	err := c.Write(header)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range income {
+		record := []string{
+			row.Day,
+			strconv.FormatUint(row.ValidatorIndex, 10),
+			formatGweiAsEth(int64(row.StartBalance)),
+			formatGweiAsEth(int64(row.EndBalance)),
+			formatGweiAsEth(int64(row.Deposits)),
+			formatGweiAsEth(row.Income),
+		}
+		if currency != "" {
+			price, fiat := "", ""
+			if row.Price != nil {
+				price = strconv.FormatFloat(*row.Price, 'f', -1, 64)
+				fiat = strconv.FormatFloat(*row.IncomeFiat, 'f', 6, 64)
+			}
+			record = append(record, price, fiat)
+		}
+		err = c.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+	c.Flush()
+	return c.Error()
+}
+
+// DashboardDataIncomeExport exports the daily income of the validators of the dashboard as csv or json
+func DashboardDataIncomeExport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	queryValidators, err := parseValidatorsFromQueryString(q.Get("validators"))
+	if err != nil {
+		http.Error(w, "Invalid query", 400)
+		return
+	}
+
+	query, err := parseIncomeExportQuery(q, "csv")
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	income, err := GetValidatorDailyIncome(queryValidators, query)
+	if err != nil {
+		logger.WithError(err).WithField("route", r.URL.String()).Errorf("error retrieving validator income")
+		http.Error(w, "Internal server error", 503)
+		return
+	}
+
+	if query.Format == "csv" {
+		filename := fmt.Sprintf("income_%v_%v.csv", query.Start.Format("2006-01-02"), query.End.Format("2006-01-02"))
+		err = writeDailyIncomeCSV(w, filename, query.Currency, income)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(income)
+	}
+	if err != nil {
+		logger.WithError(err).WithField("route", r.URL.String()).Errorf("error writing income export")
+		return
+	}
+}
+
+// ApiV2ValidatorDailyIncome returns the daily income of up to 100 validators as json or csv
+func ApiV2ValidatorDailyIncome(w http.ResponseWriter, r *http.Request) {
+	indices, ok := parseApiV2ValidatorIndices(w, r)
+	if !ok {
+		return
+	}
+	sendApiV2DailyIncome(w, r, indices)
+}
+
+// ApiV2Eth1AddressDailyIncome returns the daily income of the validators that have been deposited by an eth1 address
+// as json or csv
+func ApiV2Eth1AddressDailyIncome(w http.ResponseWriter, r *http.Request) {
+	address, err := hex.DecodeString(strings.Replace(mux.Vars(r)["address"], "0x", "", -1))
+	if err != nil || len(address) != 20 {
+		sendApiV2Error(w, r, http.StatusBadRequest, "invalid eth1 address provided")
+		return
+	}
+
+	indices, err := db.GetValidatorIndicesByEth1Address(address)
+	if err != nil {
+		logger.Errorf("error retrieving validator indices for api v2 %v route: %v", r.URL.String(), err)
+		sendApiV2Error(w, r, http.StatusInternalServerError, "could not retrieve db results")
+		return
+	}
+	sendApiV2DailyIncome(w, r, indices)
+}
+
+func sendApiV2DailyIncome(w http.ResponseWriter, r *http.Request, indices []uint64) {
+	query, err := parseIncomeExportQuery(r.URL.Query(), "json")
+	if err != nil {
+		sendApiV2Error(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if query.Days()*len(indices) > incomeExportMaxRows {
+		sendApiV2Error(w, r, http.StatusBadRequest, fmt.Sprintf("the export is limited to %v rows (validators times days), please request a shorter time range", incomeExportMaxRows))
+		return
+	}
+
+	income, err := GetValidatorDailyIncome(indices, query)
+	if err != nil {
+		logger.Errorf("error retrieving validator income for api v2 %v route: %v", r.URL.String(), err)
+		sendApiV2Error(w, r, http.StatusInternalServerError, "could not retrieve db results")
+		return
+	}
+
+	if query.Format == "csv" {
+		filename := fmt.Sprintf("income_%v_%v.csv", query.Start.Format("2006-01-02"), query.End.Format("2006-01-02"))
+		err = writeDailyIncomeCSV(w, filename, query.Currency, income)
+		if err != nil {
+			logger.Errorf("error writing csv for api v2 %v route: %v", r.URL.String(), err)
+		}
+		return
+	}
+	sendApiV2Response(w, r, income, "")
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/urfave/negroni"
+)
+
+var httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "explorer_http_request_duration_seconds",
+	Help:    "Duration of the http requests by route.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"route", "method", "code"})
+
+type routeTemplateKey struct{}
+
+// HttpMetricsMiddleware records the duration of the requests by the template of the mux route that handled them, the
+// template is set by the HttpRouteMiddleware of the innermost router
+func HttpMetricsMiddleware(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	start := time.Now()
+	route := "unmatched"
+	next(w, r.WithContext(context.WithValue(r.Context(), routeTemplateKey{}, &route)))
+
+	// the push feed streams are long-lived and would distort the latencies
+	if strings.HasPrefix(route, "/api/feed/") {
+		return
+	}
+	code := http.StatusOK
+	if rw, ok := w.(negroni.ResponseWriter); ok && rw.Status() != 0 {
+		code = rw.Status()
+	}
+	httpRequestDuration.WithLabelValues(route, r.Method, strconv.Itoa(code)).Observe(time.Since(start).Seconds())
+}
+
+// HttpRouteMiddleware passes the template of the matched route to the HttpMetricsMiddleware, it has to be used by
+// all routers
+func HttpRouteMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route, ok := r.Context().Value(routeTemplateKey{}).(*string); ok {
+			if current := mux.CurrentRoute(r); current != nil {
+				if template, err := current.GetPathTemplate(); err == nil {
+					*route = template
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
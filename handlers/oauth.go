@@ -1,14 +1,429 @@
 package handlers
 
-/*
+import (
+	"context"
+	"encoding/json"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
 
-TODO:
-Check code creation timestamp (expiration)
-Set consumed flag once exchanged for access token
+// oauthCodeMaxAge is the time within which an authorization code has to be exchanged for tokens
+const oauthCodeMaxAge = time.Minute * 10
 
-Code -> AC & Refresh token:
-Check for consumed & expired during access token exchange
-Store device information in this table (makes it easier for users to manage & revoke access for their devices)
+// oauthAccessTokenTTL is the lifetime of an access token, afterwards the app has to use its refresh token
+const oauthAccessTokenTTL = time.Hour
 
+// maxDeviceNameLength is the length of the device_name column of users_devices
+const maxDeviceNameLength = 20
 
-*/
\ No newline at end of file
+type apiUserDeviceKey struct{}
+
+// oauthError is an error of the authorization or the token endpoint (RFC 6749 sections 4.1.2.1 and 5.2)
+type oauthError struct {
+	Code        string
+	Description string
+}
+
+func (e *oauthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+// parseOAuthAuthorizeRequest validates the parameters of an authorization request of the app registered with the
+// redirect uri. Only the authorization code flow with a S256 pkce challenge (RFC 7636) is supported.
+func parseOAuthAuthorizeRequest(appData *types.OAuthAppData, values url.Values) (*types.OAuthAuthorizeRequest, *oauthError) {
+	req := &types.OAuthAuthorizeRequest{
+		ClientID:            appData.ID,
+		RedirectURI:         appData.RedirectURI,
+		State:               values.Get("state"),
+		CodeChallenge:       values.Get("code_challenge"),
+		CodeChallengeMethod: values.Get("code_challenge_method"),
+		DeviceName:          strings.TrimSpace(values.Get("device_name")),
+	}
+
+	if values.Get("client_id") != strconv.FormatUint(appData.ID, 10) {
+		return req, &oauthError{"invalid_request", "client_id does not match the redirect_uri"}
+	}
+	if values.Get("response_type") != "code" {
+		return req, &oauthError{"unsupported_response_type", "only the authorization code flow is supported"}
+	}
+	if req.CodeChallengeMethod != "S256" {
+		return req, &oauthError{"invalid_request", "code_challenge_method must be S256"}
+	}
+	if !utils.IsValidCodeChallenge(req.CodeChallenge) {
+		return req, &oauthError{"invalid_request", "missing or invalid code_challenge"}
+	}
+
+	if req.DeviceName == "" {
+		req.DeviceName = appData.AppName
+	}
+	if name := []rune(req.DeviceName); len(name) > maxDeviceNameLength {
+		req.DeviceName = string(name[:maxDeviceNameLength])
+	}
+	return req, nil
+}
+
+// oauthRedirect redirects the user agent back to the app, the params are added to the query of the redirect uri
+func oauthRedirect(w http.ResponseWriter, r *http.Request, redirectURI string, params url.Values) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		logger.Errorf("error parsing oauth redirect uri %v: %v", redirectURI, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+// oauthErrorRedirect returns an error of an authorization request to the app
+func oauthErrorRedirect(w http.ResponseWriter, r *http.Request, req *types.OAuthAuthorizeRequest, e *oauthError) {
+	params := url.Values{"error": {e.Code}}
+	if e.Description != "" {
+		params.Set("error_description", e.Description)
+	}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	oauthRedirect(w, r, req.RedirectURI, params)
+}
+
+// ApiOAuthToken godoc
+// @Summary Exchange an authorization code or a refresh token for an access token
+// @Tags User
+// @Description Token endpoint of the oauth authorization code flow (RFC 6749) for third-party and mobile apps.
+// @Description Authorization codes are issued by /user/authorize and have to be redeemed with the pkce code_verifier (RFC 7636) within 10 minutes.
+// @Description Access tokens expire after one hour. Every use of a refresh token returns a new refresh token and invalidates the old one.
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "authorization_code or refresh_token"
+// @Param client_id formData string true "Id of the registered app"
+// @Param code formData string false "Authorization code (authorization_code grant)"
+// @Param redirect_uri formData string false "Redirect uri of the app (authorization_code grant)"
+// @Param code_verifier formData string false "Pkce code verifier (authorization_code grant)"
+// @Param refresh_token formData string false "Refresh token (refresh_token grant)"
+// @Success 200 {object} string
+// @Router /api/v1/oauth/token [post]
+func ApiOAuthToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	err := r.ParseForm()
+	if err != nil {
+		sendOAuthError(w, http.StatusBadRequest, &oauthError{"invalid_request", "could not parse form"})
+		return
+	}
+
+	clientID, err := strconv.ParseUint(r.PostFormValue("client_id"), 10, 64)
+	if err != nil {
+		sendOAuthError(w, http.StatusUnauthorized, &oauthError{"invalid_client", "missing or invalid client_id"})
+		return
+	}
+
+	switch r.PostFormValue("grant_type") {
+	case "authorization_code":
+		oauthAuthorizationCodeGrant(w, r, clientID)
+	case "refresh_token":
+		oauthRefreshTokenGrant(w, r, clientID)
+	case "":
+		sendOAuthError(w, http.StatusBadRequest, &oauthError{"invalid_request", "missing grant_type"})
+	default:
+		sendOAuthError(w, http.StatusBadRequest, &oauthError{"unsupported_grant_type", "grant_type must be authorization_code or refresh_token"})
+	}
+}
+
+// oauthAuthorizationCodeGrant consumes an authorization code and registers a new device of the user with the issued tokens
+func oauthAuthorizationCodeGrant(w http.ResponseWriter, r *http.Request, clientID uint64) {
+	code := r.PostFormValue("code")
+	verifier := r.PostFormValue("code_verifier")
+	if code == "" || verifier == "" {
+		sendOAuthError(w, http.StatusBadRequest, &oauthError{"invalid_request", "missing code or code_verifier"})
+		return
+	}
+
+	appData, err := db.GetAppDataFromRedirectUri(r.PostFormValue("redirect_uri"))
+	if err != nil || appData.ID != clientID {
+		sendOAuthError(w, http.StatusBadRequest, &oauthError{"invalid_grant", "redirect_uri does not match the client"})
+		return
+	}
+
+	authCode, err := db.ConsumeAuthorizeCode(utils.HashOAuthToken(code), oauthCodeMaxAge)
+	if err != nil {
+		logger.Errorf("error consuming oauth code of app %v: %v", clientID, err)
+		sendOAuthError(w, http.StatusInternalServerError, &oauthError{"server_error", ""})
+		return
+	}
+	if authCode == nil || authCode.AppID != clientID {
+		sendOAuthError(w, http.StatusBadRequest, &oauthError{"invalid_grant", "invalid, expired or already used code"})
+		return
+	}
+	if !utils.VerifyCodeChallenge(authCode.CodeChallenge, verifier) {
+		sendOAuthError(w, http.StatusBadRequest, &oauthError{"invalid_grant", "code_verifier does not match the code_challenge"})
+		return
+	}
+
+	accessToken, accessTokenHash, err := utils.NewOAuthToken()
+	if err != nil {
+		logger.Errorf("error creating oauth access token: %v", err)
+		sendOAuthError(w, http.StatusInternalServerError, &oauthError{"server_error", ""})
+		return
+	}
+	refreshToken, refreshTokenHash, err := utils.NewOAuthToken()
+	if err != nil {
+		logger.Errorf("error creating oauth refresh token: %v", err)
+		sendOAuthError(w, http.StatusInternalServerError, &oauthError{"server_error", ""})
+		return
+	}
+
+	device := &types.UserDevice{UserID: authCode.UserID, AppID: clientID, DeviceName: authCode.DeviceName}
+	err = db.AddUserDevice(device, refreshTokenHash, accessTokenHash, oauthAccessTokenTTL)
+	if err != nil {
+		logger.Errorf("error adding device of user %v for app %v: %v", authCode.UserID, clientID, err)
+		sendOAuthError(w, http.StatusInternalServerError, &oauthError{"server_error", ""})
+		return
+	}
+
+	sendOAuthTokens(w, accessToken, refreshToken)
+}
+
+// oauthRefreshTokenGrant rotates the refresh token of a device and issues a new access token
+func oauthRefreshTokenGrant(w http.ResponseWriter, r *http.Request, clientID uint64) {
+	refreshToken := r.PostFormValue("refresh_token")
+	if refreshToken == "" {
+		sendOAuthError(w, http.StatusBadRequest, &oauthError{"invalid_request", "missing refresh_token"})
+		return
+	}
+
+	newAccessToken, newAccessTokenHash, err := utils.NewOAuthToken()
+	if err != nil {
+		logger.Errorf("error creating oauth access token: %v", err)
+		sendOAuthError(w, http.StatusInternalServerError, &oauthError{"server_error", ""})
+		return
+	}
+	newRefreshToken, newRefreshTokenHash, err := utils.NewOAuthToken()
+	if err != nil {
+		logger.Errorf("error creating oauth refresh token: %v", err)
+		sendOAuthError(w, http.StatusInternalServerError, &oauthError{"server_error", ""})
+		return
+	}
+
+	device, err := db.RotateUserDeviceTokens(clientID, utils.HashOAuthToken(refreshToken), newRefreshTokenHash, newAccessTokenHash, oauthAccessTokenTTL)
+	if err != nil {
+		logger.Errorf("error rotating refresh token of app %v: %v", clientID, err)
+		sendOAuthError(w, http.StatusInternalServerError, &oauthError{"server_error", ""})
+		return
+	}
+	if device == nil {
+		sendOAuthError(w, http.StatusBadRequest, &oauthError{"invalid_grant", "invalid or revoked refresh_token"})
+		return
+	}
+
+	sendOAuthTokens(w, newAccessToken, newRefreshToken)
+}
+
+// ApiOAuthRevoke godoc
+// @Summary Revoke a refresh token or an access token
+// @Tags User
+// @Description Token revocation endpoint (RFC 7009). Revoking a refresh token signs the device out, its access token becomes invalid as well.
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param client_id formData string true "Id of the registered app"
+// @Param token formData string true "Refresh token or access token"
+// @Success 200 {object} string
+// @Router /api/v1/oauth/revoke [post]
+func ApiOAuthRevoke(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	err := r.ParseForm()
+	if err != nil {
+		sendOAuthError(w, http.StatusBadRequest, &oauthError{"invalid_request", "could not parse form"})
+		return
+	}
+
+	clientID, err := strconv.ParseUint(r.PostFormValue("client_id"), 10, 64)
+	if err != nil {
+		sendOAuthError(w, http.StatusUnauthorized, &oauthError{"invalid_client", "missing or invalid client_id"})
+		return
+	}
+	token := r.PostFormValue("token")
+	if token == "" {
+		sendOAuthError(w, http.StatusBadRequest, &oauthError{"invalid_request", "missing token"})
+		return
+	}
+
+	// unknown tokens are not an error, the client can not do anything about them (RFC 7009 section 2.2)
+	err = db.RevokeOAuthToken(clientID, utils.HashOAuthToken(token))
+	if err != nil {
+		logger.Errorf("error revoking oauth token of app %v: %v", clientID, err)
+		sendOAuthError(w, http.StatusInternalServerError, &oauthError{"server_error", ""})
+		return
+	}
+	w.Write([]byte("{}"))
+}
+
+func sendOAuthTokens(w http.ResponseWriter, accessToken, refreshToken string) {
+	err := json.NewEncoder(w).Encode(&types.ApiOAuthToken{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(oauthAccessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+	})
+	if err != nil {
+		logger.Errorf("error serializing oauth tokens: %v", err)
+	}
+}
+
+func sendOAuthError(w http.ResponseWriter, status int, e *oauthError) {
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(&types.ApiOAuthError{Error: e.Code, ErrorDescription: e.Description})
+	if err != nil {
+		logger.Errorf("error serializing oauth error: %v", err)
+	}
+}
+
+// ApiUserAuthMiddleware authenticates the requests of the user-scoped api routes with the access token of an authorized
+// device that is passed in the Authorization header (RFC 6750). CORS preflight requests do not carry the token and
+// are passed on without authentication.
+func ApiUserAuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		j := json.NewEncoder(w)
+
+		token := ""
+		if auth := r.Header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+			token = strings.TrimSpace(auth[7:])
+		}
+		if token == "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			sendErrorResponse(j, r.URL.String(), "missing access token")
+			return
+		}
+
+		device, err := db.GetAccessTokenDevice(utils.HashOAuthToken(token))
+		if err != nil {
+			logger.Errorf("error retrieving device of access token: %v", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			sendErrorResponse(j, r.URL.String(), "could not verify access token")
+			return
+		}
+		if device == nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			sendErrorResponse(j, r.URL.String(), "invalid or expired access token")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiUserDeviceKey{}, device)))
+	})
+}
+
+// getApiUserDevice returns the device that has been authenticated by the ApiUserAuthMiddleware
+func getApiUserDevice(r *http.Request) *types.UserDevice {
+	device, _ := r.Context().Value(apiUserDeviceKey{}).(*types.UserDevice)
+	return device
+}
+
+// ApiUserDeviceNotifications godoc
+// @Summary Register the push notification token of the authorized device
+// @Tags User
+// @Description Sets the firebase cloud messaging token of the device the access token has been issued to.
+// @Description Notifications of subscriptions with the push channel are only sent to devices that have enabled notifications.
+// @Description Requires an access token of the oauth flow in the Authorization header (Bearer).
+// @Accept json
+// @Produce json
+// @Param body body string true "{\"notification_token\": \"<fcm token>\", \"notify_enabled\": true}"
+// @Success 200 {object} string
+// @Router /api/v1/user/device/notifications [post]
+func ApiUserDeviceNotifications(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	device := getApiUserDevice(r)
+
+	req := struct {
+		NotificationToken string `json:"notification_token"`
+		NotifyEnabled     bool   `json:"notify_enabled"`
+	}{}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxApiUserRequestSize)).Decode(&req)
+	if err != nil {
+		sendApiUserError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(req.NotificationToken) > 500 {
+		sendApiUserError(w, r, http.StatusBadRequest, "notification_token must not be longer than 500 characters")
+		return
+	}
+	if req.NotificationToken == "" {
+		req.NotifyEnabled = false
+	}
+
+	err = db.UpdateUserDeviceNotifications(device.ID, req.NotificationToken, req.NotifyEnabled)
+	if err != nil {
+		logger.Errorf("error updating notification token of device %v: %v", device.ID, err)
+		sendApiUserError(w, r, http.StatusInternalServerError, "could not update notification token")
+		return
+	}
+	device.NotifyEnabled = req.NotifyEnabled
+
+	sendOKResponse(json.NewEncoder(w), r.URL.String(), []interface{}{device})
+}
+
+// UserDeviceRevokePost revokes the access of a device of the user, its refresh and access token are rejected immediately
+func UserDeviceRevokePost(w http.ResponseWriter, r *http.Request) {
+	user, session, err := getUserSession(w, r)
+	if err != nil {
+		logger.Errorf("error retrieving session: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		logger.Errorf("error parsing form: %v", err)
+		session.AddFlash(authInternalServerErrorFlashMsg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.FormValue("id"), 10, 64)
+	if err != nil {
+		session.AddFlash("Error: Invalid device!")
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+
+	revoked, err := db.RevokeUserDevice(user.UserID, id)
+	if err != nil {
+		logger.Errorf("error revoking device %v of user %v: %v", id, user.UserID, err)
+		session.AddFlash(authInternalServerErrorFlashMsg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+	if !revoked {
+		session.AddFlash("Error: Invalid device!")
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+
+	session.AddFlash("Device signed out successfully ✔️")
+	session.Save(r, w)
+	http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"eth2-exporter/db"
+	"eth2-exporter/services"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"eth2-exporter/version"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var reorgsTemplate = template.Must(template.New("reorgs").ParseFiles("templates/layout.html", "templates/reorgs.html"))
+
+// Reorgs will return the reorgs using a go template
+func Reorgs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	data := &types.PageData{
+		HeaderAd: true,
+		Meta: &types.Meta{
+			Title:       fmt.Sprintf("%v - Reorgs - beaconcha.in - %v", utils.Config.Frontend.SiteName, time.Now().Year()),
+			Description: "beaconcha.in makes the Ethereum 2.0. beacon chain accessible to non-technical end users",
+			Path:        "/reorgs",
+			GATag:       utils.Config.Frontend.GATag,
+		},
+		ShowSyncingMessage:    services.IsSyncing(),
+		Active:                "stats",
+		Data:                  nil,
+		User:                  getUser(w, r),
+		Version:               version.Version,
+		ChainSlotsPerEpoch:    utils.Config.Chain.SlotsPerEpoch,
+		ChainSecondsPerSlot:   utils.Config.Chain.SecondsPerSlot,
+		ChainGenesisTimestamp: utils.Config.Chain.GenesisTimestamp,
+		CurrentEpoch:          services.LatestEpoch(),
+		CurrentSlot:           services.LatestSlot(),
+		FinalizationDelay:     services.FinalizationDelay(),
+		Mainnet:               utils.Config.Chain.Mainnet,
+		DepositContract:       utils.Config.Indexer.Eth1DepositContractAddress,
+	}
+
+	err := reorgsTemplate.ExecuteTemplate(w, "layout", data)
+
+	if err != nil {
+		logger.Errorf("error executing template for %v route: %v", r.URL.String(), err)
+		http.Error(w, "Internal server error", 503)
+		return
+	}
+}
+
+// ReorgsData will return the reorgs as datatable json
+func ReorgsData(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+
+	draw, err := strconv.ParseUint(q.Get("draw"), 10, 64)
+	if err != nil {
+		logger.Errorf("error converting datatables data parameter from string to int: %v", err)
+		http.Error(w, "Internal server error", 503)
+		return
+	}
+	start, err := strconv.ParseUint(q.Get("start"), 10, 64)
+	if err != nil {
+		logger.Errorf("error converting datatables start parameter from string to int: %v", err)
+		http.Error(w, "Internal server error", 503)
+		return
+	}
+	length, err := strconv.ParseUint(q.Get("length"), 10, 64)
+	if err != nil {
+		logger.Errorf("error converting datatables length parameter from string to int: %v", err)
+		http.Error(w, "Internal server error", 503)
+		return
+	}
+	if length > 100 {
+		length = 100
+	}
+
+	reorgsCount, err := db.GetReorgsCount()
+	if err != nil {
+		logger.Errorf("error retrieving reorgs count: %v", err)
+		http.Error(w, "Internal server error", 503)
+		return
+	}
+
+	reorgs, err := db.GetReorgs(length, start)
+	if err != nil {
+		logger.Errorf("error retrieving reorgs: %v", err)
+		http.Error(w, "Internal server error", 503)
+		return
+	}
+
+	tableData := make([][]interface{}, len(reorgs))
+	for i, reorg := range reorgs {
+		affectedSlots := make([]string, len(reorg.AffectedSlots))
+		for j, slot := range reorg.AffectedSlots {
+			affectedSlots[j] = string(utils.FormatBlockSlot(uint64(slot)))
+		}
+
+		tableData[i] = []interface{}{
+			utils.FormatTimestamp(reorg.Ts.Unix()),
+			utils.FormatEpoch(reorg.Epoch),
+			reorg.Depth,
+			template.HTML(fmt.Sprintf("%v (%v)", utils.FormatBlockSlot(reorg.OldHeadSlot), utils.FormatHash(reorg.OldHeadRoot))),
+			template.HTML(fmt.Sprintf("%v (%v)", utils.FormatBlockSlot(reorg.NewHeadSlot), utils.FormatBlockRoot(reorg.NewHeadRoot))),
+			template.HTML(strings.Join(affectedSlots, ", ")),
+		}
+	}
+
+	data := &types.DataTableResponse{
+		Draw:            draw,
+		RecordsTotal:    reorgsCount,
+		RecordsFiltered: reorgsCount,
+		Data:            tableData,
+	}
+
+	err = json.NewEncoder(w).Encode(data)
+	if err != nil {
+		logger.Errorf("error enconding json response for %v route: %v", r.URL.String(), err)
+		http.Error(w, "Internal server error", 503)
+		return
+	}
+}
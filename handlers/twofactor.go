@@ -0,0 +1,370 @@
+package handlers
+
+import (
+	"eth2-exporter/db"
+	"eth2-exporter/ratelimit"
+	"eth2-exporter/services"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"eth2-exporter/version"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/skip2/go-qrcode"
+)
+
+var loginTwoFactorTemplate = template.Must(template.New("login2fa").Funcs(utils.GetTemplateFuncs()).ParseFiles("templates/layout.html", "templates/login2fa.html"))
+
+// twoFactorLoginTimeout is the time a user has to enter the second factor after the password has been verified
+var twoFactorLoginTimeout = time.Minute * 5
+
+// twoFactorLimit limits the codes that can be entered per user to prevent guessing them
+var twoFactorLimit = ratelimit.Limit{Requests: 5, Period: time.Minute * 5}
+var twoFactorLimiter = ratelimit.NewLimiter()
+
+var twoFactorRecoveryCodesCount = 10
+var twoFactorInvalidCodeFlashMsg = "Error: Invalid authentication code!"
+
+// LoginTwoFactor renders the second step of the login for users that have enabled two-factor authentication.
+func LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	session, err := utils.SessionStore.Get(r, authSessionName)
+	if err != nil {
+		logger.Errorf("error retrieving session for login route: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if _, ok := session.Values["totp_user_id"].(uint64); !ok {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	data := &types.PageData{
+		Meta: &types.Meta{
+			Description: "beaconcha.in makes the Ethereum 2.0. beacon chain accessible to non-technical end users",
+			Path:        "/login/2fa",
+			GATag:       utils.Config.Frontend.GATag,
+		},
+		Active:                "login",
+		Data:                  types.AuthData{Flashes: utils.GetFlashes(w, r, authSessionName)},
+		User:                  getUser(w, r),
+		Version:               version.Version,
+		ChainSlotsPerEpoch:    utils.Config.Chain.SlotsPerEpoch,
+		ChainSecondsPerSlot:   utils.Config.Chain.SecondsPerSlot,
+		ChainGenesisTimestamp: utils.Config.Chain.GenesisTimestamp,
+		CurrentEpoch:          services.LatestEpoch(),
+		CurrentSlot:           services.LatestSlot(),
+		FinalizationDelay:     services.FinalizationDelay(),
+		Mainnet:               utils.Config.Chain.Mainnet,
+		DepositContract:       utils.Config.Indexer.Eth1DepositContractAddress,
+	}
+	err = loginTwoFactorTemplate.ExecuteTemplate(w, "layout", data)
+	if err != nil {
+		logger.Errorf("error executing template for %v route: %v", r.URL.String(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+// LoginTwoFactorPost completes the login of a user whose password has been verified with a totp or recovery code.
+func LoginTwoFactorPost(w http.ResponseWriter, r *http.Request) {
+	session, err := utils.SessionStore.Get(r, authSessionName)
+	if err != nil {
+		logger.Errorf("error retrieving session for login route: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	userID, ok := session.Values["totp_user_id"].(uint64)
+	ts, _ := session.Values["totp_ts"].(int64)
+	if !ok || time.Since(time.Unix(ts, 0)) > twoFactorLoginTimeout {
+		delete(session.Values, "totp_user_id")
+		delete(session.Values, "totp_ts")
+		session.AddFlash("Error: Your login has expired, please sign in again.")
+		session.Save(r, w)
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	totp, err := db.GetUserTOTP(userID)
+	if err != nil {
+		logger.Errorf("error retrieving totp settings of user %v: %v", userID, err)
+		session.AddFlash(authInternalServerErrorFlashMsg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/login/2fa", http.StatusSeeOther)
+		return
+	}
+
+	if msg := checkTwoFactor(userID, totp, r.FormValue("code")); msg != "" {
+		session.AddFlash(msg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/login/2fa", http.StatusSeeOther)
+		return
+	}
+
+	delete(session.Values, "totp_user_id")
+	delete(session.Values, "totp_ts")
+	session.Values["authenticated"] = true
+	session.Values["user_id"] = userID
+	redirect := loginRedirect(session)
+	session.Save(r, w)
+	http.Redirect(w, r, redirect, http.StatusSeeOther)
+}
+
+// UserTwoFactorSetupPost starts the totp enrollment of a user by generating a new secret that is shown as qr code on the settings page.
+func UserTwoFactorSetupPost(w http.ResponseWriter, r *http.Request) {
+	user := getUser(w, r)
+
+	secret, err := utils.NewTOTPSecret()
+	if err != nil {
+		logger.Errorf("error generating totp secret: %v", err)
+		utils.SetFlash(w, r, authSessionName, authInternalServerErrorFlashMsg)
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+
+	err = db.SetUserTOTPSecret(user.UserID, secret)
+	if err != nil {
+		logger.Errorf("error saving totp secret of user %v: %v", user.UserID, err)
+		utils.SetFlash(w, r, authSessionName, authInternalServerErrorFlashMsg)
+	}
+	http.Redirect(w, r, "/user/settings#two-factor", http.StatusSeeOther)
+}
+
+// UserTwoFactorEnablePost completes the totp enrollment once the user has entered a valid code of the new secret.
+func UserTwoFactorEnablePost(w http.ResponseWriter, r *http.Request) {
+	user, session, err := getUserSession(w, r)
+	if err != nil {
+		logger.Errorf("error retrieving session: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	totp, err := db.GetUserTOTP(user.UserID)
+	if err != nil {
+		logger.Errorf("error retrieving totp settings of user %v: %v", user.UserID, err)
+		session.AddFlash(authInternalServerErrorFlashMsg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+	if totp.Enabled || totp.Secret == nil {
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+
+	if msg := checkTwoFactor(user.UserID, totp, r.FormValue("code")); msg != "" {
+		session.AddFlash(msg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings#two-factor", http.StatusSeeOther)
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err == nil {
+		err = db.EnableUserTOTP(user.UserID, hashes)
+	}
+	if err != nil {
+		logger.Errorf("error enabling totp for user %v: %v", user.UserID, err)
+		session.AddFlash(authInternalServerErrorFlashMsg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings#two-factor", http.StatusSeeOther)
+		return
+	}
+
+	session.Values["recovery_codes"] = strings.Join(codes, " ")
+	session.AddFlash("Two-factor authentication enabled ✔️")
+	session.Save(r, w)
+	http.Redirect(w, r, "/user/settings#two-factor", http.StatusSeeOther)
+}
+
+// UserTwoFactorDisablePost disables two-factor authentication after verifying a totp or recovery code.
+func UserTwoFactorDisablePost(w http.ResponseWriter, r *http.Request) {
+	user, session, err := getUserSession(w, r)
+	if err != nil {
+		logger.Errorf("error retrieving session: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	if msg := requireTwoFactor(user.UserID, r.FormValue("code")); msg != "" {
+		session.AddFlash(msg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings#two-factor", http.StatusSeeOther)
+		return
+	}
+
+	err = db.DisableUserTOTP(user.UserID)
+	if err != nil {
+		logger.Errorf("error disabling totp for user %v: %v", user.UserID, err)
+		session.AddFlash(authInternalServerErrorFlashMsg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings#two-factor", http.StatusSeeOther)
+		return
+	}
+
+	session.AddFlash("Two-factor authentication disabled")
+	session.Save(r, w)
+	http.Redirect(w, r, "/user/settings#two-factor", http.StatusSeeOther)
+}
+
+// UserRecoveryCodesPost replaces the recovery codes of a user after verifying a totp or recovery code.
+func UserRecoveryCodesPost(w http.ResponseWriter, r *http.Request) {
+	user, session, err := getUserSession(w, r)
+	if err != nil {
+		logger.Errorf("error retrieving session: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	totp, err := db.GetUserTOTP(user.UserID)
+	if err != nil {
+		logger.Errorf("error retrieving totp settings of user %v: %v", user.UserID, err)
+		session.AddFlash(authInternalServerErrorFlashMsg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+	if !totp.Enabled {
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+
+	if msg := checkTwoFactor(user.UserID, totp, r.FormValue("code")); msg != "" {
+		session.AddFlash(msg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings#two-factor", http.StatusSeeOther)
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err == nil {
+		err = db.ReplaceUserRecoveryCodes(user.UserID, hashes)
+	}
+	if err != nil {
+		logger.Errorf("error replacing the recovery codes of user %v: %v", user.UserID, err)
+		session.AddFlash(authInternalServerErrorFlashMsg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings#two-factor", http.StatusSeeOther)
+		return
+	}
+
+	session.Values["recovery_codes"] = strings.Join(codes, " ")
+	session.Save(r, w)
+	http.Redirect(w, r, "/user/settings#two-factor", http.StatusSeeOther)
+}
+
+// setTwoFactorSettingsData adds the two-factor authentication state of a user to the settings page, recovery codes
+// that have just been generated are taken from the session so that they are only shown once
+func setTwoFactorSettingsData(w http.ResponseWriter, r *http.Request, session *sessions.Session, data *types.UserSettingsPageData, userID uint64) error {
+	totp, err := db.GetUserTOTP(userID)
+	if err != nil {
+		return fmt.Errorf("error retrieving totp settings: %v", err)
+	}
+
+	data.TwoFactorEnabled = totp.Enabled
+	if totp.Enabled {
+		data.RecoveryCodesRemaining, err = db.GetUserRecoveryCodesCount(userID)
+		if err != nil {
+			return fmt.Errorf("error retrieving recovery codes count: %v", err)
+		}
+		if codes, ok := session.Values["recovery_codes"].(string); ok {
+			data.RecoveryCodes = strings.Split(codes, " ")
+			delete(session.Values, "recovery_codes")
+			session.Save(r, w)
+		}
+	} else if totp.Secret != nil {
+		issuer := utils.Config.Frontend.SiteName
+		if issuer == "" {
+			issuer = "beaconcha.in"
+		}
+		qr, err := qrCodeSVG(utils.TOTPKeyURI(issuer, data.Email, *totp.Secret))
+		if err != nil {
+			return fmt.Errorf("error encoding totp qr code: %v", err)
+		}
+		data.TwoFactorSecret = *totp.Secret
+		data.TwoFactorQRCode = qr
+	}
+	return nil
+}
+
+// qrCodeSVG encodes the content as a qr code at error correction level M and returns it as a scalable svg image
+// including the quiet zone
+func qrCodeSVG(content string) (template.HTML, error) {
+	qr, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return "", err
+	}
+	bitmap := qr.Bitmap()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %[1]d %[1]d" shape-rendering="crispEdges">`, len(bitmap))
+	sb.WriteString(`<rect width="100%" height="100%" fill="#fff"/><path fill="#000" d="`)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&sb, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	sb.WriteString(`"/></svg>`)
+	return template.HTML(sb.String()), nil
+}
+
+// requireTwoFactor verifies the code of a user before a sensitive action and returns the flash message to show if
+// the code has not been accepted. Users that have not enabled two-factor authentication are not checked.
+func requireTwoFactor(userID uint64, code string) string {
+	totp, err := db.GetUserTOTP(userID)
+	if err != nil {
+		logger.Errorf("error retrieving totp settings of user %v: %v", userID, err)
+		return authInternalServerErrorFlashMsg
+	}
+	if !totp.Enabled {
+		return ""
+	}
+	return checkTwoFactor(userID, totp, code)
+}
+
+// checkTwoFactor verifies a totp or recovery code of a user and returns the flash message to show if the code has
+// not been accepted. Accepted codes can not be used again.
+func checkTwoFactor(userID uint64, totp *types.UserTOTP, code string) string {
+	if !twoFactorLimiter.Allow(fmt.Sprintf("%v", userID), twoFactorLimit).Allowed {
+		return "Error: Too many attempts, please try again in a few minutes."
+	}
+	if totp.Secret == nil {
+		return twoFactorInvalidCodeFlashMsg
+	}
+
+	var ok bool
+	var err error
+	if step, valid := utils.VerifyTOTP(*totp.Secret, code, time.Now()); valid {
+		ok, err = db.UseUserTOTPStep(userID, step)
+	} else if totp.Enabled {
+		ok, err = db.UseUserRecoveryCode(userID, utils.HashRecoveryCode(code))
+	}
+	if err != nil {
+		logger.Errorf("error verifying the two-factor code of user %v: %v", userID, err)
+		return authInternalServerErrorFlashMsg
+	}
+	if !ok {
+		return twoFactorInvalidCodeFlashMsg
+	}
+	return ""
+}
+
+// newRecoveryCodes returns new recovery codes together with their hashes
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.NewRecoveryCodes(twoFactorRecoveryCodesCount)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error generating recovery codes: %v", err)
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashRecoveryCode(code)
+	}
+	return codes, hashes, nil
+}
@@ -1,7 +1,6 @@
 package handlers
 
 import (
-	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -31,9 +30,13 @@ var notificationTemplate = template.Must(template.New("user").Funcs(utils.GetTem
 var authorizeTemplate = template.Must(template.New("user").Funcs(utils.GetTemplateFuncs()).ParseFiles("templates/layout.html", "templates/user/authorize.html"))
 
 func UserAuthMiddleware(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
-	user := getUser(w, r)
+	user, session, err := getUserSession(w, r)
 	if !user.Authenticated {
 		logger.Errorf("User not authorized")
+		// apps send the user to the authorization page, continue the authorization after the login
+		if err == nil && r.Method == "GET" && r.URL.Path == "/user/authorize" {
+			session.Values["login_redirect"] = r.URL.RequestURI()
+		}
 		utils.SetFlash(w, r, authSessionName, "Error: Please login first")
 		http.Redirect(w, r, "/login", http.StatusSeeOther)
 		return
@@ -62,7 +65,30 @@ func UserSettings(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	apiKeys, err := db.GetUserApiKeys(user.UserID)
+	if err != nil {
+		logger.Errorf("error retrieving the api keys for user %v: %v", user.UserID, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	devices, err := db.GetUserDevices(user.UserID)
+	if err != nil {
+		logger.Errorf("error retrieving the devices of user %v: %v", user.UserID, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
 	userSettingsData.Email = email
+	userSettingsData.ApiKeys = apiKeys
+	userSettingsData.Devices = devices
+
+	err = setTwoFactorSettingsData(w, r, session, userSettingsData, user.UserID)
+	if err != nil {
+		logger.Errorf("error retrieving the two-factor settings of user %v: %v", user.UserID, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
 	userSettingsData.Flashes = utils.GetFlashes(w, r, authSessionName)
 	userSettingsData.CsrfField = csrf.TemplateField(r)
 
@@ -111,13 +137,19 @@ func UserAuthorizeConfirm(w http.ResponseWriter, r *http.Request) {
 	redirectURI := q.Get("redirect_uri")
 
 	appData, err := db.GetAppDataFromRedirectUri(redirectURI)
-	logger.Infof("appData %v", appData)
 	if err != nil {
-		logger.Errorf("error app not found: %v %v", user.UserID, appData, err)
+		logger.Errorf("error app not found: %v %v", user.UserID, err)
 		utils.SetFlash(w, r, authSessionName, "Error: App not found. Is your redirect_uri correct and registered?")
 		session.Save(r, w)
 	} else {
+		// the redirect uri is registered, all further errors are returned to the app
+		authorizeRequest, oauthErr := parseOAuthAuthorizeRequest(appData, q)
+		if oauthErr != nil {
+			oauthErrorRedirect(w, r, authorizeRequest, oauthErr)
+			return
+		}
 		authorizeData.AppData = appData
+		authorizeData.Request = authorizeRequest
 	}
 
 	authorizeData.CsrfField = csrf.TemplateField(r)
@@ -187,6 +219,43 @@ func UserNotifications(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var networkSubscriptions []string
+	err = db.DB.Select(&networkSubscriptions, `
+	SELECT event_name
+	FROM users_subscriptions
+	WHERE user_id = $1 AND event_name LIKE 'network_%'
+	`, user.UserID)
+	if err != nil {
+		logger.Errorf("error retrieving network subscriptions %v route: %v", r.URL.String(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	userNotificationsData.Webhook, err = db.GetUserWebhook(user.UserID)
+	if err != nil {
+		logger.Errorf("error retrieving webhook %v route: %v", r.URL.String(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	userNotificationsData.PushDevices, err = db.GetUserPushDevicesCount(user.UserID)
+	if err != nil {
+		logger.Errorf("error retrieving push device count %v route: %v", r.URL.String(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	userNotificationsData.Deliveries, err = db.GetUserNotificationDeliveries(user.UserID, 10)
+	if err != nil {
+		logger.Errorf("error retrieving notification deliveries %v route: %v", r.URL.String(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	userNotificationsData.NetworkSubscriptions = make(map[string]bool)
+	for _, eventName := range networkSubscriptions {
+		userNotificationsData.NetworkSubscriptions[eventName] = true
+	}
 	userNotificationsData.CountSubscriptions = countSubscriptions
 	userNotificationsData.WatchlistIndices = watchlistIndices
 	userNotificationsData.CountWatchlist = len(watchlistIndices)
@@ -383,6 +452,11 @@ func UserSubscriptionsData(w http.ResponseWriter, r *http.Request) {
 			sub.EventName,
 			utils.FormatTimestamp(sub.CreatedTime.Unix()),
 			ls,
+			map[string]interface{}{
+				"event":    sub.EventName,
+				"filter":   sub.EventFilter,
+				"channels": sub.Channels,
+			},
 		})
 	}
 
@@ -403,6 +477,8 @@ func UserSubscriptionsData(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// UserAuthorizeConfirmPost issues an authorization code for the app once the user has confirmed the authorization
+// request and redirects back to the app
 func UserAuthorizeConfirmPost(w http.ResponseWriter, r *http.Request) {
 	logger := logger.WithField("route", r.URL.String())
 	user, session, err := getUserSession(w, r)
@@ -412,6 +488,13 @@ func UserAuthorizeConfirmPost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = r.ParseForm()
+	if err != nil {
+		logger.Errorf("error parsing form: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
 	redirectURI := r.FormValue("redirect_uri")
 
 	if user.Authenticated == true {
@@ -424,33 +507,35 @@ func UserAuthorizeConfirmPost(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		callbackTemplate := appData.RedirectURI + "?code="
-
-		codeBytes, err1 := utils.GenerateRandomBytesSecure(32)
-		if err1 != nil {
-			logger.Errorf("error creating secure random bytes for user: %v %v", user.UserID, err1)
-			http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
-			utils.SetFlash(w, r, authSessionName, "Error: Could not create secure random bytes.")
-			session.Save(r, w)
-			http.Redirect(w, r, "/user/authorize", http.StatusSeeOther)
+		authorizeRequest, oauthErr := parseOAuthAuthorizeRequest(appData, r.PostForm)
+		if oauthErr != nil {
+			oauthErrorRedirect(w, r, authorizeRequest, oauthErr)
+			return
+		}
+		if r.FormValue("action") == "deny" {
+			oauthErrorRedirect(w, r, authorizeRequest, &oauthError{"access_denied", "the user denied the authorization"})
 			return
 		}
 
-		code := hex.EncodeToString(codeBytes) // return to user
-		codeHashedBytes := sha256.Sum256([]byte(code))
-		codeHashed := hex.EncodeToString(codeHashedBytes[:]) // save hashed code in db
+		code, codeHashed, err := utils.NewOAuthToken() // return the code to the app, save the hashed code in db
+		if err != nil {
+			logger.Errorf("error creating secure random bytes for user: %v %v", user.UserID, err)
+			oauthErrorRedirect(w, r, authorizeRequest, &oauthError{"server_error", ""})
+			return
+		}
 
-		err2 := db.AddAuthorizeCode(user.UserID, codeHashed, appData.ID)
-		if err2 != nil {
-			logger.Errorf("error adding authorization code for user: %v %v", user.UserID, err2)
-			utils.SetFlash(w, r, authSessionName, "Error: Couldn't link your account")
-			session.Save(r, w)
-			http.Redirect(w, r, "/user/authorize", http.StatusSeeOther)
+		err = db.AddAuthorizeCode(user.UserID, codeHashed, appData.ID, authorizeRequest.CodeChallenge, authorizeRequest.DeviceName)
+		if err != nil {
+			logger.Errorf("error adding authorization code for user: %v %v", user.UserID, err)
+			oauthErrorRedirect(w, r, authorizeRequest, &oauthError{"server_error", ""})
 			return
 		}
 
-		callback := callbackTemplate + codeHashed
-		http.Redirect(w, r, callback, http.StatusSeeOther)
+		params := url.Values{"code": {code}}
+		if authorizeRequest.State != "" {
+			params.Set("state", authorizeRequest.State)
+		}
+		oauthRedirect(w, r, appData.RedirectURI, params)
 		return
 	} else {
 		logger.Error("Not authorized")
@@ -468,6 +553,13 @@ func UserDeletePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if user.Authenticated == true {
+		if msg := requireTwoFactor(user.UserID, r.FormValue("code")); msg != "" {
+			session.AddFlash(msg)
+			session.Save(r, w)
+			http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+			return
+		}
+
 		err := db.DeleteUserById(user.UserID)
 		if err != nil {
 			logger.Errorf("error deleting user by email for user: %v %v", user.UserID, err)
@@ -580,6 +672,13 @@ func UserUpdateEmailPost(w http.ResponseWriter, r *http.Request) {
 	}
 	email := r.FormValue("email")
 
+	if msg := requireTwoFactor(user.UserID, r.FormValue("code")); msg != "" {
+		session.AddFlash(msg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+
 	if !utils.IsValidEmail(email) {
 		session.AddFlash("Error: Invalid email format!")
 		session.Save(r, w)
@@ -901,12 +1000,17 @@ func UserNotificationsSubscribe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	isPkey := !pkeyRegex.MatchString(filter)
+	if isNetworkEvent(eventName) {
+		// network events apply to the whole chain and can not be filtered
+		filter = ""
+	} else {
+		isPkey := !pkeyRegex.MatchString(filter)
 
-	if len(filter) != 96 && isPkey {
-		logger.Errorf("error invalid pubkey characters or length: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		if len(filter) != 96 && isPkey {
+			logger.Errorf("error invalid pubkey characters or length: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
 	}
 
 	err = db.AddSubscription(user.UserID, eventName, filter)
@@ -933,12 +1037,17 @@ func UserNotificationsUnsubscribe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	isPkey := !pkeyRegex.MatchString(filter)
+	if isNetworkEvent(eventName) {
+		// network events apply to the whole chain and can not be filtered
+		filter = ""
+	} else {
+		isPkey := !pkeyRegex.MatchString(filter)
 
-	if len(filter) != 96 && isPkey {
-		logger.Errorf("error invalid pubkey characters or length: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
+		if len(filter) != 96 && isPkey {
+			logger.Errorf("error invalid pubkey characters or length: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
 	}
 
 	err = db.DeleteSubscription(user.UserID, eventName, filter)
@@ -949,3 +1058,7 @@ func UserNotificationsUnsubscribe(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(200)
 }
+
+func isNetworkEvent(eventName types.EventName) bool {
+	return strings.HasPrefix(string(eventName), "network_")
+}
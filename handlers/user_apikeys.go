@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"eth2-exporter/db"
+	"eth2-exporter/services"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"eth2-exporter/version"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var userApiUsageTemplate = template.Must(template.New("user").Funcs(utils.GetTemplateFuncs()).ParseFiles("templates/layout.html", "templates/user/apiusage.html"))
+
+// maxUserApiKeys is the maximum number of active api keys of a user
+const maxUserApiKeys = 5
+
+// UserApiKeyCreatePost creates a new api key with the free plan for the user
+func UserApiKeyCreatePost(w http.ResponseWriter, r *http.Request) {
+	user, session, err := getUserSession(w, r)
+	if err != nil {
+		logger.Errorf("error retrieving session: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		logger.Errorf("error parsing form: %v", err)
+		session.AddFlash(authInternalServerErrorFlashMsg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if len(name) > 40 {
+		session.AddFlash("Error: The name of the api key must not be longer than 40 characters!")
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+
+	count, err := db.GetUserActiveApiKeysCount(user.UserID)
+	if err != nil {
+		logger.Errorf("error retrieving the api key count for user %v: %v", user.UserID, err)
+		session.AddFlash(authInternalServerErrorFlashMsg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+	if count >= maxUserApiKeys {
+		session.AddFlash("Error: You can not have more than 5 active api keys, please revoke an unused key first.")
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+
+	apiKey := &types.ApiKey{
+		UserID: user.UserID,
+		Key:    utils.RandomString(40),
+		Name:   name,
+		Plan:   "free",
+	}
+	err = db.CreateApiKey(apiKey)
+	if err != nil {
+		logger.Errorf("error creating api key for user %v: %v", user.UserID, err)
+		session.AddFlash(authInternalServerErrorFlashMsg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+
+	session.AddFlash("API key created successfully ✔️")
+	session.Save(r, w)
+	http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+}
+
+// UserApiKeyRevokePost revokes an api key of the user, the key is rejected by the api within a minute
+func UserApiKeyRevokePost(w http.ResponseWriter, r *http.Request) {
+	user, session, err := getUserSession(w, r)
+	if err != nil {
+		logger.Errorf("error retrieving session: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		logger.Errorf("error parsing form: %v", err)
+		session.AddFlash(authInternalServerErrorFlashMsg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.FormValue("id"), 10, 64)
+	if err != nil {
+		session.AddFlash("Error: Invalid api key!")
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+
+	revoked, err := db.RevokeApiKey(user.UserID, id)
+	if err != nil {
+		logger.Errorf("error revoking api key %v of user %v: %v", id, user.UserID, err)
+		session.AddFlash(authInternalServerErrorFlashMsg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+	if !revoked {
+		session.AddFlash("Error: Invalid api key!")
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+		return
+	}
+
+	session.AddFlash("API key revoked successfully ✔️")
+	session.Save(r, w)
+	http.Redirect(w, r, "/user/settings", http.StatusSeeOther)
+}
+
+// UserApiUsage renders the api usage template with the calls of the api keys of the user during the last 30 days
+func UserApiUsage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+	user := getUser(w, r)
+
+	apiKeys, err := db.GetUserApiKeys(user.UserID)
+	if err != nil {
+		logger.Errorf("error retrieving the api keys for user %v: %v", user.UserID, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	today := time.Now().UTC().Truncate(time.Hour * 24)
+	stats, err := db.GetUserApiStatistics(user.UserID, today.Add(time.Hour*24*-29))
+	if err != nil {
+		logger.Errorf("error retrieving the api statistics for user %v: %v", user.UserID, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	usage := make(map[string]*types.UserApiKeyUsage, len(apiKeys))
+	usagePageData := &types.UserApiUsagePageData{
+		Keys:  make([]*types.UserApiKeyUsage, 0, len(apiKeys)),
+		Daily: stats,
+	}
+	for _, apiKey := range apiKeys {
+		plan := apiPlans[apiKey.Plan]
+		if plan == nil {
+			plan = apiPlans["free"]
+		}
+		keyUsage := &types.UserApiKeyUsage{
+			ApiKey:   apiKey,
+			PlanName: plan.Name,
+			PerDay:   plan.PerDay,
+			PerMonth: plan.PerMonth,
+		}
+		usage[apiKey.Key] = keyUsage
+		usagePageData.Keys = append(usagePageData.Keys, keyUsage)
+	}
+	for _, stat := range stats {
+		keyUsage := usage[stat.ApiKey]
+		if keyUsage == nil {
+			continue
+		}
+		keyUsage.Month += stat.Count
+		if !stat.Ts.Before(today) {
+			keyUsage.Today += stat.Count
+		}
+	}
+
+	data := &types.PageData{
+		HeaderAd: true,
+		Meta: &types.Meta{
+			Title:       utils.Config.Frontend.SiteName + " - API usage",
+			Description: "beaconcha.in makes the Ethereum 2.0. beacon chain accessible to non-technical end users",
+			Path:        "/user/apiusage",
+			GATag:       utils.Config.Frontend.GATag,
+		},
+		Active:                "user",
+		Data:                  usagePageData,
+		User:                  user,
+		Version:               version.Version,
+		ChainSlotsPerEpoch:    utils.Config.Chain.SlotsPerEpoch,
+		ChainSecondsPerSlot:   utils.Config.Chain.SecondsPerSlot,
+		ChainGenesisTimestamp: utils.Config.Chain.GenesisTimestamp,
+		CurrentEpoch:          services.LatestEpoch(),
+		CurrentSlot:           services.LatestSlot(),
+		FinalizationDelay:     services.FinalizationDelay(),
+		Mainnet:               utils.Config.Chain.Mainnet,
+		DepositContract:       utils.Config.Indexer.Eth1DepositContractAddress,
+	}
+
+	err = userApiUsageTemplate.ExecuteTemplate(w, "layout", data)
+	if err != nil {
+		logger.Errorf("error executing template for %v route: %v", r.URL.String(), err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+}
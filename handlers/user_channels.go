@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"eth2-exporter/db"
+	"eth2-exporter/notify"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"net/http"
+	"strings"
+)
+
+// UserNotificationsChannels sets the channels a subscription is delivered on. The channels are passed as a comma
+// separated list in the channels parameter.
+func UserNotificationsChannels(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+	user := getUser(w, r)
+	q := r.URL.Query()
+	filter := strings.Replace(q.Get("filter"), "0x", "", -1)
+
+	eventName, err := types.EventNameFromString(q.Get("event"))
+	if err != nil {
+		logger.Errorf("error invalid event name: %v", err)
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	if isNetworkEvent(eventName) {
+		filter = ""
+	}
+
+	channels := make([]types.NotificationChannel, 0, len(types.NotificationChannels))
+	for _, c := range strings.Split(q.Get("channels"), ",") {
+		if c == "" {
+			continue
+		}
+		channel, err := types.NotificationChannelFromString(c)
+		if err != nil {
+			logger.Errorf("error invalid notification channel: %v", err)
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+		channels = append(channels, channel)
+	}
+
+	err = db.UpdateSubscriptionChannels(user.UserID, eventName, filter, channels)
+	if err != nil {
+		logger.Errorf("error updating channels of subscription for user %v eventName %v eventfilter %v: %v", user.UserID, eventName, filter, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(200)
+}
+
+// UserNotificationsWebhookPost sets the webhook the user receives notifications on and generates a new signing secret
+func UserNotificationsWebhookPost(w http.ResponseWriter, r *http.Request) {
+	user, session, err := getUserSession(w, r)
+	if err != nil {
+		logger.Errorf("error retrieving session: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		logger.Errorf("error parsing form: %v", err)
+		session.AddFlash(authInternalServerErrorFlashMsg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/notifications", http.StatusSeeOther)
+		return
+	}
+
+	webhookURL := strings.TrimSpace(r.FormValue("url"))
+	err = notify.ValidateWebhookURL(webhookURL)
+	if err == notify.ErrWebhookAddressNotAllowed {
+		session.AddFlash("Error: Invalid webhook url, the webhook has to point to a public address")
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/notifications", http.StatusSeeOther)
+		return
+	}
+	if err != nil || len(webhookURL) > 500 {
+		session.AddFlash("Error: Invalid webhook url, the url has to start with https://")
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/notifications", http.StatusSeeOther)
+		return
+	}
+
+	err = db.SaveUserWebhook(&types.UserWebhook{UserID: user.UserID, URL: webhookURL, Secret: utils.RandomString(32)})
+	if err != nil {
+		logger.Errorf("error saving webhook for user %v: %v", user.UserID, err)
+		session.AddFlash(authInternalServerErrorFlashMsg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/notifications", http.StatusSeeOther)
+		return
+	}
+
+	session.AddFlash("Webhook saved successfully ✔️")
+	session.Save(r, w)
+	http.Redirect(w, r, "/user/notifications", http.StatusSeeOther)
+}
+
+// UserNotificationsWebhookDeletePost removes the webhook of the user
+func UserNotificationsWebhookDeletePost(w http.ResponseWriter, r *http.Request) {
+	user, session, err := getUserSession(w, r)
+	if err != nil {
+		logger.Errorf("error retrieving session: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = db.DeleteUserWebhook(user.UserID)
+	if err != nil {
+		logger.Errorf("error deleting webhook for user %v: %v", user.UserID, err)
+		session.AddFlash(authInternalServerErrorFlashMsg)
+		session.Save(r, w)
+		http.Redirect(w, r, "/user/notifications", http.StatusSeeOther)
+		return
+	}
+
+	session.AddFlash("Webhook removed successfully ✔️")
+	session.Save(r, w)
+	http.Redirect(w, r, "/user/notifications", http.StatusSeeOther)
+}
@@ -307,6 +307,13 @@ func Validator(w http.ResponseWriter, r *http.Request) {
 		validatorPageData.Apr = float64(-1)
 	}
 
+	validatorPageData.Rewards, err = GetValidatorRewardsBreakdown([]uint64{index})
+	if err != nil {
+		logger.Errorf("error retrieving validator rewards breakdown: %v", err)
+		http.Error(w, "Internal server error", 503)
+		return
+	}
+
 	var effectiveBalanceHistory []*types.ValidatorBalanceHistory
 	err = db.DB.Select(&effectiveBalanceHistory, "SELECT epoch, COALESCE(effectivebalance, 0) as balance FROM validator_balances WHERE validatorindex = $1 ORDER BY epoch", index)
 	if err != nil {
@@ -386,26 +393,15 @@ func Validator(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = db.DB.Get(&validatorPageData.AverageAttestationInclusionDistance, `
-	SELECT 
-		COALESCE(AVG(1 + inclusionslot - COALESCE((SELECT MIN(slot) 
-	FROM 
-		blocks 
-	WHERE 
-		slot > attestation_assignments.attesterslot AND blocks.status IN ('1', '3')), 0)), 0) 
-	FROM 
-		attestation_assignments 
-	WHERE epoch > $1 AND validatorindex = $2 AND inclusionslot > 0
-	`, int64(data.CurrentEpoch)-100, index)
+	validatorPageData.Effectiveness, err = GetValidatorEffectivenessBreakdown([]uint64{index})
 	if err != nil {
-		logger.Errorf("error retrieving AverageAttestationInclusionDistance: %v", err)
+		logger.Errorf("error retrieving validator effectiveness breakdown: %v", err)
 		http.Error(w, "Internal server error", 503)
 		return
 	}
 
-	if validatorPageData.AverageAttestationInclusionDistance > 0 {
-		validatorPageData.AttestationInclusionEffectiveness = 1.0 / validatorPageData.AverageAttestationInclusionDistance * 100
-	}
+	validatorPageData.AverageAttestationInclusionDistance = validatorPageData.Effectiveness.LastDay.InclusionDelay
+	validatorPageData.AttestationInclusionEffectiveness = validatorPageData.Effectiveness.LastDay.Effectiveness * 100
 
 	data.Data = validatorPageData
 
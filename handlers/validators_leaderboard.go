@@ -92,6 +92,7 @@ func ValidatorsLeaderboardData(w http.ResponseWriter, r *http.Request) {
 		"5": "performance7d",
 		"6": "performance31d",
 		"7": "performance365d",
+		"8": "COALESCE(validator_effectiveness.effectiveness, 0)",
 	}
 	orderBy, exists := orderByMap[orderColumn]
 	if !exists {
@@ -120,9 +121,11 @@ func ValidatorsLeaderboardData(w http.ResponseWriter, r *http.Request) {
 					ROW_NUMBER() OVER (ORDER BY `+orderBy+` DESC) AS rank,
 					validator_performance.*,
 					validators.pubkey, 
-					COALESCE(validators.name, '') AS name
+					COALESCE(validators.name, '') AS name,
+					COALESCE(validator_effectiveness.effectiveness, 0) AS effectiveness
 				FROM validator_performance 
 					LEFT JOIN validators ON validators.validatorindex = validator_performance.validatorindex
+					LEFT JOIN validator_effectiveness ON validator_effectiveness.validatorindex = validator_performance.validatorindex
 				ORDER BY `+orderBy+` `+orderDir+`
 			) AS a
 			LIMIT $1 OFFSET $2`, length, start)
@@ -151,9 +154,11 @@ func ValidatorsLeaderboardData(w http.ResponseWriter, r *http.Request) {
 					ROW_NUMBER() OVER (ORDER BY `+orderBy+` DESC) AS rank,
 					validator_performance.*,
 					validators.pubkey, 
-					COALESCE(validators.name, '') AS name
+					COALESCE(validators.name, '') AS name,
+					COALESCE(validator_effectiveness.effectiveness, 0) AS effectiveness
 				FROM validator_performance 
 					LEFT JOIN validators ON validators.validatorindex = validator_performance.validatorindex
+					LEFT JOIN validator_effectiveness ON validator_effectiveness.validatorindex = validator_performance.validatorindex
 				ORDER BY `+orderBy+` `+orderDir+`
 			) AS a
 			WHERE (encode(a.pubkey::bytea, 'hex') LIKE $3
@@ -178,6 +183,7 @@ func ValidatorsLeaderboardData(w http.ResponseWriter, r *http.Request) {
 			utils.FormatIncome(b.Performance7d),
 			utils.FormatIncome(b.Performance31d),
 			utils.FormatIncome(b.Performance365d),
+			utils.FormatAttestationInclusionEffectiveness(b.Effectiveness * 100),
 		}
 	}
 
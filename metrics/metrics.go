@@ -0,0 +1,65 @@
+// Package metrics serves the prometheus metrics of the indexer and the frontend
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.New().WithField("module", "metrics")
+
+// RegisterDBStats registers the connection pool statistics of a database with the name as db label
+func RegisterDBStats(name string, db *sql.DB) {
+	labels := prometheus.Labels{"db": name}
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "explorer_db_open_connections",
+		Help:        "Number of established connections to the database.",
+		ConstLabels: labels,
+	}, func() float64 {
+		return float64(db.Stats().OpenConnections)
+	})
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "explorer_db_in_use_connections",
+		Help:        "Number of connections to the database that are in use.",
+		ConstLabels: labels,
+	}, func() float64 {
+		return float64(db.Stats().InUse)
+	})
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name:        "explorer_db_idle_connections",
+		Help:        "Number of idle connections to the database.",
+		ConstLabels: labels,
+	}, func() float64 {
+		return float64(db.Stats().Idle)
+	})
+	promauto.NewCounterFunc(prometheus.CounterOpts{
+		Name:        "explorer_db_wait_count_total",
+		Help:        "Number of connections waited for.",
+		ConstLabels: labels,
+	}, func() float64 {
+		return float64(db.Stats().WaitCount)
+	})
+	promauto.NewCounterFunc(prometheus.CounterOpts{
+		Name:        "explorer_db_wait_duration_seconds_total",
+		Help:        "Time blocked waiting for a new connection.",
+		ConstLabels: labels,
+	}, func() float64 {
+		return db.Stats().WaitDuration.Seconds()
+	})
+}
+
+// Serve serves the metrics of the default registry on /metrics of the address
+func Serve(address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	logger.Infof("metrics server listening on %v", address)
+	err := http.ListenAndServe(address, mux)
+	if err != nil {
+		logger.Errorf("error serving metrics: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package notify
+
+import (
+	"eth2-exporter/mail"
+	"eth2-exporter/types"
+)
+
+// EmailNotifier sends the notifications as plaintext mails
+type EmailNotifier struct {
+	send func(to, subject, msg string) error
+}
+
+// NewEmailNotifier creates a notifier that sends mails with the configured mail service. The number of mails is
+// limited by the maxMailsPerEmailPerDay setting that is shared with all other mails sent to the address.
+func NewEmailNotifier() *EmailNotifier {
+	return &EmailNotifier{send: mail.SendMailRateLimited}
+}
+
+func (n *EmailNotifier) Channel() types.NotificationChannel {
+	return types.EmailNotificationChannel
+}
+
+func (n *EmailNotifier) Target(recipient *types.NotificationRecipient) string {
+	return recipient.Email
+}
+
+func (n *EmailNotifier) MaxPerDay() int {
+	return 0
+}
+
+func (n *EmailNotifier) Send(recipient *types.NotificationRecipient, msg *Message) error {
+	return n.send(recipient.Email, msg.Subject, msg.Text())
+}
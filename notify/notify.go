@@ -0,0 +1,175 @@
+package notify
+
+import (
+	"errors"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+)
+
+var logger = logrus.New().WithField("module", "notify")
+
+var notificationsSent = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "explorer_notifications_sent_total",
+	Help: "Number of notification deliveries by event, channel and status (sent, failed or ratelimited).",
+}, []string{"event", "channel", "status"})
+
+// Notifier delivers messages to users on one notification channel
+type Notifier interface {
+	Channel() types.NotificationChannel
+	// Target returns the address of the recipient on the channel, empty if the recipient can not be reached on it
+	Target(recipient *types.NotificationRecipient) string
+	// MaxPerDay returns the maximum number of messages a recipient receives on the channel per day, zero is unlimited
+	MaxPerDay() int
+	Send(recipient *types.NotificationRecipient, msg *Message) error
+}
+
+// Message is a batch of notifications that is delivered to a user at once
+type Message struct {
+	Subject       string
+	Notifications map[types.EventName][]types.Notification
+}
+
+// eventNames returns the event names of the message in a stable order
+func (m *Message) eventNames() []types.EventName {
+	events := make([]types.EventName, 0, len(m.Notifications))
+	for event := range m.Notifications {
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i] < events[j]
+	})
+	return events
+}
+
+// SubscriptionIDs returns the ids of the subscriptions the notifications of the message belong to
+func (m *Message) SubscriptionIDs() []uint64 {
+	ids := make([]uint64, 0)
+	seen := make(map[uint64]bool)
+	for _, ns := range m.Notifications {
+		for _, n := range ns {
+			if !seen[n.GetSubscriptionID()] {
+				seen[n.GetSubscriptionID()] = true
+				ids = append(ids, n.GetSubscriptionID())
+			}
+		}
+	}
+	return ids
+}
+
+// Text returns the message as plaintext
+func (m *Message) Text() string {
+	msg := ""
+	for _, event := range m.eventNames() {
+		if len(msg) > 0 {
+			msg += "\n"
+		}
+		msg += fmt.Sprintf("%s\n====\n\n", event)
+		for _, n := range m.Notifications[event] {
+			msg += fmt.Sprintf("%s\n", n.GetInfo())
+		}
+		if event == types.ValidatorBalanceDecreasedEventName {
+			msg += "\nYou will not receive any further balance decrease mails for these validators until the balance of a validator is increasing again.\n"
+		}
+	}
+	msg += fmt.Sprintf("\nBest regards\n\n%s", utils.Config.Frontend.SiteDomain)
+	return msg
+}
+
+// notificationPayload is the json representation of a notification sent by the webhook and push channels
+type notificationPayload struct {
+	Event          types.EventName `json:"event"`
+	Epoch          uint64          `json:"epoch"`
+	SubscriptionID uint64          `json:"subscription_id"`
+	Info           string          `json:"info"`
+}
+
+func (m *Message) payload() []*notificationPayload {
+	payload := make([]*notificationPayload, 0)
+	for _, event := range m.eventNames() {
+		for _, n := range m.Notifications[event] {
+			payload = append(payload, &notificationPayload{
+				Event:          event,
+				Epoch:          n.GetEpoch(),
+				SubscriptionID: n.GetSubscriptionID(),
+				Info:           n.GetInfo(),
+			})
+		}
+	}
+	return payload
+}
+
+// Notifiers returns the configured notifiers of all notification channels
+func Notifiers() map[types.NotificationChannel]Notifier {
+	cfg := utils.Config.Frontend.Notifications
+	return map[types.NotificationChannel]Notifier{
+		types.EmailNotificationChannel:   NewEmailNotifier(),
+		types.WebhookNotificationChannel: NewWebhookNotifier(time.Second*time.Duration(cfg.Webhook.Timeout), cfg.Webhook.MaxPerDay),
+		types.PushNotificationChannel:    NewPushNotifier(cfg.Push.Endpoint, cfg.Push.ServerKey, cfg.Push.MaxPerDay),
+	}
+}
+
+// Deliver sends the message to the recipient unless the rate limit of the channel has been exceeded and
+// records the attempt in the delivery log
+func Deliver(n Notifier, recipient *types.NotificationRecipient, msg *Message) error {
+	delivery := &types.NotificationDelivery{
+		UserID:  recipient.UserID,
+		Channel: n.Channel(),
+		Target:  n.Target(recipient),
+		Status:  "sent",
+		Ts:      time.Now(),
+	}
+	for _, id := range msg.SubscriptionIDs() {
+		delivery.SubscriptionIDs = append(delivery.SubscriptionIDs, int64(id))
+	}
+
+	err := checkRateLimit(n, recipient)
+	if err == nil {
+		err = n.Send(recipient, msg)
+	}
+
+	var rateLimitError *types.RateLimitError
+	if errors.As(err, &rateLimitError) {
+		delivery.Status = "ratelimited"
+		delivery.Error = err.Error()
+	} else if err != nil {
+		delivery.Status = "failed"
+		delivery.Error = err.Error()
+	}
+
+	for _, event := range msg.eventNames() {
+		notificationsSent.WithLabelValues(string(event), string(delivery.Channel), delivery.Status).Inc()
+	}
+
+	dbErr := db.SaveNotificationDelivery(delivery)
+	if dbErr != nil {
+		logger.Errorf("error saving %v delivery of user %v: %v", delivery.Channel, delivery.UserID, dbErr)
+	}
+
+	return err
+}
+
+// checkRateLimit returns a rate limit error if the recipient has already received the maximum number of
+// messages on the channel during the last 24 hours
+func checkRateLimit(n Notifier, recipient *types.NotificationRecipient) error {
+	if n.MaxPerDay() <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	count, oldest, err := db.GetNotificationDeliveriesCount(recipient.UserID, n.Channel(), now.Add(time.Hour*-24))
+	if err != nil {
+		return fmt.Errorf("error retrieving %v deliveries of user %v: %v", n.Channel(), recipient.UserID, err)
+	}
+	if count >= n.MaxPerDay() {
+		return &types.RateLimitError{TimeLeft: oldest.Add(time.Hour * 24).Sub(now)}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package notify
+
+import (
+	"encoding/json"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testNotification struct {
+	subscriptionID uint64
+	epoch          uint64
+}
+
+func (n *testNotification) GetSubscriptionID() uint64 {
+	return n.subscriptionID
+}
+
+func (n *testNotification) GetEpoch() uint64 {
+	return n.epoch
+}
+
+func (n *testNotification) GetEventName() types.EventName {
+	return types.NetworkReorgEventName
+}
+
+func (n *testNotification) GetInfo() string {
+	return "reorg at epoch " + strconv.FormatUint(n.epoch, 10)
+}
+
+func testMessage() *Message {
+	return &Message{
+		Subject: "test",
+		Notifications: map[types.EventName][]types.Notification{
+			types.NetworkReorgEventName: {&testNotification{subscriptionID: 1, epoch: 10}, &testNotification{subscriptionID: 1, epoch: 11}},
+		},
+	}
+}
+
+func TestEmailNotifier(t *testing.T) {
+	utils.Config = &types.Config{}
+	utils.Config.Frontend.SiteDomain = "beaconcha.in"
+
+	var to, subject, body string
+	n := &EmailNotifier{send: func(t, s, b string) error {
+		to, subject, body = t, s, b
+		return nil
+	}}
+
+	err := n.Send(&types.NotificationRecipient{Email: "user@example.com"}, testMessage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if to != "user@example.com" || subject != "test" || !strings.Contains(body, "reorg at epoch 11") {
+		t.Errorf("unexpected mail to %v with subject %v: %v", to, subject, body)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var payload webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get("X-Signature-Timestamp"), 10, 64)
+		if r.Header.Get("X-Signature") != "sha256="+SignWebhookPayload("secret", ts, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.Unmarshal(body, &payload)
+	}))
+	defer server.Close()
+
+	// the test server listens on a loopback address
+	n := newWebhookNotifier(time.Second, 0, func(net.IP) bool { return true })
+	err := n.Send(&types.NotificationRecipient{WebhookURL: server.URL, WebhookSecret: "secret"}, testMessage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Notifications) != 2 || payload.Notifications[1].Epoch != 11 || payload.Notifications[1].Event != types.NetworkReorgEventName {
+		t.Errorf("unexpected webhook payload: %+v", payload)
+	}
+
+	err = n.Send(&types.NotificationRecipient{WebhookURL: server.URL, WebhookSecret: "wrong"}, testMessage())
+	if err == nil {
+		t.Errorf("expected error for rejected webhook request")
+	}
+}
+
+func TestWebhookNotifierAddresses(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier(time.Second, 0).Send(&types.NotificationRecipient{WebhookURL: server.URL, WebhookSecret: "secret"}, testMessage())
+	if err == nil || requests != 0 {
+		t.Errorf("expected the webhook request to the loopback address to be rejected, got %v after %v requests", err, requests)
+	}
+
+	n := newWebhookNotifier(time.Second, 0, func(ip net.IP) bool { return ip.IsLoopback() })
+	err = n.Send(&types.NotificationRecipient{WebhookURL: server.URL, WebhookSecret: "secret"}, testMessage())
+	if err == nil || requests != 1 {
+		t.Errorf("expected the redirect not to be followed, got %v after %v requests", err, requests)
+	}
+
+	for _, ip := range []string{"127.0.0.1", "10.1.2.3", "172.16.0.1", "192.168.1.1", "169.254.169.254", "100.64.0.1", "0.0.0.0", "224.0.0.1", "::1", "fe80::1", "fd00:ec2::254", "::ffff:127.0.0.1"} {
+		if IsPublicWebhookIP(net.ParseIP(ip)) {
+			t.Errorf("expected %v not to be public", ip)
+		}
+	}
+	for _, ip := range []string{"1.1.1.1", "172.32.0.1", "2606:4700:4700::1111"} {
+		if !IsPublicWebhookIP(net.ParseIP(ip)) {
+			t.Errorf("expected %v to be public", ip)
+		}
+	}
+
+	for webhookURL, valid := range map[string]bool{
+		"https://example.com/hook":         true,
+		"https://1.1.1.1/hook":             true,
+		"http://example.com/hook":          false,
+		"https://127.0.0.1:8080/hook":      false,
+		"https://[::1]/hook":               false,
+		"https://169.254.169.254/metadata": false,
+	} {
+		if err := ValidateWebhookURL(webhookURL); (err == nil) != valid {
+			t.Errorf("unexpected validation result for %v: %v", webhookURL, err)
+		}
+	}
+}
+
+func TestPushNotifier(t *testing.T) {
+	var req pushRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "key=server-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(&pushResponse{Success: len(req.RegistrationIDs) - 1, Failure: 1})
+	}))
+	defer server.Close()
+
+	n := NewPushNotifier(server.URL, "server-key", 0)
+	if n.Target(&types.NotificationRecipient{}) != "" {
+		t.Errorf("expected recipient without devices to be unreachable")
+	}
+
+	err := n.Send(&types.NotificationRecipient{DeviceTokens: []string{"a", "b"}}, testMessage())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(req.RegistrationIDs) != 2 || req.Notification.Title != "test" || len(req.Data.Notifications) != 2 {
+		t.Errorf("unexpected push request: %+v", req)
+	}
+
+	err = n.Send(&types.NotificationRecipient{DeviceTokens: []string{"a"}}, testMessage())
+	if err == nil {
+		t.Errorf("expected error if the delivery to all devices failed")
+	}
+
+	err = NewPushNotifier(server.URL, "wrong", 0).Send(&types.NotificationRecipient{DeviceTokens: []string{"a"}}, testMessage())
+	if err == nil {
+		t.Errorf("expected error for unauthorized push request")
+	}
+}
@@ -0,0 +1,106 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"eth2-exporter/types"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultPushEndpoint is the legacy http endpoint of firebase cloud messaging
+const defaultPushEndpoint = "https://fcm.googleapis.com/fcm/send"
+
+// PushNotifier sends the notifications to the devices of the user that have enabled notifications in the
+// mobile app, using the notification tokens stored in users_devices
+type PushNotifier struct {
+	client    *http.Client
+	endpoint  string
+	serverKey string
+	maxPerDay int
+}
+
+type pushRequest struct {
+	RegistrationIDs []string          `json:"registration_ids"`
+	Notification    *pushNotification `json:"notification"`
+	Data            *webhookPayload   `json:"data"`
+}
+
+type pushNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type pushResponse struct {
+	Success int `json:"success"`
+	Failure int `json:"failure"`
+}
+
+// NewPushNotifier creates a push notifier for the given push service endpoint (default fcm)
+func NewPushNotifier(endpoint, serverKey string, maxPerDay int) *PushNotifier {
+	if endpoint == "" {
+		endpoint = defaultPushEndpoint
+	}
+	return &PushNotifier{client: &http.Client{Timeout: time.Second * 10}, endpoint: endpoint, serverKey: serverKey, maxPerDay: maxPerDay}
+}
+
+func (n *PushNotifier) Channel() types.NotificationChannel {
+	return types.PushNotificationChannel
+}
+
+func (n *PushNotifier) Target(recipient *types.NotificationRecipient) string {
+	if n.serverKey == "" || len(recipient.DeviceTokens) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%v devices", len(recipient.DeviceTokens))
+}
+
+func (n *PushNotifier) MaxPerDay() int {
+	return n.maxPerDay
+}
+
+func (n *PushNotifier) Send(recipient *types.NotificationRecipient, msg *Message) error {
+	payload := msg.payload()
+	infos := make([]string, len(payload))
+	for i, p := range payload {
+		infos[i] = p.Info
+	}
+
+	body, err := json.Marshal(&pushRequest{
+		RegistrationIDs: recipient.DeviceTokens,
+		Notification:    &pushNotification{Title: msg.Subject, Body: strings.Join(infos, "\n")},
+		Data:            &webhookPayload{Subject: msg.Subject, Ts: time.Now().Unix(), Notifications: payload},
+	})
+	if err != nil {
+		return fmt.Errorf("error serializing push request: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", n.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating push request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "key="+n.serverKey)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending push request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error sending push request: unexpected status code %v", resp.StatusCode)
+	}
+
+	res := &pushResponse{}
+	err = json.NewDecoder(resp.Body).Decode(res)
+	if err != nil {
+		return fmt.Errorf("error decoding push response: %v", err)
+	}
+	if res.Success == 0 {
+		return fmt.Errorf("error sending push request: delivery to all %v devices failed", res.Failure)
+	}
+	return nil
+}
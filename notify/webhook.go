@@ -0,0 +1,188 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"eth2-exporter/types"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// WebhookNotifier posts the notifications as signed json to the webhook of the user. The body is signed with
+// HMAC-SHA256 using the secret of the webhook, the signature is computed over "<timestamp>.<body>" and sent
+// in the X-Signature header as "sha256=<hex>" along with the timestamp in the X-Signature-Timestamp header.
+type WebhookNotifier struct {
+	client    *http.Client
+	maxPerDay int
+}
+
+// webhookPayload is the body of a webhook request
+type webhookPayload struct {
+	Subject       string                 `json:"subject"`
+	Ts            int64                  `json:"ts"`
+	Notifications []*notificationPayload `json:"notifications"`
+}
+
+// ErrWebhookAddressNotAllowed is returned for webhooks that point to a loopback, private, link-local, multicast or
+// unspecified address
+var ErrWebhookAddressNotAllowed = errors.New("the webhook address is not a public address")
+
+// blockedWebhookNetworks holds the networks besides the loopback, link-local, multicast and unspecified addresses that
+// webhooks must not be sent to
+var blockedWebhookNetworks = mustParseCIDRs(
+	"0.0.0.0/8",      // this network
+	"10.0.0.0/8",     // private (RFC 1918)
+	"100.64.0.0/10",  // carrier-grade nat
+	"172.16.0.0/12",  // private (RFC 1918)
+	"192.0.0.0/24",   // ietf protocol assignments
+	"192.168.0.0/16", // private (RFC 1918)
+	"198.18.0.0/15",  // benchmarking
+	"240.0.0.0/4",    // reserved, including the broadcast address
+	"64:ff9b::/96",   // nat64 to ipv4 addresses
+	"fc00::/7",       // unique local addresses, including the ipv6 metadata endpoint of aws
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// IsPublicWebhookIP returns false for the addresses webhooks must not be sent to. Link-local addresses include the
+// metadata endpoints of the cloud providers (169.254.169.254).
+func IsPublicWebhookIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, network := range blockedWebhookNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateWebhookURL checks the url of a webhook before it is saved, hosts that are ip addresses have to be public. The
+// addresses host names resolve to are checked when the webhook is sent.
+func ValidateWebhookURL(webhookURL string) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return fmt.Errorf("error parsing webhook url: %v", err)
+	}
+	if u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("the webhook url has to start with https://")
+	}
+	ip := net.ParseIP(u.Hostname())
+	if ip != nil && !IsPublicWebhookIP(ip) {
+		return ErrWebhookAddressNotAllowed
+	}
+	return nil
+}
+
+// NewWebhookNotifier creates a webhook notifier, requests time out after timeout (default 10 seconds). The requests
+// are only sent to public addresses and redirects are not followed.
+func NewWebhookNotifier(timeout time.Duration, maxPerDay int) *WebhookNotifier {
+	return newWebhookNotifier(timeout, maxPerDay, IsPublicWebhookIP)
+}
+
+func newWebhookNotifier(timeout time.Duration, maxPerDay int, allowIP func(net.IP) bool) *WebhookNotifier {
+	if timeout <= 0 {
+		timeout = time.Second * 10
+	}
+
+	// the resolved address is checked right before connecting so that host names can not be rebound to internal
+	// addresses after a check, no proxy is used as the check would apply to the address of the proxy
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: time.Second * 30,
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !allowIP(ip) {
+				return ErrWebhookAddressNotAllowed
+			}
+			return nil
+		},
+	}
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     time.Second * 90,
+			TLSHandshakeTimeout: time.Second * 10,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return &WebhookNotifier{client: client, maxPerDay: maxPerDay}
+}
+
+func (n *WebhookNotifier) Channel() types.NotificationChannel {
+	return types.WebhookNotificationChannel
+}
+
+func (n *WebhookNotifier) Target(recipient *types.NotificationRecipient) string {
+	return recipient.WebhookURL
+}
+
+func (n *WebhookNotifier) MaxPerDay() int {
+	return n.maxPerDay
+}
+
+func (n *WebhookNotifier) Send(recipient *types.NotificationRecipient, msg *Message) error {
+	ts := time.Now().Unix()
+	body, err := json.Marshal(&webhookPayload{Subject: msg.Subject, Ts: ts, Notifications: msg.payload()})
+	if err != nil {
+		return fmt.Errorf("error serializing webhook payload: %v", err)
+	}
+
+	req, err := http.NewRequest("POST", recipient.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating webhook request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Signature-Timestamp", strconv.FormatInt(ts, 10))
+	req.Header.Set("X-Signature", "sha256="+SignWebhookPayload(recipient.WebhookSecret, ts, body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending webhook request: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("error sending webhook request: unexpected status code %v", resp.StatusCode)
+	}
+	return nil
+}
+
+// SignWebhookPayload returns the hex encoded HMAC-SHA256 signature of a webhook request
+func SignWebhookPayload(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", ts)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,56 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testItem struct {
+	Index    uint64     `json:"index" doc:"Index of the item"`
+	Name     string     `json:"name,omitempty"`
+	Status   string     `json:"status" enum:"a,b"`
+	Ts       time.Time  `json:"ts"`
+	Parent   *testItem  `json:"parent"`
+	Children []testItem `json:"children"`
+	Data     []byte     `json:"data"`
+	Ignored  string     `json:"-"`
+	internal string
+}
+
+func TestSchemaOf(t *testing.T) {
+	spec := New(Info{Title: "test", Version: "1"})
+
+	ref := spec.SchemaOf(testItem{})
+	if ref.Ref != "#/components/schemas/testItem" {
+		t.Fatalf("expected a reference to testItem, got %+v", ref)
+	}
+
+	schema := spec.Components.Schemas["testItem"]
+	if schema == nil {
+		t.Fatalf("expected testItem in the components")
+	}
+
+	expected := map[string]*Schema{
+		"index":    {Type: "integer", Format: "int64", Description: "Index of the item"},
+		"name":     {Type: "string"},
+		"status":   {Type: "string", Enum: []string{"a", "b"}},
+		"ts":       {Type: "string", Format: "date-time"},
+		"parent":   {Ref: "#/components/schemas/testItem"},
+		"children": {Type: "array", Items: &Schema{Ref: "#/components/schemas/testItem"}},
+		"data":     {Type: "string", Format: "byte"},
+	}
+	if !reflect.DeepEqual(schema.Properties, expected) {
+		t.Errorf("unexpected properties: %+v", schema.Properties)
+	}
+
+	required := []string{"index", "status", "ts", "children", "data"}
+	if !reflect.DeepEqual(schema.Required, required) {
+		t.Errorf("expected required %v, got %v", required, schema.Required)
+	}
+
+	nullable := spec.SchemaOf(new(uint64))
+	if nullable.Type != "integer" || !nullable.Nullable {
+		t.Errorf("expected a nullable integer, got %+v", nullable)
+	}
+}
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit allows Requests requests per Period, a limit of zero requests is unlimited
+type Limit struct {
+	Requests uint64
+	Period   time.Duration
+}
+
+// Result is the outcome of a request checked against a limit
+type Result struct {
+	Allowed    bool
+	Limit      uint64
+	Remaining  uint64
+	Reset      time.Duration // time until the bucket is full again
+	RetryAfter time.Duration // time until the next request is allowed, zero if the request has been allowed
+}
+
+type bucket struct {
+	tokens   float64
+	capacity float64
+	rate     float64 // tokens per second
+	last     time.Time
+}
+
+// refill adds the tokens that have accumulated since the last request
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+}
+
+// Limiter is an in-memory token-bucket rate limiter. Every key has its own bucket that holds up to
+// Limit.Requests tokens and is refilled continuously over Limit.Period.
+type Limiter struct {
+	mux     sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// NewLimiter creates a limiter and starts a goroutine that removes the buckets of idle keys
+func NewLimiter() *Limiter {
+	l := &Limiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+	go l.cleaner()
+	return l
+}
+
+// Allow takes a token from the bucket of key for the given limit
+func (l *Limiter) Allow(key string, limit Limit) *Result {
+	if limit.Requests == 0 || limit.Period <= 0 {
+		return &Result{Allowed: true}
+	}
+
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	now := l.now()
+	capacity := float64(limit.Requests)
+
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[key] = b
+	}
+	b.capacity = capacity
+	b.rate = capacity / limit.Period.Seconds()
+	b.refill(now)
+
+	res := &Result{Limit: limit.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsToDuration((1 - b.tokens) / b.rate)
+	}
+	res.Remaining = uint64(b.tokens)
+	res.Reset = secondsToDuration((b.capacity - b.tokens) / b.rate)
+	return res
+}
+
+// cleaner periodically removes the buckets that have been refilled completely, a full bucket is
+// identical to the new bucket that is created on the next request of its key
+func (l *Limiter) cleaner() {
+	for {
+		time.Sleep(time.Minute * 10)
+		l.removeFull()
+	}
+}
+
+func (l *Limiter) removeFull() {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+
+	now := l.now()
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= b.capacity {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds)) * time.Second
+}
@@ -0,0 +1,41 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	l := &Limiter{buckets: make(map[string]*bucket), now: func() time.Time { return now }}
+	limit := Limit{Requests: 10, Period: time.Minute}
+
+	for i := 0; i < 10; i++ {
+		if res := l.Allow("a", limit); !res.Allowed || res.Remaining != uint64(9-i) {
+			t.Fatalf("expected request %v to be allowed with %v remaining, got %+v", i, 9-i, res)
+		}
+	}
+
+	res := l.Allow("a", limit)
+	if res.Allowed || res.RetryAfter != time.Second*6 || res.Reset != time.Minute {
+		t.Errorf("expected request to be limited with retry after 6s and reset after 1m, got %+v", res)
+	}
+	if res := l.Allow("b", limit); !res.Allowed {
+		t.Errorf("expected request of another key to be allowed")
+	}
+
+	now = now.Add(time.Second * 6)
+	if res := l.Allow("a", limit); !res.Allowed || res.Remaining != 0 {
+		t.Errorf("expected request to be allowed after refill, got %+v", res)
+	}
+
+	if res := l.Allow("a", Limit{}); !res.Allowed {
+		t.Errorf("expected request without limit to be allowed")
+	}
+
+	now = now.Add(time.Minute)
+	l.removeFull()
+	if len(l.buckets) != 0 {
+		t.Errorf("expected full buckets to be removed, got %v buckets", len(l.buckets))
+	}
+}
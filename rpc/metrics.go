@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"eth2-exporter/types"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	rpcRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "explorer_rpc_request_duration_seconds",
+		Help:    "Duration of the calls to the backend nodes.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"node", "method"})
+	rpcRequestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "explorer_rpc_request_errors_total",
+		Help: "Number of failed calls to the backend nodes.",
+	}, []string{"node", "method"})
+)
+
+// InstrumentedClient records the latency and errors of the calls to a backend node
+type InstrumentedClient struct {
+	name   string
+	client Client
+}
+
+// NewInstrumentedClient wraps the client of the backend node with the name
+func NewInstrumentedClient(name string, client Client) *InstrumentedClient {
+	return &InstrumentedClient{name: name, client: client}
+}
+
+func (ic *InstrumentedClient) observe(method string, start time.Time, err error) {
+	rpcRequestDuration.WithLabelValues(ic.name, method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		rpcRequestErrors.WithLabelValues(ic.name, method).Inc()
+	}
+}
+
+// GetChainHead gets the chain head from the node
+func (ic *InstrumentedClient) GetChainHead() (*types.ChainHead, error) {
+	start := time.Now()
+	res, err := ic.client.GetChainHead()
+	ic.observe("GetChainHead", start, err)
+	return res, err
+}
+
+// GetEpochData gets the epoch data from the node
+func (ic *InstrumentedClient) GetEpochData(epoch uint64) (*types.EpochData, error) {
+	start := time.Now()
+	res, err := ic.client.GetEpochData(epoch)
+	ic.observe("GetEpochData", start, err)
+	return res, err
+}
+
+// GetValidatorQueue gets the validator queue from the node
+func (ic *InstrumentedClient) GetValidatorQueue() (*types.ValidatorQueue, error) {
+	start := time.Now()
+	res, err := ic.client.GetValidatorQueue()
+	ic.observe("GetValidatorQueue", start, err)
+	return res, err
+}
+
+// GetAttestationPool gets the attestation pool from the node
+func (ic *InstrumentedClient) GetAttestationPool() ([]*types.Attestation, error) {
+	start := time.Now()
+	res, err := ic.client.GetAttestationPool()
+	ic.observe("GetAttestationPool", start, err)
+	return res, err
+}
+
+// GetEpochAssignments gets the epoch assignments from the node
+func (ic *InstrumentedClient) GetEpochAssignments(epoch uint64) (*types.EpochAssignments, error) {
+	start := time.Now()
+	res, err := ic.client.GetEpochAssignments(epoch)
+	ic.observe("GetEpochAssignments", start, err)
+	return res, err
+}
+
+// GetBlocksBySlot gets the blocks of a slot from the node
+func (ic *InstrumentedClient) GetBlocksBySlot(slot uint64) ([]*types.Block, error) {
+	start := time.Now()
+	res, err := ic.client.GetBlocksBySlot(slot)
+	ic.observe("GetBlocksBySlot", start, err)
+	return res, err
+}
+
+// GetValidatorParticipation gets the validator participation of an epoch from the node
+func (ic *InstrumentedClient) GetValidatorParticipation(epoch uint64) (*types.ValidatorParticipation, error) {
+	start := time.Now()
+	res, err := ic.client.GetValidatorParticipation(epoch)
+	ic.observe("GetValidatorParticipation", start, err)
+	return res, err
+}
@@ -0,0 +1,243 @@
+package rpc
+
+import (
+	"bytes"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// multiClientSyncTolerance is the amount of slots a node may lag behind the best known head and still be considered synced
+var multiClientSyncTolerance = uint64(2)
+
+// MultiClientNode is a named backend node of a MultiClient
+type MultiClientNode struct {
+	Name   string
+	Client Client
+
+	head    *types.ChainHead
+	healthy bool
+	lastErr error
+}
+
+// MultiClient holds several backend nodes and routes all calls to the healthiest synced node,
+// failing over to the other nodes if a call errors
+type MultiClient struct {
+	nodes      []*MultiClientNode
+	crossCheck bool
+	mux        *sync.RWMutex
+}
+
+// NewMultiClient is used to create a new client that wraps the passed nodes. The order of the nodes defines
+// their priority if several nodes are synced. If crossCheck is set the head and finalized roots of all nodes
+// will be compared and any divergence will be logged.
+func NewMultiClient(nodes []*MultiClientNode, crossCheck bool) (*MultiClient, error) {
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no backend nodes configured")
+	}
+
+	client := &MultiClient{
+		nodes:      nodes,
+		crossCheck: crossCheck,
+		mux:        &sync.RWMutex{},
+	}
+
+	client.updateNodes()
+	go client.nodesUpdater()
+
+	return client, nil
+}
+
+func (mc *MultiClient) nodesUpdater() {
+	for {
+		time.Sleep(time.Second * time.Duration(utils.Config.Chain.SecondsPerSlot))
+		mc.updateNodes()
+	}
+}
+
+// updateNodes retrieves the chain head of all nodes and updates their health status
+func (mc *MultiClient) updateNodes() {
+	heads := make([]*types.ChainHead, len(mc.nodes))
+	errs := make([]error, len(mc.nodes))
+
+	wg := &sync.WaitGroup{}
+	for i, node := range mc.nodes {
+		wg.Add(1)
+		go func(i int, node *MultiClientNode) {
+			defer wg.Done()
+			heads[i], errs[i] = node.Client.GetChainHead()
+		}(i, node)
+	}
+	wg.Wait()
+
+	mc.mux.Lock()
+	defer mc.mux.Unlock()
+
+	for i, node := range mc.nodes {
+		if errs[i] != nil {
+			if node.healthy || node.lastErr == nil {
+				logger.Errorf("backend node %v is unavailable: %v", node.Name, errs[i])
+			}
+			node.healthy = false
+			node.lastErr = errs[i]
+			continue
+		}
+		if !node.healthy {
+			logger.Infof("backend node %v is available at head slot %v", node.Name, heads[i].HeadSlot)
+		}
+		node.head = heads[i]
+		node.healthy = true
+		node.lastErr = nil
+	}
+
+	if mc.crossCheck {
+		mc.crossCheckNodes()
+	}
+}
+
+// crossCheckNodes compares the head and finalized roots of all healthy nodes and logs any divergence
+func (mc *MultiClient) crossCheckNodes() {
+	for i, a := range mc.nodes {
+		if !a.healthy {
+			continue
+		}
+		for _, b := range mc.nodes[i+1:] {
+			if !b.healthy {
+				continue
+			}
+			if a.head.HeadSlot == b.head.HeadSlot && !bytes.Equal(a.head.HeadBlockRoot, b.head.HeadBlockRoot) {
+				logger.Warnf("backend nodes %v and %v diverge at head slot %v: %x != %x", a.Name, b.Name, a.head.HeadSlot, a.head.HeadBlockRoot, b.head.HeadBlockRoot)
+			}
+			if a.head.FinalizedEpoch == b.head.FinalizedEpoch && !bytes.Equal(a.head.FinalizedBlockRoot, b.head.FinalizedBlockRoot) {
+				logger.Errorf("backend nodes %v and %v diverge at finalized epoch %v: %x != %x", a.Name, b.Name, a.head.FinalizedEpoch, a.head.FinalizedBlockRoot, b.head.FinalizedBlockRoot)
+			}
+		}
+	}
+}
+
+// candidates returns the nodes in the order they should be tried: synced nodes by priority,
+// then lagging nodes by head slot and finally unhealthy nodes as last resort
+func (mc *MultiClient) candidates() []*MultiClientNode {
+	mc.mux.RLock()
+	defer mc.mux.RUnlock()
+
+	bestSlot := uint64(0)
+	for _, node := range mc.nodes {
+		if node.healthy && node.head.HeadSlot > bestSlot {
+			bestSlot = node.head.HeadSlot
+		}
+	}
+
+	synced := make([]*MultiClientNode, 0, len(mc.nodes))
+	lagging := make([]*MultiClientNode, 0, len(mc.nodes))
+	unhealthy := make([]*MultiClientNode, 0, len(mc.nodes))
+	for _, node := range mc.nodes {
+		if !node.healthy {
+			unhealthy = append(unhealthy, node)
+		} else if node.head.HeadSlot+multiClientSyncTolerance >= bestSlot {
+			synced = append(synced, node)
+		} else {
+			lagging = append(lagging, node)
+		}
+	}
+	sort.SliceStable(lagging, func(i, j int) bool {
+		return lagging[i].head.HeadSlot > lagging[j].head.HeadSlot
+	})
+
+	return append(append(synced, lagging...), unhealthy...)
+}
+
+func (mc *MultiClient) markUnhealthy(node *MultiClientNode, err error) {
+	mc.mux.Lock()
+	defer mc.mux.Unlock()
+
+	node.healthy = false
+	node.lastErr = err
+}
+
+// do calls f with the client of each candidate node until the call succeeds
+func (mc *MultiClient) do(method string, f func(client Client) error) error {
+	var err error
+	for _, node := range mc.candidates() {
+		err = f(node.Client)
+		if err == nil {
+			return nil
+		}
+		logger.Errorf("error calling %v on backend node %v, failing over: %v", method, node.Name, err)
+		mc.markUnhealthy(node, err)
+	}
+	return fmt.Errorf("error calling %v on all backend nodes: %v", method, err)
+}
+
+// GetChainHead gets the chain head from the healthiest node
+func (mc *MultiClient) GetChainHead() (*types.ChainHead, error) {
+	var res *types.ChainHead
+	err := mc.do("GetChainHead", func(client Client) (err error) {
+		res, err = client.GetChainHead()
+		return err
+	})
+	return res, err
+}
+
+// GetEpochData gets the epoch data from the healthiest node
+func (mc *MultiClient) GetEpochData(epoch uint64) (*types.EpochData, error) {
+	var res *types.EpochData
+	err := mc.do("GetEpochData", func(client Client) (err error) {
+		res, err = client.GetEpochData(epoch)
+		return err
+	})
+	return res, err
+}
+
+// GetValidatorQueue gets the validator queue from the healthiest node
+func (mc *MultiClient) GetValidatorQueue() (*types.ValidatorQueue, error) {
+	var res *types.ValidatorQueue
+	err := mc.do("GetValidatorQueue", func(client Client) (err error) {
+		res, err = client.GetValidatorQueue()
+		return err
+	})
+	return res, err
+}
+
+// GetAttestationPool gets the attestation pool from the healthiest node
+func (mc *MultiClient) GetAttestationPool() ([]*types.Attestation, error) {
+	var res []*types.Attestation
+	err := mc.do("GetAttestationPool", func(client Client) (err error) {
+		res, err = client.GetAttestationPool()
+		return err
+	})
+	return res, err
+}
+
+// GetEpochAssignments gets the epoch assignments from the healthiest node
+func (mc *MultiClient) GetEpochAssignments(epoch uint64) (*types.EpochAssignments, error) {
+	var res *types.EpochAssignments
+	err := mc.do("GetEpochAssignments", func(client Client) (err error) {
+		res, err = client.GetEpochAssignments(epoch)
+		return err
+	})
+	return res, err
+}
+
+// GetBlocksBySlot gets the blocks of a slot from the healthiest node
+func (mc *MultiClient) GetBlocksBySlot(slot uint64) ([]*types.Block, error) {
+	var res []*types.Block
+	err := mc.do("GetBlocksBySlot", func(client Client) (err error) {
+		res, err = client.GetBlocksBySlot(slot)
+		return err
+	})
+	return res, err
+}
+
+// GetValidatorParticipation gets the validator participation from the healthiest node
+func (mc *MultiClient) GetValidatorParticipation(epoch uint64) (*types.ValidatorParticipation, error) {
+	var res *types.ValidatorParticipation
+	err := mc.do("GetValidatorParticipation", func(client Client) (err error) {
+		res, err = client.GetValidatorParticipation(epoch)
+		return err
+	})
+	return res, err
+}
@@ -0,0 +1,107 @@
+package rpc
+
+import (
+	"errors"
+	"eth2-exporter/types"
+	"testing"
+)
+
+// fakeClient is a Client that returns a fixed chain head and fails all calls if err is set
+type fakeClient struct {
+	head  *types.ChainHead
+	err   error
+	calls int
+}
+
+func (fc *fakeClient) GetChainHead() (*types.ChainHead, error) {
+	fc.calls++
+	return fc.head, fc.err
+}
+
+func (fc *fakeClient) GetEpochData(epoch uint64) (*types.EpochData, error) {
+	fc.calls++
+	return &types.EpochData{Epoch: epoch}, fc.err
+}
+
+func (fc *fakeClient) GetValidatorQueue() (*types.ValidatorQueue, error) {
+	fc.calls++
+	return &types.ValidatorQueue{}, fc.err
+}
+
+func (fc *fakeClient) GetAttestationPool() ([]*types.Attestation, error) {
+	fc.calls++
+	return nil, fc.err
+}
+
+func (fc *fakeClient) GetEpochAssignments(epoch uint64) (*types.EpochAssignments, error) {
+	fc.calls++
+	return &types.EpochAssignments{}, fc.err
+}
+
+func (fc *fakeClient) GetBlocksBySlot(slot uint64) ([]*types.Block, error) {
+	fc.calls++
+	return nil, fc.err
+}
+
+func (fc *fakeClient) GetValidatorParticipation(epoch uint64) (*types.ValidatorParticipation, error) {
+	fc.calls++
+	return &types.ValidatorParticipation{Epoch: epoch}, fc.err
+}
+
+func newTestMultiClient(t *testing.T, clients ...*fakeClient) *MultiClient {
+	setStandardTestConfig()
+	nodes := make([]*MultiClientNode, len(clients))
+	for i, c := range clients {
+		nodes[i] = &MultiClientNode{Name: string(rune('a' + i)), Client: c}
+	}
+	mc, err := NewMultiClient(nodes, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mc
+}
+
+func TestMultiClientPrefersSyncedNode(t *testing.T) {
+	lagging := &fakeClient{head: &types.ChainHead{HeadSlot: 10}}
+	synced := &fakeClient{head: &types.ChainHead{HeadSlot: 100}}
+	mc := newTestMultiClient(t, lagging, synced)
+
+	head, err := mc.GetChainHead()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.HeadSlot != 100 {
+		t.Errorf("expected the call to be routed to the synced node, got head slot %v", head.HeadSlot)
+	}
+}
+
+func TestMultiClientFailover(t *testing.T) {
+	first := &fakeClient{head: &types.ChainHead{HeadSlot: 100}}
+	second := &fakeClient{head: &types.ChainHead{HeadSlot: 100}}
+	mc := newTestMultiClient(t, first, second)
+
+	first.err = errors.New("connection refused")
+	data, err := mc.GetEpochData(3)
+	if err != nil {
+		t.Fatalf("expected failover to the second node, got error: %v", err)
+	}
+	if data.Epoch != 3 {
+		t.Errorf("unexpected epoch %v", data.Epoch)
+	}
+
+	// the failed node must be tried last until the next health update
+	callsBefore := first.calls
+	_, err = mc.GetValidatorParticipation(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.calls != callsBefore {
+		t.Errorf("expected the unhealthy node to be skipped")
+	}
+
+	second.err = errors.New("connection refused")
+	_, err = mc.GetValidatorQueue()
+	if err == nil {
+		t.Errorf("expected an error if all nodes fail")
+	}
+}
@@ -0,0 +1,706 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/prysmaticlabs/go-bitfield"
+)
+
+// errNotFound is returned by StandardClient.get if the node responded with a 404
+var errNotFound = errors.New("not found")
+
+// StandardClient holds the info of a client that speaks the standardized beacon node api (/eth/v1)
+type StandardClient struct {
+	endpoint            string
+	assignmentsCache    *lru.Cache
+	assignmentsCacheMux *sync.Mutex
+}
+
+// NewStandardClient is used to create a new client for the standardized beacon node api
+func NewStandardClient(endpoint string) (*StandardClient, error) {
+	client := &StandardClient{
+		endpoint:            endpoint,
+		assignmentsCacheMux: &sync.Mutex{},
+	}
+	client.assignmentsCache, _ = lru.New(128)
+
+	return client, nil
+}
+
+// GetChainHead gets the chain head using the header and finality checkpoints of the head state
+func (sc *StandardClient) GetChainHead() (*types.ChainHead, error) {
+	resp, err := sc.get(fmt.Sprintf("%v/eth/v1/beacon/headers/head", sc.endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving chain head: %v", err)
+	}
+
+	var parsedHead standardHeaderResponse
+	err = json.Unmarshal(resp, &parsedHead)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing chain head: %v", err)
+	}
+
+	resp, err = sc.get(fmt.Sprintf("%v/eth/v1/beacon/states/head/finality_checkpoints", sc.endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving finality checkpoints of head: %v", err)
+	}
+
+	var parsedFinality standardFinalityCheckpointsResponse
+	err = json.Unmarshal(resp, &parsedFinality)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing finality checkpoints of head: %v", err)
+	}
+
+	headSlot := uint64(parsedHead.Data.Header.Message.Slot)
+	finalizedEpoch := uint64(parsedFinality.Data.Finalized.Epoch)
+	justifiedEpoch := uint64(parsedFinality.Data.CurrentJustified.Epoch)
+	previousJustifiedEpoch := uint64(parsedFinality.Data.PreviousJustified.Epoch)
+
+	return &types.ChainHead{
+		HeadSlot:                   headSlot,
+		HeadEpoch:                  headSlot / utils.Config.Chain.SlotsPerEpoch,
+		HeadBlockRoot:              utils.MustParseHex(parsedHead.Data.Root),
+		FinalizedSlot:              finalizedEpoch * utils.Config.Chain.SlotsPerEpoch,
+		FinalizedEpoch:             finalizedEpoch,
+		FinalizedBlockRoot:         utils.MustParseHex(parsedFinality.Data.Finalized.Root),
+		JustifiedSlot:              justifiedEpoch * utils.Config.Chain.SlotsPerEpoch,
+		JustifiedEpoch:             justifiedEpoch,
+		JustifiedBlockRoot:         utils.MustParseHex(parsedFinality.Data.CurrentJustified.Root),
+		PreviousJustifiedSlot:      previousJustifiedEpoch * utils.Config.Chain.SlotsPerEpoch,
+		PreviousJustifiedEpoch:     previousJustifiedEpoch,
+		PreviousJustifiedBlockRoot: utils.MustParseHex(parsedFinality.Data.PreviousJustified.Root),
+	}, nil
+}
+
+// GetValidatorQueue will derive the validator queue from the validator states of the head state as the standard api does not provide a queue endpoint
+func (sc *StandardClient) GetValidatorQueue() (*types.ValidatorQueue, error) {
+	validators, err := sc.getValidators("head")
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving validator queue data: %v", err)
+	}
+
+	queue := &types.ValidatorQueue{
+		ActivationPublicKeys:       [][]byte{},
+		ExitPublicKeys:             [][]byte{},
+		ActivationValidatorIndices: []uint64{},
+		ExitValidatorIndices:       []uint64{},
+	}
+
+	activeCount := uint64(0)
+	for _, validator := range validators {
+		switch validator.Status {
+		case "pending_queued":
+			queue.ActivationPublicKeys = append(queue.ActivationPublicKeys, utils.MustParseHex(validator.Validator.Pubkey))
+			queue.ActivationValidatorIndices = append(queue.ActivationValidatorIndices, uint64(validator.Index))
+		case "active_exiting":
+			activeCount++
+			queue.ExitPublicKeys = append(queue.ExitPublicKeys, utils.MustParseHex(validator.Validator.Pubkey))
+			queue.ExitValidatorIndices = append(queue.ExitValidatorIndices, uint64(validator.Index))
+		case "active_ongoing", "active_slashed":
+			activeCount++
+		}
+	}
+
+	// see get_validator_churn_limit in the phase0 spec (MIN_PER_EPOCH_CHURN_LIMIT = 4, CHURN_LIMIT_QUOTIENT = 65536)
+	queue.ChurnLimit = activeCount / 65536
+	if queue.ChurnLimit < 4 {
+		queue.ChurnLimit = 4
+	}
+
+	return queue, nil
+}
+
+// GetAttestationPool will get the attestation pool of the node
+func (sc *StandardClient) GetAttestationPool() ([]*types.Attestation, error) {
+	resp, err := sc.get(fmt.Sprintf("%v/eth/v1/beacon/pool/attestations", sc.endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving attestation pool: %v", err)
+	}
+
+	var parsedResponse standardAttestationPoolResponse
+	err = json.Unmarshal(resp, &parsedResponse)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing attestation pool: %v", err)
+	}
+
+	attestations := make([]*types.Attestation, 0, len(parsedResponse.Data))
+	for _, attestation := range parsedResponse.Data {
+		attestations = append(attestations, &types.Attestation{
+			AggregationBits: utils.MustParseHex(attestation.AggregationBits),
+			Attesters:       []uint64{},
+			Data:            attestation.Data.toAttestationData(),
+			Signature:       utils.MustParseHex(attestation.Signature),
+		})
+	}
+
+	return attestations, nil
+}
+
+// GetEpochAssignments will get the epoch assignments from the proposer duties and the beacon committees of the epoch
+func (sc *StandardClient) GetEpochAssignments(epoch uint64) (*types.EpochAssignments, error) {
+	sc.assignmentsCacheMux.Lock()
+	defer sc.assignmentsCacheMux.Unlock()
+
+	cachedValue, found := sc.assignmentsCache.Get(epoch)
+	if found {
+		return cachedValue.(*types.EpochAssignments), nil
+	}
+
+	resp, err := sc.get(fmt.Sprintf("%v/eth/v1/validator/duties/proposer/%v", sc.endpoint, epoch))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving proposer duties: %v", err)
+	}
+
+	var parsedProposerResponse standardProposerDutiesResponse
+	err = json.Unmarshal(resp, &parsedProposerResponse)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing proposer duties: %v", err)
+	}
+
+	resp, err = sc.get(fmt.Sprintf("%v/eth/v1/beacon/states/%v/committees?epoch=%v", sc.endpoint, epoch*utils.Config.Chain.SlotsPerEpoch, epoch))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving beacon committees: %v", err)
+	}
+
+	var parsedCommitteesResponse standardCommitteesResponse
+	err = json.Unmarshal(resp, &parsedCommitteesResponse)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing beacon committees: %v", err)
+	}
+
+	assignments := &types.EpochAssignments{
+		ProposerAssignments: make(map[uint64]uint64),
+		AttestorAssignments: make(map[string]uint64),
+	}
+
+	for _, duty := range parsedProposerResponse.Data {
+		assignments.ProposerAssignments[uint64(duty.Slot)] = uint64(duty.ValidatorIndex)
+	}
+
+	for _, committee := range parsedCommitteesResponse.Data {
+		for memberIndex, validatorIndex := range committee.Validators {
+			assignments.AttestorAssignments[utils.FormatAttestorAssignmentKey(uint64(committee.Slot), uint64(committee.Index), uint64(memberIndex))] = uint64(validatorIndex)
+		}
+	}
+
+	if len(assignments.AttestorAssignments) > 0 && len(assignments.ProposerAssignments) > 0 {
+		sc.assignmentsCache.Add(epoch, assignments)
+	}
+
+	return assignments, nil
+}
+
+// GetEpochData will get the epoch data from the standard beacon node api
+func (sc *StandardClient) GetEpochData(epoch uint64) (*types.EpochData, error) {
+	var err error
+
+	data := &types.EpochData{}
+	data.Epoch = epoch
+
+	validators, err := sc.getValidators(fmt.Sprintf("%v", epoch*utils.Config.Chain.SlotsPerEpoch))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving epoch validators: %v", err)
+	}
+
+	data.ValidatorIndices = make(map[string]uint64)
+	data.Validators = make([]*types.Validator, 0, len(validators))
+
+	for _, validator := range validators {
+		pubKey := utils.MustParseHex(validator.Validator.Pubkey)
+		data.ValidatorIndices[fmt.Sprintf("%x", pubKey)] = uint64(validator.Index)
+
+		data.Validators = append(data.Validators, &types.Validator{
+			Index:                      uint64(validator.Index),
+			PublicKey:                  pubKey,
+			WithdrawalCredentials:      utils.MustParseHex(validator.Validator.WithdrawalCredentials),
+			Balance:                    uint64(validator.Balance),
+			EffectiveBalance:           uint64(validator.Validator.EffectiveBalance),
+			Slashed:                    validator.Validator.Slashed,
+			ActivationEligibilityEpoch: uint64(validator.Validator.ActivationEligibilityEpoch),
+			ActivationEpoch:            uint64(validator.Validator.ActivationEpoch),
+			ExitEpoch:                  uint64(validator.Validator.ExitEpoch),
+			WithdrawableEpoch:          uint64(validator.Validator.WithdrawableEpoch),
+		})
+	}
+
+	logger.Printf("retrieved data for %v validators for epoch %v", len(data.Validators), epoch)
+
+	data.ValidatorAssignmentes, err = sc.GetEpochAssignments(epoch)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving assignments for epoch %v: %v", epoch, err)
+	}
+	logger.Printf("retrieved validator assignment data for epoch %v", epoch)
+
+	// Retrieve all blocks for the epoch
+	data.Blocks = make(map[uint64]map[string]*types.Block)
+
+	for slot := epoch * utils.Config.Chain.SlotsPerEpoch; slot <= (epoch+1)*utils.Config.Chain.SlotsPerEpoch-1; slot++ {
+		if utils.SlotToTime(slot).After(time.Now()) { // Skip asking for future blocks
+			continue
+		}
+
+		blocks, err := sc.GetBlocksBySlot(slot)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, block := range blocks {
+			if data.Blocks[block.Slot] == nil {
+				data.Blocks[block.Slot] = make(map[string]*types.Block)
+			}
+			data.Blocks[block.Slot][fmt.Sprintf("%x", block.BlockRoot)] = block
+		}
+	}
+	logger.Printf("retrieved %v blocks for epoch %v", len(data.Blocks), epoch)
+
+	// Fill up missed and scheduled blocks
+	for slot, proposer := range data.ValidatorAssignmentes.ProposerAssignments {
+		_, found := data.Blocks[slot]
+		if !found {
+			// Proposer was assigned but did not yet propose a block
+			data.Blocks[slot] = make(map[string]*types.Block)
+			data.Blocks[slot]["0x0"] = &types.Block{
+				Status:            0,
+				Proposer:          proposer,
+				BlockRoot:         []byte{0x0},
+				Slot:              slot,
+				ParentRoot:        []byte{},
+				StateRoot:         []byte{},
+				Signature:         []byte{},
+				RandaoReveal:      []byte{},
+				Graffiti:          []byte{},
+				BodyRoot:          []byte{},
+				Eth1Data:          &types.Eth1Data{},
+				ProposerSlashings: make([]*types.ProposerSlashing, 0),
+				AttesterSlashings: make([]*types.AttesterSlashing, 0),
+				Attestations:      make([]*types.Attestation, 0),
+				Deposits:          make([]*types.Deposit, 0),
+				VoluntaryExits:    make([]*types.VoluntaryExit, 0),
+			}
+
+			if utils.SlotToTime(slot).After(time.Now().Add(time.Second * -60)) {
+				// Block is in the future, set status to scheduled
+				data.Blocks[slot]["0x0"].Status = 0
+				data.Blocks[slot]["0x0"].BlockRoot = []byte{0x0}
+			} else {
+				// Block is in the past, set status to missed
+				data.Blocks[slot]["0x0"].Status = 2
+				data.Blocks[slot]["0x0"].BlockRoot = []byte{0x1}
+			}
+		}
+	}
+
+	// Unused for now
+	data.BeaconCommittees = make(map[uint64][]*types.BeaconCommitteItem)
+
+	data.EpochParticipationStats, err = sc.GetValidatorParticipation(epoch)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving epoch participation statistics for epoch %v: %v", epoch, err)
+	}
+
+	return data, nil
+}
+
+// GetBlocksBySlot will get all blocks (canonical and orphaned) the node knows of for a slot
+func (sc *StandardClient) GetBlocksBySlot(slot uint64) ([]*types.Block, error) {
+	resp, err := sc.get(fmt.Sprintf("%v/eth/v1/beacon/headers?slot=%v", sc.endpoint, slot))
+	if err == errNotFound {
+		return []*types.Block{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving block headers at slot %v: %v", slot, err)
+	}
+
+	var parsedHeaders standardHeadersResponse
+	err = json.Unmarshal(resp, &parsedHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing block headers at slot %v: %v", slot, err)
+	}
+
+	blocks := make([]*types.Block, 0, len(parsedHeaders.Data))
+	for _, header := range parsedHeaders.Data {
+		block, err := sc.getBlock(header.Root)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+
+	return blocks, nil
+}
+
+// GetValidatorParticipation will get the finalization status of an epoch. The participation rate and
+// the voted ether are not available via the standard api and are therefore always set to 0
+func (sc *StandardClient) GetValidatorParticipation(epoch uint64) (*types.ValidatorParticipation, error) {
+	resp, err := sc.get(fmt.Sprintf("%v/eth/v1/beacon/states/head/finality_checkpoints", sc.endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving finality checkpoints for epoch %v: %v", epoch, err)
+	}
+
+	var parsedResponse standardFinalityCheckpointsResponse
+	err = json.Unmarshal(resp, &parsedResponse)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing finality checkpoints for epoch %v: %v", epoch, err)
+	}
+
+	return &types.ValidatorParticipation{
+		Epoch:                   epoch,
+		Finalized:               epoch <= uint64(parsedResponse.Data.Finalized.Epoch),
+		GlobalParticipationRate: 0,
+		VotedEther:              0,
+		EligibleEther:           0,
+	}, nil
+}
+
+func (sc *StandardClient) getValidators(stateID string) ([]*standardValidator, error) {
+	resp, err := sc.get(fmt.Sprintf("%v/eth/v1/beacon/states/%v/validators", sc.endpoint, stateID))
+	if err != nil {
+		return nil, err
+	}
+
+	var parsedResponse standardValidatorsResponse
+	err = json.Unmarshal(resp, &parsedResponse)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing validators of state %v: %v", stateID, err)
+	}
+
+	return parsedResponse.Data, nil
+}
+
+func (sc *StandardClient) getBlock(blockRoot string) (*types.Block, error) {
+	resp, err := sc.get(fmt.Sprintf("%v/eth/v1/beacon/blocks/%v", sc.endpoint, blockRoot))
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving block %v: %v", blockRoot, err)
+	}
+
+	var parsedResponse standardBlockResponse
+	err = json.Unmarshal(resp, &parsedResponse)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing block %v: %v", blockRoot, err)
+	}
+
+	message := parsedResponse.Data.Message
+	body := message.Body
+
+	block := &types.Block{
+		Status:       1,
+		Proposer:     uint64(message.ProposerIndex),
+		BlockRoot:    utils.MustParseHex(blockRoot),
+		Slot:         uint64(message.Slot),
+		ParentRoot:   utils.MustParseHex(message.ParentRoot),
+		StateRoot:    utils.MustParseHex(message.StateRoot),
+		Signature:    utils.MustParseHex(parsedResponse.Data.Signature),
+		RandaoReveal: utils.MustParseHex(body.RandaoReveal),
+		Graffiti:     utils.MustParseHex(body.Graffiti),
+		BodyRoot:     []byte{},
+		Eth1Data: &types.Eth1Data{
+			DepositRoot:  utils.MustParseHex(body.Eth1Data.DepositRoot),
+			DepositCount: uint64(body.Eth1Data.DepositCount),
+			BlockHash:    utils.MustParseHex(body.Eth1Data.BlockHash),
+		},
+		ProposerSlashings: make([]*types.ProposerSlashing, len(body.ProposerSlashings)),
+		AttesterSlashings: make([]*types.AttesterSlashing, len(body.AttesterSlashings)),
+		Attestations:      make([]*types.Attestation, len(body.Attestations)),
+		Deposits:          make([]*types.Deposit, len(body.Deposits)),
+		VoluntaryExits:    make([]*types.VoluntaryExit, len(body.VoluntaryExits)),
+	}
+
+	for i, proposerSlashing := range body.ProposerSlashings {
+		block.ProposerSlashings[i] = &types.ProposerSlashing{
+			ProposerIndex: uint64(proposerSlashing.SignedHeader1.Message.ProposerIndex),
+			Header1:       proposerSlashing.SignedHeader1.toBlock(),
+			Header2:       proposerSlashing.SignedHeader2.toBlock(),
+		}
+	}
+
+	for i, attesterSlashing := range body.AttesterSlashings {
+		block.AttesterSlashings[i] = &types.AttesterSlashing{
+			Attestation1: attesterSlashing.Attestation1.toIndexedAttestation(),
+			Attestation2: attesterSlashing.Attestation2.toIndexedAttestation(),
+		}
+	}
+
+	for i, attestation := range body.Attestations {
+		a := &types.Attestation{
+			AggregationBits: utils.MustParseHex(attestation.AggregationBits),
+			Attesters:       []uint64{},
+			Data:            attestation.Data.toAttestationData(),
+			Signature:       utils.MustParseHex(attestation.Signature),
+		}
+
+		aggregationBits := bitfield.Bitlist(a.AggregationBits)
+		assignments, err := sc.GetEpochAssignments(a.Data.Slot / utils.Config.Chain.SlotsPerEpoch)
+		if err != nil {
+			return nil, fmt.Errorf("error receiving epoch assignment for epoch %v: %v", a.Data.Slot/utils.Config.Chain.SlotsPerEpoch, err)
+		}
+
+		for i := uint64(0); i < aggregationBits.Len(); i++ {
+			if aggregationBits.BitAt(i) {
+				validator, found := assignments.AttestorAssignments[utils.FormatAttestorAssignmentKey(a.Data.Slot, a.Data.CommitteeIndex, i)]
+				if !found { // This should never happen!
+					validator = 0
+					logger.Errorf("error retrieving assigned validator for attestation %v of block %v for slot %v committee index %v member index %v", i, block.Slot, a.Data.Slot, a.Data.CommitteeIndex, i)
+				}
+				a.Attesters = append(a.Attesters, validator)
+			}
+		}
+
+		block.Attestations[i] = a
+	}
+
+	for i, deposit := range body.Deposits {
+		proof := make([][]byte, len(deposit.Proof))
+		for j, p := range deposit.Proof {
+			proof[j] = utils.MustParseHex(p)
+		}
+
+		block.Deposits[i] = &types.Deposit{
+			Proof:                 proof,
+			PublicKey:             utils.MustParseHex(deposit.Data.Pubkey),
+			WithdrawalCredentials: utils.MustParseHex(deposit.Data.WithdrawalCredentials),
+			Amount:                uint64(deposit.Data.Amount),
+			Signature:             utils.MustParseHex(deposit.Data.Signature),
+		}
+	}
+
+	for i, voluntaryExit := range body.VoluntaryExits {
+		block.VoluntaryExits[i] = &types.VoluntaryExit{
+			Epoch:          uint64(voluntaryExit.Message.Epoch),
+			ValidatorIndex: uint64(voluntaryExit.Message.ValidatorIndex),
+			Signature:      utils.MustParseHex(voluntaryExit.Signature),
+		}
+	}
+
+	return block, nil
+}
+
+func (sc *StandardClient) get(url string) ([]byte, error) {
+	client := &http.Client{Timeout: time.Second * 60}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error-response: %s", data)
+	}
+
+	return data, err
+}
+
+// uint64Str is used to parse the quoted integers of the standard api
+type uint64Str uint64
+
+func (s *uint64Str) UnmarshalJSON(b []byte) error {
+	if len(b) >= 2 && b[0] == '"' && b[len(b)-1] == '"' {
+		b = b[1 : len(b)-1]
+	}
+	v, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		return err
+	}
+	*s = uint64Str(v)
+	return nil
+}
+
+type standardCheckpoint struct {
+	Epoch uint64Str `json:"epoch"`
+	Root  string    `json:"root"`
+}
+
+type standardAttestationData struct {
+	Slot            uint64Str          `json:"slot"`
+	Index           uint64Str          `json:"index"`
+	BeaconBlockRoot string             `json:"beacon_block_root"`
+	Source          standardCheckpoint `json:"source"`
+	Target          standardCheckpoint `json:"target"`
+}
+
+func (d *standardAttestationData) toAttestationData() *types.AttestationData {
+	return &types.AttestationData{
+		Slot:            uint64(d.Slot),
+		CommitteeIndex:  uint64(d.Index),
+		BeaconBlockRoot: utils.MustParseHex(d.BeaconBlockRoot),
+		Source: &types.Checkpoint{
+			Epoch: uint64(d.Source.Epoch),
+			Root:  utils.MustParseHex(d.Source.Root),
+		},
+		Target: &types.Checkpoint{
+			Epoch: uint64(d.Target.Epoch),
+			Root:  utils.MustParseHex(d.Target.Root),
+		},
+	}
+}
+
+type standardAttestation struct {
+	AggregationBits string                  `json:"aggregation_bits"`
+	Data            standardAttestationData `json:"data"`
+	Signature       string                  `json:"signature"`
+}
+
+type standardIndexedAttestation struct {
+	AttestingIndices []uint64Str             `json:"attesting_indices"`
+	Data             standardAttestationData `json:"data"`
+	Signature        string                  `json:"signature"`
+}
+
+func (a *standardIndexedAttestation) toIndexedAttestation() *types.IndexedAttestation {
+	indices := make([]uint64, len(a.AttestingIndices))
+	for i, index := range a.AttestingIndices {
+		indices[i] = uint64(index)
+	}
+	return &types.IndexedAttestation{
+		Data:             a.Data.toAttestationData(),
+		AttestingIndices: indices,
+		Signature:        utils.MustParseHex(a.Signature),
+	}
+}
+
+type standardBeaconBlockHeader struct {
+	Slot          uint64Str `json:"slot"`
+	ProposerIndex uint64Str `json:"proposer_index"`
+	ParentRoot    string    `json:"parent_root"`
+	StateRoot     string    `json:"state_root"`
+	BodyRoot      string    `json:"body_root"`
+}
+
+type standardSignedBeaconBlockHeader struct {
+	Message   standardBeaconBlockHeader `json:"message"`
+	Signature string                    `json:"signature"`
+}
+
+func (h *standardSignedBeaconBlockHeader) toBlock() *types.Block {
+	return &types.Block{
+		Slot:       uint64(h.Message.Slot),
+		ParentRoot: utils.MustParseHex(h.Message.ParentRoot),
+		StateRoot:  utils.MustParseHex(h.Message.StateRoot),
+		Signature:  utils.MustParseHex(h.Signature),
+		BodyRoot:   utils.MustParseHex(h.Message.BodyRoot),
+	}
+}
+
+type standardHeader struct {
+	Root      string                          `json:"root"`
+	Canonical bool                            `json:"canonical"`
+	Header    standardSignedBeaconBlockHeader `json:"header"`
+}
+
+type standardHeaderResponse struct {
+	Data standardHeader `json:"data"`
+}
+
+type standardHeadersResponse struct {
+	Data []standardHeader `json:"data"`
+}
+
+type standardFinalityCheckpointsResponse struct {
+	Data struct {
+		PreviousJustified standardCheckpoint `json:"previous_justified"`
+		CurrentJustified  standardCheckpoint `json:"current_justified"`
+		Finalized         standardCheckpoint `json:"finalized"`
+	} `json:"data"`
+}
+
+type standardValidator struct {
+	Index     uint64Str `json:"index"`
+	Balance   uint64Str `json:"balance"`
+	Status    string    `json:"status"`
+	Validator struct {
+		Pubkey                     string    `json:"pubkey"`
+		WithdrawalCredentials      string    `json:"withdrawal_credentials"`
+		EffectiveBalance           uint64Str `json:"effective_balance"`
+		Slashed                    bool      `json:"slashed"`
+		ActivationEligibilityEpoch uint64Str `json:"activation_eligibility_epoch"`
+		ActivationEpoch            uint64Str `json:"activation_epoch"`
+		ExitEpoch                  uint64Str `json:"exit_epoch"`
+		WithdrawableEpoch          uint64Str `json:"withdrawable_epoch"`
+	} `json:"validator"`
+}
+
+type standardValidatorsResponse struct {
+	Data []*standardValidator `json:"data"`
+}
+
+type standardProposerDutiesResponse struct {
+	Data []struct {
+		Pubkey         string    `json:"pubkey"`
+		ValidatorIndex uint64Str `json:"validator_index"`
+		Slot           uint64Str `json:"slot"`
+	} `json:"data"`
+}
+
+type standardCommitteesResponse struct {
+	Data []struct {
+		Index      uint64Str   `json:"index"`
+		Slot       uint64Str   `json:"slot"`
+		Validators []uint64Str `json:"validators"`
+	} `json:"data"`
+}
+
+type standardAttestationPoolResponse struct {
+	Data []standardAttestation `json:"data"`
+}
+
+type standardBlockResponse struct {
+	Data struct {
+		Message struct {
+			Slot          uint64Str `json:"slot"`
+			ProposerIndex uint64Str `json:"proposer_index"`
+			ParentRoot    string    `json:"parent_root"`
+			StateRoot     string    `json:"state_root"`
+			Body          struct {
+				RandaoReveal string `json:"randao_reveal"`
+				Eth1Data     struct {
+					DepositRoot  string    `json:"deposit_root"`
+					DepositCount uint64Str `json:"deposit_count"`
+					BlockHash    string    `json:"block_hash"`
+				} `json:"eth1_data"`
+				Graffiti          string `json:"graffiti"`
+				ProposerSlashings []struct {
+					SignedHeader1 standardSignedBeaconBlockHeader `json:"signed_header_1"`
+					SignedHeader2 standardSignedBeaconBlockHeader `json:"signed_header_2"`
+				} `json:"proposer_slashings"`
+				AttesterSlashings []struct {
+					Attestation1 standardIndexedAttestation `json:"attestation_1"`
+					Attestation2 standardIndexedAttestation `json:"attestation_2"`
+				} `json:"attester_slashings"`
+				Attestations []standardAttestation `json:"attestations"`
+				Deposits     []struct {
+					Proof []string `json:"proof"`
+					Data  struct {
+						Pubkey                string    `json:"pubkey"`
+						WithdrawalCredentials string    `json:"withdrawal_credentials"`
+						Amount                uint64Str `json:"amount"`
+						Signature             string    `json:"signature"`
+					} `json:"data"`
+				} `json:"deposits"`
+				VoluntaryExits []struct {
+					Message struct {
+						Epoch          uint64Str `json:"epoch"`
+						ValidatorIndex uint64Str `json:"validator_index"`
+					} `json:"message"`
+					Signature string `json:"signature"`
+				} `json:"voluntary_exits"`
+			} `json:"body"`
+		} `json:"message"`
+		Signature string `json:"signature"`
+	} `json:"data"`
+}
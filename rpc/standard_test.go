@@ -0,0 +1,176 @@
+package rpc
+
+import (
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// standardFixtures maps the request uris of the standard beacon node api to the recorded responses in testdata/standard
+var standardFixtures = map[string]string{
+	"/eth/v1/beacon/headers/head":                     "header_head.json",
+	"/eth/v1/beacon/states/head/finality_checkpoints": "finality_checkpoints_head.json",
+	"/eth/v1/beacon/states/head/validators":           "validators_head.json",
+	"/eth/v1/beacon/states/4/validators":              "validators_4.json",
+	"/eth/v1/beacon/states/4/committees?epoch=1":      "committees_4_epoch_1.json",
+	"/eth/v1/validator/duties/proposer/1":             "duties_proposer_1.json",
+	"/eth/v1/beacon/headers?slot=4":                   "headers_slot_4.json",
+	"/eth/v1/beacon/headers?slot=5":                   "headers_slot_5.json",
+	"/eth/v1/beacon/headers?slot=7":                   "headers_slot_7.json",
+	"/eth/v1/beacon/blocks/" + fixtureRoot(0x04):      "block_04.json",
+	"/eth/v1/beacon/blocks/" + fixtureRoot(0x05):      "block_05.json",
+	"/eth/v1/beacon/blocks/" + fixtureRoot(0x51):      "block_51.json",
+	"/eth/v1/beacon/pool/attestations":                "attestation_pool.json",
+}
+
+func fixtureRoot(b byte) string {
+	root := "0x"
+	for i := 0; i < 32; i++ {
+		root += fmt.Sprintf("%02x", b)
+	}
+	return root
+}
+
+func newStandardFixtureServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fixture, found := standardFixtures[r.URL.RequestURI()]
+		if !found {
+			http.Error(w, `{"code":404,"message":"not found"}`, http.StatusNotFound)
+			return
+		}
+		data, err := ioutil.ReadFile(filepath.Join("testdata", "standard", fixture))
+		if err != nil {
+			t.Fatalf("error reading fixture %v: %v", fixture, err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}))
+}
+
+func setStandardTestConfig() {
+	utils.Config = &types.Config{}
+	utils.Config.Chain.SlotsPerEpoch = 4
+	utils.Config.Chain.SecondsPerSlot = 12
+	utils.Config.Chain.GenesisTimestamp = 0
+}
+
+func TestStandardClientGetChainHead(t *testing.T) {
+	setStandardTestConfig()
+	srv := newStandardFixtureServer(t)
+	defer srv.Close()
+
+	client, _ := NewStandardClient(srv.URL)
+	head, err := client.GetChainHead()
+	if err != nil {
+		t.Fatalf("error retrieving chain head: %v", err)
+	}
+
+	if head.HeadSlot != 6 || head.HeadEpoch != 1 {
+		t.Errorf("expected head slot 6 in epoch 1, got slot %v in epoch %v", head.HeadSlot, head.HeadEpoch)
+	}
+	if fmt.Sprintf("%#x", head.HeadBlockRoot) != fixtureRoot(0x06) {
+		t.Errorf("unexpected head block root %#x", head.HeadBlockRoot)
+	}
+	if head.FinalizedEpoch != 0 || head.JustifiedEpoch != 1 || head.JustifiedSlot != 4 {
+		t.Errorf("unexpected checkpoints: finalized epoch %v, justified epoch %v, justified slot %v", head.FinalizedEpoch, head.JustifiedEpoch, head.JustifiedSlot)
+	}
+}
+
+func TestStandardClientGetEpochData(t *testing.T) {
+	setStandardTestConfig()
+	srv := newStandardFixtureServer(t)
+	defer srv.Close()
+
+	client, _ := NewStandardClient(srv.URL)
+	data, err := client.GetEpochData(1)
+	if err != nil {
+		t.Fatalf("error retrieving epoch data: %v", err)
+	}
+
+	if len(data.Validators) != 4 {
+		t.Fatalf("expected 4 validators, got %v", len(data.Validators))
+	}
+	if data.Validators[2].Balance != 32000000002 {
+		t.Errorf("unexpected balance %v for validator 2", data.Validators[2].Balance)
+	}
+
+	if len(data.ValidatorAssignmentes.ProposerAssignments) != 4 || data.ValidatorAssignmentes.ProposerAssignments[6] != 2 {
+		t.Errorf("unexpected proposer assignments %v", data.ValidatorAssignmentes.ProposerAssignments)
+	}
+
+	if len(data.Blocks[4]) != 1 {
+		t.Fatalf("expected 1 block at slot 4, got %v", len(data.Blocks[4]))
+	}
+	if len(data.Blocks[5]) != 2 {
+		t.Errorf("expected 2 blocks at slot 5, got %v", len(data.Blocks[5]))
+	}
+	for _, slot := range []uint64{6, 7} {
+		if data.Blocks[slot]["0x0"] == nil || data.Blocks[slot]["0x0"].Status != 2 {
+			t.Errorf("expected slot %v to be marked as missed", slot)
+		}
+	}
+
+	block := data.Blocks[4][fixtureRoot(0x04)[2:]]
+	if block == nil {
+		t.Fatalf("block 0x04 is missing from slot 4")
+	}
+	if block.Proposer != 0 || block.Eth1Data.DepositCount != 4 {
+		t.Errorf("unexpected block header data: proposer %v, deposit count %v", block.Proposer, block.Eth1Data.DepositCount)
+	}
+	if len(block.Attestations) != 1 || len(block.Attestations[0].Attesters) != 1 || block.Attestations[0].Attesters[0] != 0 {
+		t.Errorf("unexpected attesters %+v", block.Attestations)
+	}
+	if len(block.Deposits) != 1 || block.Deposits[0].Amount != 32000000000 || len(block.Deposits[0].Proof) != 2 {
+		t.Errorf("unexpected deposits %+v", block.Deposits)
+	}
+	if len(block.VoluntaryExits) != 1 || block.VoluntaryExits[0].ValidatorIndex != 3 {
+		t.Errorf("unexpected voluntary exits %+v", block.VoluntaryExits)
+	}
+
+	if data.EpochParticipationStats.Finalized {
+		t.Errorf("epoch 1 should not be finalized")
+	}
+}
+
+func TestStandardClientGetValidatorQueue(t *testing.T) {
+	setStandardTestConfig()
+	srv := newStandardFixtureServer(t)
+	defer srv.Close()
+
+	client, _ := NewStandardClient(srv.URL)
+	queue, err := client.GetValidatorQueue()
+	if err != nil {
+		t.Fatalf("error retrieving validator queue: %v", err)
+	}
+
+	if queue.ChurnLimit != 4 {
+		t.Errorf("expected churn limit 4, got %v", queue.ChurnLimit)
+	}
+	if len(queue.ActivationValidatorIndices) != 1 || queue.ActivationValidatorIndices[0] != 2 {
+		t.Errorf("unexpected activation queue %v", queue.ActivationValidatorIndices)
+	}
+	if len(queue.ExitValidatorIndices) != 1 || queue.ExitValidatorIndices[0] != 3 {
+		t.Errorf("unexpected exit queue %v", queue.ExitValidatorIndices)
+	}
+}
+
+func TestStandardClientGetAttestationPool(t *testing.T) {
+	setStandardTestConfig()
+	srv := newStandardFixtureServer(t)
+	defer srv.Close()
+
+	client, _ := NewStandardClient(srv.URL)
+	attestations, err := client.GetAttestationPool()
+	if err != nil {
+		t.Fatalf("error retrieving attestation pool: %v", err)
+	}
+
+	if len(attestations) != 1 || attestations[0].Data.Slot != 6 || attestations[0].Data.Target.Epoch != 1 {
+		t.Errorf("unexpected attestation pool %+v", attestations)
+	}
+}
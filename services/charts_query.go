@@ -0,0 +1,154 @@
+package services
+
+import (
+	"errors"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// maxChartPoints limits the number of buckets of a time series
+const maxChartPoints = 10000
+
+// ErrChartRangeNotSupported is returned for charts that only show the latest state if an older state is requested
+var ErrChartRangeNotSupported = errors.New("the chart only shows the latest state")
+
+// ErrTooManyChartPoints is returned if a time series has more than maxChartPoints buckets
+var ErrTooManyChartPoints = fmt.Errorf("the chart is limited to %v data points, please request a shorter time range or larger buckets", maxChartPoints)
+
+// ChartBuckets are the supported bucket sizes of the time series of the charts
+var ChartBuckets = []string{"epoch", "hour", "day", "week"}
+
+var chartBucketAdjectives = map[string]string{
+	"epoch": "per-epoch",
+	"hour":  "hourly",
+	"day":   "daily",
+	"week":  "weekly",
+}
+
+// IsChartBucket returns true if the bucket size is supported
+func IsChartBucket(bucket string) bool {
+	_, ok := chartBucketAdjectives[bucket]
+	return ok
+}
+
+// DefaultChartQuery returns the query of the chart that is shown on the charts page: the whole history (or the
+// default range of the chart) up to now in the default buckets of the chart
+func DefaultChartQuery(chart string) (*types.ChartQuery, error) {
+	ch, ok := ChartHandlers[chart]
+	if !ok {
+		return nil, fmt.Errorf("unknown chart %v", chart)
+	}
+
+	now := time.Now().UTC()
+	query := &types.ChartQuery{
+		From:   time.Unix(0, 0).UTC(),
+		To:     now,
+		Bucket: ch.DefaultBucket,
+	}
+	if ch.DefaultRange != 0 {
+		query.From = now.Add(-ch.DefaultRange)
+	}
+	return query, nil
+}
+
+// GetChartData returns the data of the chart for the query. Charts without time series ignore the bucket size.
+func GetChartData(chart string, query *types.ChartQuery) (*types.GenericChartData, error) {
+	ch, ok := ChartHandlers[chart]
+	if !ok {
+		return nil, fmt.Errorf("unknown chart %v", chart)
+	}
+	if ch.DefaultBucket == "" {
+		query.Bucket = ""
+	} else if !IsChartBucket(query.Bucket) {
+		return nil, fmt.Errorf("invalid bucket %v", query.Bucket)
+	}
+	return ch.DataFunc(query)
+}
+
+// chartBucketAdjective returns the adjective of the bucket size for chart descriptions, e.g. daily
+func chartBucketAdjective(bucket string, capitalize bool) string {
+	adjective := chartBucketAdjectives[bucket]
+	if capitalize && adjective != "" {
+		return strings.ToUpper(adjective[:1]) + adjective[1:]
+	}
+	return adjective
+}
+
+// chartBucketStart returns the start of the bucket that contains the time
+func chartBucketStart(bucket string, ts time.Time) time.Time {
+	ts = ts.UTC()
+	switch bucket {
+	case "epoch":
+		genesis := int64(utils.Config.Chain.GenesisTimestamp)
+		duration := int64(utils.Config.Chain.SecondsPerSlot * utils.Config.Chain.SlotsPerEpoch)
+		return time.Unix(genesis+int64(math.Floor(float64(ts.Unix()-genesis)/float64(duration)))*duration, 0).UTC()
+	case "hour":
+		return ts.Truncate(time.Hour)
+	case "day":
+		return ts.Truncate(time.Hour * 24)
+	case "week":
+		// the zero time is a monday, so weeks start on mondays like in postgres
+		return ts.Truncate(time.Hour * 24 * 7)
+	}
+	return ts
+}
+
+// chartBucketExpr returns the sql expression of the start of the bucket that contains the timestamp column as unix
+// timestamp
+func chartBucketExpr(bucket, column string) string {
+	if bucket == "epoch" {
+		genesis := utils.Config.Chain.GenesisTimestamp
+		duration := utils.Config.Chain.SecondsPerSlot * utils.Config.Chain.SlotsPerEpoch
+		return fmt.Sprintf("(%d + floor((EXTRACT(epoch FROM %s) - %d) / %d) * %d)::bigint", genesis, column, genesis, duration, duration)
+	}
+	return fmt.Sprintf("EXTRACT(epoch FROM date_trunc('%s', %s))::bigint", bucket, column)
+}
+
+// chartStatsSource returns the rollup table with the columns ts, epoch, proposed_blocks, missed_blocks,
+// orphaned_blocks, validatorscount, eligibleether, totalvalidatorbalance, averagevalidatorbalance, rewards and
+// deposits. Buckets of at least a day are aggregated from the daily rollup.
+func chartStatsSource(bucket string) string {
+	if bucket == "day" || bucket == "week" {
+		return `(
+			SELECT
+				day::timestamp AS ts, last_epoch AS epoch, proposed_blocks, missed_blocks, orphaned_blocks, validatorscount,
+				eligibleether, totalvalidatorbalance, averagevalidatorbalance, rewards, deposits
+			FROM daily_stats
+		) s`
+	}
+	return `(
+		SELECT
+			ts, epoch, proposed_blocks, missed_blocks, orphaned_blocks, validatorscount, eligibleether,
+			totalvalidatorbalance, averagevalidatorbalance,
+			totalvalidatorbalance - SUM(activation_deposits + extra_deposits) OVER (ORDER BY epoch) AS rewards,
+			deposits
+		FROM epoch_stats
+	) s`
+}
+
+// selectChartSeries selects the aggregated columns of the source per bucket of the query into dest. The source must
+// have a ts column, the start of the bucket is selected as unix timestamp into the bucket column. Buckets that are
+// cut by the start of the range are completed.
+func selectChartSeries(dest interface{}, query *types.ChartQuery, source, columns string) error {
+	err := db.DB.Select(dest, fmt.Sprintf(`
+		SELECT %s AS bucket, %s
+		FROM %s
+		WHERE ts >= $1 AND ts < $2
+		GROUP BY bucket
+		ORDER BY bucket
+		LIMIT %d`, chartBucketExpr(query.Bucket, "ts"), columns, source, maxChartPoints+1),
+		chartBucketStart(query.Bucket, query.From), query.To.UTC())
+	if err != nil {
+		return err
+	}
+	if reflect.ValueOf(dest).Elem().Len() > maxChartPoints {
+		return ErrTooManyChartPoints
+	}
+	return nil
+}
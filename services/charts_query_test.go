@@ -0,0 +1,34 @@
+package services
+
+import (
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"testing"
+	"time"
+)
+
+func TestChartBucketStart(t *testing.T) {
+	utils.Config = &types.Config{}
+	utils.Config.Chain.GenesisTimestamp = 1606824023
+	utils.Config.Chain.SecondsPerSlot = 12
+	utils.Config.Chain.SlotsPerEpoch = 32
+
+	// thursday
+	ts := time.Date(2020, 12, 10, 15, 42, 7, 0, time.UTC)
+	tests := []struct {
+		bucket string
+		want   time.Time
+	}{
+		{bucket: "epoch", want: time.Unix(1606824023+2059*384, 0).UTC()},
+		{bucket: "hour", want: time.Date(2020, 12, 10, 15, 0, 0, 0, time.UTC)},
+		{bucket: "day", want: time.Date(2020, 12, 10, 0, 0, 0, 0, time.UTC)},
+		{bucket: "week", want: time.Date(2020, 12, 7, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		got := chartBucketStart(tt.bucket, ts)
+		if !got.Equal(tt.want) {
+			t.Errorf("chartBucketStart(%v, %v) = %v, want %v", tt.bucket, ts, got, tt.want)
+		}
+	}
+}
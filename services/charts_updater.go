@@ -13,28 +13,30 @@ import (
 )
 
 type chartHandler struct {
-	Order    int
-	DataFunc func() (*types.GenericChartData, error)
+	Order         int
+	DataFunc      func(query *types.ChartQuery) (*types.GenericChartData, error)
+	DefaultBucket string        // empty for charts without time series
+	DefaultRange  time.Duration // zero for the whole history
 }
 
 var ChartHandlers = map[string]chartHandler{
-	"blocks":             {1, blocksChartData},
-	"validators":         {2, activeValidatorsChartData},
-	"staked_ether":       {3, stakedEtherChartData},
-	"average_balance":    {4, averageBalanceChartData},
-	"network_liveness":   {5, networkLivenessChartData},
-	"participation_rate": {6, participationRateChartData},
-	"inclusion_distance": {6, inclusionDistanceChartData},
+	"blocks":             {1, blocksChartData, "day", 0},
+	"validators":         {2, activeValidatorsChartData, "day", 0},
+	"staked_ether":       {3, stakedEtherChartData, "day", 0},
+	"average_balance":    {4, averageBalanceChartData, "day", 0},
+	"network_liveness":   {5, networkLivenessChartData, "epoch", 0},
+	"participation_rate": {6, participationRateChartData, "epoch", 0},
+	"inclusion_distance": {6, inclusionDistanceChartData, "epoch", time.Hour * 24 * 7},
 	// "incorrect_attestations":         {6, incorrectAttestationsChartData},
-	"validator_income":               {7, averageDailyValidatorIncomeChartData},
-	"staking_rewards":                {8, stakingRewardsChartData},
-	"stake_effectiveness":            {9, stakeEffectivenessChartData},
-	"balance_distribution":           {10, balanceDistributionChartData},
-	"effective_balance_distribution": {11, effectiveBalanceDistributionChartData},
-	"performance_distribution_365d":  {12, performanceDistribution365dChartData},
-	"deposits":                       {13, depositsChartData},
-	"deposits_distribution":          {13, depositsDistributionChartData},
-	"graffiti_wordcloud":             {14, graffitiCloudChartData},
+	"validator_income":               {7, averageDailyValidatorIncomeChartData, "day", 0},
+	"staking_rewards":                {8, stakingRewardsChartData, "day", 0},
+	"stake_effectiveness":            {9, stakeEffectivenessChartData, "epoch", 0},
+	"balance_distribution":           {10, balanceDistributionChartData, "", 0},
+	"effective_balance_distribution": {11, effectiveBalanceDistributionChartData, "", 0},
+	"performance_distribution_365d":  {12, performanceDistribution365dChartData, "", 0},
+	"deposits":                       {13, depositsChartData, "day", 0},
+	"deposits_distribution":          {13, depositsDistributionChartData, "", 0},
+	"graffiti_wordcloud":             {14, graffitiCloudChartData, "", 0},
 }
 
 // LatestChartsPageData returns the latest chart page data
@@ -60,11 +62,13 @@ func chartsPageDataUpdater() {
 		data, err := getChartsPageData()
 		if err != nil {
 			logger.WithField("epoch", latestEpoch).Errorf("error updating chartPageData: %v", err)
+			db.ReportServiceRun(types.ServiceCharts, err)
 			time.Sleep(sleepDuration)
 			continue
 		}
 		logger.WithField("epoch", latestEpoch).WithField("duration", time.Since(now)).Info("chartPageData update completed")
 		chartsPageData.Store(&data)
+		db.ReportServiceRun(types.ServiceCharts, nil)
 		prevEpoch = latestEpoch
 		if latestEpoch == 0 {
 			time.Sleep(time.Second * 60 * 10)
@@ -88,7 +92,12 @@ func getChartsPageData() ([]*types.ChartsPageDataChart, error) {
 	for i, ch := range ChartHandlers {
 		go func(i string, ch chartHandler) {
 			defer wg.Done()
-			data, err := ch.DataFunc()
+			query, err := DefaultChartQuery(i)
+			if err != nil {
+				chartHandlerResChan <- &chartHandlerRes{ch.Order, i, nil, err}
+				return
+			}
+			data, err := ch.DataFunc(query)
 			chartHandlerResChan <- &chartHandlerRes{ch.Order, i, data, err}
 		}(i, ch)
 	}
@@ -119,57 +128,46 @@ func getChartsPageData() ([]*types.ChartsPageDataChart, error) {
 	return pageCharts, nil
 }
 
-func blocksChartData() (*types.GenericChartData, error) {
+func blocksChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
 	if LatestEpoch() == 0 {
 		return nil, fmt.Errorf("chart-data not available pre-genesis")
 	}
 
 	rows := []struct {
-		Epoch     uint64
-		Status    uint64
-		NbrBlocks uint64
+		Bucket         int64
+		ProposedBlocks uint64 `db:"proposed_blocks"`
+		MissedBlocks   uint64 `db:"missed_blocks"`
+		OrphanedBlocks uint64 `db:"orphaned_blocks"`
 	}{}
 
-	err := db.DB.Select(&rows, "SELECT epoch, status, count(*) as nbrBlocks FROM blocks GROUP BY epoch, status ORDER BY epoch")
+	err := selectChartSeries(&rows, query, chartStatsSource(query.Bucket), `
+		SUM(proposed_blocks) AS proposed_blocks,
+		SUM(missed_blocks) AS missed_blocks,
+		SUM(orphaned_blocks) AS orphaned_blocks`)
 	if err != nil {
 		return nil, err
 	}
 
-	dailyProposedBlocks := [][]float64{}
-	dailyMissedBlocks := [][]float64{}
-	dailyOrphanedBlocks := [][]float64{}
+	proposedBlocks := [][]float64{}
+	missedBlocks := [][]float64{}
+	orphanedBlocks := [][]float64{}
 
 	for _, row := range rows {
-		day := float64(utils.EpochToTime(row.Epoch).Truncate(time.Hour*24).Unix() * 1000)
-
-		if row.Status == 1 {
-			if len(dailyProposedBlocks) == 0 || dailyProposedBlocks[len(dailyProposedBlocks)-1][0] != day {
-				dailyProposedBlocks = append(dailyProposedBlocks, []float64{day, float64(row.NbrBlocks)})
-			} else {
-				dailyProposedBlocks[len(dailyProposedBlocks)-1][1] += float64(row.NbrBlocks)
-			}
+		ts := float64(row.Bucket * 1000)
+		if row.ProposedBlocks > 0 {
+			proposedBlocks = append(proposedBlocks, []float64{ts, float64(row.ProposedBlocks)})
 		}
-
-		if row.Status == 2 {
-			if len(dailyMissedBlocks) == 0 || dailyMissedBlocks[len(dailyMissedBlocks)-1][0] != day {
-				dailyMissedBlocks = append(dailyMissedBlocks, []float64{day, float64(row.NbrBlocks)})
-			} else {
-				dailyMissedBlocks[len(dailyMissedBlocks)-1][1] += float64(row.NbrBlocks)
-			}
+		if row.MissedBlocks > 0 {
+			missedBlocks = append(missedBlocks, []float64{ts, float64(row.MissedBlocks)})
 		}
-
-		if row.Status == 3 {
-			if len(dailyOrphanedBlocks) == 0 || dailyOrphanedBlocks[len(dailyOrphanedBlocks)-1][0] != day {
-				dailyOrphanedBlocks = append(dailyOrphanedBlocks, []float64{day, float64(row.NbrBlocks)})
-			} else {
-				dailyOrphanedBlocks[len(dailyOrphanedBlocks)-1][1] += float64(row.NbrBlocks)
-			}
+		if row.OrphanedBlocks > 0 {
+			orphanedBlocks = append(orphanedBlocks, []float64{ts, float64(row.OrphanedBlocks)})
 		}
 	}
 
 	chartData := &types.GenericChartData{
 		Title:        "Blocks",
-		Subtitle:     "History of daily blocks proposed.",
+		Subtitle:     fmt.Sprintf("History of %v blocks proposed.", chartBucketAdjective(query.Bucket, false)),
 		XAxisTitle:   "",
 		YAxisTitle:   "# of Blocks",
 		StackingMode: "normal",
@@ -177,15 +175,15 @@ func blocksChartData() (*types.GenericChartData, error) {
 		Series: []*types.GenericChartDataSeries{
 			{
 				Name: "Proposed",
-				Data: dailyProposedBlocks,
+				Data: proposedBlocks,
 			},
 			{
 				Name: "Missed",
-				Data: dailyMissedBlocks,
+				Data: missedBlocks,
 			},
 			{
 				Name: "Orphaned",
-				Data: dailyOrphanedBlocks,
+				Data: orphanedBlocks,
 			},
 		},
 	}
@@ -193,34 +191,30 @@ func blocksChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
-func activeValidatorsChartData() (*types.GenericChartData, error) {
+func activeValidatorsChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
 	if LatestEpoch() == 0 {
 		return nil, fmt.Errorf("chart-data not available pre-genesis")
 	}
 
 	rows := []struct {
-		Epoch           uint64
-		ValidatorsCount uint64
+		Bucket          int64
+		Validatorscount uint64
 	}{}
 
-	err := db.DB.Select(&rows, "SELECT epoch, validatorscount FROM epochs ORDER BY epoch")
+	err := selectChartSeries(&rows, query, chartStatsSource(query.Bucket), "(array_agg(validatorscount ORDER BY epoch DESC))[1] AS validatorscount")
 	if err != nil {
 		return nil, err
 	}
 
-	dailyActiveValidators := [][]float64{}
+	seriesData := [][]float64{}
 
 	for _, row := range rows {
-		day := float64(utils.EpochToTime(row.Epoch).Truncate(time.Hour*24).Unix() * 1000)
-
-		if len(dailyActiveValidators) == 0 || dailyActiveValidators[len(dailyActiveValidators)-1][0] != day {
-			dailyActiveValidators = append(dailyActiveValidators, []float64{day, float64(row.ValidatorsCount)})
-		}
+		seriesData = append(seriesData, []float64{float64(row.Bucket * 1000), float64(row.Validatorscount)})
 	}
 
 	chartData := &types.GenericChartData{
 		Title:        "Validators",
-		Subtitle:     "History of daily active validators.",
+		Subtitle:     fmt.Sprintf("History of %v active validators.", chartBucketAdjective(query.Bucket, false)),
 		XAxisTitle:   "",
 		YAxisTitle:   "# of Validators",
 		StackingMode: "false",
@@ -228,7 +222,7 @@ func activeValidatorsChartData() (*types.GenericChartData, error) {
 		Series: []*types.GenericChartDataSeries{
 			{
 				Name: "# of Validators",
-				Data: dailyActiveValidators,
+				Data: seriesData,
 			},
 		},
 	}
@@ -236,34 +230,30 @@ func activeValidatorsChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
-func stakedEtherChartData() (*types.GenericChartData, error) {
+func stakedEtherChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
 	if LatestEpoch() == 0 {
 		return nil, fmt.Errorf("chart-data not available pre-genesis")
 	}
 
 	rows := []struct {
-		Epoch         uint64
-		EligibleEther uint64
+		Bucket        int64
+		Eligibleether uint64
 	}{}
 
-	err := db.DB.Select(&rows, "SELECT epoch, eligibleether FROM epochs ORDER BY epoch")
+	err := selectChartSeries(&rows, query, chartStatsSource(query.Bucket), "(array_agg(eligibleether ORDER BY epoch DESC))[1] AS eligibleether")
 	if err != nil {
 		return nil, err
 	}
 
-	dailyStakedEther := [][]float64{}
+	seriesData := [][]float64{}
 
 	for _, row := range rows {
-		day := float64(utils.EpochToTime(row.Epoch).Truncate(time.Hour*24).Unix() * 1000)
-
-		if len(dailyStakedEther) == 0 || dailyStakedEther[len(dailyStakedEther)-1][0] != day {
-			dailyStakedEther = append(dailyStakedEther, []float64{day, float64(row.EligibleEther) / 1000000000})
-		}
+		seriesData = append(seriesData, []float64{float64(row.Bucket * 1000), float64(row.Eligibleether) / 1000000000})
 	}
 
 	chartData := &types.GenericChartData{
 		Title:        "Staked Ether",
-		Subtitle:     "History of daily staked Ether, which is the sum of all Effective Balances.",
+		Subtitle:     fmt.Sprintf("History of %v staked Ether, which is the sum of all Effective Balances.", chartBucketAdjective(query.Bucket, false)),
 		XAxisTitle:   "",
 		YAxisTitle:   "Ether",
 		StackingMode: "false",
@@ -271,7 +261,7 @@ func stakedEtherChartData() (*types.GenericChartData, error) {
 		Series: []*types.GenericChartDataSeries{
 			{
 				Name: "Staked Ether",
-				Data: dailyStakedEther,
+				Data: seriesData,
 			},
 		},
 	}
@@ -279,34 +269,30 @@ func stakedEtherChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
-func averageBalanceChartData() (*types.GenericChartData, error) {
+func averageBalanceChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
 	if LatestEpoch() == 0 {
 		return nil, fmt.Errorf("chart-data not available pre-genesis")
 	}
 
 	rows := []struct {
-		Epoch                   uint64
-		AverageValidatorBalance uint64
+		Bucket                  int64
+		Averagevalidatorbalance uint64
 	}{}
 
-	err := db.DB.Select(&rows, "SELECT epoch, averagevalidatorbalance FROM epochs ORDER BY epoch")
+	err := selectChartSeries(&rows, query, chartStatsSource(query.Bucket), "(array_agg(averagevalidatorbalance ORDER BY epoch DESC))[1] AS averagevalidatorbalance")
 	if err != nil {
 		return nil, err
 	}
 
-	dailyAverageBalance := [][]float64{}
+	seriesData := [][]float64{}
 
 	for _, row := range rows {
-		day := float64(utils.EpochToTime(row.Epoch).Truncate(time.Hour*24).Unix() * 1000)
-
-		if len(dailyAverageBalance) == 0 || dailyAverageBalance[len(dailyAverageBalance)-1][0] != day {
-			dailyAverageBalance = append(dailyAverageBalance, []float64{day, utils.RoundDecimals(float64(row.AverageValidatorBalance)/1e9, 4)})
-		}
+		seriesData = append(seriesData, []float64{float64(row.Bucket * 1000), utils.RoundDecimals(float64(row.Averagevalidatorbalance)/1e9, 4)})
 	}
 
 	chartData := &types.GenericChartData{
 		Title:        "Validator Balance",
-		Subtitle:     "Average Daily Validator Balance.",
+		Subtitle:     fmt.Sprintf("Average %v Validator Balance.", chartBucketAdjective(query.Bucket, true)),
 		XAxisTitle:   "",
 		YAxisTitle:   "Ether",
 		StackingMode: "false",
@@ -314,7 +300,7 @@ func averageBalanceChartData() (*types.GenericChartData, error) {
 		Series: []*types.GenericChartDataSeries{
 			{
 				Name: "Average Balance [ETH]",
-				Data: dailyAverageBalance,
+				Data: seriesData,
 			},
 		},
 	}
@@ -322,18 +308,17 @@ func averageBalanceChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
-func networkLivenessChartData() (*types.GenericChartData, error) {
+func networkLivenessChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
 	if LatestEpoch() == 0 {
 		return nil, fmt.Errorf("chart-data not available pre-genesis")
 	}
 
 	rows := []struct {
-		Timestamp      uint64
-		HeadEpoch      uint64
-		FinalizedEpoch uint64
+		Bucket        int64
+		FinalityDelay uint64 `db:"finality_delay"`
 	}{}
 
-	err := db.DB.Select(&rows, "SELECT EXTRACT(epoch FROM ts)::INT AS timestamp, headepoch, finalizedepoch FROM network_liveness ORDER BY ts")
+	err := selectChartSeries(&rows, query, "(SELECT ts, finality_delay FROM epoch_stats WHERE finality_delay IS NOT NULL) s", "MAX(finality_delay) AS finality_delay")
 	if err != nil {
 		return nil, err
 	}
@@ -341,16 +326,11 @@ func networkLivenessChartData() (*types.GenericChartData, error) {
 	seriesData := [][]float64{}
 
 	for _, row := range rows {
-		// networkliveness := (1 - 4*float64(row.HeadEpoch-2-row.FinalizedEpoch)/100)
-		// if networkliveness < 0 {
-		// 	networkliveness = 0
-		// }
 		seriesData = append(seriesData, []float64{
-			float64(row.Timestamp * 1000),
-			float64(row.HeadEpoch - row.FinalizedEpoch),
+			float64(row.Bucket * 1000),
+			float64(row.FinalityDelay),
 		})
 	}
-
 	chartData := &types.GenericChartData{
 		Title:                           "Network Liveness",
 		Subtitle:                        "Network Liveness measures how far the last Finalized Epoch is behind the Head Epoch. The protocol allows epochs to be finalized after 2 epochs.",
@@ -370,17 +350,23 @@ func networkLivenessChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
-func participationRateChartData() (*types.GenericChartData, error) {
+func participationRateChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
 	if LatestEpoch() == 0 {
 		return nil, fmt.Errorf("chart-data not available pre-genesis")
 	}
 
+	// the participation of the latest epoch is not final yet
+	latestEpochTime := utils.EpochToTime(LatestEpoch())
+	if query.To.After(latestEpochTime) {
+		query.To = latestEpochTime
+	}
+
 	rows := []struct {
-		Epoch                   uint64
+		Bucket                  int64
 		Globalparticipationrate float64
 	}{}
 
-	err := db.DB.Select(&rows, "SELECT epoch, globalparticipationrate FROM epochs WHERE epoch < $1 ORDER BY epoch", LatestEpoch())
+	err := selectChartSeries(&rows, query, "epoch_stats", "AVG(globalparticipationrate) AS globalparticipationrate")
 	if err != nil {
 		return nil, err
 	}
@@ -389,11 +375,10 @@ func participationRateChartData() (*types.GenericChartData, error) {
 
 	for _, row := range rows {
 		seriesData = append(seriesData, []float64{
-			float64(utils.EpochToTime(row.Epoch).Unix() * 1000),
+			float64(row.Bucket * 1000),
 			utils.RoundDecimals(row.Globalparticipationrate*100, 2),
 		})
 	}
-
 	chartData := &types.GenericChartData{
 		Title:        "Participation Rate",
 		Subtitle:     "Participation Rate measures how many of the validators expected to attest to blocks are actually doing so.",
@@ -412,30 +397,17 @@ func participationRateChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
-func inclusionDistanceChartData() (*types.GenericChartData, error) {
+func inclusionDistanceChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
 	if LatestEpoch() == 0 {
 		return nil, fmt.Errorf("chart-data not available pre-genesis")
 	}
 
-	latestEpoch := LatestEpoch()
-	epochOffset := uint64(0)
-	maxEpochs := 7 * 3600 * 24 / (utils.Config.Chain.SlotsPerEpoch * utils.Config.Chain.SecondsPerSlot)
-	if latestEpoch > maxEpochs {
-		epochOffset = latestEpoch - maxEpochs
-	}
-
 	rows := []struct {
-		Epoch             uint64
+		Bucket            int64
 		Inclusiondistance float64
 	}{}
 
-	err := db.DB.Select(&rows, `
-		select a.epoch, avg(a.inclusionslot - a.attesterslot) as inclusiondistance
-		from attestation_assignments a
-		inner join blocks b on b.slot = a.attesterslot and b.status = '1'
-		where a.inclusionslot > 0 and a.epoch > $1
-		group by a.epoch
-		order by a.epoch asc`, epochOffset)
+	err := selectChartSeries(&rows, query, "(SELECT ts, inclusion_distance FROM epoch_stats WHERE inclusion_distance IS NOT NULL) s", "AVG(inclusion_distance) AS inclusiondistance")
 	if err != nil {
 		return nil, err
 	}
@@ -444,13 +416,13 @@ func inclusionDistanceChartData() (*types.GenericChartData, error) {
 
 	for _, row := range rows {
 		seriesData = append(seriesData, []float64{
-			float64(utils.EpochToTime(row.Epoch).Unix() * 1000),
+			float64(row.Bucket * 1000),
 			utils.RoundDecimals(row.Inclusiondistance, 2),
 		})
 	}
 
 	chartData := &types.GenericChartData{
-		Title:        "Average Inclusion Distance (last 7 days)",
+		Title:        "Average Inclusion Distance",
 		Subtitle:     "Inclusion Distance measures how long it took to include attestations in slots.",
 		XAxisTitle:   "",
 		YAxisTitle:   "Average Inclusion Distance [slots]",
@@ -485,12 +457,10 @@ func votingDistributionChartData() (*types.GenericChartData, error) {
 	}{}
 
 	err := db.DB.Select(&rows, `
-		select a.epoch, avg(a.inclusionslot - a.attesterslot) as inclusiondistance
-		from attestation_assignments a
-		inner join blocks b on b.slot = a.attesterslot and b.status = '1'
-		where a.inclusionslot > 0 and a.epoch > $1
-		group by a.epoch
-		order by a.epoch asc`, epochOffset)
+		SELECT epoch, inclusion_distance AS inclusiondistance
+		FROM epoch_stats
+		WHERE epoch > $1 AND inclusion_distance IS NOT NULL
+		ORDER BY epoch`, epochOffset)
 	if err != nil {
 		return nil, err
 	}
@@ -522,94 +492,52 @@ func votingDistributionChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
-func averageDailyValidatorIncomeChartData() (*types.GenericChartData, error) {
+func averageDailyValidatorIncomeChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
 	if LatestEpoch() == 0 {
 		return nil, fmt.Errorf("chart-data not available pre-genesis")
 	}
 
+	// the income of the first bucket is measured from the rewards at the end of the previous bucket
+	start := chartBucketStart(query.Bucket, query.From)
+	rangeQuery := *query
+	rangeQuery.From = chartBucketStart(query.Bucket, start.Add(-time.Second))
+
 	rows := []struct {
-		Epoch           uint64
+		Bucket          int64
 		Validatorscount uint64
 		Rewards         int64
 	}{}
 
-	err := db.DB.Select(&rows, `
-		with
-			firstdeposits as (
-				select distinct
-					vb.epoch,
-					sum(coalesce(vb.balance,32e9)) over (order by v.activationepoch asc) as amount
-				from validators v
-					left join validator_balances vb
-						on vb.validatorindex = v.validatorindex
-						and vb.epoch = v.activationepoch
-				order by vb.epoch
-			),
-			extradeposits as (
-				select distinct
-					(d.block_slot/32)-1 AS epoch,
-					sum(d.amount) over (
-						order by d.block_slot/32 asc
-					) as amount
-				from validators
-					inner join blocks_deposits d
-						on d.publickey = validators.pubkey
-						and d.block_slot/32 > validators.activationepoch
-				order by epoch
-			)
-		select 
-			e.epoch,
-			e.validatorscount,
-			e.totalvalidatorbalance-coalesce(fd.amount,0)-coalesce(ed.amount,0) as rewards
-		from epochs e
-			left join firstdeposits fd on fd.epoch = (
-				select epoch from firstdeposits where epoch <= e.epoch order by epoch desc limit 1
-			)
-			left join extradeposits ed on fd.epoch = (
-				select epoch from extradeposits where epoch <= e.epoch order by epoch desc limit 1
-			)
-		order by epoch`)
+	err := selectChartSeries(&rows, &rangeQuery, chartStatsSource(query.Bucket), `
+		(array_agg(validatorscount ORDER BY epoch DESC))[1] AS validatorscount,
+		(array_agg(rewards ORDER BY epoch DESC))[1] AS rewards`)
 	if err != nil {
 		return nil, err
 	}
 
 	seriesData := [][]float64{}
 
-	var rewards int64
-	var day float64
-	validatorsCount := uint64(0)
-	prevDayRewards := int64(0)
-	prevDay := float64(utils.EpochToTime(0).Truncate(time.Hour*24).Unix() * 1000)
+	prevRewards := int64(0)
 	for _, row := range rows {
-		validatorsCount = row.Validatorscount
-		rewards = row.Rewards
-		day = float64(utils.EpochToTime(row.Epoch).Truncate(time.Hour*24).Unix() * 1000)
-		if day != prevDay {
-			// data for previous day
+		if row.Validatorscount != 0 && row.Bucket >= start.Unix() {
 			seriesData = append(seriesData, []float64{
-				prevDay,
-				utils.RoundDecimals(float64(rewards-prevDayRewards)/float64(validatorsCount)/1e9, 4),
+				float64(row.Bucket * 1000),
+				utils.RoundDecimals(float64(row.Rewards-prevRewards)/float64(row.Validatorscount)/1e9, 4),
 			})
-			prevDayRewards = row.Rewards
-			prevDay = day
 		}
+		prevRewards = row.Rewards
 	}
-	// data for current day
-	seriesData = append(seriesData, []float64{
-		day,
-		utils.RoundDecimals(float64(rewards-prevDayRewards)/float64(validatorsCount)/1e9, 4),
-	})
 
 	chartData := &types.GenericChartData{
 		Title:        "Validator Income",
-		Subtitle:     "Average Daily Validator Income.",
+		Subtitle:     fmt.Sprintf("Average %v Validator Income.", chartBucketAdjective(query.Bucket, true)),
 		XAxisTitle:   "",
-		YAxisTitle:   "Average Daily Validator Income [ETH/day]",
+		YAxisTitle:   fmt.Sprintf("Average %v Validator Income [ETH/%v]", chartBucketAdjective(query.Bucket, true), query.Bucket),
 		StackingMode: "false",
 		Type:         "column",
 		Series: []*types.GenericChartDataSeries{
 			{
-				Name: "Average Daily Validator Income",
+				Name: fmt.Sprintf("Average %v Validator Income", chartBucketAdjective(query.Bucket, true)),
 				Data: seriesData,
 			},
 		},
@@ -618,78 +546,29 @@ func averageDailyValidatorIncomeChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
-func stakingRewardsChartData() (*types.GenericChartData, error) {
+func stakingRewardsChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
 	if LatestEpoch() == 0 {
 		return nil, fmt.Errorf("chart-data not available pre-genesis")
 	}
 
 	rows := []struct {
-		Epoch   uint64
+		Bucket  int64
 		Rewards int64
 	}{}
 
-	err := db.DB.Select(&rows, `
-		with
-			firstdeposits as (
-				select distinct
-					vb.epoch,
-					sum(coalesce(vb.balance,32e9)) over (order by v.activationepoch asc) as amount
-				from validators v
-					left join validator_balances vb
-						on vb.validatorindex = v.validatorindex
-						and vb.epoch = v.activationepoch
-				order by vb.epoch
-			),
-			extradeposits as (
-				select distinct
-					(d.block_slot/32)-1 AS epoch,
-					sum(d.amount) over (
-						order by d.block_slot/32 asc
-					) as amount
-				from validators
-					inner join blocks_deposits d
-						on d.publickey = validators.pubkey
-						and d.block_slot/32 > validators.activationepoch
-				order by epoch
-			)
-		select 
-			e.epoch,
-			e.totalvalidatorbalance-coalesce(fd.amount,0)-coalesce(ed.amount,0) as rewards
-		from epochs e
-			left join firstdeposits fd on fd.epoch = (
-				select epoch from firstdeposits where epoch <= e.epoch order by epoch desc limit 1
-			)
-			left join extradeposits ed on ed.epoch = (
-				select epoch from extradeposits where epoch <= e.epoch order by epoch desc limit 1
-			)
-		order by epoch`)
+	err := selectChartSeries(&rows, query, chartStatsSource(query.Bucket), "(array_agg(rewards ORDER BY epoch DESC))[1] AS rewards")
 	if err != nil {
 		return nil, err
 	}
 
 	seriesData := [][]float64{}
 
-	var rewards float64
-	var day float64
-	prevDay := float64(utils.EpochToTime(0).Truncate(time.Hour*24).Unix() * 1000)
 	for _, row := range rows {
-		rewards = utils.RoundDecimals(float64(row.Rewards)/1e9, 4)
-		day = float64(utils.EpochToTime(row.Epoch).Truncate(time.Hour*24).Unix() * 1000)
-		if day != prevDay {
-			// data for previous day
-			seriesData = append(seriesData, []float64{
-				prevDay,
-				rewards,
-			})
-			prevDay = day
-		}
+		seriesData = append(seriesData, []float64{
+			float64(row.Bucket * 1000),
+			utils.RoundDecimals(float64(row.Rewards)/1e9, 4),
+		})
 	}
-	// data for current day
-	seriesData = append(seriesData, []float64{
-		day,
-		rewards,
-	})
-
 	chartData := &types.GenericChartData{
 		Title:        "Staking Rewards",
 		Subtitle:     "Total Accumulated Staking Rewards",
@@ -725,25 +604,18 @@ func estimatedValidatorIncomeChartData() (*types.GenericChartData, error) {
 
 	// note: eligibleether might not be correct, need to check what exactly the node returns
 	// for the reward-calculation we need the sum of all effective balances
+	// the extra deposits of an epoch are not income and are subtracted from the total balance
 	err := db.DB.Select(&rows, `
-		with
-			extradeposits as (
-				select
-					(d.block_slot/32) as epoch,
-					sum(d.amount) as amount
-					from validators
-				inner join blocks_deposits d 
-					on d.publickey = validators.pubkey
-					and (d.block_slot/32) > validators.activationepoch
-				group by epoch
-			)
-		select 
-			epochs.epoch, eligibleether, votedether, validatorscount, globalparticipationrate,
-			coalesce(totalvalidatorbalance - coalesce(ed.amount,0),0) as totalvalidatorbalance
-		from epochs
-			left join extradeposits ed on epochs.epoch = ed.epoch
-			left join network_liveness nl on epochs.epoch = nl.headepoch
-		order by epoch;`)
+		SELECT
+			epoch,
+			eligibleether,
+			votedether,
+			validatorscount,
+			globalparticipationrate,
+			COALESCE(finality_delay, 0) AS finalitydelay,
+			totalvalidatorbalance - extra_deposits AS totalvalidatorbalance
+		FROM epoch_stats
+		ORDER BY epoch`)
 	if err != nil {
 		return nil, err
 	}
@@ -826,23 +698,17 @@ func estimatedValidatorIncomeChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
-func stakeEffectivenessChartData() (*types.GenericChartData, error) {
+func stakeEffectivenessChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
 	if LatestEpoch() == 0 {
 		return nil, fmt.Errorf("chart-data not available pre-genesis")
 	}
 
 	rows := []struct {
-		Epoch                 uint64
-		Totalvalidatorbalance uint64
-		Eligibleether         uint64
+		Bucket        int64
+		Effectiveness float64
 	}{}
 
-	err := db.DB.Select(&rows, `
-		SELECT
-			epoch, 
-			COALESCE(totalvalidatorbalance, 0) AS totalvalidatorbalance,
-			COALESCE(eligibleether, 0) AS eligibleether
-		FROM epochs ORDER BY epoch`)
+	err := selectChartSeries(&rows, query, "(SELECT ts, eligibleether, totalvalidatorbalance FROM epoch_stats WHERE eligibleether > 0 AND totalvalidatorbalance > 0) s", "AVG(100 * eligibleether::float / totalvalidatorbalance) AS effectiveness")
 	if err != nil {
 		return nil, err
 	}
@@ -850,18 +716,11 @@ func stakeEffectivenessChartData() (*types.GenericChartData, error) {
 	seriesData := [][]float64{}
 
 	for _, row := range rows {
-		if row.Eligibleether == 0 {
-			continue
-		}
-		if row.Totalvalidatorbalance == 0 {
-			continue
-		}
 		seriesData = append(seriesData, []float64{
-			float64(utils.EpochToTime(row.Epoch).Unix() * 1000),
-			utils.RoundDecimals(100*float64(row.Eligibleether)/float64(row.Totalvalidatorbalance), 2),
+			float64(row.Bucket * 1000),
+			utils.RoundDecimals(row.Effectiveness, 2),
 		})
 	}
-
 	chartData := &types.GenericChartData{
 		Title:        "Stake Effectiveness",
 		Subtitle:     "Stake Effectiveness measures the relation between the sum of all effective balances and the sum of all balances. 100% Stake Effectiveness means that 100% of the locked Ether is used for staking.",
@@ -880,39 +739,41 @@ func stakeEffectivenessChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
-func balanceDistributionChartData() (*types.GenericChartData, error) {
-	if LatestEpoch() == 0 {
-		return nil, fmt.Errorf("chart-data not available pre-genesis")
+// balanceDistributionSource returns the epoch and the table of the balances at the end of the range of the query.
+// The latest balances are read from the validators table, older balances from the latest stored epoch at or before
+// the end of the range as the balances may have been downsampled to one epoch per day.
+func balanceDistributionSource(query *types.ChartQuery) (uint64, string) {
+	latestEpoch := LatestEpoch()
+	epoch := uint64(utils.TimeToEpoch(query.To))
+	if epoch >= latestEpoch {
+		return latestEpoch, "validators"
 	}
+	return epoch, fmt.Sprintf("(select * from validator_balances where epoch = (select max(epoch) from validator_balances where epoch <= %d)) b", epoch)
+}
 
-	tx, err := db.DB.Beginx()
-	if err != nil {
-		return nil, err
+func balanceDistributionChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
+	if LatestEpoch() == 0 {
+		return nil, fmt.Errorf("chart-data not available pre-genesis")
 	}
-	defer tx.Rollback()
 
-	var currentEpoch uint64
-	err = tx.Get(&currentEpoch, "select max(epoch) from validator_balances")
-	if err != nil {
-		return nil, err
-	}
+	currentEpoch, balances := balanceDistributionSource(query)
 
 	rows := []struct {
 		MaxBalance float64
 		Count      float64
 	}{}
 
-	err = tx.Select(&rows, `
+	err := db.DB.Select(&rows, `
 		with
 			stats as (
 				select 
 					min(balance) as min,
 					max(balance) as max
-				from validator_balances where epoch = (select max(epoch) as maxepoch from validator_balances) 
+				from `+balances+`
 			),
 			balances as (
 				select balance
-				from validator_balances where epoch = (select max(epoch) as maxepoch from validator_balances)
+				from `+balances+`
 			),
 			histogram as (
 				select 
@@ -932,10 +793,6 @@ func balanceDistributionChartData() (*types.GenericChartData, error) {
 		return nil, err
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		return nil, err
-	}
 	seriesData := make([][]float64, len(rows))
 
 	for i, row := range rows {
@@ -963,39 +820,29 @@ func balanceDistributionChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
-func effectiveBalanceDistributionChartData() (*types.GenericChartData, error) {
+func effectiveBalanceDistributionChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
 	if LatestEpoch() == 0 {
 		return nil, fmt.Errorf("chart-data not available pre-genesis")
 	}
 
-	tx, err := db.DB.Beginx()
-	if err != nil {
-		return nil, err
-	}
-	defer tx.Rollback()
-
-	var currentEpoch uint64
-	err = tx.Get(&currentEpoch, "select max(epoch) from validator_balances")
-	if err != nil {
-		return nil, err
-	}
+	currentEpoch, balances := balanceDistributionSource(query)
 
 	rows := []struct {
 		MaxBalance float64
 		Count      float64
 	}{}
 
-	err = tx.Select(&rows, `
+	err := db.DB.Select(&rows, `
 		with
 			stats as (
 				select 
 					min(effectivebalance) as min,
 					max(effectivebalance) as max
-				from validator_balances where epoch = (select max(epoch) as maxepoch from validator_balances) 
+				from `+balances+`
 			),
 			balances as (
 				select effectivebalance
-				from validator_balances where epoch = (select max(epoch) as maxepoch from validator_balances)
+				from `+balances+`
 			),
 			histogram as (
 				select 
@@ -1015,10 +862,6 @@ func effectiveBalanceDistributionChartData() (*types.GenericChartData, error) {
 		return nil, err
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		return nil, err
-	}
 	seriesData := make([][]float64, len(rows))
 
 	for i, row := range rows {
@@ -1046,10 +889,13 @@ func effectiveBalanceDistributionChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
-func performanceDistribution1dChartData() (*types.GenericChartData, error) {
+func performanceDistribution1dChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
 	if LatestEpoch() == 0 {
 		return nil, fmt.Errorf("chart-data not available pre-genesis")
 	}
+	if query.To.Before(utils.EpochToTime(LatestEpoch())) {
+		return nil, ErrChartRangeNotSupported
+	}
 
 	var err error
 
@@ -1112,10 +958,13 @@ func performanceDistribution1dChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
-func performanceDistribution7dChartData() (*types.GenericChartData, error) {
+func performanceDistribution7dChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
 	if LatestEpoch() == 0 {
 		return nil, fmt.Errorf("chart-data not available pre-genesis")
 	}
+	if query.To.Before(utils.EpochToTime(LatestEpoch())) {
+		return nil, ErrChartRangeNotSupported
+	}
 
 	var err error
 
@@ -1178,10 +1027,13 @@ func performanceDistribution7dChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
-func performanceDistribution31dChartData() (*types.GenericChartData, error) {
+func performanceDistribution31dChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
 	if LatestEpoch() == 0 {
 		return nil, fmt.Errorf("chart-data not available pre-genesis")
 	}
+	if query.To.Before(utils.EpochToTime(LatestEpoch())) {
+		return nil, ErrChartRangeNotSupported
+	}
 
 	var err error
 
@@ -1244,10 +1096,13 @@ func performanceDistribution31dChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
-func performanceDistribution365dChartData() (*types.GenericChartData, error) {
+func performanceDistribution365dChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
 	if LatestEpoch() == 0 {
 		return nil, fmt.Errorf("chart-data not available pre-genesis")
 	}
+	if query.To.Before(utils.EpochToTime(LatestEpoch())) {
+		return nil, ErrChartRangeNotSupported
+	}
 
 	var err error
 
@@ -1313,74 +1168,64 @@ func performanceDistribution365dChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
-func depositsChartData() (*types.GenericChartData, error) {
+func depositsChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
 	var err error
 
 	eth1Rows := []struct {
-		Timestamp int64
-		Amount    uint64
-		Valid     bool
+		Bucket        int64
+		ValidAmount   uint64 `db:"valid_amount"`
+		InvalidAmount uint64 `db:"invalid_amount"`
 	}{}
 
-	err = db.DB.Select(&eth1Rows, `
-		select
-			extract(epoch from block_ts)::int as timestamp,
-			amount,
-			valid_signature as valid
-		from eth1_deposits
-		order by timestamp`)
+	// buckets of at least a day are aggregated from the daily rollup
+	eth1Source := "(SELECT day::timestamp AS ts, valid_amount, invalid_amount FROM eth1_deposits_daily) s"
+	if query.Bucket != "day" && query.Bucket != "week" {
+		eth1Source = `(
+			SELECT
+				block_ts AS ts,
+				CASE WHEN valid_signature THEN amount ELSE 0 END AS valid_amount,
+				CASE WHEN valid_signature THEN 0 ELSE amount END AS invalid_amount
+			FROM eth1_deposits
+		) s`
+	}
+	err = selectChartSeries(&eth1Rows, query, eth1Source, "SUM(valid_amount) AS valid_amount, SUM(invalid_amount) AS invalid_amount")
 	if err != nil {
 		return nil, fmt.Errorf("error getting eth1-deposits: %w", err)
 	}
 
 	eth2Rows := []struct {
-		Slot   uint64
-		Amount uint64
+		Bucket   int64
+		Deposits uint64
 	}{}
 
-	err = db.DB.Select(&eth2Rows, `
-		select block_slot as slot, amount 
-		from blocks_deposits
-		order by slot`)
+	err = selectChartSeries(&eth2Rows, query, chartStatsSource(query.Bucket), "SUM(deposits) AS deposits")
 	if err != nil {
 		return nil, fmt.Errorf("error getting eth2-deposits: %w", err)
 	}
 
-	dailySuccessfulEth1Deposits := [][]float64{}
-	dailyFailedEth1Deposits := [][]float64{}
-	dailyEth2Deposits := [][]float64{}
+	successfulEth1Deposits := [][]float64{}
+	failedEth1Deposits := [][]float64{}
+	eth2Deposits := [][]float64{}
 
 	for _, row := range eth1Rows {
-		day := float64(time.Unix(row.Timestamp, 0).Truncate(time.Hour*24).Unix() * 1000)
-
-		if row.Valid {
-			if len(dailySuccessfulEth1Deposits) == 0 || dailySuccessfulEth1Deposits[len(dailySuccessfulEth1Deposits)-1][0] != day {
-				dailySuccessfulEth1Deposits = append(dailySuccessfulEth1Deposits, []float64{day, float64(row.Amount / 1e9)})
-			} else {
-				dailySuccessfulEth1Deposits[len(dailySuccessfulEth1Deposits)-1][1] += float64(row.Amount / 1e9)
-			}
-		} else {
-			if len(dailyFailedEth1Deposits) == 0 || dailyFailedEth1Deposits[len(dailyFailedEth1Deposits)-1][0] != day {
-				dailyFailedEth1Deposits = append(dailyFailedEth1Deposits, []float64{day, float64(row.Amount / 1e9)})
-			} else {
-				dailyFailedEth1Deposits[len(dailyFailedEth1Deposits)-1][1] += float64(row.Amount / 1e9)
-			}
+		ts := float64(row.Bucket * 1000)
+		if row.ValidAmount > 0 {
+			successfulEth1Deposits = append(successfulEth1Deposits, []float64{ts, float64(row.ValidAmount / 1e9)})
+		}
+		if row.InvalidAmount > 0 {
+			failedEth1Deposits = append(failedEth1Deposits, []float64{ts, float64(row.InvalidAmount / 1e9)})
 		}
 	}
 
 	for _, row := range eth2Rows {
-		day := float64(utils.SlotToTime(row.Slot).Truncate(time.Hour*24).Unix() * 1000)
-
-		if len(dailyEth2Deposits) == 0 || dailyEth2Deposits[len(dailyEth2Deposits)-1][0] != day {
-			dailyEth2Deposits = append(dailyEth2Deposits, []float64{day, float64(row.Amount / 1e9)})
-		} else {
-			dailyEth2Deposits[len(dailyEth2Deposits)-1][1] += float64(row.Amount / 1e9)
+		if row.Deposits > 0 {
+			eth2Deposits = append(eth2Deposits, []float64{float64(row.Bucket * 1000), float64(row.Deposits / 1e9)})
 		}
 	}
 
 	chartData := &types.GenericChartData{
 		Title:        "Deposits",
-		Subtitle:     "Daily Amount of deposited ETH.",
+		Subtitle:     fmt.Sprintf("%v Amount of deposited ETH.", chartBucketAdjective(query.Bucket, true)),
 		XAxisTitle:   "Income",
 		YAxisTitle:   "Deposited ETH",
 		StackingMode: "normal",
@@ -1388,17 +1233,17 @@ func depositsChartData() (*types.GenericChartData, error) {
 		Series: []*types.GenericChartDataSeries{
 			{
 				Name:  "ETH2",
-				Data:  dailyEth2Deposits,
+				Data:  eth2Deposits,
 				Stack: "eth2",
 			},
 			{
 				Name:  "ETH1 (success)",
-				Data:  dailySuccessfulEth1Deposits,
+				Data:  successfulEth1Deposits,
 				Stack: "eth1",
 			},
 			{
 				Name:  "ETH1 (failed)",
-				Data:  dailyFailedEth1Deposits,
+				Data:  failedEth1Deposits,
 				Stack: "eth1",
 			},
 		},
@@ -1407,7 +1252,7 @@ func depositsChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
-func depositsDistributionChartData() (*types.GenericChartData, error) {
+func depositsDistributionChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
 	var err error
 
 	rows := []struct {
@@ -1420,12 +1265,12 @@ func depositsDistributionChartData() (*types.GenericChartData, error) {
 		from (
 			select publickey, from_address
 			from eth1_deposits
-			where valid_signature = true
+			where valid_signature = true and block_ts >= $1 and block_ts < $2
 			group by publickey, from_address
 			having sum(amount) >= 32e9
 		) a
 		group by from_address
-		order by count desc`)
+		order by count desc`, query.From.UTC(), query.To.UTC())
 	if err != nil {
 		return nil, fmt.Errorf("error getting eth1-deposits-distribution: %w", err)
 	}
@@ -1486,7 +1331,7 @@ func depositsDistributionChartData() (*types.GenericChartData, error) {
 	return chartData, nil
 }
 
-func graffitiCloudChartData() (*types.GenericChartData, error) {
+func graffitiCloudChartData(query *types.ChartQuery) (*types.GenericChartData, error) {
 	if LatestEpoch() == 0 {
 		return nil, fmt.Errorf("chart-data not available pre-genesis")
 	}
@@ -1505,13 +1350,15 @@ func graffitiCloudChartData() (*types.GenericChartData, error) {
 				select count(*), graffiti
 				from blocks 
 				where graffiti <> '\x' and graffiti <> '\x0000000000000000000000000000000000000000000000000000000000000000'
+					and slot >= $1 and slot < $2
 				group by graffiti order by count desc limit 25
 			)
 		select count(distinct blocks.proposer) as validators, graffities.graffiti as name, graffities.count as weight
 		from blocks 
 			inner join graffities on blocks.graffiti = graffities.graffiti 
+		where blocks.slot >= $1 and blocks.slot < $2
 		group by graffities.graffiti, graffities.count
-		order by weight desc`)
+		order by weight desc`, utils.TimeToSlot(uint64(query.From.Unix())), utils.TimeToSlot(uint64(query.To.Unix()))+1)
 	if err != nil {
 		return nil, fmt.Errorf("error getting graffiti-occurences: %w", err)
 	}
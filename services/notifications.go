@@ -1,15 +1,18 @@
 package services
 
 import (
+	"errors"
 	"eth2-exporter/db"
-	"eth2-exporter/mail"
+	"eth2-exporter/notify"
 	"eth2-exporter/types"
 	"eth2-exporter/utils"
 	"fmt"
+	"sync"
 	"time"
 )
 
 func notificationsSender() {
+	notifiers := notify.Notifiers()
 	for {
 		// check if the explorer is not too far behind, if we set this value to close (10m) it could potentially never send any notifications
 		// if IsSyncing() {
@@ -19,67 +22,166 @@ func notificationsSender() {
 			continue
 		}
 		start := time.Now()
-		notificationsByEMail := collectNotifications()
-		sendNotifications(notificationsByEMail)
-		logger.WithField("emails", len(notificationsByEMail)).WithField("duration", time.Since(start)).Info("notifications completed")
+		notificationsByUserID, err := collectNotifications()
+		sendErr := sendNotifications(notifiers, notificationsByUserID)
+		if sendErr != nil {
+			logger.Error(sendErr)
+			err = sendErr
+		}
+		logger.WithField("users", len(notificationsByUserID)).WithField("duration", time.Since(start)).Info("notifications completed")
+		db.ReportServiceRun(types.ServiceNotifications, err)
 		time.Sleep(time.Second * 60)
 	}
 }
 
-func collectNotifications() map[string]map[types.EventName][]types.Notification {
-	notificationsByEmail := map[string]map[types.EventName][]types.Notification{}
-	var err error
-	err = collectValidatorBalanceDecreasedNotifications(notificationsByEmail)
+// collectNotifications runs all collectors, a failing collector does not prevent the notifications of the others from
+// being sent but its error is returned
+func collectNotifications() (map[uint64]map[types.EventName][]types.Notification, error) {
+	notificationsByUserID := map[uint64]map[types.EventName][]types.Notification{}
+	collectors := []struct {
+		name    string
+		collect func(map[uint64]map[types.EventName][]types.Notification) error
+	}{
+		{"validator_balance_decreased", collectValidatorBalanceDecreasedNotifications},
+		{"validator_got_slashed", collectValidatorGotSlashedNotifications},
+		{"validator_missed_proposal", collectValidatorMissedProposalNotifications},
+		{"validator_missed_attestation", collectValidatorMissedAttestationNotifications},
+		{"validator_did_slash", collectValidatorDidSlashNotifications},
+		{"validator_state_changed", collectValidatorStateChangedNotifications},
+		{"validator_received_deposit", collectValidatorReceivedDepositNotifications},
+		{"network_reorg", collectNetworkReorgNotifications},
+		{"network_slashing", collectNetworkSlashingNotifications},
+		{"network queue", collectNetworkQueueNotifications},
+		{"network_liveness_increased", collectNetworkLivenessNotifications},
+	}
+	var collectErr error
+	for _, c := range collectors {
+		err := c.collect(notificationsByUserID)
+		if err != nil {
+			collectErr = fmt.Errorf("error collecting %v notifications: %v", c.name, err)
+			logger.Error(collectErr)
+		}
+	}
+	return notificationsByUserID, collectErr
+}
+
+// sendNotifications delivers the notifications of the users in parallel and waits for the deliveries, the error of a
+// failed delivery is returned once all deliveries are done
+func sendNotifications(notifiers map[types.NotificationChannel]notify.Notifier, notificationsByUserID map[uint64]map[types.EventName][]types.Notification) error {
+	if len(notificationsByUserID) == 0 {
+		return nil
+	}
+
+	userIDs := make([]uint64, 0, len(notificationsByUserID))
+	subIDs := make([]uint64, 0)
+	for userID, userNotifications := range notificationsByUserID {
+		userIDs = append(userIDs, userID)
+		for _, ns := range userNotifications {
+			for _, n := range ns {
+				subIDs = append(subIDs, n.GetSubscriptionID())
+			}
+		}
+	}
+
+	recipients, err := db.GetNotificationRecipients(userIDs)
 	if err != nil {
-		logger.Errorf("error collecting validator_balance_decreased notifications: %v", err)
+		return fmt.Errorf("error retrieving notification recipients: %v", err)
 	}
-	err = collectValidatorGotSlashedNotifications(notificationsByEmail)
+	channelsBySubID, err := db.GetSubscriptionsChannels(subIDs)
 	if err != nil {
-		logger.Errorf("error collecting validator_got_slashed notifications: %v", err)
+		return fmt.Errorf("error retrieving channels of subscriptions: %v", err)
 	}
-	return notificationsByEmail
-}
 
-func sendNotifications(notificationsByEmail map[string]map[types.EventName][]types.Notification) {
-	for userEmail, userNotifications := range notificationsByEmail {
-		go func(userEmail string, userNotifications map[types.EventName][]types.Notification) {
-			sentSubsByEpoch := map[uint64][]uint64{}
-			subject := fmt.Sprintf("%s: Notification", utils.Config.Frontend.SiteDomain)
-			msg := ""
-			for event, ns := range userNotifications {
-				if len(msg) > 0 {
-					msg += "\n"
+	wg := sync.WaitGroup{}
+	deliveryErrMux := sync.Mutex{}
+	var deliveryErr error
+	for userID, userNotifications := range notificationsByUserID {
+		recipient, exists := recipients[userID]
+		if !exists {
+			continue
+		}
+
+		wg.Add(1)
+		go func(recipient *types.NotificationRecipient, userNotifications map[types.EventName][]types.Notification) {
+			defer wg.Done()
+			sentSubs := map[uint64]bool{}
+			for _, channel := range types.NotificationChannels {
+				notifier := notifiers[channel]
+				if notifier == nil || notifier.Target(recipient) == "" {
+					continue
 				}
-				msg += fmt.Sprintf("%s\n====\n\n", event)
-				for _, n := range ns {
-					msg += fmt.Sprintf("%s\n", n.GetInfo())
-					e := n.GetEpoch()
-					if _, exists := sentSubsByEpoch[e]; !exists {
-						sentSubsByEpoch[e] = []uint64{n.GetSubscriptionID()}
-					} else {
-						sentSubsByEpoch[e] = append(sentSubsByEpoch[e], n.GetSubscriptionID())
+
+				msg := &notify.Message{
+					Subject:       fmt.Sprintf("%s: Notification", utils.Config.Frontend.SiteDomain),
+					Notifications: map[types.EventName][]types.Notification{},
+				}
+				for event, ns := range userNotifications {
+					for _, n := range ns {
+						if hasNotificationChannel(channelsBySubID[n.GetSubscriptionID()], channel) {
+							msg.Notifications[event] = append(msg.Notifications[event], n)
+						}
 					}
 				}
-				if event == "validator_balance_decreased" {
-					msg += "\nYou will not receive any further balance decrease mails for these validators until the balance of a validator is increasing again.\n"
+				if len(msg.Notifications) == 0 {
+					continue
 				}
-			}
-			msg += fmt.Sprintf("\nBest regards\n\n%s", utils.Config.Frontend.SiteDomain)
 
-			err := mail.SendMailRateLimited(userEmail, subject, msg)
-			if err != nil {
-				logger.Errorf("error sending notification-email: %v", err)
-				return
+				err := notify.Deliver(notifier, recipient, msg)
+				if err != nil {
+					logger.Errorf("error sending %v notification to user %v: %v", channel, recipient.UserID, err)
+					// rate limited deliveries are expected and do not make the run fail
+					var rateLimitError *types.RateLimitError
+					if !errors.As(err, &rateLimitError) {
+						deliveryErrMux.Lock()
+						deliveryErr = fmt.Errorf("error sending %v notification: %v", channel, err)
+						deliveryErrMux.Unlock()
+					}
+					continue
+				}
+				for _, subID := range msg.SubscriptionIDs() {
+					sentSubs[subID] = true
+				}
 			}
 
+			// The collectors only create notifications for epochs after the last sent epoch of a subscription
+			lastEpochBySub := map[uint64]uint64{}
+			for _, ns := range userNotifications {
+				for _, n := range ns {
+					if sentSubs[n.GetSubscriptionID()] && n.GetEpoch() >= lastEpochBySub[n.GetSubscriptionID()] {
+						lastEpochBySub[n.GetSubscriptionID()] = n.GetEpoch()
+					}
+				}
+			}
+			sentSubsByEpoch := map[uint64][]uint64{}
+			for subID, epoch := range lastEpochBySub {
+				sentSubsByEpoch[epoch] = append(sentSubsByEpoch[epoch], subID)
+			}
 			for epoch, subIDs := range sentSubsByEpoch {
-				err = db.UpdateSubscriptionsLastSent(subIDs, time.Now(), epoch)
+				err := db.UpdateSubscriptionsLastSent(subIDs, time.Now(), epoch)
 				if err != nil {
 					logger.Errorf("error updating sent-time of sent notifications: %v", err)
 				}
 			}
-		}(userEmail, userNotifications)
+		}(recipient, userNotifications)
+	}
+	wg.Wait()
+	return deliveryErr
+}
+
+func addNotification(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, userID uint64, n types.Notification) {
+	if _, exists := notificationsByUserID[userID]; !exists {
+		notificationsByUserID[userID] = map[types.EventName][]types.Notification{}
 	}
+	notificationsByUserID[userID][n.GetEventName()] = append(notificationsByUserID[userID][n.GetEventName()], n)
+}
+
+func hasNotificationChannel(channels []types.NotificationChannel, channel types.NotificationChannel) bool {
+	for _, c := range channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
 }
 
 type validatorBalanceDecreasedNotification struct {
@@ -114,7 +216,7 @@ func (n *validatorBalanceDecreasedNotification) GetInfo() string {
 // and creates notifications for all subscriptions which have not been notified about the validator since the last time its balance increased.
 // It looks 10 epochs back for when the balance increased the last time, this means if the explorer is not running for 10 epochs it is possible
 // that no new notification is sent even if there was a balance-increase.
-func collectValidatorBalanceDecreasedNotifications(notificationsByEmail map[string]map[types.EventName][]types.Notification) error {
+func collectValidatorBalanceDecreasedNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification) error {
 	latestEpoch := LatestEpoch()
 	if latestEpoch < 3 {
 		return nil
@@ -122,17 +224,17 @@ func collectValidatorBalanceDecreasedNotifications(notificationsByEmail map[stri
 
 	var dbResult []struct {
 		SubscriptionID uint64 `db:"id"`
-		Email          string `db:"email"`
+		UserID         uint64 `db:"user_id"`
 		ValidatorIndex uint64 `db:"validatorindex"`
 		StartBalance   uint64 `db:"startbalance"`
 		EndBalance     uint64 `db:"endbalance"`
 	}
 
 	err := db.DB.Select(&dbResult, `
-		SELECT id, email, validatorindex, startbalance, endbalance FROM (
+		SELECT id, user_id, validatorindex, startbalance, endbalance FROM (
 			SELECT 
 				us.id, 
-				u.email, 
+				us.user_id, 
 				v.validatorindex, 
 				vb0.balance AS endbalance, 
 				vb3.balance AS startbalance, 
@@ -166,13 +268,7 @@ func collectValidatorBalanceDecreasedNotifications(notificationsByEmail map[stri
 			EndBalance:     r.EndBalance,
 		}
 
-		if _, exists := notificationsByEmail[r.Email]; !exists {
-			notificationsByEmail[r.Email] = map[types.EventName][]types.Notification{}
-		}
-		if _, exists := notificationsByEmail[r.Email][n.GetEventName()]; !exists {
-			notificationsByEmail[r.Email][n.GetEventName()] = []types.Notification{}
-		}
-		notificationsByEmail[r.Email][n.GetEventName()] = append(notificationsByEmail[r.Email][n.GetEventName()], n)
+		addNotification(notificationsByUserID, r.UserID, n)
 	}
 
 	return nil
@@ -202,7 +298,7 @@ func (n *validatorGotSlashedNotification) GetInfo() string {
 	return fmt.Sprintf(`Validator %[1]v has been slashed at epoch %[2]v by validator %[3]v for %[4]s. For more information visit: https://%[5]v/validator/%[1]v`, n.ValidatorIndex, n.Epoch, n.Slasher, n.Reason, utils.Config.Frontend.SiteDomain)
 }
 
-func collectValidatorGotSlashedNotifications(notificationsByEmail map[string]map[types.EventName][]types.Notification) error {
+func collectValidatorGotSlashedNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification) error {
 	latestEpoch := LatestEpoch()
 	if latestEpoch == 0 {
 		return nil
@@ -210,7 +306,7 @@ func collectValidatorGotSlashedNotifications(notificationsByEmail map[string]map
 
 	var dbResult []struct {
 		SubscriptionID uint64 `db:"id"`
-		Email          string `db:"email"`
+		UserID         uint64 `db:"user_id"`
 		ValidatorIndex uint64 `db:"validatorindex"`
 		Slasher        uint64 `db:"slasher"`
 		Epoch          uint64 `db:"epoch"`
@@ -245,7 +341,7 @@ func collectValidatorGotSlashedNotifications(notificationsByEmail map[string]map
 				) a
 				ORDER BY slashedvalidator, slot
 			)
-		SELECT us.id, u.email, v.validatorindex, s.slasher, s.epoch, s.reason
+		SELECT us.id, us.user_id, v.validatorindex, s.slasher, s.epoch, s.reason
 		FROM users_subscriptions us
 		INNER JOIN users u ON u.id = us.user_id
 		INNER JOIN validators v ON ENCODE(v.pubkey, 'hex') = us.event_filter
@@ -264,13 +360,405 @@ func collectValidatorGotSlashedNotifications(notificationsByEmail map[string]map
 			Epoch:          r.Epoch,
 			Reason:         r.Reason,
 		}
-		if _, exists := notificationsByEmail[r.Email]; !exists {
-			notificationsByEmail[r.Email] = map[types.EventName][]types.Notification{}
+		addNotification(notificationsByUserID, r.UserID, n)
+	}
+
+	return nil
+}
+
+type networkReorgNotification struct {
+	SubscriptionID uint64
+	Epoch          uint64
+	Depth          uint64
+	NewHeadSlot    uint64
+}
+
+func (n *networkReorgNotification) GetSubscriptionID() uint64 {
+	return n.SubscriptionID
+}
+
+func (n *networkReorgNotification) GetEpoch() uint64 {
+	return n.Epoch
+}
+
+func (n *networkReorgNotification) GetEventName() types.EventName {
+	return types.NetworkReorgEventName
+}
+
+func (n *networkReorgNotification) GetInfo() string {
+	return fmt.Sprintf(`A chain reorg of depth %[1]v has been detected at epoch %[2]v, the new head of the chain is slot %[3]v. For more information visit: https://%[4]v/reorgs`, n.Depth, n.Epoch, n.NewHeadSlot, utils.Config.Frontend.SiteDomain)
+}
+
+// collectNetworkReorgNotifications creates notifications for all reorgs that have been detected since the last notification of a subscription
+func collectNetworkReorgNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification) error {
+	var dbResult []struct {
+		SubscriptionID uint64 `db:"id"`
+		UserID         uint64 `db:"user_id"`
+		Epoch          uint64 `db:"epoch"`
+		Depth          uint64 `db:"depth"`
+		NewHeadSlot    uint64 `db:"new_head_slot"`
+	}
+
+	err := db.DB.Select(&dbResult, `
+		SELECT us.id, us.user_id, r.epoch, r.depth, r.new_head_slot
+		FROM users_subscriptions us
+		INNER JOIN users u ON u.id = us.user_id
+		INNER JOIN reorgs r ON r.ts > COALESCE(us.last_sent_ts, us.created_ts)
+		WHERE us.event_name = $1
+		ORDER BY r.id`,
+		types.NetworkReorgEventName)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range dbResult {
+		n := &networkReorgNotification{
+			SubscriptionID: r.SubscriptionID,
+			Epoch:          r.Epoch,
+			Depth:          r.Depth,
+			NewHeadSlot:    r.NewHeadSlot,
+		}
+		addNotification(notificationsByUserID, r.UserID, n)
+	}
+
+	return nil
+}
+
+type validatorMissedProposalNotification struct {
+	SubscriptionID uint64
+	ValidatorIndex uint64
+	Epoch          uint64
+	Slot           uint64
+}
+
+func (n *validatorMissedProposalNotification) GetSubscriptionID() uint64 {
+	return n.SubscriptionID
+}
+
+func (n *validatorMissedProposalNotification) GetEpoch() uint64 {
+	return n.Epoch
+}
+
+func (n *validatorMissedProposalNotification) GetEventName() types.EventName {
+	return types.ValidatorMissedProposalEventName
+}
+
+func (n *validatorMissedProposalNotification) GetInfo() string {
+	return fmt.Sprintf(`Validator %[1]v missed the proposal of the block at slot %[2]v in epoch %[3]v. For more information visit: https://%[4]v/block/%[2]v`, n.ValidatorIndex, n.Slot, n.Epoch, utils.Config.Frontend.SiteDomain)
+}
+
+// collectValidatorMissedProposalNotifications creates notifications for all missed proposals of subscribed validators
+// since the last notification of a subscription. Only epochs before the latest epoch are considered as the status
+// of a proposal of the latest epoch may still change.
+func collectValidatorMissedProposalNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification) error {
+	latestEpoch := LatestEpoch()
+	if latestEpoch == 0 {
+		return nil
+	}
+
+	var dbResult []struct {
+		SubscriptionID uint64 `db:"id"`
+		UserID         uint64 `db:"user_id"`
+		ValidatorIndex uint64 `db:"validatorindex"`
+		Epoch          uint64 `db:"epoch"`
+		Slot           uint64 `db:"proposerslot"`
+	}
+
+	err := db.DB.Select(&dbResult, `
+		SELECT us.id, us.user_id, pa.validatorindex, pa.epoch, pa.proposerslot
+		FROM users_subscriptions us
+		INNER JOIN validators v ON ENCODE(v.pubkey, 'hex') = us.event_filter
+		INNER JOIN proposal_assignments pa ON pa.validatorindex = v.validatorindex AND pa.status = 2
+		WHERE us.event_name = $1 AND pa.epoch < $2 AND pa.epoch >= us.created_epoch AND (us.last_sent_epoch IS NULL OR pa.epoch > us.last_sent_epoch)
+		ORDER BY pa.proposerslot`,
+		types.ValidatorMissedProposalEventName, latestEpoch)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range dbResult {
+		n := &validatorMissedProposalNotification{
+			SubscriptionID: r.SubscriptionID,
+			ValidatorIndex: r.ValidatorIndex,
+			Epoch:          r.Epoch,
+			Slot:           r.Slot,
+		}
+		addNotification(notificationsByUserID, r.UserID, n)
+	}
+
+	return nil
+}
+
+type validatorMissedAttestationNotification struct {
+	SubscriptionID uint64
+	ValidatorIndex uint64
+	Epoch          uint64
+	Slot           uint64
+}
+
+func (n *validatorMissedAttestationNotification) GetSubscriptionID() uint64 {
+	return n.SubscriptionID
+}
+
+func (n *validatorMissedAttestationNotification) GetEpoch() uint64 {
+	return n.Epoch
+}
+
+func (n *validatorMissedAttestationNotification) GetEventName() types.EventName {
+	return types.ValidatorMissedAttestationEventName
+}
+
+func (n *validatorMissedAttestationNotification) GetInfo() string {
+	return fmt.Sprintf(`Validator %[1]v missed the attestation at slot %[2]v in epoch %[3]v. For more information visit: https://%[4]v/validator/%[1]v`, n.ValidatorIndex, n.Slot, n.Epoch, utils.Config.Frontend.SiteDomain)
+}
+
+// collectValidatorMissedAttestationNotifications creates notifications for all attestations of subscribed validators
+// that have not been included since the last notification of a subscription. An attestation can be included until
+// the end of the following epoch, so the latest two epochs are not considered yet. At most 100 epochs are checked.
+func collectValidatorMissedAttestationNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification) error {
+	latestEpoch := LatestEpoch()
+	if latestEpoch < 2 {
+		return nil
+	}
+
+	var dbResult []struct {
+		SubscriptionID uint64 `db:"id"`
+		UserID         uint64 `db:"user_id"`
+		ValidatorIndex uint64 `db:"validatorindex"`
+		Epoch          uint64 `db:"epoch"`
+		Slot           uint64 `db:"attesterslot"`
+	}
+
+	err := db.DB.Select(&dbResult, `
+		SELECT us.id, us.user_id, aa.validatorindex, aa.epoch, aa.attesterslot
+		FROM users_subscriptions us
+		INNER JOIN validators v ON ENCODE(v.pubkey, 'hex') = us.event_filter
+		INNER JOIN attestation_assignments aa ON aa.validatorindex = v.validatorindex AND aa.status = 0 AND aa.epoch <= $2 AND aa.epoch > $2 - 100
+		WHERE us.event_name = $1 AND aa.epoch >= us.created_epoch AND (us.last_sent_epoch IS NULL OR aa.epoch > us.last_sent_epoch)
+		ORDER BY aa.attesterslot`,
+		types.ValidatorMissedAttestationEventName, latestEpoch-2)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range dbResult {
+		n := &validatorMissedAttestationNotification{
+			SubscriptionID: r.SubscriptionID,
+			ValidatorIndex: r.ValidatorIndex,
+			Epoch:          r.Epoch,
+			Slot:           r.Slot,
+		}
+		addNotification(notificationsByUserID, r.UserID, n)
+	}
+
+	return nil
+}
+
+type validatorDidSlashNotification struct {
+	SubscriptionID         uint64
+	ValidatorIndex         uint64
+	Epoch                  uint64
+	Slot                   uint64
+	ProposerSlashingsCount uint64
+	AttesterSlashingsCount uint64
+}
+
+func (n *validatorDidSlashNotification) GetSubscriptionID() uint64 {
+	return n.SubscriptionID
+}
+
+func (n *validatorDidSlashNotification) GetEpoch() uint64 {
+	return n.Epoch
+}
+
+func (n *validatorDidSlashNotification) GetEventName() types.EventName {
+	return types.ValidatorDidSlashEventName
+}
+
+func (n *validatorDidSlashNotification) GetInfo() string {
+	return fmt.Sprintf(`Validator %[1]v included %[2]v proposer slashing(s) and %[3]v attester slashing(s) in the block at slot %[4]v in epoch %[5]v. For more information visit: https://%[6]v/block/%[4]v`, n.ValidatorIndex, n.ProposerSlashingsCount, n.AttesterSlashingsCount, n.Slot, n.Epoch, utils.Config.Frontend.SiteDomain)
+}
+
+// collectValidatorDidSlashNotifications creates notifications for all canonical blocks proposed by subscribed
+// validators that include slashings since the last notification of a subscription
+func collectValidatorDidSlashNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification) error {
+	var dbResult []struct {
+		SubscriptionID         uint64 `db:"id"`
+		UserID                 uint64 `db:"user_id"`
+		ValidatorIndex         uint64 `db:"proposer"`
+		Epoch                  uint64 `db:"epoch"`
+		Slot                   uint64 `db:"slot"`
+		ProposerSlashingsCount uint64 `db:"proposerslashingscount"`
+		AttesterSlashingsCount uint64 `db:"attesterslashingscount"`
+	}
+
+	err := db.DB.Select(&dbResult, `
+		SELECT us.id, us.user_id, b.proposer, b.epoch, b.slot, b.proposerslashingscount, b.attesterslashingscount
+		FROM users_subscriptions us
+		INNER JOIN validators v ON ENCODE(v.pubkey, 'hex') = us.event_filter
+		INNER JOIN blocks b ON b.proposer = v.validatorindex AND b.status = '1' AND (b.proposerslashingscount > 0 OR b.attesterslashingscount > 0)
+		WHERE us.event_name = $1 AND b.epoch >= us.created_epoch AND (us.last_sent_epoch IS NULL OR b.epoch > us.last_sent_epoch)
+		ORDER BY b.slot`,
+		types.ValidatorDidSlashEventName)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range dbResult {
+		n := &validatorDidSlashNotification{
+			SubscriptionID:         r.SubscriptionID,
+			ValidatorIndex:         r.ValidatorIndex,
+			Epoch:                  r.Epoch,
+			Slot:                   r.Slot,
+			ProposerSlashingsCount: r.ProposerSlashingsCount,
+			AttesterSlashingsCount: r.AttesterSlashingsCount,
 		}
-		if _, exists := notificationsByEmail[r.Email][n.GetEventName()]; !exists {
-			notificationsByEmail[r.Email][n.GetEventName()] = []types.Notification{}
+		addNotification(notificationsByUserID, r.UserID, n)
+	}
+
+	return nil
+}
+
+type validatorStateChangedNotification struct {
+	SubscriptionID uint64
+	ValidatorIndex uint64
+	Epoch          uint64
+	State          string
+}
+
+func (n *validatorStateChangedNotification) GetSubscriptionID() uint64 {
+	return n.SubscriptionID
+}
+
+func (n *validatorStateChangedNotification) GetEpoch() uint64 {
+	return n.Epoch
+}
+
+func (n *validatorStateChangedNotification) GetEventName() types.EventName {
+	return types.ValidatorStateChangedEventName
+}
+
+func (n *validatorStateChangedNotification) GetInfo() string {
+	var change string
+	switch n.State {
+	case "eligible":
+		change = "became eligible for activation"
+	case "active":
+		change = "has been activated"
+	case "exited":
+		change = "has exited"
+	case "withdrawable":
+		change = "became withdrawable"
+	default:
+		change = "changed its state to " + n.State
+	}
+	return fmt.Sprintf(`Validator %[1]v %[2]v at epoch %[3]v. For more information visit: https://%[4]v/validator/%[1]v`, n.ValidatorIndex, change, n.Epoch, utils.Config.Frontend.SiteDomain)
+}
+
+// collectValidatorStateChangedNotifications creates notifications for the activation eligibility, activation, exit
+// and withdrawability epochs of subscribed validators that have been reached since the last notification of a subscription
+func collectValidatorStateChangedNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification) error {
+	latestEpoch := LatestEpoch()
+	if latestEpoch == 0 {
+		return nil
+	}
+
+	var dbResult []struct {
+		SubscriptionID uint64 `db:"id"`
+		UserID         uint64 `db:"user_id"`
+		ValidatorIndex uint64 `db:"validatorindex"`
+		Epoch          uint64 `db:"epoch"`
+		State          string `db:"state"`
+	}
+
+	err := db.DB.Select(&dbResult, `
+		SELECT us.id, us.user_id, v.validatorindex, s.epoch, s.state
+		FROM users_subscriptions us
+		INNER JOIN validators v ON ENCODE(v.pubkey, 'hex') = us.event_filter
+		CROSS JOIN LATERAL (VALUES
+			('eligible', v.activationeligibilityepoch),
+			('active', v.activationepoch),
+			('exited', v.exitepoch),
+			('withdrawable', v.withdrawableepoch)
+		) AS s(state, epoch)
+		WHERE us.event_name = $1 AND s.epoch <= $2 AND s.epoch >= us.created_epoch AND (us.last_sent_epoch IS NULL OR s.epoch > us.last_sent_epoch)
+		ORDER BY s.epoch`,
+		types.ValidatorStateChangedEventName, latestEpoch)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range dbResult {
+		n := &validatorStateChangedNotification{
+			SubscriptionID: r.SubscriptionID,
+			ValidatorIndex: r.ValidatorIndex,
+			Epoch:          r.Epoch,
+			State:          r.State,
+		}
+		addNotification(notificationsByUserID, r.UserID, n)
+	}
+
+	return nil
+}
+
+type validatorReceivedDepositNotification struct {
+	SubscriptionID     uint64
+	ValidatorPublicKey string
+	Epoch              uint64
+	Slot               uint64
+	Amount             uint64
+}
+
+func (n *validatorReceivedDepositNotification) GetSubscriptionID() uint64 {
+	return n.SubscriptionID
+}
+
+func (n *validatorReceivedDepositNotification) GetEpoch() uint64 {
+	return n.Epoch
+}
+
+func (n *validatorReceivedDepositNotification) GetEventName() types.EventName {
+	return types.ValidatorReceivedDepositEventName
+}
+
+func (n *validatorReceivedDepositNotification) GetInfo() string {
+	return fmt.Sprintf(`Validator 0x%[1]v received a deposit of %.9[2]f ETH in the block at slot %[3]v in epoch %[4]v. For more information visit: https://%[5]v/validator/%[1]v`, n.ValidatorPublicKey, float64(n.Amount)/1e9, n.Slot, n.Epoch, utils.Config.Frontend.SiteDomain)
+}
+
+// collectValidatorReceivedDepositNotifications creates notifications for all deposits to subscribed validators that
+// have been included in canonical blocks since the last notification of a subscription. The subscription is matched
+// by the public key of the deposit so that deposits to validators that are not yet part of the validator set are found as well.
+func collectValidatorReceivedDepositNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification) error {
+	var dbResult []struct {
+		SubscriptionID     uint64 `db:"id"`
+		UserID             uint64 `db:"user_id"`
+		ValidatorPublicKey string `db:"event_filter"`
+		Epoch              uint64 `db:"epoch"`
+		Slot               uint64 `db:"slot"`
+		Amount             uint64 `db:"amount"`
+	}
+
+	err := db.DB.Select(&dbResult, `
+		SELECT us.id, us.user_id, us.event_filter, b.epoch, b.slot, bd.amount
+		FROM users_subscriptions us
+		INNER JOIN blocks_deposits bd ON ENCODE(bd.publickey, 'hex') = us.event_filter
+		INNER JOIN blocks b ON b.slot = bd.block_slot AND b.status = '1'
+		WHERE us.event_name = $1 AND b.epoch >= us.created_epoch AND (us.last_sent_epoch IS NULL OR b.epoch > us.last_sent_epoch)
+		ORDER BY b.slot, bd.block_index`,
+		types.ValidatorReceivedDepositEventName)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range dbResult {
+		n := &validatorReceivedDepositNotification{
+			SubscriptionID:     r.SubscriptionID,
+			ValidatorPublicKey: r.ValidatorPublicKey,
+			Epoch:              r.Epoch,
+			Slot:               r.Slot,
+			Amount:             r.Amount,
 		}
-		notificationsByEmail[r.Email][n.GetEventName()] = append(notificationsByEmail[r.Email][n.GetEventName()], n)
+		addNotification(notificationsByUserID, r.UserID, n)
 	}
 
 	return nil
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"database/sql"
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"time"
+)
+
+// Names of the network metrics whose state is stored in the network_notification_states table
+const (
+	activationQueueFullState = "activation_queue_full"
+	exitQueueFullState       = "exit_queue_full"
+	livenessLostState        = "liveness_lost"
+)
+
+// Defaults of the network notification thresholds if they are not set in the config
+const (
+	defaultQueueFullEpochs     = 225 // one day at the churn limit
+	defaultFinalityDelayEpochs = 5
+	defaultSlashingsThreshold  = 1
+	defaultHysteresis          = 0.2
+)
+
+func networkQueueFullEpochs() float64 {
+	if utils.Config.Frontend.Notifications.Network.QueueFullEpochs > 0 {
+		return utils.Config.Frontend.Notifications.Network.QueueFullEpochs
+	}
+	return defaultQueueFullEpochs
+}
+
+func networkFinalityDelayEpochs() float64 {
+	if utils.Config.Frontend.Notifications.Network.FinalityDelayEpochs > 0 {
+		return utils.Config.Frontend.Notifications.Network.FinalityDelayEpochs
+	}
+	return defaultFinalityDelayEpochs
+}
+
+func networkSlashingsThreshold() uint64 {
+	if utils.Config.Frontend.Notifications.Network.SlashingsThreshold > 0 {
+		return utils.Config.Frontend.Notifications.Network.SlashingsThreshold
+	}
+	return defaultSlashingsThreshold
+}
+
+func networkHysteresis() float64 {
+	h := utils.Config.Frontend.Notifications.Network.Hysteresis
+	if h > 0 && h < 1 {
+		return h
+	}
+	return defaultHysteresis
+}
+
+// nextThresholdState returns whether a metric is above its threshold. A metric enters the state once it reaches the
+// threshold and only leaves it again once it falls below threshold*(1-hysteresis), so that a metric that flaps
+// around the threshold does not change the state on every check.
+func nextThresholdState(active bool, value, threshold, hysteresis float64) bool {
+	if active {
+		return value > threshold*(1-hysteresis)
+	}
+	return value >= threshold
+}
+
+// updateNetworkNotificationState applies the current value of a metric to its stored state and returns the new state.
+// The first check of a metric only records its state, notifications are only sent for later changes.
+func updateNetworkNotificationState(name string, value, threshold float64, epoch uint64) (*types.NetworkNotificationState, error) {
+	state, err := db.GetNetworkNotificationState(name)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving network notification state %v: %v", name, err)
+	}
+
+	if state == nil {
+		state = &types.NetworkNotificationState{Name: name, Active: nextThresholdState(false, value, threshold, 0)}
+	} else if active := nextThresholdState(state.Active, value, threshold, networkHysteresis()); active != state.Active {
+		now := time.Now()
+		state.Active = active
+		state.ChangedEpoch = epoch
+		state.ChangedTs = &now
+		logger.Infof("network notification state %v changed to %v at epoch %v (value %.2f, threshold %.2f)", name, active, epoch, value, threshold)
+	}
+	state.Value = value
+
+	err = db.SaveNetworkNotificationState(state)
+	if err != nil {
+		return nil, fmt.Errorf("error saving network notification state %v: %v", name, err)
+	}
+	return state, nil
+}
+
+type networkStateNotification struct {
+	SubscriptionID uint64
+	Epoch          uint64
+	EventName      types.EventName
+	Value          float64
+}
+
+func (n *networkStateNotification) GetSubscriptionID() uint64 {
+	return n.SubscriptionID
+}
+
+func (n *networkStateNotification) GetEpoch() uint64 {
+	return n.Epoch
+}
+
+func (n *networkStateNotification) GetEventName() types.EventName {
+	return n.EventName
+}
+
+func (n *networkStateNotification) GetInfo() string {
+	switch n.EventName {
+	case types.NetworkValidatorActivationQueueFullEventName:
+		return fmt.Sprintf(`The activation queue is full since epoch %[1]v, new validators have to wait about %.0[2]f epochs to be activated. For more information visit: https://%[3]v/validators`, n.Epoch, n.Value, utils.Config.Frontend.SiteDomain)
+	case types.NetworkValidatorActivationQueueNotFullEventName:
+		return fmt.Sprintf(`The activation queue is not full anymore since epoch %[1]v, new validators have to wait about %.0[2]f epochs to be activated. For more information visit: https://%[3]v/validators`, n.Epoch, n.Value, utils.Config.Frontend.SiteDomain)
+	case types.NetworkValidatorExitQueueFullEventName:
+		return fmt.Sprintf(`The exit queue is full since epoch %[1]v, exiting validators have to wait about %.0[2]f epochs to exit. For more information visit: https://%[3]v/validators`, n.Epoch, n.Value, utils.Config.Frontend.SiteDomain)
+	case types.NetworkValidatorExitQueueNotFullEventName:
+		return fmt.Sprintf(`The exit queue is not full anymore since epoch %[1]v, exiting validators have to wait about %.0[2]f epochs to exit. For more information visit: https://%[3]v/validators`, n.Epoch, n.Value, utils.Config.Frontend.SiteDomain)
+	case types.NetworkLivenessIncreasedEventName:
+		return fmt.Sprintf(`The network is finalizing again since epoch %[1]v, the head is %.0[2]f epochs ahead of the last finalized epoch. For more information visit: https://%[3]v/charts/network_liveness`, n.Epoch, n.Value, utils.Config.Frontend.SiteDomain)
+	}
+	return ""
+}
+
+// addNetworkStateNotifications creates a notification of the event for all subscriptions that have been created before
+// the last change of the state and that have not been notified about it yet
+func addNetworkStateNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, eventName types.EventName, state *types.NetworkNotificationState) error {
+	var dbResult []struct {
+		SubscriptionID uint64 `db:"id"`
+		UserID         uint64 `db:"user_id"`
+	}
+
+	err := db.DB.Select(&dbResult, `
+		SELECT us.id, us.user_id
+		FROM users_subscriptions us
+		INNER JOIN users u ON u.id = us.user_id
+		WHERE us.event_name = $1 AND us.created_ts < $2 AND (us.last_sent_epoch IS NULL OR us.last_sent_epoch < $3)`,
+		eventName, state.ChangedTs, state.ChangedEpoch)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range dbResult {
+		n := &networkStateNotification{
+			SubscriptionID: r.SubscriptionID,
+			Epoch:          state.ChangedEpoch,
+			EventName:      eventName,
+			Value:          state.Value,
+		}
+		addNotification(notificationsByUserID, r.UserID, n)
+	}
+
+	return nil
+}
+
+// addNetworkStateChangeNotifications notifies the subscribers of fullEvent while the state is active and the
+// subscribers of notFullEvent once the state has been left again
+func addNetworkStateChangeNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification, state *types.NetworkNotificationState, fullEvent, notFullEvent types.EventName) error {
+	if state.ChangedTs == nil {
+		return nil
+	}
+	if state.Active {
+		return addNetworkStateNotifications(notificationsByUserID, fullEvent, state)
+	}
+	return addNetworkStateNotifications(notificationsByUserID, notFullEvent, state)
+}
+
+// collectNetworkQueueNotifications creates notifications once the activation or exit queue becomes full or not full.
+// A queue is full if processing it at the churn limit takes at least the configured number of epochs.
+func collectNetworkQueueNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification) error {
+	queueCount := struct {
+		EnteringValidators uint64 `db:"entering_validators_count"`
+		ExitingValidators  uint64 `db:"exiting_validators_count"`
+	}{}
+
+	err := db.DB.Get(&queueCount, "SELECT entering_validators_count, exiting_validators_count FROM queue ORDER BY ts DESC LIMIT 1")
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error retrieving validator queue count: %v", err)
+	}
+
+	epoch := LatestEpoch()
+	var activeCount uint64
+	err = db.DB.Get(&activeCount, "SELECT COUNT(*) FROM validators WHERE activationepoch <= $1 AND exitepoch > $1", epoch)
+	if err != nil {
+		return fmt.Errorf("error retrieving active validator count: %v", err)
+	}
+
+	// see get_validator_churn_limit in the phase0 spec (MIN_PER_EPOCH_CHURN_LIMIT = 4, CHURN_LIMIT_QUOTIENT = 65536)
+	churnLimit := float64(activeCount) / 65536
+	if churnLimit < 4 {
+		churnLimit = 4
+	}
+
+	activationState, err := updateNetworkNotificationState(activationQueueFullState, float64(queueCount.EnteringValidators)/churnLimit, networkQueueFullEpochs(), epoch)
+	if err != nil {
+		return err
+	}
+	err = addNetworkStateChangeNotifications(notificationsByUserID, activationState, types.NetworkValidatorActivationQueueFullEventName, types.NetworkValidatorActivationQueueNotFullEventName)
+	if err != nil {
+		return err
+	}
+
+	exitState, err := updateNetworkNotificationState(exitQueueFullState, float64(queueCount.ExitingValidators)/churnLimit, networkQueueFullEpochs(), epoch)
+	if err != nil {
+		return err
+	}
+	return addNetworkStateChangeNotifications(notificationsByUserID, exitState, types.NetworkValidatorExitQueueFullEventName, types.NetworkValidatorExitQueueNotFullEventName)
+}
+
+// collectNetworkLivenessNotifications creates notifications once the network finalizes again after the head has been
+// at least the configured number of epochs ahead of the last finalized epoch
+func collectNetworkLivenessNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification) error {
+	liveness := struct {
+		HeadEpoch      uint64 `db:"headepoch"`
+		FinalizedEpoch uint64 `db:"finalizedepoch"`
+	}{}
+
+	err := db.DB.Get(&liveness, "SELECT headepoch, finalizedepoch FROM network_liveness ORDER BY ts DESC LIMIT 1")
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error retrieving network liveness: %v", err)
+	}
+
+	delay := float64(0)
+	if liveness.HeadEpoch > liveness.FinalizedEpoch {
+		delay = float64(liveness.HeadEpoch - liveness.FinalizedEpoch)
+	}
+
+	state, err := updateNetworkNotificationState(livenessLostState, delay, networkFinalityDelayEpochs(), liveness.HeadEpoch)
+	if err != nil {
+		return err
+	}
+	if state.ChangedTs == nil || state.Active {
+		return nil
+	}
+	return addNetworkStateNotifications(notificationsByUserID, types.NetworkLivenessIncreasedEventName, state)
+}
+
+type networkSlashingNotification struct {
+	SubscriptionID         uint64
+	Epoch                  uint64
+	StartEpoch             uint64
+	ProposerSlashingsCount uint64
+	AttesterSlashingsCount uint64
+}
+
+func (n *networkSlashingNotification) GetSubscriptionID() uint64 {
+	return n.SubscriptionID
+}
+
+func (n *networkSlashingNotification) GetEpoch() uint64 {
+	return n.Epoch
+}
+
+func (n *networkSlashingNotification) GetEventName() types.EventName {
+	return types.NetworkSlashingEventName
+}
+
+func (n *networkSlashingNotification) GetInfo() string {
+	return fmt.Sprintf(`%[1]v proposer slashing(s) and %[2]v attester slashing(s) have been included in blocks between epoch %[3]v and %[4]v. For more information visit: https://%[5]v/validators/slashings`, n.ProposerSlashingsCount, n.AttesterSlashingsCount, n.StartEpoch, n.Epoch, utils.Config.Frontend.SiteDomain)
+}
+
+// collectNetworkSlashingNotifications creates a notification once the number of slashings that have been included in
+// canonical blocks since the last notification of a subscription reaches the configured threshold
+func collectNetworkSlashingNotifications(notificationsByUserID map[uint64]map[types.EventName][]types.Notification) error {
+	var dbResult []struct {
+		SubscriptionID         uint64 `db:"id"`
+		UserID                 uint64 `db:"user_id"`
+		StartEpoch             uint64 `db:"start_epoch"`
+		Epoch                  uint64 `db:"epoch"`
+		ProposerSlashingsCount uint64 `db:"proposerslashingscount"`
+		AttesterSlashingsCount uint64 `db:"attesterslashingscount"`
+	}
+
+	err := db.DB.Select(&dbResult, `
+		SELECT
+			us.id,
+			us.user_id,
+			MIN(b.epoch) AS start_epoch,
+			MAX(b.epoch) AS epoch,
+			SUM(b.proposerslashingscount) AS proposerslashingscount,
+			SUM(b.attesterslashingscount) AS attesterslashingscount
+		FROM users_subscriptions us
+		INNER JOIN users u ON u.id = us.user_id
+		INNER JOIN blocks b ON b.status = '1' AND (b.proposerslashingscount > 0 OR b.attesterslashingscount > 0)
+		WHERE us.event_name = $1 AND b.epoch >= us.created_epoch AND (us.last_sent_epoch IS NULL OR b.epoch > us.last_sent_epoch)
+		GROUP BY us.id, us.user_id
+		HAVING SUM(b.proposerslashingscount + b.attesterslashingscount) >= $2`,
+		types.NetworkSlashingEventName, networkSlashingsThreshold())
+	if err != nil {
+		return err
+	}
+
+	for _, r := range dbResult {
+		n := &networkSlashingNotification{
+			SubscriptionID:         r.SubscriptionID,
+			Epoch:                  r.Epoch,
+			StartEpoch:             r.StartEpoch,
+			ProposerSlashingsCount: r.ProposerSlashingsCount,
+			AttesterSlashingsCount: r.AttesterSlashingsCount,
+		}
+		addNotification(notificationsByUserID, r.UserID, n)
+	}
+
+	return nil
+}
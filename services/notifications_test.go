@@ -0,0 +1,28 @@
+package services
+
+import "testing"
+
+func TestNextThresholdState(t *testing.T) {
+	tests := []struct {
+		active bool
+		value  float64
+		want   bool
+	}{
+		{active: false, value: 50, want: false},
+		{active: false, value: 99, want: false},
+		{active: false, value: 100, want: true},
+		{active: true, value: 100, want: true},
+		// the state is kept while the value flaps within the hysteresis band
+		{active: true, value: 90, want: true},
+		{active: true, value: 80.5, want: true},
+		{active: true, value: 80, want: false},
+		{active: false, value: 90, want: false},
+	}
+
+	for _, tt := range tests {
+		got := nextThresholdState(tt.active, tt.value, 100, 0.2)
+		if got != tt.want {
+			t.Errorf("nextThresholdState(%v, %v, 100, 0.2) = %v, want %v", tt.active, tt.value, got, tt.want)
+		}
+	}
+}
@@ -16,11 +16,13 @@ func statsUpdater() {
 		statResult, err := calculateStats()
 		if err != nil {
 			logger.WithField("epoch", latestEpoch).Errorf("error updating stats: %v", err)
+			db.ReportServiceRun(types.ServiceStats, err)
 			time.Sleep(sleepDuration)
 			continue
 		}
 		logger.WithField("epoch", latestEpoch).WithField("duration", time.Since(now)).Info("stats update completed")
 		latestStats.Store(statResult)
+		db.ReportServiceRun(types.ServiceStats, nil)
 		time.Sleep(sleepDuration)
 	}
 }
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"eth2-exporter/db"
+	"eth2-exporter/types"
+	"eth2-exporter/utils"
+	"fmt"
+	"time"
+)
+
+// health verdicts of the components of the status endpoint
+const (
+	statusHealthy   = "healthy"
+	statusUnhealthy = "unhealthy"
+	statusUnknown   = "unknown"
+)
+
+// maxFailedEpochs is the maximum number of failed epochs listed by the status endpoint
+const maxFailedEpochs = 100
+
+// maxFinalityDelay is the number of epochs the finalized epoch may lag behind the head before the chain is considered
+// unhealthy
+const maxFinalityDelay = 4
+
+// serviceCheck defines when a background service is considered healthy: it has to succeed at least every maxAge and
+// may not lag more than maxLag blocks or epochs behind the head of the chain it follows (zero disables the lag check)
+type serviceCheck struct {
+	Name   string
+	MaxAge time.Duration
+	MaxLag uint64
+}
+
+func serviceChecks() []serviceCheck {
+	epochDuration := time.Second * time.Duration(utils.Config.Chain.SecondsPerSlot*utils.Config.Chain.SlotsPerEpoch)
+	return []serviceCheck{
+		{types.ServiceIndexer, 2 * epochDuration, 2},
+		{types.ServiceEth1Deposits, 10 * time.Minute, 100},
+		{types.ServicePerformance, 3 * epochDuration, 0},
+		{types.ServiceCharts, 3 * epochDuration, 0},
+		{types.ServiceStats, 10 * time.Minute, 0},
+		{types.ServiceNotifications, 10 * time.Minute, 0},
+	}
+}
+
+// GetStatus returns the sync and indexing status of the explorer with a health verdict for each of its components.
+// Components whose data can not be retrieved are reported as unhealthy instead of failing the whole status.
+func GetStatus() *types.ApiStatus {
+	now := time.Now()
+	status := &types.ApiStatus{Ts: now, Healthy: true, FailedEpochs: []uint64{}}
+
+	services, err := db.GetServiceStatus()
+	if err != nil {
+		logger.Errorf("error retrieving service status: %v", err)
+		status.Components = append(status.Components, &types.ApiStatusComponent{Name: "services", Status: statusUnhealthy, Message: "error retrieving service status"})
+	}
+	status.Services = services
+	servicesByName := make(map[string]*types.ServiceStatus, len(services))
+	for _, s := range services {
+		servicesByName[s.Name] = s
+	}
+
+	status.DbHeadEpoch, err = db.GetLatestEpoch()
+	if err != nil {
+		logger.Errorf("error retrieving latest epoch: %v", err)
+	}
+	if indexer := servicesByName[types.ServiceIndexer]; indexer != nil {
+		status.NodeHeadEpoch = indexer.Head
+	}
+	if eth1 := servicesByName[types.ServiceEth1Deposits]; eth1 != nil {
+		status.Eth1ExporterBlock = eth1.Height
+		status.Eth1HeadBlock = eth1.Head
+	}
+
+	for _, check := range serviceChecks() {
+		status.Components = append(status.Components, serviceVerdict(check, servicesByName[check.Name], now))
+	}
+
+	finality := &types.ApiStatusComponent{Name: "finality"}
+	status.FinalizedEpoch, err = db.GetLatestFinalizedEpoch()
+	if err != nil {
+		logger.Errorf("error retrieving latest finalized epoch: %v", err)
+		finality.Status = statusUnhealthy
+		finality.Message = "error retrieving latest finalized epoch"
+	} else {
+		head := status.NodeHeadEpoch
+		if status.DbHeadEpoch > head {
+			head = status.DbHeadEpoch
+		}
+		finality.Status, finality.Message = finalityVerdict(head, status.FinalizedEpoch)
+	}
+	status.Components = append(status.Components, finality)
+
+	queue := &types.ApiStatusComponent{Name: "export_queue", Status: statusHealthy}
+	status.PendingEpochs, err = db.CountExportJobs("pending")
+	if err != nil {
+		logger.Errorf("error counting pending export jobs: %v", err)
+		queue.Status = statusUnhealthy
+		queue.Message = "error retrieving export queue"
+	}
+	failed, err := db.CountExportJobs("failed")
+	if err != nil {
+		logger.Errorf("error counting failed export jobs: %v", err)
+		queue.Status = statusUnhealthy
+		queue.Message = "error retrieving export queue"
+	}
+	if failed > 0 {
+		status.FailedEpochs, err = db.GetExportJobEpochs("failed", maxFailedEpochs)
+		if err != nil {
+			logger.Errorf("error retrieving failed export jobs: %v", err)
+			status.FailedEpochs = []uint64{}
+		}
+		queue.Status = statusUnhealthy
+		queue.Message = fmt.Sprintf("%v epochs failed to export", failed)
+	} else if queue.Status == statusHealthy {
+		queue.Message = fmt.Sprintf("%v epochs pending", status.PendingEpochs)
+	}
+	status.Components = append(status.Components, queue)
+
+	database := &types.ApiStatusComponent{Name: "database", Status: statusHealthy}
+	status.DatabaseSize, err = db.GetDatabaseSize()
+	if err != nil {
+		logger.Errorf("error retrieving database size: %v", err)
+		database.Status = statusUnhealthy
+		database.Message = "error retrieving database size"
+	}
+	status.Components = append(status.Components, database)
+
+	for _, c := range status.Components {
+		if c.Status == statusUnhealthy {
+			status.Healthy = false
+		}
+	}
+	return status
+}
+
+// RedactStatus removes the error messages of the services and the database size from the status, the verdicts of the
+// components only state whether a run failed and can be shown publicly
+func RedactStatus(status *types.ApiStatus) {
+	for _, s := range status.Services {
+		s.LastError = ""
+	}
+	status.DatabaseSize = 0
+}
+
+// serviceVerdict judges the last runs of a background service, services that have not reported yet are unknown
+func serviceVerdict(check serviceCheck, s *types.ServiceStatus, now time.Time) *types.ApiStatusComponent {
+	c := &types.ApiStatusComponent{Name: check.Name, Status: statusHealthy}
+	if s == nil {
+		c.Status = statusUnknown
+		c.Message = "no runs reported"
+		return c
+	}
+
+	failing := s.LastFailureTs != nil && (s.LastSuccessTs == nil || s.LastFailureTs.After(*s.LastSuccessTs))
+	switch {
+	case s.LastSuccessTs == nil:
+		c.Status = statusUnhealthy
+		c.Message = "no successful run"
+		return c
+	case now.Sub(*s.LastSuccessTs) > check.MaxAge:
+		c.Status = statusUnhealthy
+		c.Message = fmt.Sprintf("last successful run %v ago", now.Sub(*s.LastSuccessTs).Round(time.Second))
+	case check.MaxLag > 0 && s.Head > s.Height+check.MaxLag:
+		c.Status = statusUnhealthy
+		c.Message = fmt.Sprintf("%v behind head", s.Head-s.Height)
+	case check.MaxLag > 0:
+		c.Message = fmt.Sprintf("%v behind head", lag(s.Head, s.Height))
+	}
+	if failing {
+		if c.Message != "" {
+			c.Message += ", "
+		}
+		c.Message += "last run failed"
+	}
+	return c
+}
+
+// finalityVerdict judges the distance between the head and the finalized epoch
+func finalityVerdict(head, finalized uint64) (string, string) {
+	delay := lag(head, finalized)
+	if delay > maxFinalityDelay {
+		return statusUnhealthy, fmt.Sprintf("last finalized epoch is %v epochs behind head", delay)
+	}
+	return statusHealthy, fmt.Sprintf("%v epochs behind head", delay)
+}
+
+func lag(head, height uint64) uint64 {
+	if height > head {
+		return 0
+	}
+	return head - height
+}
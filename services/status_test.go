@@ -0,0 +1,49 @@
+package services
+
+import (
+	"eth2-exporter/types"
+	"testing"
+	"time"
+)
+
+func TestServiceVerdict(t *testing.T) {
+	now := time.Date(2020, 12, 10, 15, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time {
+		ts := now.Add(-d)
+		return &ts
+	}
+	check := serviceCheck{Name: types.ServiceEth1Deposits, MaxAge: 10 * time.Minute, MaxLag: 100}
+
+	tests := []struct {
+		name   string
+		status *types.ServiceStatus
+		want   string
+	}{
+		{name: "not reported", status: nil, want: statusUnknown},
+		{name: "up to date", status: &types.ServiceStatus{LastSuccessTs: ago(time.Minute), Height: 1000, Head: 1050}, want: statusHealthy},
+		{name: "stale", status: &types.ServiceStatus{LastSuccessTs: ago(time.Hour), Height: 1000, Head: 1000}, want: statusUnhealthy},
+		{name: "lagging", status: &types.ServiceStatus{LastSuccessTs: ago(time.Minute), Height: 1000, Head: 1101}, want: statusUnhealthy},
+		{name: "never succeeded", status: &types.ServiceStatus{LastFailureTs: ago(time.Minute), LastError: "timeout"}, want: statusUnhealthy},
+		{name: "recent failure", status: &types.ServiceStatus{LastSuccessTs: ago(2 * time.Minute), LastFailureTs: ago(time.Minute), LastError: "timeout", Height: 1000, Head: 1000}, want: statusHealthy},
+	}
+
+	for _, tt := range tests {
+		got := serviceVerdict(check, tt.status, now)
+		if got.Status != tt.want {
+			t.Errorf("serviceVerdict of %v = %v (%v), want %v", tt.name, got.Status, got.Message, tt.want)
+		}
+	}
+
+	status := &types.ApiStatus{DatabaseSize: 1 << 30, Services: []*types.ServiceStatus{{Name: types.ServiceIndexer, LastError: "dial tcp 10.0.0.1:4000: connection refused"}}}
+	RedactStatus(status)
+	if status.DatabaseSize != 0 || status.Services[0].LastError != "" {
+		t.Errorf("RedactStatus left the database size %v and the error %q", status.DatabaseSize, status.Services[0].LastError)
+	}
+
+	if status, _ := finalityVerdict(100, 98); status != statusHealthy {
+		t.Errorf("finalityVerdict(100, 98) = %v, want %v", status, statusHealthy)
+	}
+	if status, _ := finalityVerdict(100, 90); status != statusUnhealthy {
+		t.Errorf("finalityVerdict(100, 90) = %v, want %v", status, statusUnhealthy)
+	}
+}
@@ -381,6 +381,14 @@ $(document).ready(function() {
     $('.multiselect-border').removeClass('focused')
   })
 
+  $('#income-export').on('submit', function(event) {
+    event.preventDefault()
+    if (!state.validators.length) return
+    var params = $(this).serializeArray().filter(function(p) { return p.value !== '' })
+    params.unshift({ name: 'validators', value: state.validators.join(',') })
+    window.location = '/dashboard/data/income/export?' + $.param(params)
+  })
+
   $('#clear-search').on('click', function(event) {
     if(state) {
       state = setInitialState()
@@ -588,6 +596,58 @@ $(document).ready(function() {
           document.querySelector('#earnings-total').innerText = total || '0.000'
         }
       })
+      $.ajax({
+        url: '/dashboard/data/rewards' + qryStr,
+        success: function(result) {
+          var t1 = Date.now()
+          console.log(`loaded rewards: fetch: ${t1-t0}ms`)
+          if (!result) return
+          var formatRewards = function(value) {
+            var eth = (value/1e9).toFixed(4)
+            if (value > 0) return '<span class="text-success">+' + eth + ' ETH</span>'
+            if (value < 0) return '<span class="text-danger">' + eth + ' ETH</span>'
+            return eth + ' ETH'
+          }
+          var rows = [['1 day', result.lastDay], ['7 days', result.lastWeek], ['31 days', result.lastMonth]].map(function(row) {
+            var r = row[1]
+            var penalties = -r.inactivity_penalty - r.slashing_penalty
+            var total = r.source_reward + r.target_reward + r.head_reward + r.inclusion_delay_reward + r.proposer_reward + penalties
+            return '<tr><td>' + row[0] + '</td>' + [r.source_reward, r.target_reward, r.head_reward, r.inclusion_delay_reward, r.proposer_reward, penalties, total].map(function(value) {
+              return '<td>' + formatRewards(value) + '</td>'
+            }).join('') + '</tr>'
+          })
+          document.querySelector('#rewards tbody').innerHTML = rows.join('')
+          document.getElementById('rewards-table-holder').style.display = 'block'
+        }
+      })
+      $.ajax({
+        url: '/dashboard/data/effectiveness' + qryStr,
+        success: function(result) {
+          var t1 = Date.now()
+          console.log(`loaded effectiveness: fetch: ${t1-t0}ms`)
+          if (!result) return
+          var rows = [['1 day', result.lastDay], ['7 days', result.lastWeek], ['31 days', result.lastMonth]].map(function(row) {
+            var e = row[1]
+            var percentage = function(n) {
+              return (e.attestations ? n / e.attestations * 100 : 0).toFixed(1) + '%'
+            }
+            return '<tr><td>' + row[0] + '</td>' + [
+              e.attestations,
+              percentage(e.included),
+              percentage(e.correct_source),
+              percentage(e.correct_target),
+              percentage(e.correct_head),
+              e.inclusion_delay.toFixed(2) + ' / ' + e.optimal_inclusion_delay.toFixed(2),
+              (e.effectiveness * 100).toFixed(0) + '%'
+            ].map(function(value) {
+              return '<td>' + value + '</td>'
+            }).join('') + '</tr>'
+          })
+          document.querySelector('#effectiveness tbody').innerHTML = rows.join('')
+          document.getElementById('effectiveness-table-holder').style.display = 'block'
+        }
+      })
+      document.getElementById('income-export-holder').style.display = 'block'
       $.ajax({
         url: '/dashboard/data/validators' + qryStr,
         success: function(result) {
@@ -638,6 +698,9 @@ $(document).ready(function() {
       document.querySelector('#copy-button').style.visibility = "hidden"
       document.querySelector('#bookmark-button').style.visibility = "hidden"
       document.querySelector('#clear-search').style.visibility = "hidden"
+      document.getElementById('rewards-table-holder').style.display = 'none'
+      document.getElementById('effectiveness-table-holder').style.display = 'none'
+      document.getElementById('income-export-holder').style.display = 'none'
       // window.location = "/dashboard"
     }
 
@@ -1,6 +1,255 @@
 package types
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
 type ApiResponse struct {
 	Status string      `json:"status"`
 	Data   interface{} `json:"data"`
 }
+
+// ApiCacheEntry is a struct to hold a cached api response stored in the database
+type ApiCacheEntry struct {
+	Key         string     `db:"key"`
+	ContentType string     `db:"content_type"`
+	Body        []byte     `db:"body"`
+	ExpiresTs   *time.Time `db:"expires_ts"`
+}
+
+// ApiKey is a struct to hold an api key issued to a user
+type ApiKey struct {
+	ID        uint64     `db:"id" json:"id"`
+	UserID    uint64     `db:"user_id" json:"user_id"`
+	Key       string     `db:"api_key" json:"api_key"`
+	Name      string     `db:"name" json:"name"`
+	Plan      string     `db:"plan" json:"plan"`
+	Active    bool       `db:"active" json:"active"`
+	CreatedTs time.Time  `db:"created_ts" json:"created_ts"`
+	RevokedTs *time.Time `db:"revoked_ts" json:"revoked_ts"`
+}
+
+// ApiStatistic is a struct to hold the number of calls of an api key to a route during a day
+type ApiStatistic struct {
+	Ts     time.Time `db:"ts" json:"ts"`
+	ApiKey string    `db:"apikey" json:"apikey"`
+	Call   string    `db:"call" json:"call"`
+	Count  uint64    `db:"count" json:"count"`
+}
+
+// ApiOAuthToken is the response of the oauth token endpoint (RFC 6749 section 5.1)
+type ApiOAuthToken struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// ApiOAuthError is the error response of the oauth token endpoint (RFC 6749 section 5.2)
+type ApiOAuthError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// ApiUserValidator is a struct to hold a validator tagged by a user, the index and balance are nil as long as the
+// deposit of the validator has not been processed by the beacon chain
+type ApiUserValidator struct {
+	Index   *uint64        `db:"validatorindex" json:"index"`
+	Pubkey  string         `db:"pubkey" json:"pubkey"`
+	Balance *uint64        `db:"balance" json:"balance"`
+	Tags    pq.StringArray `db:"tags" json:"tags"`
+}
+
+// ApiUserValidatorsRequest is the request body of the user watchlist and tags routes, the validators are passed as
+// indices or hex encoded public keys
+type ApiUserValidatorsRequest struct {
+	Validators []string `json:"validators"`
+}
+
+// ApiUserSubscriptionRequest is the request body of the user subscriptions route
+type ApiUserSubscriptionRequest struct {
+	EventName   string   `json:"event_name"`
+	EventFilter string   `json:"event_filter"`
+	Channels    []string `json:"channels"`
+}
+
+// ApiV2Response is the envelope of all /api/v2 responses, data is always an array
+type ApiV2Response struct {
+	Status     string      `json:"status"`
+	Data       interface{} `json:"data"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// ApiV2Validator is a struct to hold a validator returned by the /api/v2 validator route
+type ApiV2Validator struct {
+	Index                      uint64  `db:"validatorindex" json:"index"`
+	Pubkey                     string  `db:"pubkey" json:"pubkey" doc:"Hex encoded public key with 0x prefix"`
+	WithdrawalCredentials      string  `db:"withdrawalcredentials" json:"withdrawal_credentials" doc:"Hex encoded withdrawal credentials with 0x prefix"`
+	Name                       string  `db:"name" json:"name"`
+	Balance                    uint64  `db:"balance" json:"balance" doc:"Balance in Gwei"`
+	EffectiveBalance           uint64  `db:"effectivebalance" json:"effective_balance" doc:"Effective balance in Gwei"`
+	Slashed                    bool    `db:"slashed" json:"slashed"`
+	ActivationEligibilityEpoch uint64  `db:"activationeligibilityepoch" json:"activation_eligibility_epoch"`
+	ActivationEpoch            uint64  `db:"activationepoch" json:"activation_epoch"`
+	ExitEpoch                  uint64  `db:"exitepoch" json:"exit_epoch"`
+	WithdrawableEpoch          uint64  `db:"withdrawableepoch" json:"withdrawable_epoch"`
+	LastAttestationSlot        *uint64 `db:"lastattestationslot" json:"last_attestation_slot"`
+}
+
+// ApiV2ValidatorBalance is a struct to hold the balance of a validator at an epoch
+type ApiV2ValidatorBalance struct {
+	Epoch            uint64 `db:"epoch" json:"epoch"`
+	ValidatorIndex   uint64 `db:"validatorindex" json:"validator_index"`
+	Balance          uint64 `db:"balance" json:"balance" doc:"Balance in Gwei"`
+	EffectiveBalance uint64 `db:"effectivebalance" json:"effective_balance" doc:"Effective balance in Gwei"`
+}
+
+// ApiV2ValidatorPerformance is a struct to hold the income of a validator over the last day, week, month and year
+type ApiV2ValidatorPerformance struct {
+	ValidatorIndex  uint64 `db:"validatorindex" json:"validator_index"`
+	Balance         uint64 `db:"balance" json:"balance" doc:"Balance in Gwei"`
+	Performance1d   int64  `db:"performance1d" json:"performance_1d" doc:"Income of the last day in Gwei"`
+	Performance7d   int64  `db:"performance7d" json:"performance_7d" doc:"Income of the last 7 days in Gwei"`
+	Performance31d  int64  `db:"performance31d" json:"performance_31d" doc:"Income of the last 31 days in Gwei"`
+	Performance365d int64  `db:"performance365d" json:"performance_365d" doc:"Income of the last 365 days in Gwei"`
+}
+
+// ApiV2ValidatorAttestation is a struct to hold an attestation assignment of a validator
+type ApiV2ValidatorAttestation struct {
+	Epoch          uint64  `db:"epoch" json:"epoch"`
+	ValidatorIndex uint64  `db:"validatorindex" json:"validator_index"`
+	AttesterSlot   uint64  `db:"attesterslot" json:"attester_slot"`
+	CommitteeIndex uint64  `db:"committeeindex" json:"committee_index"`
+	Status         string  `db:"status" json:"status" enum:"scheduled,executed,missed"`
+	InclusionSlot  *uint64 `db:"inclusionslot" json:"inclusion_slot" doc:"Slot of the block that included the attestation first, null if it has not been included"`
+}
+
+// ApiV2ValidatorProposal is a struct to hold a proposal assignment of a validator
+type ApiV2ValidatorProposal struct {
+	Epoch          uint64  `db:"epoch" json:"epoch"`
+	ValidatorIndex uint64  `db:"validatorindex" json:"validator_index"`
+	Slot           uint64  `db:"proposerslot" json:"slot"`
+	Status         string  `db:"status" json:"status" enum:"scheduled,proposed,missed,orphaned"`
+	BlockRoot      *string `db:"blockroot" json:"block_root" doc:"Hex encoded root of the proposed block with 0x prefix, null if no block has been proposed"`
+}
+
+// ApiV2Cursor is the position of the last row of a page of a paginated /api/v2 route. Key is the epoch or slot the
+// rows are sorted by (descending), rows with the same key are sorted by the validator index (ascending).
+type ApiV2Cursor struct {
+	Key            uint64
+	ValidatorIndex uint64
+}
+
+// ValidatorDailyIncome is a struct to hold the income of a validator during a day (UTC). Income is the change of the
+// balance minus the deposits of the day.
+type ValidatorDailyIncome struct {
+	Day            string   `json:"day" doc:"Day in the format YYYY-MM-DD (UTC)"`
+	ValidatorIndex uint64   `json:"validator_index"`
+	StartBalance   uint64   `json:"start_balance" doc:"Balance at the start of the day in Gwei"`
+	EndBalance     uint64   `json:"end_balance" doc:"Balance at the end of the day in Gwei"`
+	Deposits       uint64   `json:"deposits" doc:"Deposits processed during the day in Gwei"`
+	Income         int64    `json:"income" doc:"Income of the day in Gwei"`
+	Price          *float64 `json:"price,omitempty" doc:"Price of 1 ETH in the requested currency at the end of the day, omitted if no currency was requested or no price is available"`
+	IncomeFiat     *float64 `json:"income_fiat,omitempty" doc:"Income of the day in the requested currency"`
+}
+
+// ValidatorDepositAmount is a struct to hold the amount of a deposit to a validator that has been processed in an epoch
+type ValidatorDepositAmount struct {
+	Epoch          uint64 `db:"epoch"`
+	ValidatorIndex uint64 `db:"validatorindex"`
+	Amount         uint64 `db:"amount"`
+}
+
+// Price is a struct to hold the price of 1 ETH in a currency at a day
+type Price struct {
+	Day      time.Time `db:"ts"`
+	Currency string    `db:"currency"`
+	Price    float64   `db:"price"`
+}
+
+// ApiChartData is a struct to hold the series of a chart returned by the /api/v1/chart/{chart}/data route
+type ApiChartData struct {
+	Chart  string `json:"chart"`
+	From   int64  `json:"from"`
+	To     int64  `json:"to"`
+	Bucket string `json:"bucket,omitempty"`
+	*GenericChartData
+}
+
+// ApiStatus is a struct to hold the sync and indexing status of the explorer returned by the /api/status route
+type ApiStatus struct {
+	Healthy           bool                  `json:"healthy"`
+	Ts                time.Time             `json:"ts"`
+	NodeHeadEpoch     uint64                `json:"node_head_epoch"`
+	DbHeadEpoch       uint64                `json:"db_head_epoch"`
+	FinalizedEpoch    uint64                `json:"finalized_epoch"`
+	Eth1ExporterBlock uint64                `json:"eth1_exporter_block"`
+	Eth1HeadBlock     uint64                `json:"eth1_head_block"`
+	PendingEpochs     uint64                `json:"pending_epochs"`
+	FailedEpochs      []uint64              `json:"failed_epochs"` // the first 100 failed epochs
+	DatabaseSize      uint64                `json:"database_size,omitempty"`
+	Services          []*ServiceStatus      `json:"services"`
+	Components        []*ApiStatusComponent `json:"components"`
+}
+
+// ApiStatusComponent is a struct to hold the health verdict of a component of the explorer
+type ApiStatusComponent struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"` // healthy, unhealthy or unknown if the component has not reported yet
+	Message string `json:"message"`
+}
+
+// Topics of the events of the push feed
+const (
+	FeedTopicBlock         = "block"
+	FeedTopicOrphanedBlock = "orphaned_block"
+	FeedTopicEpoch         = "epoch"
+	FeedTopicFinality      = "finality"
+	FeedTopicSlashing      = "slashing"
+)
+
+// FeedTopics are all topics of the push feed
+var FeedTopics = []string{FeedTopicBlock, FeedTopicOrphanedBlock, FeedTopicEpoch, FeedTopicFinality, FeedTopicSlashing}
+
+// FeedEvent is a struct to hold an event of the push feed. Validators are the indices of the validators the event
+// concerns, they are used to filter the events of a subscription.
+type FeedEvent struct {
+	Topic      string          `json:"topic"`
+	Validators []uint64        `json:"validators,omitempty"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// FeedBlock is a struct to hold the data of the block and orphaned_block events of the push feed
+type FeedBlock struct {
+	Epoch     uint64 `json:"epoch"`
+	Slot      uint64 `json:"slot"`
+	BlockRoot string `json:"block_root"`
+	Proposer  uint64 `json:"proposer"`
+}
+
+// FeedEpoch is a struct to hold the data of the epoch events of the push feed
+type FeedEpoch struct {
+	Epoch           uint64    `json:"epoch"`
+	Ts              time.Time `json:"ts"`
+	ValidatorsCount uint64    `json:"validators_count"`
+}
+
+// FeedFinality is a struct to hold the data of the finality events of the push feed
+type FeedFinality struct {
+	HeadEpoch              uint64 `json:"head_epoch"`
+	FinalizedEpoch         uint64 `json:"finalized_epoch"`
+	JustifiedEpoch         uint64 `json:"justified_epoch"`
+	PreviousJustifiedEpoch uint64 `json:"previous_justified_epoch"`
+}
+
+// FeedSlashing is a struct to hold the data of the slashing events of the push feed
+type FeedSlashing struct {
+	Epoch             uint64   `json:"epoch"`
+	Slot              uint64   `json:"slot"`
+	Type              string   `json:"type"` // proposer or attester
+	Slasher           uint64   `json:"slasher"`
+	SlashedValidators []uint64 `json:"slashed_validators"`
+}
@@ -8,6 +8,7 @@ type Config struct {
 		Name     string `yaml:"name" envconfig:"DB_NAME"`
 		Host     string `yaml:"host" envconfig:"DB_HOST"`
 		Port     string `yaml:"port" envconfig:"DB_PORT"`
+		Migrate  bool   `yaml:"migrate" envconfig:"DB_MIGRATE"` // apply pending schema migrations on startup
 	} `yaml:"database"`
 	Chain struct {
 		Network                        string `yaml:"network" envconfig:"CHAIN_NETWORK"`
@@ -24,12 +25,25 @@ type Config struct {
 		IndexMissingEpochsOnStartup bool `yaml:"indexMissingEpochsOnStartup" envconfig:"INDEXER_MISSING_INDEX_ON_STARTUP"`
 		CheckAllBlocksOnStartup     bool `yaml:"checkAllBlocksOnStartup" envconfig:"INDEXER_CHECK_ALL_BLOCKS_ON_STARTUP"`
 		UpdateAllEpochStatistics    bool `yaml:"updateAllEpochStatistics" envconfig:"INDEXER_UPDATE_ALL_EPOCH_STATISTICS"`
-		Node                        struct {
+		ExportConcurrency           int  `yaml:"exportConcurrency" envconfig:"INDEXER_EXPORT_CONCURRENCY"`
+		ExportQueue                 struct {
+			MaxAttempts     int `yaml:"maxAttempts" envconfig:"INDEXER_EXPORT_QUEUE_MAX_ATTEMPTS"`
+			RetryBackoff    int `yaml:"retryBackoff" envconfig:"INDEXER_EXPORT_QUEUE_RETRY_BACKOFF"`
+			MaxRetryBackoff int `yaml:"maxRetryBackoff" envconfig:"INDEXER_EXPORT_QUEUE_MAX_RETRY_BACKOFF"`
+		} `yaml:"exportQueue"`
+		Node struct {
 			Port     string `yaml:"port" envconfig:"INDEXER_NODE_PORT"`
 			Host     string `yaml:"host" envconfig:"INDEXER_NODE_HOST"`
 			Type     string `yaml:"type" envconfig:"INDEXER_NODE_TYPE"`
 			PageSize int32  `yaml:"pageSize" envconfig:"INDEXER_NODE_PAGE_SIZE"`
 		} `yaml:"node"`
+		Nodes []struct {
+			Name string `yaml:"name"`
+			Host string `yaml:"host"`
+			Port string `yaml:"port"`
+			Type string `yaml:"type"`
+		} `yaml:"nodes"`
+		CrossCheckNodes               bool   `yaml:"crossCheckNodes" envconfig:"INDEXER_CROSS_CHECK_NODES"`
 		Eth1Endpoint                  string `yaml:"eth1Endpoint" envconfig:"INDEXER_ETH1_ENDPOINT"`
 		Eth1DepositContractAddress    string `yaml:"eth1DepositContractAddress" envconfig:"INDEXER_ETH1_DEPOSIT_CONTRACT_ADDRESS"`
 		Eth1DepositContractFirstBlock uint64 `yaml:"eth1DepositContractFirstBlock" envconfig:"INDEXER_ETH1_DEPOSIT_CONTRACT_FIRST_BLOCK"`
@@ -39,15 +53,22 @@ type Config struct {
 			EndEpoch   uint64   `yaml:"endEpoch" envconfig:"INDEXER_ONETIMEEXPORT_END_EPOCH"`
 			Epochs     []uint64 `yaml:"epochs" envconfig:"INDEXER_ONETIMEEXPORT_EPOCHS"`
 		} `yaml:"onetimeexport"`
+		Retention struct {
+			PartitionDays                 uint64 `yaml:"partitionDays" envconfig:"INDEXER_RETENTION_PARTITION_DAYS"`
+			BalancesDays                  uint64 `yaml:"balancesDays" envconfig:"INDEXER_RETENTION_BALANCES_DAYS"`
+			AttestationAssignmentsDays    uint64 `yaml:"attestationAssignmentsDays" envconfig:"INDEXER_RETENTION_ATTESTATION_ASSIGNMENTS_DAYS"`
+			ArchiveAttestationAssignments bool   `yaml:"archiveAttestationAssignments" envconfig:"INDEXER_RETENTION_ARCHIVE_ATTESTATION_ASSIGNMENTS"`
+		} `yaml:"retention"`
 	} `yaml:"indexer"`
 	Frontend struct {
-		OnlyAPI      bool   `yaml:"onlyAPI" envconfig:"FRONTEND_ONLY_API"`
-		CsrfAuthKey  string `yaml:"csrfAuthKey" envconfig:"FRONTEND_CSRFAUTHKEY`
-		Enabled      bool   `yaml:"enabled" envconfig:"FRONTEND_ENABLED"`
-		Imprint      string `yaml:"imprint" envconfig:"FRONTEND_IMPRINT"`
-		SiteDomain   string `yaml:"siteDomain" envconfig:"FRONTEND_SITE_DOMAIN"`
-		SiteName     string `yaml:"siteName" envconfig:"FRONTEND_SITE_NAME"`
-		SiteSubtitle string `yaml:"siteSubtitle" envconfig:"FRONTEND_SITE_SUBTITLE"`
+		OnlyAPI      bool     `yaml:"onlyAPI" envconfig:"FRONTEND_ONLY_API"`
+		CsrfAuthKey  string   `yaml:"csrfAuthKey" envconfig:"FRONTEND_CSRFAUTHKEY`
+		Enabled      bool     `yaml:"enabled" envconfig:"FRONTEND_ENABLED"`
+		Imprint      string   `yaml:"imprint" envconfig:"FRONTEND_IMPRINT"`
+		SiteDomain   string   `yaml:"siteDomain" envconfig:"FRONTEND_SITE_DOMAIN"`
+		SiteName     string   `yaml:"siteName" envconfig:"FRONTEND_SITE_NAME"`
+		SiteSubtitle string   `yaml:"siteSubtitle" envconfig:"FRONTEND_SITE_SUBTITLE"`
+		Admins       []string `yaml:"admins" envconfig:"FRONTEND_ADMINS"`
 		Server       struct {
 			Port string `yaml:"port" envconfig:"FRONTEND_SERVER_PORT"`
 			Host string `yaml:"host" envconfig:"FRONTEND_SERVER_HOST"`
@@ -61,7 +82,30 @@ type Config struct {
 		} `yaml:"database"`
 		Notifications struct {
 			Enabled bool `yaml:"enabled" envconfig:"FRONTEND_NOTIFICATIONS_ENABLED"`
+			Webhook struct {
+				MaxPerDay int `yaml:"maxPerDay" envconfig:"FRONTEND_NOTIFICATIONS_WEBHOOK_MAX_PER_DAY"`
+				Timeout   int `yaml:"timeout" envconfig:"FRONTEND_NOTIFICATIONS_WEBHOOK_TIMEOUT"`
+			} `yaml:"webhook"`
+			Push struct {
+				Endpoint  string `yaml:"endpoint" envconfig:"FRONTEND_NOTIFICATIONS_PUSH_ENDPOINT"`
+				ServerKey string `yaml:"serverKey" envconfig:"FRONTEND_NOTIFICATIONS_PUSH_SERVER_KEY"`
+				MaxPerDay int    `yaml:"maxPerDay" envconfig:"FRONTEND_NOTIFICATIONS_PUSH_MAX_PER_DAY"`
+			} `yaml:"push"`
+			Network struct {
+				QueueFullEpochs     float64 `yaml:"queueFullEpochs" envconfig:"FRONTEND_NOTIFICATIONS_NETWORK_QUEUE_FULL_EPOCHS"`
+				FinalityDelayEpochs float64 `yaml:"finalityDelayEpochs" envconfig:"FRONTEND_NOTIFICATIONS_NETWORK_FINALITY_DELAY_EPOCHS"`
+				SlashingsThreshold  uint64  `yaml:"slashingsThreshold" envconfig:"FRONTEND_NOTIFICATIONS_NETWORK_SLASHINGS_THRESHOLD"`
+				Hysteresis          float64 `yaml:"hysteresis" envconfig:"FRONTEND_NOTIFICATIONS_NETWORK_HYSTERESIS"`
+			} `yaml:"network"`
 		} `yaml:"notifications"`
+		ApiCache struct {
+			Type string `yaml:"type" envconfig:"FRONTEND_API_CACHE_TYPE"`
+			Size int    `yaml:"size" envconfig:"FRONTEND_API_CACHE_SIZE"`
+		} `yaml:"apiCache"`
+		Feed struct {
+			MaxConnections          int `yaml:"maxConnections" envconfig:"FRONTEND_FEED_MAX_CONNECTIONS"`
+			MaxConnectionsPerClient int `yaml:"maxConnectionsPerClient" envconfig:"FRONTEND_FEED_MAX_CONNECTIONS_PER_CLIENT"`
+		} `yaml:"feed"`
 		SessionSecret          string `yaml:"sessionSecret" envconfig:"FRONTEND_SESSION_SECRET"`
 		MaxMailsPerEmailPerDay int    `yaml:"maxMailsPerEmailPerDay" envconfig:"FRONTEND_MAX_MAIL_PER_EMAIL_PER_DAY"`
 		Mail                   struct {
@@ -79,4 +123,8 @@ type Config struct {
 		} `yaml:"mail"`
 		GATag string `yaml:"gatag"  envconfig:"GATAG"`
 	} `yaml:"frontend"`
+	Metrics struct {
+		Enabled bool   `yaml:"enabled" envconfig:"METRICS_ENABLED"`
+		Address string `yaml:"address" envconfig:"METRICS_ADDRESS"`
+	} `yaml:"metrics"`
 }
@@ -1,6 +1,11 @@
 package types
 
 import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
 	ethpb "github.com/prysmaticlabs/ethereumapis/eth/v1alpha1"
 )
 
@@ -168,6 +173,22 @@ type MinimalBlock struct {
 	Slot       uint64 `db:"slot"`
 	BlockRoot  []byte `db:"blockroot"`
 	ParentRoot []byte `db:"parentroot"`
+	Status     string `db:"status"`
+	Proposer   uint64 `db:"proposer"`
+}
+
+// Reorg is a struct to hold a detected reorganization of the canonical chain
+type Reorg struct {
+	ID             uint64        `db:"id"`
+	Ts             time.Time     `db:"ts"`
+	Epoch          uint64        `db:"epoch"`
+	Depth          uint64        `db:"depth"`
+	OldHeadSlot    uint64        `db:"old_head_slot"`
+	OldHeadRoot    []byte        `db:"old_head_root"`
+	NewHeadSlot    uint64        `db:"new_head_slot"`
+	NewHeadRoot    []byte        `db:"new_head_root"`
+	AffectedSlots  pq.Int64Array `db:"affected_slots"`
+	OrphanedBlocks pq.ByteaArray `db:"orphaned_blocks"`
 }
 
 // BlockComparisonContainer is a struct to hold block comparison data
@@ -177,6 +198,46 @@ type BlockComparisonContainer struct {
 	Node  *MinimalBlock
 }
 
+// ExportProgress is a struct to hold the progress of a resumable export run
+type ExportProgress struct {
+	Name               string        `db:"name"`
+	StartEpoch         uint64        `db:"startepoch"`
+	EndEpoch           uint64        `db:"endepoch"`
+	LastCommittedEpoch sql.NullInt64 `db:"lastcommittedepoch"`
+}
+
+// ExportJob is a struct to hold an entry of the epoch export queue
+type ExportJob struct {
+	Epoch         uint64    `db:"epoch" json:"epoch"`
+	Status        string    `db:"status" json:"status"`
+	Attempts      int       `db:"attempts" json:"attempts"`
+	LastError     string    `db:"last_error" json:"last_error"`
+	NextAttemptTs time.Time `db:"next_attempt_ts" json:"next_attempt_ts"`
+	CreatedTs     time.Time `db:"created_ts" json:"created_ts"`
+	UpdatedTs     time.Time `db:"updated_ts" json:"updated_ts"`
+}
+
+// Names of the background services that record their runs in the service_status table
+const (
+	ServiceIndexer       = "indexer"
+	ServiceEth1Deposits  = "eth1_deposits"
+	ServicePerformance   = "performance"
+	ServiceCharts        = "charts"
+	ServiceStats         = "stats"
+	ServiceNotifications = "notifications"
+)
+
+// ServiceStatus is a struct to hold the last runs of a background service. Services that follow a chain report the
+// block or epoch they have processed (height) and the head of the chain.
+type ServiceStatus struct {
+	Name          string     `db:"name" json:"name"`
+	LastSuccessTs *time.Time `db:"last_success_ts" json:"last_success_ts"`
+	LastFailureTs *time.Time `db:"last_failure_ts" json:"last_failure_ts"`
+	LastError     string     `db:"last_error" json:"last_error,omitempty"`
+	Height        uint64     `db:"height" json:"height"`
+	Head          uint64     `db:"head" json:"head"`
+}
+
 // EpochAssignments is a struct to hold epoch assignment data
 type EpochAssignments struct {
 	ProposerAssignments map[uint64]uint64
@@ -210,3 +271,101 @@ type Eth2Deposit struct {
 	Amount                uint64 `db:"amount"`
 	Signature             []byte `db:"signature"`
 }
+
+// ValidatorRewards is a struct to hold the breakdown of the rewards and penalties of a validator for an epoch in Gwei
+type ValidatorRewards struct {
+	Epoch                uint64 `db:"epoch" json:"epoch"`
+	ValidatorIndex       uint64 `db:"validatorindex" json:"validator_index"`
+	SourceReward         int64  `db:"source_reward" json:"source_reward" doc:"Reward for a correct source vote, negative if the vote has been missed"`
+	TargetReward         int64  `db:"target_reward" json:"target_reward" doc:"Reward for a correct target vote, negative if the vote was missed or wrong"`
+	HeadReward           int64  `db:"head_reward" json:"head_reward" doc:"Reward for a correct head vote, negative if the vote was missed or wrong"`
+	InclusionDelayReward int64  `db:"inclusion_delay_reward" json:"inclusion_delay_reward"`
+	ProposerReward       int64  `db:"proposer_reward" json:"proposer_reward" doc:"Reward for including attestations and slashings in proposed blocks"`
+	InactivityPenalty    int64  `db:"inactivity_penalty" json:"inactivity_penalty" doc:"Penalty while the chain does not finalize"`
+	SlashingPenalty      int64  `db:"slashing_penalty" json:"slashing_penalty"`
+}
+
+// Total returns the sum of all rewards minus the penalties
+func (r *ValidatorRewards) Total() int64 {
+	return r.SourceReward + r.TargetReward + r.HeadReward + r.InclusionDelayReward + r.ProposerReward - r.InactivityPenalty - r.SlashingPenalty
+}
+
+// Penalties returns the inactivity and slashing penalties as a negative amount
+func (r *ValidatorRewards) Penalties() int64 {
+	return -r.InactivityPenalty - r.SlashingPenalty
+}
+
+// RewardsValidator is a struct to hold the state of a validator that is needed to compute its rewards for an epoch
+type RewardsValidator struct {
+	Index             uint64 `db:"validatorindex"`
+	EffectiveBalance  uint64 `db:"effectivebalance"`
+	ActivationEpoch   uint64 `db:"activationepoch"`
+	ExitEpoch         uint64 `db:"exitepoch"`
+	WithdrawableEpoch uint64 `db:"withdrawableepoch"`
+}
+
+// RewardsAttestation is a struct to hold an attestation included in a canonical block
+type RewardsAttestation struct {
+	BlockSlot       uint64        `db:"block_slot"`
+	Proposer        uint64        `db:"proposer"`
+	Slot            uint64        `db:"slot"`
+	BeaconBlockRoot []byte        `db:"beaconblockroot"`
+	SourceEpoch     uint64        `db:"source_epoch"`
+	SourceRoot      []byte        `db:"source_root"`
+	TargetRoot      []byte        `db:"target_root"`
+	Validators      pq.Int64Array `db:"validators"`
+}
+
+// RewardsSlashing is a struct to hold a validator that got slashed by a slashing included in a canonical block
+type RewardsSlashing struct {
+	Epoch          uint64 `db:"epoch"`
+	Proposer       uint64 `db:"proposer"`
+	ValidatorIndex uint64 `db:"validatorindex"`
+}
+
+// EffectivenessAssignment is a struct to hold the attestation duty of a validator for an epoch
+type EffectivenessAssignment struct {
+	ValidatorIndex uint64 `db:"validatorindex"`
+	AttesterSlot   uint64 `db:"attesterslot"`
+}
+
+// AttestationEffectiveness is a struct to hold the quality of the attestation of a validator for an epoch
+type AttestationEffectiveness struct {
+	Epoch                uint64 `db:"epoch" json:"epoch"`
+	ValidatorIndex       uint64 `db:"validatorindex" json:"validator_index"`
+	AttesterSlot         uint64 `db:"attesterslot" json:"attester_slot"`
+	InclusionSlot        uint64 `db:"inclusionslot" json:"inclusion_slot" doc:"Slot of the first canonical block that included the attestation, 0 if it has been missed"`
+	OptimalInclusionSlot uint64 `db:"optimalinclusionslot" json:"optimal_inclusion_slot" doc:"First slot after the attester slot with a canonical block"`
+	CorrectSource        bool   `db:"correct_source" json:"correct_source"`
+	CorrectTarget        bool   `db:"correct_target" json:"correct_target"`
+	CorrectHead          bool   `db:"correct_head" json:"correct_head"`
+}
+
+// Effectiveness returns the optimal inclusion delay divided by the actual inclusion delay, so slots without a
+// canonical block do not count against the validator. Missed attestations have an effectiveness of 0.
+func (a *AttestationEffectiveness) Effectiveness() float64 {
+	if a.InclusionSlot <= a.AttesterSlot {
+		return 0
+	}
+	return float64(a.OptimalInclusionSlot-a.AttesterSlot) / float64(a.InclusionSlot-a.AttesterSlot)
+}
+
+// ValidatorEffectiveness is a struct to hold the attestation quality of validators aggregated over several epochs
+type ValidatorEffectiveness struct {
+	Attestations          uint64  `db:"attestations" json:"attestations"`
+	Included              uint64  `db:"included" json:"included"`
+	CorrectSource         uint64  `db:"correct_source" json:"correct_source"`
+	CorrectTarget         uint64  `db:"correct_target" json:"correct_target"`
+	CorrectHead           uint64  `db:"correct_head" json:"correct_head"`
+	InclusionDelay        float64 `db:"inclusion_delay" json:"inclusion_delay" doc:"Average inclusion delay of the included attestations in slots"`
+	OptimalInclusionDelay float64 `db:"optimal_inclusion_delay" json:"optimal_inclusion_delay" doc:"Average inclusion delay of the included attestations if they had been included in the next canonical block"`
+	Effectiveness         float64 `db:"effectiveness" json:"effectiveness" doc:"Average optimal inclusion delay divided by the actual inclusion delay, missed attestations count as 0"`
+}
+
+// Percentage returns n as percentage of the attestations
+func (e *ValidatorEffectiveness) Percentage(n uint64) float64 {
+	if e.Attestations == 0 {
+		return 0
+	}
+	return float64(n) / float64(e.Attestations) * 100
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 )
 
@@ -22,6 +23,7 @@ const (
 	NetworkValidatorExitQueueFullEventName          EventName = "network_validator_exit_queue_full"
 	NetworkValidatorExitQueueNotFullEventName       EventName = "network_validator_exit_queue_not_full"
 	NetworkLivenessIncreasedEventName               EventName = "network_liveness_increased"
+	NetworkReorgEventName                           EventName = "network_reorg"
 )
 
 var EventNames = []EventName{
@@ -38,6 +40,7 @@ var EventNames = []EventName{
 	NetworkValidatorExitQueueFullEventName,
 	NetworkValidatorExitQueueNotFullEventName,
 	NetworkLivenessIncreasedEventName,
+	NetworkReorgEventName,
 }
 
 func EventNameFromString(event string) (EventName, error) {
@@ -49,6 +52,29 @@ func EventNameFromString(event string) (EventName, error) {
 	return "", errors.Errorf("Could not convert event to string. %v is not a known event type", event)
 }
 
+type NotificationChannel string
+
+const (
+	EmailNotificationChannel   NotificationChannel = "email"
+	WebhookNotificationChannel NotificationChannel = "webhook"
+	PushNotificationChannel    NotificationChannel = "push"
+)
+
+var NotificationChannels = []NotificationChannel{
+	EmailNotificationChannel,
+	WebhookNotificationChannel,
+	PushNotificationChannel,
+}
+
+func NotificationChannelFromString(channel string) (NotificationChannel, error) {
+	for _, c := range NotificationChannels {
+		if string(c) == channel {
+			return c, nil
+		}
+	}
+	return "", errors.Errorf("Could not convert channel to string. %v is not a known notification channel", channel)
+}
+
 type Tag string
 
 const (
@@ -63,14 +89,44 @@ type Notification interface {
 }
 
 type Subscription struct {
-	ID           uint64     `db:"id"`
-	UserID       uint64     `db:"user_id"`
-	EventName    EventName  `db:"event_name"`
-	EventFilter  string     `db:"event_filter"`
-	LastSent     *time.Time `db:"last_sent_ts"`
-	LastEpoch    *uint64    `db:"last_sent_epoch"`
-	CreatedTime  time.Time  `db:"created_ts"`
-	CreatedEpoch uint64     `db:"created_epoch"`
+	ID           uint64         `db:"id" json:"id"`
+	UserID       uint64         `db:"user_id" json:"user_id"`
+	EventName    EventName      `db:"event_name" json:"event_name"`
+	EventFilter  string         `db:"event_filter" json:"event_filter"`
+	LastSent     *time.Time     `db:"last_sent_ts" json:"last_sent_ts"`
+	LastEpoch    *uint64        `db:"last_sent_epoch" json:"last_sent_epoch"`
+	CreatedTime  time.Time      `db:"created_ts" json:"created_ts"`
+	CreatedEpoch uint64         `db:"created_epoch" json:"created_epoch"`
+	Channels     pq.StringArray `db:"channels" json:"channels"`
+}
+
+// NotificationRecipient is a struct to hold the addresses of a user for all notification channels
+type NotificationRecipient struct {
+	UserID        uint64
+	Email         string
+	WebhookURL    string
+	WebhookSecret string
+	DeviceTokens  []string
+}
+
+// UserWebhook is a struct to hold the webhook a user receives notifications on
+type UserWebhook struct {
+	UserID    uint64    `db:"user_id"`
+	URL       string    `db:"url"`
+	Secret    string    `db:"secret"`
+	CreatedTs time.Time `db:"created_ts"`
+}
+
+// NotificationDelivery is a struct to hold an entry of the delivery log of the notification channels
+type NotificationDelivery struct {
+	ID              uint64              `db:"id"`
+	UserID          uint64              `db:"user_id"`
+	Channel         NotificationChannel `db:"channel"`
+	Target          string              `db:"target"`
+	Status          string              `db:"status"` // sent, failed or ratelimited
+	Error           string              `db:"error"`
+	SubscriptionIDs pq.Int64Array       `db:"subscription_ids"`
+	Ts              time.Time           `db:"ts"`
 }
 
 type TaggedValidators struct {
@@ -87,3 +143,42 @@ type OAuthAppData struct {
 	RedirectURI string `db:"redirect_uri"`
 	Active      bool   `db:"active"`
 }
+
+// OAuthCode is a struct to hold an authorization code that has been exchanged for the tokens of a device
+type OAuthCode struct {
+	ID            uint64    `db:"id"`
+	UserID        uint64    `db:"user_id"`
+	AppID         uint64    `db:"app_id"`
+	CodeChallenge string    `db:"code_challenge"`
+	DeviceName    string    `db:"device_name"`
+	CreatedTs     time.Time `db:"created_ts"`
+}
+
+// UserDevice is a struct to hold a device (an app installation) a user has authorized via oauth
+type UserDevice struct {
+	ID            uint64     `db:"id" json:"id"`
+	UserID        uint64     `db:"user_id" json:"user_id"`
+	AppID         uint64     `db:"app_id" json:"app_id"`
+	AppName       string     `db:"app_name" json:"app_name,omitempty"`
+	DeviceName    string     `db:"device_name" json:"device_name"`
+	NotifyEnabled bool       `db:"notify_enabled" json:"notify_enabled"`
+	Active        bool       `db:"active" json:"active"`
+	CreatedTs     time.Time  `db:"created_ts" json:"created_ts"`
+	LastUsedTs    *time.Time `db:"last_used_ts" json:"last_used_ts"` // nil until the refresh token has been used for the first time
+}
+
+// UserTOTP is a struct to hold the totp two-factor authentication settings of a user
+type UserTOTP struct {
+	Secret   *string `db:"totp_secret"` // nil until the user starts the enrollment
+	Enabled  bool    `db:"totp_enabled"`
+	LastStep uint64  `db:"totp_last_step"`
+}
+
+// NetworkNotificationState is a struct to hold the last state of a network metric that is watched for notifications
+type NetworkNotificationState struct {
+	Name         string     `db:"name"`
+	Active       bool       `db:"active"` // true while the metric is above its threshold
+	Value        float64    `db:"value"`
+	ChangedEpoch uint64     `db:"changed_epoch"`
+	ChangedTs    *time.Time `db:"changed_ts"` // nil until the state has changed for the first time
+}
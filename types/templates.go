@@ -210,6 +210,8 @@ type ValidatorPageData struct {
 	Income7d                            int64
 	Income31d                           int64
 	Apr                                 float64
+	Rewards                             *ValidatorRewardsBreakdown
+	Effectiveness                       *ValidatorEffectivenessBreakdown
 	Proposals                           [][]uint64
 	BalanceHistoryChartData             [][]float64
 	EffectiveBalanceHistoryChartData    [][]float64
@@ -254,15 +256,16 @@ type ValidatorBalance struct {
 
 // ValidatorPerformance is a struct for the validator performance data
 type ValidatorPerformance struct {
-	Rank            uint64 `db:"rank"`
-	Index           uint64 `db:"validatorindex"`
-	PublicKey       []byte `db:"pubkey"`
-	Name            string `db:"name"`
-	Balance         uint64 `db:"balance"`
-	Performance1d   int64  `db:"performance1d"`
-	Performance7d   int64  `db:"performance7d"`
-	Performance31d  int64  `db:"performance31d"`
-	Performance365d int64  `db:"performance365d"`
+	Rank            uint64  `db:"rank"`
+	Index           uint64  `db:"validatorindex"`
+	PublicKey       []byte  `db:"pubkey"`
+	Name            string  `db:"name"`
+	Balance         uint64  `db:"balance"`
+	Performance1d   int64   `db:"performance1d"`
+	Performance7d   int64   `db:"performance7d"`
+	Performance31d  int64   `db:"performance31d"`
+	Performance365d int64   `db:"performance365d"`
+	Effectiveness   float64 `db:"effectiveness"`
 }
 
 // ValidatorAttestation is a struct for the validators attestations data
@@ -541,20 +544,20 @@ type SearchAheadValidatorsResult []struct {
 
 // GenericChartData is a struct to hold chart data
 type GenericChartData struct {
-	IsNormalChart                   bool
-	ShowGapHider                    bool
-	XAxisLabelsFormatter            template.JS
-	TooltipFormatter                template.JS
-	PlotOptionsSeriesEventsClick    template.JS
-	PlotOptionsPie                  template.JS
-	PlotOptionsSeriesCursor         string
+	IsNormalChart                   bool                      `json:"-"`
+	ShowGapHider                    bool                      `json:"-"`
+	XAxisLabelsFormatter            template.JS               `json:"-"`
+	TooltipFormatter                template.JS               `json:"-"`
+	PlotOptionsSeriesEventsClick    template.JS               `json:"-"`
+	PlotOptionsPie                  template.JS               `json:"-"`
+	PlotOptionsSeriesCursor         string                    `json:"-"`
 	Title                           string                    `json:"title"`
 	Subtitle                        string                    `json:"subtitle"`
 	XAxisTitle                      string                    `json:"x_axis_title"`
 	YAxisTitle                      string                    `json:"y_axis_title"`
 	Type                            string                    `json:"type"`
 	StackingMode                    string                    `json:"stacking_mode"`
-	ColumnDataGroupingApproximation string                    // "average", "averages", "open", "high", "low", "close" and "sum"
+	ColumnDataGroupingApproximation string                    `json:"-"` // "average", "averages", "open", "high", "low", "close" and "sum"
 	Series                          []*GenericChartDataSeries `json:"series"`
 }
 
@@ -566,6 +569,14 @@ type GenericChartDataSeries struct {
 	Type  string      `json:"type,omitempty"`
 }
 
+// ChartQuery restricts the data of a chart to a time range and sets the size of the buckets its time series are
+// aggregated to
+type ChartQuery struct {
+	From   time.Time
+	To     time.Time
+	Bucket string // epoch, hour, day or week, empty for charts without time series
+}
+
 // ChartsPageData is an array to hold charts for the charts-page
 type ChartsPageData []*ChartsPageDataChart
 
@@ -599,6 +610,20 @@ type ValidatorEarnings struct {
 	LastMonth int64 `json:"lastMonth"`
 }
 
+// ValidatorRewardsBreakdown is a struct to hold the summed up rewards of selected validators over the last day, week and month
+type ValidatorRewardsBreakdown struct {
+	LastDay   *ValidatorRewards `json:"lastDay"`
+	LastWeek  *ValidatorRewards `json:"lastWeek"`
+	LastMonth *ValidatorRewards `json:"lastMonth"`
+}
+
+// ValidatorEffectivenessBreakdown is a struct to hold the attestation effectiveness of selected validators over the last day, week and month
+type ValidatorEffectivenessBreakdown struct {
+	LastDay   *ValidatorEffectiveness `json:"lastDay"`
+	LastWeek  *ValidatorEffectiveness `json:"lastWeek"`
+	LastMonth *ValidatorEffectiveness `json:"lastMonth"`
+}
+
 // ValidatorAttestationSlashing is a struct to hold data of an attestation-slashing
 type ValidatorAttestationSlashing struct {
 	Epoch                  uint64        `db:"epoch" json:"epoch,omitempty"`
@@ -708,22 +733,45 @@ type CsrfData struct {
 }
 
 type UserSettingsPageData struct {
-	Email     string `json:"email"`
-	CsrfField template.HTML
+	Email                  string `json:"email"`
+	CsrfField              template.HTML
+	ApiKeys                []*ApiKey
+	Devices                []*UserDevice
+	TwoFactorEnabled       bool
+	TwoFactorSecret        string        // secret of a pending enrollment
+	TwoFactorQRCode        template.HTML // svg qr code of the secret of a pending enrollment
+	RecoveryCodes          []string      // recovery codes that have just been generated, they are only shown once
+	RecoveryCodesRemaining int
 	AuthData
 }
 
 type UserAuthorizeConfirmPageData struct {
 	AppData *OAuthAppData
+	Request *OAuthAuthorizeRequest
 	AuthData
 }
 
+// OAuthAuthorizeRequest is a struct to hold the parameters of an oauth authorization request that are passed through
+// the confirmation form
+type OAuthAuthorizeRequest struct {
+	ClientID            uint64
+	RedirectURI         string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	DeviceName          string
+}
+
 type UserNotificationsPageData struct {
-	Email              string   `json:"email"`
-	CountWatchlist     int      `json:"countwatchlist"`
-	CountSubscriptions int      `json:"countsubscriptions"`
-	WatchlistIndices   []uint64 `json:"watchlistIndices"`
-	DashboardLink      string   `json:"dashboardLink"`
+	Email                string          `json:"email"`
+	CountWatchlist       int             `json:"countwatchlist"`
+	CountSubscriptions   int             `json:"countsubscriptions"`
+	WatchlistIndices     []uint64        `json:"watchlistIndices"`
+	DashboardLink        string          `json:"dashboardLink"`
+	NetworkSubscriptions map[string]bool `json:"networkSubscriptions"`
+	Webhook              *UserWebhook
+	PushDevices          int
+	Deliveries           []*NotificationDelivery
 	AuthData
 	// Subscriptions []*Subscription
 }
@@ -750,3 +798,26 @@ type RateLimitError struct {
 func (e *RateLimitError) Error() string {
 	return fmt.Sprintf("rate limit has been exceeded, %v left", e.TimeLeft)
 }
+
+// AdminExportQueuePageData is a struct to hold the data of the export queue admin page
+type AdminExportQueuePageData struct {
+	Failed    []*ExportJob
+	Pending   []*ExportJob
+	CsrfField template.HTML
+}
+
+// UserApiUsagePageData is a struct to hold the data of the api usage page
+type UserApiUsagePageData struct {
+	Keys  []*UserApiKeyUsage
+	Daily []*ApiStatistic
+}
+
+// UserApiKeyUsage is a struct to hold the usage of an api key compared to the limits of its plan
+type UserApiKeyUsage struct {
+	ApiKey   *ApiKey
+	PlanName string
+	Today    uint64
+	Month    uint64
+	PerDay   uint64 // zero if the plan is unlimited
+	PerMonth uint64 // zero if the plan is unlimited
+}
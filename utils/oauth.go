@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"regexp"
+)
+
+// pkceRegex matches a code verifier (43 to 128 unreserved characters) or a S256 code challenge (RFC 7636 section 4)
+var pkceRegex = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// NewOAuthToken returns a random token for an oauth code, access token or refresh token together with the hash of the
+// token that is stored in the db
+func NewOAuthToken() (string, string, error) {
+	b, err := GenerateRandomBytesSecure(32)
+	if err != nil {
+		return "", "", err
+	}
+	token := hex.EncodeToString(b)
+	return token, HashOAuthToken(token), nil
+}
+
+// HashOAuthToken returns the hex encoded sha256 hash of an oauth token
+func HashOAuthToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// IsValidCodeChallenge returns true if challenge is a well-formed S256 pkce code challenge
+func IsValidCodeChallenge(challenge string) bool {
+	return len(challenge) == 43 && pkceRegex.MatchString(challenge)
+}
+
+// VerifyCodeChallenge returns true if the pkce code verifier matches the S256 code challenge of the authorization request
+func VerifyCodeChallenge(challenge, verifier string) bool {
+	if !pkceRegex.MatchString(verifier) {
+		return false
+	}
+	h := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(h[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
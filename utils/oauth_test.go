@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyCodeChallenge(t *testing.T) {
+	// example of RFC 7636 appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	if !IsValidCodeChallenge(challenge) {
+		t.Errorf("expected %v to be a valid code challenge", challenge)
+	}
+	if !VerifyCodeChallenge(challenge, verifier) {
+		t.Errorf("expected verifier %v to match challenge %v", verifier, challenge)
+	}
+
+	tests := []struct {
+		challenge string
+		verifier  string
+	}{
+		{challenge: challenge, verifier: verifier[:42] + "l"},
+		{challenge: challenge, verifier: ""},
+		{challenge: verifier, verifier: verifier},
+		{challenge: "", verifier: verifier},
+		{challenge: challenge, verifier: strings.Repeat("a", 129)},
+	}
+	for _, tt := range tests {
+		if VerifyCodeChallenge(tt.challenge, tt.verifier) {
+			t.Errorf("expected verifier %q not to match challenge %q", tt.verifier, tt.challenge)
+		}
+	}
+
+	if IsValidCodeChallenge(challenge[:42]) || IsValidCodeChallenge(challenge[:42]+"+") {
+		t.Errorf("expected truncated and non url-safe challenges to be invalid")
+	}
+}
+
+func TestNewOAuthToken(t *testing.T) {
+	token, hash, err := NewOAuthToken()
+	if err != nil {
+		t.Fatalf("error creating token: %v", err)
+	}
+	if len(token) != 64 || len(hash) != 64 {
+		t.Errorf("token %v and hash %v must be 64 characters long to fit the db columns", token, hash)
+	}
+	if hash != HashOAuthToken(token) || hash == token {
+		t.Errorf("hash %v is not the hash of token %v", hash, token)
+	}
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTPPeriod is the time step of the totp codes (RFC 6238)
+const TOTPPeriod = 30
+
+// totpSkew is the number of time steps a code may be early or late to account for clock drift
+const totpSkew = 1
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// recoveryCodeCharset omits characters that are easily confused when typing the codes from a printout
+const recoveryCodeCharset = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// NewTOTPSecret returns a random base32 encoded 160 bit totp secret
+func NewTOTPSecret() (string, error) {
+	b, err := GenerateRandomBytesSecure(20)
+	if err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPKeyURI returns the otpauth uri of the secret that authenticator apps import from a qr code
+func TOTPKeyURI(issuer, account, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", "6")
+	v.Set("period", fmt.Sprintf("%d", TOTPPeriod))
+	return "otpauth://totp/" + url.PathEscape(issuer+":"+account) + "?" + v.Encode()
+}
+
+// TOTPCode returns the 6 digit totp code of the secret at the time
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		return "", fmt.Errorf("error decoding totp secret: %v", err)
+	}
+	return hotp(key, uint64(t.Unix())/TOTPPeriod), nil
+}
+
+// VerifyTOTP checks a code against the secret at the time allowing for one time step of clock drift. The time step
+// of the matching code is returned so that callers can reject codes that have been used before.
+func VerifyTOTP(secret, code string, t time.Time) (uint64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != 6 {
+		return 0, false
+	}
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		return 0, false
+	}
+	now := uint64(t.Unix()) / TOTPPeriod
+	for step := now - totpSkew; step <= now+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(hotp(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// hotp returns the 6 digit hotp code of the counter (RFC 4226)
+func hotp(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+// NewRecoveryCodes returns n random single-use recovery codes of the form xxxxx-xxxxx
+func NewRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, n)
+	for i := range codes {
+		b, err := GenerateRandomBytesSecure(10)
+		if err != nil {
+			return nil, err
+		}
+		for j := range b {
+			b[j] = recoveryCodeCharset[int(b[j])%len(recoveryCodeCharset)]
+		}
+		codes[i] = string(b[:5]) + "-" + string(b[5:])
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode returns the hex encoded sha256 hash of a recovery code that is stored in the db, the code is
+// normalized first so that it may be entered without the dash and in any case
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	h := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(h[:])
+}
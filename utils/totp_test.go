@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// sha1 test vectors of RFC 6238 appendix B, truncated to 6 digits
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	tests := []struct {
+		ts   int64
+		code string
+	}{
+		{ts: 59, code: "287082"},
+		{ts: 1111111109, code: "081804"},
+		{ts: 1111111111, code: "050471"},
+		{ts: 1234567890, code: "005924"},
+		{ts: 2000000000, code: "279037"},
+		{ts: 20000000000, code: "353130"},
+	}
+	for _, tt := range tests {
+		code, err := TOTPCode(secret, time.Unix(tt.ts, 0))
+		if err != nil {
+			t.Fatalf("error computing totp code: %v", err)
+		}
+		if code != tt.code {
+			t.Errorf("code at %v = %v, want %v", tt.ts, code, tt.code)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	if err != nil {
+		t.Fatalf("error generating totp secret: %v", err)
+	}
+	now := time.Unix(1600000000, 0)
+	step := uint64(now.Unix()) / TOTPPeriod
+
+	tests := []struct {
+		at    time.Time
+		valid bool
+	}{
+		{at: now, valid: true},
+		{at: now.Add(-time.Second * TOTPPeriod), valid: true},
+		{at: now.Add(time.Second * TOTPPeriod), valid: true},
+		{at: now.Add(-time.Second * TOTPPeriod * 2), valid: false},
+		{at: now.Add(time.Second * TOTPPeriod * 2), valid: false},
+	}
+	for _, tt := range tests {
+		code, _ := TOTPCode(secret, tt.at)
+		matched, ok := VerifyTOTP(secret, code, now)
+		if ok != tt.valid {
+			t.Errorf("code of %v verified = %v, want %v", tt.at, ok, tt.valid)
+		}
+		if ok && matched != uint64(tt.at.Unix())/TOTPPeriod {
+			t.Errorf("matched step = %v, want %v", matched, step)
+		}
+	}
+
+	if _, ok := VerifyTOTP(secret, "", now); ok {
+		t.Errorf("expected an empty code to be rejected")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := NewRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("error generating recovery codes: %v", err)
+	}
+	if len(codes) != 10 || len(codes[0]) != 11 {
+		t.Fatalf("unexpected recovery codes: %v", codes)
+	}
+	if HashRecoveryCode(codes[0]) != HashRecoveryCode(strings.ToUpper(strings.Replace(codes[0], "-", "", 1))) {
+		t.Errorf("expected the hash of a recovery code to ignore case and dashes")
+	}
+	if HashRecoveryCode(codes[0]) == HashRecoveryCode(codes[1]) {
+		t.Errorf("expected distinct recovery codes to have distinct hashes")
+	}
+}
@@ -134,6 +134,17 @@ func TimeToEpoch(ts time.Time) int64 {
 	return (ts.Unix() - int64(Config.Chain.GenesisTimestamp)) / int64(Config.Chain.SecondsPerSlot) / int64(Config.Chain.SlotsPerEpoch)
 }
 
+// EpochToDay will return the day (number of 24 hour periods since genesis) of an epoch
+func EpochToDay(epoch uint64) uint64 {
+	return epoch * Config.Chain.SecondsPerSlot * Config.Chain.SlotsPerEpoch / 86400
+}
+
+// DayToEpoch will return the first epoch of a day (number of 24 hour periods since genesis)
+func DayToEpoch(day uint64) uint64 {
+	secondsPerEpoch := Config.Chain.SecondsPerSlot * Config.Chain.SlotsPerEpoch
+	return (day*86400 + secondsPerEpoch - 1) / secondsPerEpoch
+}
+
 // WaitForCtrlC will block/wait until a control-c is pressed
 func WaitForCtrlC() {
 	c := make(chan os.Signal, 1)